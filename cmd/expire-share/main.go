@@ -24,9 +24,13 @@ func main() {
 
 	logger.Info("application expire-share is starting", slog.String("env", cfg.Env))
 
+	for _, warning := range cfg.Warnings {
+		logger.Warn(warning)
+	}
+
 	application := app.New(*cfg, logger)
 
-	application.MySql.MustConnect()
+	application.DB.MustConnect()
 	application.Auth.MustConnect()
 
 	application.MustMountMiddlewares()
@@ -40,7 +44,7 @@ func main() {
 
 	<-stop
 
-	_ = application.MySql.Close()
+	_ = application.DB.Close()
 	_ = application.Auth.Close()
 	_ = application.HTTP.Shutdown(ctx)
 
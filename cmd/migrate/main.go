@@ -1,16 +1,14 @@
 package main
 
 import (
-	"database/sql"
 	"errors"
+	"expire-share/internal/app/database"
 	"expire-share/internal/config"
 	"fmt"
 	"log"
 	"os"
 
 	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/mysql"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
 func main() {
@@ -22,25 +20,16 @@ func main() {
 
 	cmd := os.Args[1]
 
-	db, err := sql.Open("mysql", cfg.DbConnectionString)
+	mgr, err := database.NewMigrator(database.MigrationsDir, cfg.Driver, cfg.DbConnectionString)
 	if err != nil {
-		log.Fatalf("failed to open database: %v", err)
+		log.Fatalf("failed to init migrator: %v", err)
 	}
 
-	defer func(db *sql.DB) {
-		if err := db.Close(); err != nil {
-			log.Fatalf("failed to close database: %v", err)
+	defer func(mgr *migrate.Migrate) {
+		if err, _ := mgr.Close(); err != nil {
+			log.Fatalf("failed to close migrator: %v", err)
 		}
-	}(db)
-
-	if err := db.Ping(); err != nil {
-		log.Fatalf("failed to ping database: %v", err)
-	}
-
-	mgr, err := migrate.New("file://migrations", "mysql://"+cfg.DbConnectionString)
-	if err != nil {
-		log.Fatalf("failed to init migrator: %v", err)
-	}
+	}(mgr)
 
 	operations := map[string]func() error{
 		"up":   mgr.Up,
@@ -250,7 +250,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get info about uploaded file by its alias. Requires authentication and file ownership.",
+                "description": "Get info about uploaded file by its alias: downloads left, expiration, size, content type and SHA-256 checksum, and the files of a multi-file share. Requires authentication and file ownership, or the management token for files uploaded by guests.",
                 "consumes": [
                     "application/json"
                 ],
@@ -267,6 +267,12 @@ const docTemplate = `{
                         "name": "alias",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Management token of a guest upload",
+                        "name": "X-Management-Token",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -302,7 +308,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Deletes uploaded file by its alias. Requires authentication and file ownership.",
+                "description": "Deletes uploaded file by its alias. Requires authentication and file ownership, or the management token for files uploaded by guests.",
                 "consumes": [
                     "application/json"
                 ],
@@ -319,6 +325,12 @@ const docTemplate = `{
                         "name": "alias",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Management token of a guest upload",
+                        "name": "X-Management-Token",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -344,6 +356,299 @@ const docTemplate = `{
                         }
                     }
                 }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Changes ttl (counted from now), remaining downloads or password of uploaded file. Requires authentication and file ownership.\nValues are limited by the role of the user, admins are not limited.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "file"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/update.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/update.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (not file owner)",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "File not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Password of file encrypted with it can't be changed",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "422": {
+                        "description": "Validation error or role limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/files": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns files uploaded by current user page by page. Pass next_cursor from previous response as cursor to get the next page.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "file"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the next page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created",
+                            "expires"
+                        ],
+                        "type": "string",
+                        "default": "created",
+                        "description": "Sort field",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "all",
+                            "active",
+                            "exhausted",
+                            "expired"
+                        ],
+                        "type": "string",
+                        "default": "all",
+                        "description": "Filter by status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/list.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/paste": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Shares text, e.g. a stack trace or a config snippet, without making a file of it. It has the same TTL, download limit,\npassword and quotas as uploaded files and is viewed at /p/{alias} highlighted by its language or as plain text at /p/{alias}/raw.\nRequires authentication unless guest uploads are enabled.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "file"
+                ],
+                "parameters": [
+                    {
+                        "description": "Text and its options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/paste.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/paste.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (upload limit exceeded or custom alias not allowed)",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Alias is already taken",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "422": {
+                        "description": "Validation error, unknown language or paste too large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/quota": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns how much storage current user uses and the limits of the user role",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "file"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/quota.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
             }
         },
         "/api/upload": {
@@ -353,7 +658,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Uploads file to server with optional password protection, download limit, and expiration time. Requires authentication.",
+                "description": "Uploads file to server with optional password protection, download limit, and expiration time. Requires authentication\nunless guest uploads are enabled. Guests are limited per IP and get a management token to get info about the file or delete it.\nSeveral file parts make a multi-file share, which is downloaded as a ZIP bundle or one file at a time.\nFilenames of its parts may be relative paths, e.g. of a folder upload, the directory tree is kept.",
                 "consumes": [
                     "multipart/form-data"
                 ],
@@ -366,7 +671,7 @@ const docTemplate = `{
                 "parameters": [
                     {
                         "type": "file",
-                        "description": "File to upload",
+                        "description": "File to upload, repeat the part to upload several files or a folder",
                         "name": "file",
                         "in": "formData",
                         "required": true
@@ -389,6 +694,12 @@ const docTemplate = `{
                         "description": "File password (optional, required for download if set)",
                         "name": "password",
                         "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Custom alias (roles allowed by config only)",
+                        "name": "alias",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
@@ -407,25 +718,1471 @@ const docTemplate = `{
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (upload limit exceeded or custom alias not allowed)",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Alias is already taken",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "413": {
+                        "description": "File too large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable entity (e.g. too many files or invalid path)",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/upload/url": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Shares a file the server fetches from an http(s) URL in the background, e.g. an artifact of an internal server, without downloading it first.\nThe TTL, download limit, password and quotas are the same as for uploaded files, the size is checked as soon as the remote server announces it.\nPrivate, loopback and link-local addresses are refused unless allowed in config. Poll the status at the returned status_url until it is completed or failed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "file"
+                ],
+                "parameters": [
+                    {
+                        "description": "URL and file options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/fetch.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/fetch.CreateResponse"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the remote upload status"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (upload limit exceeded)",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "422": {
+                        "description": "Validation error or url not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many remote uploads in progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/upload/url/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the status of a remote upload with the alias of the file once it is completed or the reason it failed.\nStatuses of finished uploads are kept for uploads.remote.status_ttl. Requires authentication and upload ownership.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "file"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Remote upload id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fetch.StatusResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (not upload owner)",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Remote upload not found or has expired",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/uploads": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates a tus 1.0 upload. File content is sent afterwards with PATCH requests. Upload-Metadata may contain base64 encoded filename (required), ttl, max_downloads and password. Requires authentication.",
+                "tags": [
+                    "uploads"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Protocol version (1.0.0)",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Size of the whole file in bytes",
+                        "name": "Upload-Length",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated 'key base64(value)' pairs",
+                        "name": "Upload-Metadata",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Upload created",
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the created upload"
+                            },
+                            "Upload-Expires": {
+                                "type": "string",
+                                "description": "Time when unfinished upload is removed"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "412": {
+                        "description": "Unsupported tus version"
+                    },
+                    "422": {
+                        "description": "File too large or password-protected while file keys are bound to passwords",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            },
+            "options": {
+                "description": "Returns supported tus versions, extensions and maximum upload size.",
+                "tags": [
+                    "uploads"
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Capabilities",
+                        "headers": {
+                            "Tus-Extension": {
+                                "type": "string",
+                                "description": "Supported extensions"
+                            },
+                            "Tus-Max-Size": {
+                                "type": "int",
+                                "description": "Maximum upload size in bytes"
+                            },
+                            "Tus-Version": {
+                                "type": "string",
+                                "description": "Supported protocol versions"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/uploads/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Cancels unfinished upload and removes received data. Requires authentication and upload ownership.",
+                "tags": [
+                    "uploads"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Protocol version (1.0.0)",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (not upload owner)",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Upload not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            },
+            "head": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns how many bytes of the upload the server has received, so an interrupted upload can be resumed from that offset. Requires authentication and upload ownership.",
+                "tags": [
+                    "uploads"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Protocol version (1.0.0)",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Upload state",
+                        "headers": {
+                            "Upload-Expires": {
+                                "type": "string",
+                                "description": "Time when unfinished upload is removed"
+                            },
+                            "Upload-Length": {
+                                "type": "int",
+                                "description": "Size of the whole file"
+                            },
+                            "Upload-Offset": {
+                                "type": "int",
+                                "description": "Received bytes"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden (not upload owner)"
+                    },
+                    "404": {
+                        "description": "Upload not found"
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Appends request body to the upload at Upload-Offset. When the last byte arrives the file is shared with the parameters given on creation and its alias is returned in X-Resource-Alias header. Requires authentication and upload ownership.",
+                "consumes": [
+                    "application/offset+octet-stream"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Protocol version (1.0.0)",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset the chunk starts at",
+                        "name": "Upload-Offset",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Chunk accepted",
+                        "headers": {
+                            "Upload-Offset": {
+                                "type": "int",
+                                "description": "New offset"
+                            },
+                            "X-Resource-Alias": {
+                                "type": "string",
+                                "description": "Alias of the shared file, set after the last chunk"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (not upload owner or upload limit exceeded)",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Upload not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Offset does not match or upload is being completed by another request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "413": {
+                        "description": "Chunk exceeds upload length",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported content type",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/download/{alias}": {
+            "get": {
+                "description": "Downloads uploaded file by its alias. If file is password-protected, provide password in X-Resource-Password header.\nA download is started with POST only. GET continues a download started before and requires its session in X-Download-Session header or cookie,\na GET without a valid session is redirected to the landing page /s/{alias}, so link scanners and prefetchers never count a download.\nSupports Range, If-Range and conditional requests on GET. A download session is counted against the download limit once the whole file was sent,\nbytes of ranged transfers of the session add up, an interrupted transfer is not counted. Follow-up requests (e.g. resumed or ranged ones) that send the session back are not counted again\nuntil the download was counted, the session of a counted download can't be continued.\nLink previews, crawlers and HEAD requests are redirected to the landing page and never count a download.\nA multi-file share is downloaded as a ZIP bundle produced on the fly, which can't be ranged, or one file at a time by its path in the share.\nThe whole share counts as one download, a session covers every file of it until bytes sent of its files add up to the size of the share.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "file"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File password (required for password-protected files)",
+                        "name": "X-Resource-Password",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Download session returned by previous request, required for GET",
+                        "name": "X-Download-Session",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Byte range, e.g. bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "File content",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Content-Encoding": {
+                                "type": "string",
+                                "description": "gzip when the file is stored compressed and the client accepts it"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "File entity tag"
+                            },
+                            "Repr-Digest": {
+                                "type": "string",
+                                "description": "SHA-256 of the whole file, e.g. sha-256=:base64:"
+                            },
+                            "X-Download-Session": {
+                                "type": "string",
+                                "description": "Download session"
+                            }
+                        }
+                    },
+                    "206": {
+                        "description": "Partial file content",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Content-Encoding": {
+                                "type": "string",
+                                "description": "gzip when the file is stored compressed and the client accepts it"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "File entity tag"
+                            },
+                            "Repr-Digest": {
+                                "type": "string",
+                                "description": "SHA-256 of the whole file, e.g. sha-256=:base64:"
+                            },
+                            "X-Download-Session": {
+                                "type": "string",
+                                "description": "Download session"
+                            }
+                        }
+                    },
+                    "302": {
+                        "description": "Link preview is redirected to the landing page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "303": {
+                        "description": "GET without a valid session is redirected to the landing page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "File password required or invalid password",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "File not found or has expired",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "410": {
+                        "description": "File has no downloads left",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "416": {
+                        "description": "Range not satisfiable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "423": {
+                        "description": "File is locked after too many wrong passwords",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong passwords from client",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Downloads uploaded file by its alias. If file is password-protected, provide password in X-Resource-Password header.\nA download is started with POST only. GET continues a download started before and requires its session in X-Download-Session header or cookie,\na GET without a valid session is redirected to the landing page /s/{alias}, so link scanners and prefetchers never count a download.\nSupports Range, If-Range and conditional requests on GET. A download session is counted against the download limit once the whole file was sent,\nbytes of ranged transfers of the session add up, an interrupted transfer is not counted. Follow-up requests (e.g. resumed or ranged ones) that send the session back are not counted again\nuntil the download was counted, the session of a counted download can't be continued.\nLink previews, crawlers and HEAD requests are redirected to the landing page and never count a download.\nA multi-file share is downloaded as a ZIP bundle produced on the fly, which can't be ranged, or one file at a time by its path in the share.\nThe whole share counts as one download, a session covers every file of it until bytes sent of its files add up to the size of the share.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "file"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File password (required for password-protected files)",
+                        "name": "X-Resource-Password",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Download session returned by previous request, required for GET",
+                        "name": "X-Download-Session",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Byte range, e.g. bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "File content",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Content-Encoding": {
+                                "type": "string",
+                                "description": "gzip when the file is stored compressed and the client accepts it"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "File entity tag"
+                            },
+                            "Repr-Digest": {
+                                "type": "string",
+                                "description": "SHA-256 of the whole file, e.g. sha-256=:base64:"
+                            },
+                            "X-Download-Session": {
+                                "type": "string",
+                                "description": "Download session"
+                            }
+                        }
+                    },
+                    "206": {
+                        "description": "Partial file content",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Content-Encoding": {
+                                "type": "string",
+                                "description": "gzip when the file is stored compressed and the client accepts it"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "File entity tag"
+                            },
+                            "Repr-Digest": {
+                                "type": "string",
+                                "description": "SHA-256 of the whole file, e.g. sha-256=:base64:"
+                            },
+                            "X-Download-Session": {
+                                "type": "string",
+                                "description": "Download session"
+                            }
+                        }
+                    },
+                    "302": {
+                        "description": "Link preview is redirected to the landing page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "303": {
+                        "description": "GET without a valid session is redirected to the landing page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "File password required or invalid password",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "File not found or has expired",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "410": {
+                        "description": "File has no downloads left",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "416": {
+                        "description": "Range not satisfiable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "423": {
+                        "description": "File is locked after too many wrong passwords",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong passwords from client",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/download/{alias}/{path}": {
+            "get": {
+                "description": "Downloads uploaded file by its alias. If file is password-protected, provide password in X-Resource-Password header.\nA download is started with POST only. GET continues a download started before and requires its session in X-Download-Session header or cookie,\na GET without a valid session is redirected to the landing page /s/{alias}, so link scanners and prefetchers never count a download.\nSupports Range, If-Range and conditional requests on GET. A download session is counted against the download limit once the whole file was sent,\nbytes of ranged transfers of the session add up, an interrupted transfer is not counted. Follow-up requests (e.g. resumed or ranged ones) that send the session back are not counted again\nuntil the download was counted, the session of a counted download can't be continued.\nLink previews, crawlers and HEAD requests are redirected to the landing page and never count a download.\nA multi-file share is downloaded as a ZIP bundle produced on the fly, which can't be ranged, or one file at a time by its path in the share.\nThe whole share counts as one download, a session covers every file of it until bytes sent of its files add up to the size of the share.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "file"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Path of a file of a multi-file share, e.g. report/index.html",
+                        "name": "path",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "File password (required for password-protected files)",
+                        "name": "X-Resource-Password",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Download session returned by previous request, required for GET",
+                        "name": "X-Download-Session",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Byte range, e.g. bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "File content",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Content-Encoding": {
+                                "type": "string",
+                                "description": "gzip when the file is stored compressed and the client accepts it"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "File entity tag"
+                            },
+                            "Repr-Digest": {
+                                "type": "string",
+                                "description": "SHA-256 of the whole file, e.g. sha-256=:base64:"
+                            },
+                            "X-Download-Session": {
+                                "type": "string",
+                                "description": "Download session"
+                            }
+                        }
+                    },
+                    "206": {
+                        "description": "Partial file content",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Content-Encoding": {
+                                "type": "string",
+                                "description": "gzip when the file is stored compressed and the client accepts it"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "File entity tag"
+                            },
+                            "Repr-Digest": {
+                                "type": "string",
+                                "description": "SHA-256 of the whole file, e.g. sha-256=:base64:"
+                            },
+                            "X-Download-Session": {
+                                "type": "string",
+                                "description": "Download session"
+                            }
+                        }
+                    },
+                    "302": {
+                        "description": "Link preview is redirected to the landing page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "303": {
+                        "description": "GET without a valid session is redirected to the landing page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "File password required or invalid password",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "File not found or has expired",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "410": {
+                        "description": "File has no downloads left",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "416": {
+                        "description": "Range not satisfiable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "423": {
+                        "description": "File is locked after too many wrong passwords",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong passwords from client",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Downloads uploaded file by its alias. If file is password-protected, provide password in X-Resource-Password header.\nA download is started with POST only. GET continues a download started before and requires its session in X-Download-Session header or cookie,\na GET without a valid session is redirected to the landing page /s/{alias}, so link scanners and prefetchers never count a download.\nSupports Range, If-Range and conditional requests on GET. A download session is counted against the download limit once the whole file was sent,\nbytes of ranged transfers of the session add up, an interrupted transfer is not counted. Follow-up requests (e.g. resumed or ranged ones) that send the session back are not counted again\nuntil the download was counted, the session of a counted download can't be continued.\nLink previews, crawlers and HEAD requests are redirected to the landing page and never count a download.\nA multi-file share is downloaded as a ZIP bundle produced on the fly, which can't be ranged, or one file at a time by its path in the share.\nThe whole share counts as one download, a session covers every file of it until bytes sent of its files add up to the size of the share.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "file"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Path of a file of a multi-file share, e.g. report/index.html",
+                        "name": "path",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "File password (required for password-protected files)",
+                        "name": "X-Resource-Password",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Download session returned by previous request, required for GET",
+                        "name": "X-Download-Session",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Byte range, e.g. bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "File content",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Content-Encoding": {
+                                "type": "string",
+                                "description": "gzip when the file is stored compressed and the client accepts it"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "File entity tag"
+                            },
+                            "Repr-Digest": {
+                                "type": "string",
+                                "description": "SHA-256 of the whole file, e.g. sha-256=:base64:"
+                            },
+                            "X-Download-Session": {
+                                "type": "string",
+                                "description": "Download session"
+                            }
+                        }
+                    },
+                    "206": {
+                        "description": "Partial file content",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Content-Encoding": {
+                                "type": "string",
+                                "description": "gzip when the file is stored compressed and the client accepts it"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "File entity tag"
+                            },
+                            "Repr-Digest": {
+                                "type": "string",
+                                "description": "SHA-256 of the whole file, e.g. sha-256=:base64:"
+                            },
+                            "X-Download-Session": {
+                                "type": "string",
+                                "description": "Download session"
+                            }
+                        }
+                    },
+                    "302": {
+                        "description": "Link preview is redirected to the landing page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "303": {
+                        "description": "GET without a valid session is redirected to the landing page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "File password required or invalid password",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "File not found or has expired",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "410": {
+                        "description": "File has no downloads left",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "416": {
+                        "description": "Range not satisfiable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "423": {
+                        "description": "File is locked after too many wrong passwords",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong passwords from client",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/p/{alias}": {
+            "get": {
+                "description": "Shows text shared with /api/paste, or any other text file, as an HTML page highlighted by its language. Viewing the page counts a download\nthe same way as /download/{alias}: the paste is shown on POST of the form of the page, which asks for a password when the paste has one.\nA GET shows only the form and never counts a download, unless it sends the session of a view started before.\nLink previews, crawlers and files that are not text or too large to be shown are redirected to the landing page /s/{alias}.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "file"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Paste password (required for password-protected pastes)",
+                        "name": "password",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paste page or its form",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "303": {
+                        "description": "Not shown as text, see landing page"
+                    },
+                    "401": {
+                        "description": "Paste password required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Invalid password",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found or has expired",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has no downloads left",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Shows text shared with /api/paste, or any other text file, as an HTML page highlighted by its language. Viewing the page counts a download\nthe same way as /download/{alias}: the paste is shown on POST of the form of the page, which asks for a password when the paste has one.\nA GET shows only the form and never counts a download, unless it sends the session of a view started before.\nLink previews, crawlers and files that are not text or too large to be shown are redirected to the landing page /s/{alias}.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "file"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Paste password (required for password-protected pastes)",
+                        "name": "password",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paste page or its form",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "303": {
+                        "description": "Not shown as text, see landing page"
+                    },
+                    "401": {
+                        "description": "Paste password required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Invalid password",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found or has expired",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has no downloads left",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/p/{alias}/raw": {
+            "get": {
+                "description": "Sends the paste as plain text, e.g. for curl. Counts a download and supports the same headers as /download/{alias}.\nLike a download, it is started with POST only. A GET continues it with its session and is redirected to the paste page /p/{alias} without one.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "file"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Paste password (required for password-protected pastes)",
+                        "name": "X-Resource-Password",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Download session returned by previous request",
+                        "name": "X-Download-Session",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paste content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "303": {
+                        "description": "GET without a valid session is redirected to the paste page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Paste password required",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Invalid password",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found or has expired",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has no downloads left",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Sends the paste as plain text, e.g. for curl. Counts a download and supports the same headers as /download/{alias}.\nLike a download, it is started with POST only. A GET continues it with its session and is redirected to the paste page /p/{alias} without one.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "file"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Paste password (required for password-protected pastes)",
+                        "name": "X-Resource-Password",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Download session returned by previous request",
+                        "name": "X-Download-Session",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paste content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "303": {
+                        "description": "GET without a valid session is redirected to the paste page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Paste password required",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Invalid password",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found or has expired",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has no downloads left",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/s/{alias}": {
+            "get": {
+                "description": "Shows an HTML page with size, expiration and downloads left of a file and a download button. Filename is shown only for files without a password.\nThe page never counts a download, so link previews may fetch it safely. The download happens on the POST of its form.\nA multi-file share without a password is listed one directory at a time with links to download each file.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "file"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Landing page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "File or directory not found or file has expired",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Downloads the file after the form of the landing page is submitted, the password is sent in the form.\nCounts the download the same way as /download/{alias}. Errors are shown on the landing page.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "file"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File password (required for password-protected files)",
+                        "name": "password",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "File content",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "401": {
+                        "description": "File password required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Invalid password",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "File not found or has expired",
+                        "schema": {
+                            "type": "string"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden (upload limit exceeded)",
+                    "410": {
+                        "description": "File has no downloads left",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "type": "string"
                         }
                     },
-                    "413": {
-                        "description": "File too large",
+                    "423": {
+                        "description": "File is locked after too many wrong passwords",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "type": "string"
                         }
                     },
-                    "422": {
-                        "description": "Unprocessable entity",
+                    "429": {
+                        "description": "Too many wrong passwords from client",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "type": "string"
                         }
                     },
                     "500": {
@@ -437,14 +2194,11 @@ const docTemplate = `{
                 }
             }
         },
-        "/download/{alias}": {
+        "/s/{alias}/{path}": {
             "get": {
-                "description": "Downloads uploaded file by its alias. If file is password-protected, provide password in X-Resource-Password header.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Shows an HTML page with size, expiration and downloads left of a file and a download button. Filename is shown only for files without a password.\nThe page never counts a download, so link previews may fetch it safely. The download happens on the POST of its form.\nA multi-file share without a password is listed one directory at a time with links to download each file.",
                 "produces": [
-                    "application/octet-stream"
+                    "text/html"
                 ],
                 "tags": [
                     "file"
@@ -459,34 +2213,28 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "File password (required for password-protected files)",
-                        "name": "X-Resource-Password",
-                        "in": "header"
+                        "description": "Directory of a multi-file share, e.g. report/logs",
+                        "name": "path",
+                        "in": "path"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "File content",
-                        "schema": {
-                            "type": "file"
-                        }
-                    },
-                    "403": {
-                        "description": "File password required or invalid password",
+                        "description": "Landing page",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "type": "string"
                         }
                     },
                     "404": {
-                        "description": "File not found or has expired",
+                        "description": "File or directory not found or file has expired",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "type": "string"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "type": "string"
                         }
                     }
                 }
@@ -494,10 +2242,149 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "fetch.CreateResponse": {
+            "description": "Remote upload is started, poll its status at status_url",
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "pending"
+                },
+                "status_url": {
+                    "type": "string",
+                    "example": "/api/upload/url/9f86d081884c7d659a2feaa0c55ad015"
+                }
+            }
+        },
+        "fetch.Request": {
+            "description": "URL to fetch, omitted options are taken from config",
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "alias": {
+                    "type": "string",
+                    "example": "build-2026-10"
+                },
+                "filename": {
+                    "description": "Filename overrides the name given by the remote server",
+                    "type": "string",
+                    "example": "build.tar.gz"
+                },
+                "max_downloads": {
+                    "type": "integer",
+                    "maximum": 10000,
+                    "minimum": 1,
+                    "example": 5
+                },
+                "password": {
+                    "type": "string",
+                    "example": "1234"
+                },
+                "ttl": {
+                    "type": "string",
+                    "example": "2h30m"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://ci.example.com/artifacts/build.tar.gz"
+                }
+            }
+        },
+        "fetch.StatusResponse": {
+            "description": "Status of remote upload: pending, running, completed or failed",
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "description": "Alias is set once the file is shared",
+                    "type": "string",
+                    "example": "abc123"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Error is set when the upload failed",
+                    "type": "string",
+                    "example": "file size too big"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "filename": {
+                    "description": "Filename is known once the remote server responded",
+                    "type": "string",
+                    "example": "build.tar.gz"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015"
+                },
+                "received": {
+                    "type": "integer",
+                    "example": 524288
+                },
+                "size": {
+                    "description": "Size is announced by the remote server, omitted when unknown",
+                    "type": "integer",
+                    "example": 1048576
+                },
+                "status": {
+                    "type": "string",
+                    "example": "running"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://ci.example.com/artifacts/build.tar.gz"
+                }
+            }
+        },
+        "get.File": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "report.pdf"
+                },
+                "sha256": {
+                    "type": "string",
+                    "example": "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 1048576
+                }
+            }
+        },
         "get.Response": {
             "description": "Response with file info",
             "type": "object",
             "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
                 "downloads_left": {
                     "type": "integer"
                 },
@@ -509,6 +2396,81 @@ const docTemplate = `{
                 },
                 "expires_in": {
                     "type": "string"
+                },
+                "files": {
+                    "description": "Files are the contents of a multi-file share",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/get.File"
+                    }
+                },
+                "sha256": {
+                    "description": "SHA256 is a hex encoded checksum of the file content",
+                    "type": "string",
+                    "example": "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 1048576
+                }
+            }
+        },
+        "list.File": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "string",
+                    "example": "aB3dE9"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "downloads_left": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "report.pdf"
+                },
+                "password_protected": {
+                    "type": "boolean"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 1048576
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "exhausted",
+                        "expired"
+                    ]
+                }
+            }
+        },
+        "list.Response": {
+            "description": "Page of files uploaded by current user",
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/list.File"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
                 }
             }
         },
@@ -582,6 +2544,116 @@ const docTemplate = `{
                 }
             }
         },
+        "paste.Request": {
+            "description": "Text to share, omitted options are taken from config",
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "alias": {
+                    "type": "string",
+                    "example": "build-2026-10"
+                },
+                "content": {
+                    "type": "string",
+                    "example": "panic: runtime error: index out of range"
+                },
+                "language": {
+                    "description": "Language highlights the paste, e.g. go, python or json. Plain text when omitted",
+                    "type": "string",
+                    "example": "go"
+                },
+                "max_downloads": {
+                    "type": "integer",
+                    "maximum": 10000,
+                    "minimum": 1,
+                    "example": 5
+                },
+                "password": {
+                    "type": "string",
+                    "example": "1234"
+                },
+                "ttl": {
+                    "type": "string",
+                    "example": "2h30m"
+                }
+            }
+        },
+        "paste.Response": {
+            "description": "Response after successful paste, it is viewed at /p/{alias}",
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "language": {
+                    "type": "string",
+                    "example": "go"
+                },
+                "management_token": {
+                    "description": "ManagementToken is returned to guests only, send it in X-Management-Token\nheader to get info about the paste or delete it",
+                    "type": "string"
+                },
+                "sha256": {
+                    "type": "string",
+                    "example": "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 1024
+                }
+            }
+        },
+        "quota.Response": {
+            "description": "Usage versus role limits. Zero max_bytes, max_ttl and max_downloads mean there is no limit. Limits are omitted when unlimited is true",
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "files_count": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "max_bytes": {
+                    "type": "integer",
+                    "example": 1073741824
+                },
+                "max_downloads": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "max_file_size": {
+                    "type": "integer",
+                    "example": 524288000
+                },
+                "max_files": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "max_ttl": {
+                    "type": "string",
+                    "example": "24h0m0s"
+                },
+                "unlimited": {
+                    "type": "boolean"
+                },
+                "used_bytes": {
+                    "type": "integer",
+                    "example": 1048576
+                }
+            }
+        },
         "refresh.Request": {
             "description": "Refresh token for obtaining new access token",
             "type": "object",
@@ -667,6 +2739,70 @@ const docTemplate = `{
                 }
             }
         },
+        "update.Request": {
+            "description": "Fields to change, omitted fields stay as they are",
+            "type": "object",
+            "properties": {
+                "downloads_left": {
+                    "type": "integer",
+                    "maximum": 10000,
+                    "minimum": 1,
+                    "example": 5
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 1,
+                    "example": "1234"
+                },
+                "remove_password": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "ttl": {
+                    "type": "string",
+                    "example": "24h"
+                }
+            }
+        },
+        "update.Response": {
+            "description": "Response with updated file info",
+            "type": "object",
+            "properties": {
+                "downloads_left": {
+                    "type": "integer"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "expires_in": {
+                    "type": "string"
+                }
+            }
+        },
+        "upload.File": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "report.pdf"
+                },
+                "sha256": {
+                    "type": "string",
+                    "example": "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 1048576
+                }
+            }
+        },
         "upload.Response": {
             "description": "Response after successful file upload",
             "type": "object",
@@ -674,11 +2810,35 @@ const docTemplate = `{
                 "alias": {
                     "type": "string"
                 },
+                "content_type": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
                 "errors": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
+                },
+                "files": {
+                    "description": "Files are returned for a multi-file share",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/upload.File"
+                    }
+                },
+                "management_token": {
+                    "description": "ManagementToken is returned to guests only, send it in X-Management-Token\nheader to get info about the file or delete it",
+                    "type": "string"
+                },
+                "sha256": {
+                    "description": "SHA256 is a hex encoded checksum of the file content",
+                    "type": "string",
+                    "example": "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 1048576
                 }
             }
         }
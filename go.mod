@@ -11,6 +11,8 @@ require (
 	github.com/golang/mock v1.6.0
 	github.com/ilyakaznacheev/cleanenv v1.5.0
 	github.com/joho/godotenv v1.5.1
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v1.14.22
 	github.com/mkaascs/AuthProto v1.0.8
 	github.com/stretchr/testify v1.11.1
 	github.com/swaggo/http-swagger v1.3.4
@@ -2,27 +2,53 @@ package app
 
 import (
 	"context"
+	"database/sql"
 	_ "expire-share/docs"
 	"expire-share/internal/app/auth"
+	"expire-share/internal/app/database"
 	httpApp "expire-share/internal/app/http"
-	"expire-share/internal/app/mysql"
 	"expire-share/internal/config"
 	"expire-share/internal/delivery/handlers/api/auth/login"
 	"expire-share/internal/delivery/handlers/api/auth/logout"
 	"expire-share/internal/delivery/handlers/api/auth/refresh"
 	"expire-share/internal/delivery/handlers/api/auth/register"
+	"expire-share/internal/delivery/handlers/api/fetch"
 	"expire-share/internal/delivery/handlers/api/files/delete"
 	"expire-share/internal/delivery/handlers/api/files/get"
+	"expire-share/internal/delivery/handlers/api/files/list"
+	"expire-share/internal/delivery/handlers/api/files/update"
+	"expire-share/internal/delivery/handlers/api/paste"
+	"expire-share/internal/delivery/handlers/api/quota"
 	"expire-share/internal/delivery/handlers/api/upload"
+	"expire-share/internal/delivery/handlers/api/uploads/create"
+	"expire-share/internal/delivery/handlers/api/uploads/head"
+	"expire-share/internal/delivery/handlers/api/uploads/options"
+	"expire-share/internal/delivery/handlers/api/uploads/patch"
+	"expire-share/internal/delivery/handlers/api/uploads/terminate"
 	"expire-share/internal/delivery/handlers/download"
 	myMiddleware "expire-share/internal/delivery/middlewares"
+	"expire-share/internal/domain/interfaces/repositories"
+	"expire-share/internal/domain/interfaces/storage"
 	"expire-share/internal/infrastructure/grpc"
-	repo "expire-share/internal/infrastructure/mysql"
+	"expire-share/internal/infrastructure/mysql"
+	"expire-share/internal/infrastructure/notify"
+	"expire-share/internal/infrastructure/postgres"
+	"expire-share/internal/infrastructure/ratelimit"
+	"expire-share/internal/infrastructure/remote"
+	"expire-share/internal/infrastructure/sqlite"
+	"expire-share/internal/infrastructure/storage/compressed"
+	"expire-share/internal/infrastructure/storage/encrypted"
 	"expire-share/internal/infrastructure/storage/local"
+	"expire-share/internal/infrastructure/storage/s3"
+	"expire-share/internal/lib/log/sl"
+	"expire-share/internal/services/fetches"
 	"expire-share/internal/services/files"
+	"expire-share/internal/services/uploads"
 	"expire-share/internal/services/worker"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"os"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
@@ -30,9 +56,9 @@ import (
 )
 
 type App struct {
-	HTTP  *httpApp.App
-	MySql *mysql.App
-	Auth  *auth.App
+	HTTP *httpApp.App
+	DB   *database.App
+	Auth *auth.App
 
 	config config.Config
 	logger *slog.Logger
@@ -42,12 +68,15 @@ func New(config config.Config, logger *slog.Logger) *App {
 	httpServer := httpApp.New(logger, config.HttpServer)
 	authApp := auth.New(logger, config.AuthService)
 
-	mysql.MustMigrate(logger, config.DbConnectionString)
-	mysqlApp, _ := mysql.New(logger, config.DbConnectionString)
+	database.MustMigrate(logger, config.Driver, config.DbConnectionString)
+	dbApp, err := database.New(logger, config.Driver, config.DbConnectionString)
+	if err != nil {
+		os.Exit(1)
+	}
 
 	return &App{
 		HTTP:   httpServer,
-		MySql:  mysqlApp,
+		DB:     dbApp,
 		Auth:   authApp,
 		config: config,
 		logger: logger,
@@ -63,11 +92,20 @@ func (a *App) MustMountMiddlewares() {
 }
 
 func (a *App) MustMountHandlers() {
-	fileRepo := repo.NewFileRepo(a.MySql.DB, a.logger)
-	fileStorage := local.NewFileStorage(a.config.Storage, a.logger)
+	fileRepo := a.mustFileRepo()
+	fileStorage := a.mustFileStorage()
 	authClient := grpc.NewAuthClient(a.Auth.GRPCConn)
 
-	fileService := files.New(fileRepo, fileStorage, a.logger, a.config)
+	uploadStaging := local.NewUploadStaging(a.config.StagingPath, a.logger)
+
+	var lockNotifier files.LockNotifier
+	if a.config.LockoutWebhook != "" {
+		lockNotifier = notify.NewWebhook(a.config.LockoutWebhook)
+	}
+
+	fileService := files.New(fileRepo, fileStorage, lockNotifier, a.logger, a.config)
+	uploadService := uploads.New(uploadStaging, fileService, a.logger, a.config)
+	fetchService := fetches.New(fileService, remote.NewClient(a.config.RemoteUploads), a.logger, a.config)
 
 	if a.config.Env == config.EnvLocal {
 		a.HTTP.Router.Get("/swagger/doc.json", func(w http.ResponseWriter, r *http.Request) {
@@ -79,20 +117,86 @@ func (a *App) MustMountHandlers() {
 		))
 	}
 
-	a.HTTP.Router.Get("/download/{alias}", download.New(fileService, a.logger))
+	uploadLimit := a.rateLimit(a.config.UploadRateLimit)
+
+	a.HTTP.Router.Group(func(r chi.Router) {
+		r.Use(a.rateLimit(a.config.DownloadRateLimit))
+
+		downloadHandler := download.New(fileService, a.logger)
+		r.Get("/download/{alias}", downloadHandler)
+		r.Head("/download/{alias}", downloadHandler)
+		r.Post("/download/{alias}", downloadHandler)
+		r.Get("/download/{alias}/*", downloadHandler)
+		r.Head("/download/{alias}/*", downloadHandler)
+		r.Post("/download/{alias}/*", downloadHandler)
+
+		landing := download.NewLanding(fileService, a.logger)
+		r.Get("/s/{alias}", landing)
+		r.Head("/s/{alias}", landing)
+		r.Get("/s/{alias}/*", landing)
+		r.Head("/s/{alias}/*", landing)
+		r.Post("/s/{alias}", download.NewConfirm(fileService, fileService, a.logger))
+
+		pasteView := download.NewPaste(fileService, fileService, a.logger, a.config.Pastes)
+		r.Get("/p/{alias}", pasteView)
+		r.Head("/p/{alias}", pasteView)
+		r.Post("/p/{alias}", pasteView)
+
+		rawPaste := download.NewRawPaste(fileService, a.logger)
+		r.Get("/p/{alias}/raw", rawPaste)
+		r.Head("/p/{alias}/raw", rawPaste)
+		r.Post("/p/{alias}/raw", rawPaste)
+	})
+
+	userAuth := myMiddleware.NewAuth(authClient, a.logger)
+
+	// guests may upload, get info about and delete their files
+	guestAuth := userAuth
+	if a.config.AllowGuestUploads {
+		guestAuth = myMiddleware.NewGuestAuth(authClient, a.logger)
+	}
 
 	a.HTTP.Router.Route("/api", func(r chi.Router) {
 		r.Route("/", func(r chi.Router) {
-			r.Use(myMiddleware.NewAuth(authClient, a.logger))
-			r.Post("/upload", upload.New(fileService, a.logger, a.config))
+			r.With(guestAuth, uploadLimit).Post("/upload", upload.New(fileService, a.logger, a.config))
+			r.With(userAuth, uploadLimit,
+				myMiddleware.NewBodyParser[fetch.Request](a.config.Service, a.logger),
+				myMiddleware.NewValidator[fetch.Request](a.logger)).
+				Post("/upload/url", fetch.New(fetchService, a.logger, a.config))
+			r.With(userAuth).Get("/upload/url/{id}", fetch.NewStatus(fetchService, a.logger))
+			r.With(guestAuth, uploadLimit,
+				myMiddleware.NewBodyParser[paste.Request](a.config.Service, a.logger),
+				myMiddleware.NewValidator[paste.Request](a.logger)).
+				Post("/paste", paste.New(fileService, a.logger, a.config))
+			r.With(userAuth).Get("/files", list.New(fileService, a.logger))
+			r.With(userAuth).Get("/quota", quota.New(fileService, a.logger))
 
 			r.Route("/file/{alias}", func(r chi.Router) {
-				r.Get("/", get.New(fileService, a.logger))
-				r.Delete("/", delete.New(fileService, a.logger))
+				r.With(guestAuth).Get("/", get.New(fileService, a.logger))
+				r.With(userAuth,
+					myMiddleware.NewBodyParser[update.Request](a.config.Service, a.logger),
+					myMiddleware.NewValidator[update.Request](a.logger)).
+					Patch("/", update.New(fileService, a.logger))
+				r.With(guestAuth).Delete("/", delete.New(fileService, a.logger))
+			})
+		})
+
+		r.Route("/uploads", func(r chi.Router) {
+			r.Use(myMiddleware.NewTusResumable(a.logger))
+			r.Options("/", options.New(a.config))
+
+			r.Group(func(r chi.Router) {
+				r.Use(myMiddleware.NewAuth(authClient, a.logger))
+				r.With(uploadLimit).Post("/", create.New(uploadService, a.logger, a.config))
+				r.Head("/{id}", head.New(uploadService, a.logger))
+				r.Patch("/{id}", patch.New(uploadService, a.logger))
+				r.Delete("/{id}", terminate.New(uploadService, a.logger))
 			})
 		})
 
 		r.Route("/auth", func(r chi.Router) {
+			r.Use(a.rateLimit(a.config.AuthRateLimit))
+
 			r.With(myMiddleware.NewBodyParser[login.Request](a.config.Service, a.logger),
 				myMiddleware.NewValidator[login.Request](a.logger)).
 				Post("/login", login.New(authClient, a.logger))
@@ -112,10 +216,89 @@ func (a *App) MustMountHandlers() {
 	})
 }
 
+// rateLimit limits requests with the policy, disabled policy lets all
+// requests through
+func (a *App) rateLimit(policy config.RatePolicy) func(http.Handler) http.Handler {
+	if !policy.Enabled() {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+
+	return myMiddleware.NewRateLimit(ratelimit.NewMemoryLimiter(policy), a.logger)
+}
+
 func (a *App) StartFileWorker(ctx context.Context) {
-	fileRepo := repo.NewFileRepo(a.MySql.DB, a.logger)
-	fileStorage := local.NewFileStorage(a.config.Storage, a.logger)
+	fileRepo := a.mustFileRepo()
+	fileStorage := a.mustFileStorage()
+	uploadStaging := local.NewUploadStaging(a.config.StagingPath, a.logger)
 
-	fileWorker := worker.NewFileWorker(fileRepo, fileStorage, a.logger, a.config)
+	fileWorker := worker.NewFileWorker(fileRepo, fileStorage, uploadStaging, a.logger, a.config)
 	fileWorker.Start(ctx)
 }
+
+func (a *App) mustFileRepo() repositories.FileRepo {
+	const fn = "app.App.mustFileRepo"
+
+	fileRepo, err := newFileRepo(a.config.Driver, a.DB.DB, a.logger)
+	if err != nil {
+		a.logger.Error("failed to create file repository", sl.Error(err), slog.String("fn", fn))
+		os.Exit(1)
+	}
+
+	return fileRepo
+}
+
+func (a *App) mustFileStorage() storage.File {
+	const fn = "app.App.mustFileStorage"
+
+	fileStorage, err := newFileStorage(a.config.Storage, a.logger)
+	if err != nil {
+		a.logger.Error("failed to create file storage", sl.Error(err), slog.String("fn", fn))
+		os.Exit(1)
+	}
+
+	return fileStorage
+}
+
+func newFileStorage(cfg config.Storage, logger *slog.Logger) (storage.File, error) {
+	var fileStorage storage.File
+	switch cfg.Type {
+	case config.StorageLocal:
+		fileStorage = local.NewFileStorage(cfg, logger)
+	case config.StorageS3:
+		s3Storage, err := s3.NewFileStorage(cfg.S3Storage, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		fileStorage = s3Storage
+	default:
+		return nil, fmt.Errorf("unknown storage type: %s", cfg.Type)
+	}
+
+	if cfg.EncryptionEnabled {
+		fileStorage = encrypted.NewFileStorage(fileStorage, cfg.Encryption, logger)
+	}
+
+	// content is compressed before it is encrypted, encrypted content
+	// doesn't compress
+	if cfg.CompressionEnabled {
+		fileStorage = compressed.NewFileStorage(fileStorage, cfg.Compression, logger)
+	}
+
+	return fileStorage, nil
+}
+
+func newFileRepo(driver string, db *sql.DB, logger *slog.Logger) (repositories.FileRepo, error) {
+	switch driver {
+	case config.DatabaseMySQL:
+		return mysql.NewFileRepo(db, logger), nil
+	case config.DatabasePostgres:
+		return postgres.NewFileRepo(db, logger), nil
+	case config.DatabaseSQLite:
+		return sqlite.NewFileRepo(db, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver: %s", driver)
+	}
+}
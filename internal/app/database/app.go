@@ -0,0 +1,137 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"expire-share/internal/config"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/mysql"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// MigrationsDir keeps a set of migrations per driver, e.g. migrations/postgres
+const MigrationsDir = "migrations"
+
+type driver struct {
+	sqlName       string
+	migrateScheme string
+}
+
+var drivers = map[string]driver{
+	config.DatabaseMySQL:    {sqlName: "mysql", migrateScheme: "mysql://"},
+	config.DatabasePostgres: {sqlName: "postgres"},
+	config.DatabaseSQLite:   {sqlName: "sqlite3", migrateScheme: "sqlite3://"},
+}
+
+type App struct {
+	DB     *sql.DB
+	driver string
+	logger *slog.Logger
+}
+
+func New(logger *slog.Logger, driverName string, connectionString string) (*App, error) {
+	const fn = "app.database.App.New"
+	log := logger.With(slog.String("fn", fn))
+
+	d, ok := drivers[driverName]
+	if !ok {
+		log.Error("unknown database driver", slog.String("driver", driverName))
+		return nil, fmt.Errorf("%s: unknown database driver: %s", fn, driverName)
+	}
+
+	db, err := sql.Open(d.sqlName, connectionString)
+	if err != nil {
+		log.Error("failed to open db connection", sl.Error(err))
+		return nil, fmt.Errorf("%s: failed to open db connection: %w", fn, err)
+	}
+
+	return &App{
+		DB:     db,
+		driver: driverName,
+		logger: logger,
+	}, nil
+}
+
+func (a *App) MustConnect() {
+	if err := a.Connect(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func (a *App) Connect() error {
+	const fn = "app.database.App.Connect"
+	log := a.logger.With(slog.String("fn", fn))
+
+	if err := a.DB.Ping(); err != nil {
+		log.Error("failed to ping db", sl.Error(err))
+		return fmt.Errorf("%s: failed to ping db: %w", fn, err)
+	}
+
+	log.Info("connected to database successfully", slog.String("driver", a.driver))
+	return nil
+}
+
+func (a *App) Close() error {
+	const fn = "app.database.App.Close"
+	log := a.logger.With(slog.String("fn", fn))
+
+	if err := a.DB.Close(); err != nil {
+		log.Error("failed to close db", sl.Error(err))
+		return fmt.Errorf("%s: failed to close db: %w", fn, err)
+	}
+
+	log.Info("database closed successfully", slog.String("driver", a.driver))
+	return nil
+}
+
+func MustMigrate(logger *slog.Logger, driverName string, connectionString string) {
+	if err := Migrate(logger, MigrationsDir, driverName, connectionString); err != nil {
+		os.Exit(1)
+	}
+}
+
+func Migrate(logger *slog.Logger, dir string, driverName string, connectionString string) error {
+	const fn = "app.database.App.Migrate"
+	log := logger.With(slog.String("fn", fn))
+
+	mgr, err := NewMigrator(dir, driverName, connectionString)
+	if err != nil {
+		log.Error("failed to open migrations", sl.Error(err))
+		return fmt.Errorf("%s: failed to open migrations: %w", fn, err)
+	}
+
+	defer func(mgr *migrate.Migrate) {
+		if err, _ := mgr.Close(); err != nil {
+			log.Error("failed to close migrator", sl.Error(err))
+		}
+	}(mgr)
+
+	if err := mgr.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		log.Error("failed to up migrations", sl.Error(err))
+		return fmt.Errorf("%s: failed to up migrations: %w", fn, err)
+	}
+
+	log.Info("migrated database successfully", slog.String("driver", driverName))
+	return nil
+}
+
+// NewMigrator opens migrations of the driver from dir
+func NewMigrator(dir string, driverName string, connectionString string) (*migrate.Migrate, error) {
+	d, ok := drivers[driverName]
+	if !ok {
+		return nil, fmt.Errorf("unknown database driver: %s", driverName)
+	}
+
+	return migrate.New("file://"+filepath.Join(dir, driverName), d.migrateScheme+connectionString)
+}
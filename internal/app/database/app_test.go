@@ -0,0 +1,44 @@
+package database
+
+import (
+	"errors"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/stretchr/testify/require"
+	"io/fs"
+	"path/filepath"
+	"testing"
+)
+
+// TestMigrationsOpen catches migrations which golang-migrate refuses to
+// open, such as two files of the same version, before the service does
+func TestMigrationsOpen(t *testing.T) {
+	for name := range drivers {
+		t.Run(name, func(t *testing.T) {
+			dir, err := filepath.Abs(filepath.Join("..", "..", "..", MigrationsDir, name))
+			require.NoError(t, err)
+
+			src, err := source.Open("file://" + filepath.ToSlash(dir))
+			require.NoError(t, err)
+			defer func() { _ = src.Close() }()
+
+			version, err := src.First()
+			require.NoError(t, err)
+
+			for {
+				up, _, err := src.ReadUp(version)
+				require.NoError(t, err, "version %d has no up migration", version)
+				_ = up.Close()
+
+				down, _, err := src.ReadDown(version)
+				require.NoError(t, err, "version %d has no down migration", version)
+				_ = down.Close()
+
+				version, err = src.Next(version)
+				if errors.Is(err, fs.ErrNotExist) {
+					break
+				}
+				require.NoError(t, err)
+			}
+		})
+	}
+}
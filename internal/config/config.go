@@ -1,10 +1,17 @@
 package config
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"expire-share/internal/lib/alias"
 	"expire-share/internal/lib/sizes"
 	"fmt"
 	"log"
+	"math"
+	"net/netip"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
@@ -17,22 +24,165 @@ const (
 	EnvProd  = "prod"
 )
 
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+const (
+	AliasModeRandom = "random"
+	AliasModeWords  = "words"
+)
+
+const (
+	DatabaseMySQL    = "mysql"
+	DatabasePostgres = "postgres"
+	DatabaseSQLite   = "sqlite"
+)
+
 type Config struct {
 	Env                string `yaml:"env" env-default:"local"`
-	DbHost             string `yaml:"db_host" env-required:"true"`
-	DbPassword         string `yaml:"-" env:"MYSQL_ROOT_PASSWORD" env-required:"true"`
+	DbHost             string `yaml:"db_host"`
+	DbPassword         string `yaml:"-" env:"DB_PASSWORD,MYSQL_ROOT_PASSWORD"`
 	DbConnectionString string `yaml:"-"`
+	Database           `yaml:"database"`
 	Storage            `yaml:"storage"`
 	HttpServer         `yaml:"http_server"`
 	Service            `yaml:"service"`
+	Uploads            `yaml:"uploads"`
+	Downloads          `yaml:"downloads"`
+	Pastes             `yaml:"pastes"`
 	AuthService        `yaml:"auth_service"`
+	RateLimits         `yaml:"rate_limits"`
+	// Warnings are found at load and logged once the logger is ready
+	Warnings []string `yaml:"-"`
+}
+
+type Database struct {
+	Driver string `yaml:"driver" env-default:"mysql"`
+	DbName string `yaml:"name" env-default:"ExpireShare"`
+	DbUser string `yaml:"user" env-default:"root"`
+	// DbPath is a database file of sqlite driver
+	DbPath    string `yaml:"path" env-default:"./storage/expire-share.db"`
+	DbSSLMode string `yaml:"ssl_mode" env-default:"disable"`
 }
 
 type Storage struct {
 	Type               string `yaml:"type" env-default:"local"`
-	Path               string `yaml:"path" env-required:"true"`
+	Path               string `yaml:"path"`
 	MaxFileSize        string `yaml:"max_file_size" env-default:"100mb"`
 	MaxFileSizeInBytes int64
+	// Deduplicate stores files with the same content once, files refer
+	// to the shared content and it is removed with the last of them
+	Deduplicate bool `yaml:"deduplicate"`
+	S3Storage   `yaml:"s3"`
+	Encryption  `yaml:"encryption"`
+	Compression `yaml:"compression"`
+}
+
+// Compression gzips files which look compressible, e.g. text, before they
+// are stored. Quotas still count the original size
+type Compression struct {
+	CompressionEnabled bool `yaml:"enabled"`
+	// CompressionLevel is a gzip level from 1 to 9, zero means the default one
+	CompressionLevel int `yaml:"level"`
+}
+
+// Encryption encrypts stored files with per-file keys wrapped by a master
+// key. Master keys come from STORAGE_ENCRYPTION_KEYS as comma-separated
+// id:base64 pairs, new files use EncryptionKeyID. Older keys stay in the
+// list until files encrypted with them expire
+type Encryption struct {
+	EncryptionEnabled bool   `yaml:"enabled"`
+	EncryptionKeyID   string `yaml:"key_id"`
+	// PasswordKeys binds keys of password-protected files to their
+	// passwords, so they can't be read with the master key alone
+	PasswordKeys   bool              `yaml:"password_keys"`
+	EncryptionKeys string            `yaml:"-" env:"STORAGE_ENCRYPTION_KEYS"`
+	MasterKeys     map[string][]byte `yaml:"-"`
+}
+
+type S3Storage struct {
+	Endpoint        string `yaml:"endpoint"`
+	Region          string `yaml:"region" env-default:"us-east-1"`
+	Bucket          string `yaml:"bucket"`
+	Prefix          string `yaml:"prefix"`
+	UsePathStyle    bool   `yaml:"use_path_style" env-default:"true"`
+	PartSize        string `yaml:"part_size" env-default:"8mb"`
+	PartSizeInBytes int64
+	// Timeout limits requests which don't stream an object back and the wait
+	// for response headers of those which do
+	Timeout         time.Duration `yaml:"timeout" env-default:"1m"`
+	MaxConns        int           `yaml:"max_conns" env-default:"64"`
+	AccessKeyID     string        `yaml:"-" env:"S3_ACCESS_KEY_ID"`
+	SecretAccessKey string        `yaml:"-" env:"S3_SECRET_ACCESS_KEY"`
+}
+
+type Uploads struct {
+	// StagingPath keeps unfinished resumable uploads on the local disk for
+	// every storage type, replicas must share it to serve the same uploads
+	StagingPath      string        `yaml:"staging_path" env-default:"./storage/.uploads/"`
+	UploadExpiration time.Duration `yaml:"expiration" env-default:"24h"`
+	// PendingTimeout is how long a file may be streamed to storage before
+	// it is treated as abandoned and removed by the file worker
+	PendingTimeout time.Duration `yaml:"pending_timeout" env-default:"1h"`
+	// MaxShareFiles is how many files may be uploaded under one alias
+	MaxShareFiles int `yaml:"max_share_files" env-default:"100"`
+	RemoteUploads `yaml:"remote"`
+}
+
+// RemoteUploads are files the server fetches from an url in the background.
+// Private, loopback and link-local addresses are refused unless they are
+// in AllowedNetworks, e.g. the network of an internal artifact server
+type RemoteUploads struct {
+	FetchTimeout time.Duration `yaml:"timeout" env-default:"10m"`
+	MaxRedirects int           `yaml:"max_redirects" env-default:"5"`
+	// AllowedNetworks are CIDRs or single addresses parsed into AllowedPrefixes
+	AllowedNetworks []string       `yaml:"allowed_networks"`
+	AllowedPrefixes []netip.Prefix `yaml:"-"`
+	// MaxActiveFetches is how many remote uploads a user may run at once
+	MaxActiveFetches int `yaml:"max_active_per_user" env-default:"3"`
+	// FetchStatusTTL is how long the status of a finished remote upload is kept
+	FetchStatusTTL time.Duration `yaml:"status_ttl" env-default:"1h"`
+}
+
+type Downloads struct {
+	SessionTTL    time.Duration `yaml:"session_ttl" env-default:"1h"`
+	SessionSecret string        `yaml:"-" env:"DOWNLOAD_SESSION_SECRET"`
+	// ReservationTimeout is how long a download slot is held for a transfer
+	// that was neither confirmed nor released, e.g. after a crash
+	ReservationTimeout time.Duration `yaml:"reservation_timeout" env-default:"1h"`
+}
+
+// Pastes are text shares created from JSON and viewed in the browser
+type Pastes struct {
+	MaxPasteSize        string `yaml:"max_size" env-default:"1mb"`
+	MaxPasteSizeInBytes int64
+	// HighlightScript and HighlightStyle are URLs of highlight.js and its
+	// theme. Pastes are shown as plain text when the script is not set
+	HighlightScript string `yaml:"highlight_script"`
+	HighlightStyle  string `yaml:"highlight_style"`
+}
+
+// RateLimits are limits of requests per client. Authenticated clients are
+// counted by user, others by IP
+type RateLimits struct {
+	DownloadRateLimit RatePolicy `yaml:"download"`
+	UploadRateLimit   RatePolicy `yaml:"upload"`
+	AuthRateLimit     RatePolicy `yaml:"auth"`
+}
+
+// RatePolicy allows Requests per Period on average and up to Burst requests
+// at once. Zero Requests means there is no limit
+type RatePolicy struct {
+	Requests int           `yaml:"requests"`
+	Period   time.Duration `yaml:"period"`
+	Burst    int           `yaml:"burst"`
+}
+
+// Enabled tells whether the policy limits requests
+func (p RatePolicy) Enabled() bool {
+	return p.Requests > 0
 }
 
 type HttpServer struct {
@@ -50,12 +200,72 @@ type Service struct {
 	MaxDownloads    int16         `yaml:"default_max_downloads" env-default:"1"`
 	AliasLength     int16         `yaml:"alias_length" env-default:"6"`
 	FileWorkerDelay time.Duration `yaml:"file_worker_delay" env-default:"5m"`
-	Permissions     `yaml:"permissions"`
+	AliasMode       string        `yaml:"alias_mode" env-default:"random"`
+	AliasAlphabet   string        `yaml:"alias_alphabet" env-default:"abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"`
+	AliasWordCount  int16         `yaml:"alias_word_count" env-default:"3"`
+	AliasSeparator  string        `yaml:"alias_separator" env-default:"-"`
+	// AliasAttempts is how many aliases are tried on collision, every next
+	// one is a character longer
+	AliasAttempts int `yaml:"alias_attempts" env-default:"5"`
+	// ExpectedLiveFiles is used only to warn about too short aliases
+	ExpectedLiveFiles int64 `yaml:"expected_live_files" env-default:"10000"`
+	Permissions       `yaml:"permissions"`
+	VanityAliases     `yaml:"vanity_aliases"`
+	PasswordLockout   `yaml:"password_lockout"`
+}
+
+// VanityAliases are rules for aliases chosen by users on upload
+type VanityAliases struct {
+	VanityMinLength     int16  `yaml:"min_length" env-default:"4"`
+	VanityMaxLength     int16  `yaml:"max_length" env-default:"50"`
+	VanityBlocklistPath string `yaml:"blocklist_path"`
+	// VanityBlocklist is read from VanityBlocklistPath, aliases containing
+	// any of its words are refused
+	VanityBlocklist []string `yaml:"-"`
+}
+
+// PasswordLockout limits wrong guesses of file passwords. Zero limits
+// turn the matching protection off
+type PasswordLockout struct {
+	// LockoutMaxFailures locks a file after that many wrong passwords
+	// within LockoutWindow, LockoutMaxFailuresPerIP does the same for a client
+	LockoutMaxFailures      int           `yaml:"max_failures"`
+	LockoutMaxFailuresPerIP int           `yaml:"max_failures_per_ip"`
+	LockoutWindow           time.Duration `yaml:"window" env-default:"15m"`
+	LockoutDuration         time.Duration `yaml:"lock_duration" env-default:"15m"`
+	// DestroyAfterFailures deletes a file after that many wrong passwords in total
+	DestroyAfterFailures int `yaml:"destroy_after"`
+	// LockoutWebhook is called when a file is locked to let its owner know
+	LockoutWebhook string `yaml:"notify_webhook"`
 }
 
 type Permissions struct {
-	MaxUploadedFileForVip  int `yaml:"max_uploaded_file_for_vip" env-default:"10"`
-	MaxUploadedFileForUser int `yaml:"max_uploaded_file_for_user" env-default:"1"`
+	MaxUploadedFileForVip     int    `yaml:"max_uploaded_file_for_vip" env-default:"10"`
+	MaxUploadedFileForUser    int    `yaml:"max_uploaded_file_for_user" env-default:"1"`
+	MaxStorageForVip          string `yaml:"max_storage_for_vip" env-default:"10gb"`
+	MaxStorageForUser         string `yaml:"max_storage_for_user" env-default:"1gb"`
+	MaxStorageForVipInBytes   int64
+	MaxStorageForUserInBytes  int64
+	MaxFileSizeForVip         string `yaml:"max_file_size_for_vip"`
+	MaxFileSizeForUser        string `yaml:"max_file_size_for_user"`
+	MaxFileSizeForVipInBytes  int64
+	MaxFileSizeForUserInBytes int64
+	MaxTtlForVip              time.Duration `yaml:"max_ttl_for_vip" env-default:"168h"`
+	MaxTtlForUser             time.Duration `yaml:"max_ttl_for_user" env-default:"24h"`
+	MaxDownloadsForVip        int16         `yaml:"max_downloads_for_vip" env-default:"1000"`
+	MaxDownloadsForUser       int16         `yaml:"max_downloads_for_user" env-default:"100"`
+	VanityAliasForVip         bool          `yaml:"vanity_alias_for_vip"`
+	VanityAliasForUser        bool          `yaml:"vanity_alias_for_user"`
+	// AllowGuestUploads lets uploads without an account, guest quotas are
+	// counted per IP
+	AllowGuestUploads          bool   `yaml:"allow_guest_uploads"`
+	MaxUploadedFileForGuest    int    `yaml:"max_uploaded_file_for_guest" env-default:"3"`
+	MaxStorageForGuest         string `yaml:"max_storage_for_guest" env-default:"50mb"`
+	MaxStorageForGuestInBytes  int64
+	MaxFileSizeForGuest        string `yaml:"max_file_size_for_guest" env-default:"10mb"`
+	MaxFileSizeForGuestInBytes int64
+	MaxTtlForGuest             time.Duration `yaml:"max_ttl_for_guest" env-default:"1h"`
+	MaxDownloadsForGuest       int16         `yaml:"max_downloads_for_guest" env-default:"10"`
 }
 
 func MustLoad() *Config {
@@ -90,10 +300,399 @@ func Load() (*Config, error) {
 	}
 
 	cfg.MaxFileSizeInBytes = bytes
-	cfg.DbConnectionString = fmt.Sprintf(
-		"root:%s@tcp(%s)/ExpireShare?charset=utf8&parseTime=True",
-		cfg.DbPassword,
-		cfg.DbHost)
 
+	pasteBytes, err := sizes.ToBytes(cfg.MaxPasteSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse max paste size in config: %w", err)
+	}
+
+	cfg.MaxPasteSizeInBytes = pasteBytes
+
+	if err := validateStorage(&cfg.Storage); err != nil {
+		return nil, err
+	}
+
+	if err := validateEncryption(&cfg.Encryption); err != nil {
+		return nil, err
+	}
+
+	if cfg.CompressionLevel < 0 || cfg.CompressionLevel > 9 {
+		return nil, fmt.Errorf("storage compression level must be between 1 and 9")
+	}
+
+	if err := validatePermissions(&cfg.Permissions, cfg.MaxFileSizeInBytes); err != nil {
+		return nil, err
+	}
+
+	if err := validateAlias(&cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateVanityAliases(&cfg.VanityAliases); err != nil {
+		return nil, err
+	}
+
+	if err := validatePasswordLockout(&cfg.PasswordLockout); err != nil {
+		return nil, err
+	}
+
+	if err := validateRateLimits(&cfg.RateLimits); err != nil {
+		return nil, err
+	}
+
+	if err := validateRemoteUploads(&cfg.RemoteUploads); err != nil {
+		return nil, err
+	}
+
+	// without a shared secret sessions are valid only until restart and
+	// only on the replica that issued them
+	if cfg.SessionSecret == "" {
+		cfg.SessionSecret = rand.Text()
+	}
+
+	connectionString, err := buildConnectionString(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.DbConnectionString = connectionString
 	return &cfg, nil
 }
+
+func buildConnectionString(cfg Config) (string, error) {
+	switch cfg.Driver {
+	case DatabaseMySQL:
+		if cfg.DbHost == "" || cfg.DbPassword == "" {
+			return "", fmt.Errorf("db_host and DB_PASSWORD are required for %s database", cfg.Driver)
+		}
+
+		return fmt.Sprintf(
+			"%s:%s@tcp(%s)/%s?charset=utf8&parseTime=True",
+			cfg.DbUser,
+			cfg.DbPassword,
+			cfg.DbHost,
+			cfg.DbName), nil
+
+	case DatabasePostgres:
+		if cfg.DbHost == "" || cfg.DbPassword == "" {
+			return "", fmt.Errorf("db_host and DB_PASSWORD are required for %s database", cfg.Driver)
+		}
+
+		dsn := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(cfg.DbUser, cfg.DbPassword),
+			Host:     cfg.DbHost,
+			Path:     cfg.DbName,
+			RawQuery: url.Values{"sslmode": {cfg.DbSSLMode}}.Encode(),
+		}
+
+		return dsn.String(), nil
+
+	case DatabaseSQLite:
+		if cfg.DbPath == "" {
+			return "", fmt.Errorf("database path is required for %s database", cfg.Driver)
+		}
+
+		// writers wait for each other instead of failing with "database is locked"
+		return cfg.DbPath + "?_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate", nil
+
+	default:
+		return "", fmt.Errorf("unknown database driver: %s", cfg.Driver)
+	}
+}
+
+func validateStorage(cfg *Storage) error {
+	switch cfg.Type {
+	case StorageLocal:
+		if cfg.Path == "" {
+			return fmt.Errorf("storage path is required for %s storage", cfg.Type)
+		}
+
+	case StorageS3:
+		if cfg.Endpoint == "" || cfg.Bucket == "" {
+			return fmt.Errorf("storage endpoint and bucket are required for %s storage", cfg.Type)
+		}
+
+		partSize, err := sizes.ToBytes(cfg.PartSize)
+		if err != nil {
+			return fmt.Errorf("failed to parse s3 part size in config: %w", err)
+		}
+
+		// S3 rejects multipart parts smaller than 5mb except the last one
+		if partSize < 5*1024*1024 {
+			return fmt.Errorf("s3 part size must be at least 5mb")
+		}
+
+		cfg.PartSizeInBytes = partSize
+
+		if cfg.Timeout <= 0 {
+			return fmt.Errorf("s3 timeout must be positive")
+		}
+
+		if cfg.MaxConns <= 0 {
+			return fmt.Errorf("s3 max_conns must be positive")
+		}
+
+	default:
+		return fmt.Errorf("unknown storage type: %s", cfg.Type)
+	}
+
+	return nil
+}
+
+// masterKeySize is the size of AES-256 keys
+const masterKeySize = 32
+
+func validateEncryption(cfg *Encryption) error {
+	if !cfg.EncryptionEnabled {
+		if cfg.PasswordKeys {
+			return fmt.Errorf("storage encryption password_keys requires encryption to be enabled")
+		}
+
+		return nil
+	}
+
+	cfg.MasterKeys = make(map[string][]byte)
+	for _, pair := range strings.Split(cfg.EncryptionKeys, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(pair, ":")
+		if !ok || id == "" || len(id) > 255 {
+			return fmt.Errorf("STORAGE_ENCRYPTION_KEYS must be comma-separated id:base64 pairs")
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != masterKeySize {
+			return fmt.Errorf("storage encryption key %s must be %d base64-encoded bytes", id, masterKeySize)
+		}
+
+		if _, ok := cfg.MasterKeys[id]; ok {
+			return fmt.Errorf("storage encryption key %s is repeated", id)
+		}
+
+		cfg.MasterKeys[id] = key
+	}
+
+	if _, ok := cfg.MasterKeys[cfg.EncryptionKeyID]; !ok {
+		return fmt.Errorf("storage encryption key_id %q is not in STORAGE_ENCRYPTION_KEYS", cfg.EncryptionKeyID)
+	}
+
+	return nil
+}
+
+// minAliasGuessBits keeps the chance that a random alias points to a live
+// file below one in a million
+const minAliasGuessBits = 20
+
+func validateAlias(cfg *Config) error {
+	if cfg.AliasAttempts < 1 {
+		return fmt.Errorf("alias_attempts must be positive")
+	}
+
+	// every retry after a collision makes the alias one character or word longer
+	extra := int16(cfg.AliasAttempts - 1)
+
+	var entropy float64
+	switch cfg.AliasMode {
+	case AliasModeRandom:
+		if cfg.AliasLength < 1 {
+			return fmt.Errorf("alias_length must be positive")
+		}
+
+		if err := alias.ValidateAlphabet(cfg.AliasAlphabet); err != nil {
+			return fmt.Errorf("failed to validate alias_alphabet in config: %w", err)
+		}
+
+		if int(cfg.AliasLength+extra) > maxAliasLength {
+			return fmt.Errorf("alias_length with alias_attempts can't exceed %d characters", maxAliasLength)
+		}
+
+		entropy = alias.Entropy(cfg.AliasAlphabet, cfg.AliasLength)
+
+	case AliasModeWords:
+		if cfg.AliasWordCount < 1 {
+			return fmt.Errorf("alias_word_count must be positive")
+		}
+
+		if cfg.AliasSeparator != "-" && cfg.AliasSeparator != "_" {
+			return fmt.Errorf("alias_separator must be '-' or '_'")
+		}
+
+		if alias.MaxWordsLength(cfg.AliasWordCount+extra, cfg.AliasSeparator) > maxAliasLength {
+			return fmt.Errorf("alias_word_count with alias_attempts can't exceed %d characters", maxAliasLength)
+		}
+
+		entropy = alias.WordsEntropy(cfg.AliasWordCount)
+
+	default:
+		return fmt.Errorf("unknown alias mode: %s", cfg.AliasMode)
+	}
+
+	liveFiles := math.Log2(float64(max(cfg.ExpectedLiveFiles, 1)))
+
+	if entropy-liveFiles < minAliasGuessBits {
+		cfg.Warnings = append(cfg.Warnings, fmt.Sprintf(
+			"%s aliases have %.1f bits of entropy, with %d live files about one random alias in %.0f hits a file; make aliases longer",
+			cfg.AliasMode, entropy, cfg.ExpectedLiveFiles, math.Exp2(entropy-liveFiles)))
+	}
+
+	return nil
+}
+
+// maxAliasLength is the size of alias column
+const maxAliasLength = 50
+
+func validateVanityAliases(cfg *VanityAliases) error {
+	if cfg.VanityMinLength < 1 || cfg.VanityMinLength > cfg.VanityMaxLength {
+		return fmt.Errorf("vanity_aliases min_length must be between 1 and max_length")
+	}
+
+	if cfg.VanityMaxLength > maxAliasLength {
+		return fmt.Errorf("vanity_aliases max_length can't be greater than %d", maxAliasLength)
+	}
+
+	if cfg.VanityBlocklistPath == "" {
+		return nil
+	}
+
+	content, err := os.ReadFile(cfg.VanityBlocklistPath)
+	if err != nil {
+		return fmt.Errorf("failed to read vanity_aliases blocklist: %w", err)
+	}
+
+	// one word per line, blank lines and lines starting with # are skipped
+	for _, line := range strings.Split(string(content), "\n") {
+		word := strings.ToLower(strings.TrimSpace(line))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+
+		cfg.VanityBlocklist = append(cfg.VanityBlocklist, word)
+	}
+
+	return nil
+}
+
+func validatePasswordLockout(cfg *PasswordLockout) error {
+	if cfg.LockoutMaxFailures < 0 || cfg.LockoutMaxFailuresPerIP < 0 || cfg.DestroyAfterFailures < 0 {
+		return fmt.Errorf("password_lockout limits can't be negative")
+	}
+
+	if cfg.LockoutWindow <= 0 || cfg.LockoutDuration <= 0 {
+		return fmt.Errorf("password_lockout window and lock_duration must be positive")
+	}
+
+	if cfg.LockoutWebhook == "" {
+		return nil
+	}
+
+	webhook, err := url.Parse(cfg.LockoutWebhook)
+	if err != nil || (webhook.Scheme != "http" && webhook.Scheme != "https") || webhook.Host == "" {
+		return fmt.Errorf("password_lockout notify_webhook must be an http(s) url")
+	}
+
+	return nil
+}
+
+// validateRateLimits checks enabled policies, omitted burst equals requests
+func validateRateLimits(cfg *RateLimits) error {
+	policies := []struct {
+		name   string
+		policy *RatePolicy
+	}{
+		{"download", &cfg.DownloadRateLimit},
+		{"upload", &cfg.UploadRateLimit},
+		{"auth", &cfg.AuthRateLimit},
+	}
+
+	for _, limit := range policies {
+		if !limit.policy.Enabled() {
+			continue
+		}
+
+		if limit.policy.Period <= 0 {
+			return fmt.Errorf("rate_limits %s period must be positive", limit.name)
+		}
+
+		if limit.policy.Burst == 0 {
+			limit.policy.Burst = limit.policy.Requests
+		}
+
+		if limit.policy.Burst < 1 {
+			return fmt.Errorf("rate_limits %s burst must be positive", limit.name)
+		}
+	}
+
+	return nil
+}
+
+func validateRemoteUploads(cfg *RemoteUploads) error {
+	if cfg.FetchTimeout <= 0 || cfg.FetchStatusTTL <= 0 {
+		return fmt.Errorf("uploads remote timeout and status_ttl must be positive")
+	}
+
+	if cfg.MaxRedirects < 0 || cfg.MaxActiveFetches < 1 {
+		return fmt.Errorf("uploads remote max_redirects can't be negative and max_active_per_user must be positive")
+	}
+
+	for _, network := range cfg.AllowedNetworks {
+		if addr, err := netip.ParseAddr(network); err == nil {
+			addr = addr.Unmap()
+			cfg.AllowedPrefixes = append(cfg.AllowedPrefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return fmt.Errorf("uploads remote allowed_networks must be CIDRs or addresses: %w", err)
+		}
+
+		cfg.AllowedPrefixes = append(cfg.AllowedPrefixes, prefix.Masked())
+	}
+
+	return nil
+}
+
+// validatePermissions parses per-role sizes. Omitted max file size of a
+// role falls back to the storage max file size which is a hard limit
+func validatePermissions(cfg *Permissions, maxFileSize int64) error {
+	limits := []struct {
+		name    string
+		value   string
+		bytes   *int64
+		fileCap bool
+	}{
+		{"max_storage_for_vip", cfg.MaxStorageForVip, &cfg.MaxStorageForVipInBytes, false},
+		{"max_storage_for_user", cfg.MaxStorageForUser, &cfg.MaxStorageForUserInBytes, false},
+		{"max_storage_for_guest", cfg.MaxStorageForGuest, &cfg.MaxStorageForGuestInBytes, false},
+		{"max_file_size_for_vip", cfg.MaxFileSizeForVip, &cfg.MaxFileSizeForVipInBytes, true},
+		{"max_file_size_for_user", cfg.MaxFileSizeForUser, &cfg.MaxFileSizeForUserInBytes, true},
+		{"max_file_size_for_guest", cfg.MaxFileSizeForGuest, &cfg.MaxFileSizeForGuestInBytes, true},
+	}
+
+	for _, size := range limits {
+		if size.value == "" {
+			if size.fileCap {
+				*size.bytes = maxFileSize
+			}
+
+			continue
+		}
+
+		bytes, err := sizes.ToBytes(size.value)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s in config: %w", size.name, err)
+		}
+
+		if size.fileCap && bytes > maxFileSize {
+			return fmt.Errorf("%s can't be greater than storage max_file_size", size.name)
+		}
+
+		*size.bytes = bytes
+	}
+
+	return nil
+}
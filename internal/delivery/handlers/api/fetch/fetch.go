@@ -0,0 +1,262 @@
+package fetch
+
+import (
+	"context"
+	"expire-share/internal/config"
+	"expire-share/internal/delivery/handlers/api/upload"
+	"expire-share/internal/delivery/middlewares"
+	"expire-share/internal/delivery/util"
+	"expire-share/internal/delivery/util/response"
+	"expire-share/internal/domain/dto/fetches/commands"
+	"expire-share/internal/domain/dto/fetches/results"
+	fileCommands "expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/entities"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+)
+
+// Request represents remote upload request body
+//
+//	@Description	URL to fetch, omitted options are taken from config
+type Request struct {
+	URL string `json:"url" validate:"required,url" example:"https://ci.example.com/artifacts/build.tar.gz"`
+	// Filename overrides the name given by the remote server
+	Filename     string `json:"filename,omitempty" example:"build.tar.gz"`
+	TTL          string `json:"ttl,omitempty" example:"2h30m"`
+	MaxDownloads int16  `json:"max_downloads,omitempty" validate:"omitempty,min=1,max=10000" example:"5"`
+	Password     string `json:"password,omitempty" example:"1234"`
+	Alias        string `json:"alias,omitempty" example:"build-2026-10"`
+}
+
+// CreateResponse represents remote upload response
+//
+//	@Description	Remote upload is started, poll its status at status_url
+type CreateResponse struct {
+	response.Response
+	ID        string `json:"id,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	Status    string `json:"status,omitempty" example:"pending"`
+	StatusURL string `json:"status_url,omitempty" example:"/api/upload/url/9f86d081884c7d659a2feaa0c55ad015"`
+}
+
+// StatusResponse represents remote upload status
+//
+//	@Description	Status of remote upload: pending, running, completed or failed
+type StatusResponse struct {
+	response.Response
+	ID     string `json:"id,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	URL    string `json:"url,omitempty" example:"https://ci.example.com/artifacts/build.tar.gz"`
+	Status string `json:"status,omitempty" example:"running"`
+	// Filename is known once the remote server responded
+	Filename string `json:"filename,omitempty" example:"build.tar.gz"`
+	// Size is announced by the remote server, omitted when unknown
+	Size     int64 `json:"size,omitempty" example:"1048576"`
+	Received int64 `json:"received" example:"524288"`
+	// Alias is set once the file is shared
+	Alias string `json:"alias,omitempty" example:"abc123"`
+	// Error is set when the upload failed
+	Error      string     `json:"error,omitempty" example:"file size too big"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+type URLUploader interface {
+	CreateFetch(ctx context.Context, command commands.CreateFetch) (*results.CreateFetch, error)
+}
+
+type StatusGetter interface {
+	GetFetch(ctx context.Context, command commands.GetFetch) (*results.GetFetch, error)
+}
+
+// New @Summary Upload from URL
+//
+//	@Description	Shares a file the server fetches from an http(s) URL in the background, e.g. an artifact of an internal server, without downloading it first.
+//	@Description	The TTL, download limit, password and quotas are the same as for uploaded files, the size is checked as soon as the remote server announces it.
+//	@Description	Private, loopback and link-local addresses are refused unless allowed in config. Poll the status at the returned status_url until it is completed or failed.
+//	@Tags			file
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		Request				true	"URL and file options"
+//	@Success		202		{object}	CreateResponse
+//	@Header			202		{string}	Location			"URL of the remote upload status"
+//	@Failure		400		{object}	response.Response	"Invalid request body"
+//	@Failure		401		{object}	response.Response	"Unauthorized"
+//	@Failure		403		{object}	response.Response	"Forbidden (upload limit exceeded)"
+//	@Failure		422		{object}	response.Response	"Validation error or url not allowed"
+//	@Failure		429		{object}	response.Response	"Too many remote uploads in progress"
+//	@Failure		500		{object}	response.Response	"Internal server error"
+//	@Router			/api/upload/url [post]
+func New(uploader URLUploader, log *slog.Logger, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http.api.fetch.New"
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())))
+
+		claims, err := middlewares.GetUserClaims(r)
+		if err != nil {
+			log.Error("failed to get user claims", sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		request, ok := middlewares.GetParsedBodyRequest[Request](r)
+		if !ok {
+			log.Error("failed to parse request")
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		options, err := upload.ParseRequest(cfg.Service, func(key string) string {
+			switch key {
+			case "ttl":
+				return request.TTL
+			case "max_downloads":
+				if request.MaxDownloads != 0 {
+					return strconv.Itoa(int(request.MaxDownloads))
+				}
+			case "password":
+				return request.Password
+			}
+
+			return ""
+		})
+
+		if err != nil {
+			log.Info("invalid request", sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusBadRequest,
+				err.Error())
+			return
+		}
+
+		result, err := uploader.CreateFetch(r.Context(), commands.CreateFetch{
+			URL:          request.URL,
+			Filename:     request.Filename,
+			MaxDownloads: options.MaxDownloads,
+			Password:     options.Password,
+			TTL:          options.TTL,
+			Alias:        request.Alias,
+			RequestingUserInfo: fileCommands.RequestingUserInfo{
+				UserID: claims.UserID,
+				Roles:  claims.Roles,
+				IP:     util.ClientIP(r),
+			},
+		})
+
+		if err != nil {
+			if response.RenderFileServiceError(w, r, err) || util.IsCtxError(err) {
+				log.Info("failed to start remote upload", sl.Error(err))
+				return
+			}
+
+			log.Error("failed to start remote upload", sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		statusURL := fmt.Sprintf("%s/%s", strings.TrimSuffix(r.URL.Path, "/"), result.ID)
+
+		log.Info("remote upload was started", slog.String("fetch_id", result.ID))
+		w.Header().Set("Location", statusURL)
+		render.Status(r, http.StatusAccepted)
+		render.JSON(w, r, CreateResponse{
+			ID:        result.ID,
+			Status:    string(result.Status),
+			StatusURL: statusURL,
+		})
+	}
+}
+
+// NewStatus @Summary Get remote upload status
+//
+//	@Description	Returns the status of a remote upload with the alias of the file once it is completed or the reason it failed.
+//	@Description	Statuses of finished uploads are kept for uploads.remote.status_ttl. Requires authentication and upload ownership.
+//	@Tags			file
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Remote upload id"
+//	@Success		200	{object}	StatusResponse
+//	@Failure		401	{object}	response.Response	"Unauthorized"
+//	@Failure		403	{object}	response.Response	"Forbidden (not upload owner)"
+//	@Failure		404	{object}	response.Response	"Remote upload not found or has expired"
+//	@Failure		500	{object}	response.Response	"Internal server error"
+//	@Router			/api/upload/url/{id} [get]
+func NewStatus(getter StatusGetter, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http.api.fetch.NewStatus"
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())))
+
+		id := chi.URLParam(r, "id")
+
+		claims, err := middlewares.GetUserClaims(r)
+		if err != nil {
+			log.Error("failed to get user claims", sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		result, err := getter.GetFetch(r.Context(), commands.GetFetch{
+			ID: id,
+			RequestingUserInfo: fileCommands.RequestingUserInfo{
+				UserID: claims.UserID,
+				Roles:  claims.Roles,
+			},
+		})
+
+		if err != nil {
+			if response.RenderFileServiceError(w, r, err) || util.IsCtxError(err) {
+				log.Info("failed to get remote upload", sl.Error(err), slog.String("fetch_id", id))
+				return
+			}
+
+			log.Error("failed to get remote upload", sl.Error(err), slog.String("fetch_id", id))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		render.JSON(w, r, statusResponse(result.Fetch))
+	}
+}
+
+func statusResponse(fetch entities.Fetch) StatusResponse {
+	resp := StatusResponse{
+		ID:        fetch.ID,
+		URL:       fetch.URL,
+		Status:    string(fetch.Status),
+		Filename:  fetch.Filename,
+		Size:      max(fetch.Size, 0),
+		Received:  fetch.Received,
+		Alias:     fetch.Alias,
+		Error:     fetch.Error,
+		CreatedAt: fetch.CreatedAt,
+	}
+
+	if !fetch.FinishedAt.IsZero() {
+		resp.FinishedAt = &fetch.FinishedAt
+	}
+
+	return resp
+}
@@ -0,0 +1,216 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expire-share/internal/config"
+	"expire-share/internal/delivery/middlewares"
+	"expire-share/internal/domain/dto/fetches/commands"
+	"expire-share/internal/domain/dto/fetches/results"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/mocks"
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_Fetch(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	claims := &middlewares.UserClaims{UserID: 1, Roles: []entities.UserRole{entities.RoleUser}}
+
+	cfg := config.Config{
+		Service: config.Service{
+			MaxDownloads: 5,
+			DefaultTtl:   2 * time.Hour,
+		},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUploader := mocks.NewMockURLUploader(ctrl)
+		mockUploader.EXPECT().CreateFetch(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, cmd commands.CreateFetch) (*results.CreateFetch, error) {
+				require.Equal(t, "https://ci.example.com/build.tar.gz", cmd.URL)
+				require.Equal(t, "build.tar.gz", cmd.Filename)
+				require.Equal(t, time.Hour, cmd.TTL)
+				require.Equal(t, int16(5), cmd.MaxDownloads)
+				require.Equal(t, "secret", cmd.Password)
+				require.Equal(t, int64(1), cmd.UserID)
+				return &results.CreateFetch{ID: "f1", Status: entities.FetchPending}, nil
+			})
+
+		w := httptest.NewRecorder()
+		New(mockUploader, logger, cfg).ServeHTTP(w, newFetchRequest(Request{
+			URL:      "https://ci.example.com/build.tar.gz",
+			Filename: "build.tar.gz",
+			TTL:      "1h",
+			Password: "secret",
+		}, claims))
+
+		require.Equal(t, http.StatusAccepted, w.Code)
+		require.Equal(t, "/api/upload/url/f1", w.Header().Get("Location"))
+
+		var resp CreateResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		require.Equal(t, "f1", resp.ID)
+		require.Equal(t, "pending", resp.Status)
+		require.Equal(t, "/api/upload/url/f1", resp.StatusURL)
+	})
+
+	t.Run("invalid ttl", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		w := httptest.NewRecorder()
+		New(mocks.NewMockURLUploader(ctrl), logger, cfg).ServeHTTP(w,
+			newFetchRequest(Request{URL: "https://ci.example.com/build.tar.gz", TTL: "soon"}, claims))
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("service errors", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			err    error
+			status int
+		}{
+			{name: "url not allowed", err: domainErrors.ErrRemoteURLNotAllowed, status: http.StatusUnprocessableEntity},
+			{name: "too many fetches", err: domainErrors.ErrTooManyFetches, status: http.StatusTooManyRequests},
+			{name: "upload limit", err: domainErrors.ErrUploadLimitExceeded, status: http.StatusForbidden},
+			{name: "internal error", err: errors.New("boom"), status: http.StatusInternalServerError},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				mockUploader := mocks.NewMockURLUploader(ctrl)
+				mockUploader.EXPECT().CreateFetch(gomock.Any(), gomock.Any()).Return(nil, test.err)
+
+				w := httptest.NewRecorder()
+				New(mockUploader, logger, cfg).ServeHTTP(w,
+					newFetchRequest(Request{URL: "https://ci.example.com/build.tar.gz"}, claims))
+
+				require.Equal(t, test.status, w.Code)
+			})
+		}
+	})
+
+	t.Run("missing user claims", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		w := httptest.NewRecorder()
+		New(mocks.NewMockURLUploader(ctrl), logger, cfg).ServeHTTP(w,
+			newFetchRequest(Request{URL: "https://ci.example.com/build.tar.gz"}, nil))
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestHandler_FetchStatus(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	claims := &middlewares.UserClaims{UserID: 1, Roles: []entities.UserRole{entities.RoleUser}}
+
+	t.Run("completed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		finishedAt := time.Now()
+
+		mockGetter := mocks.NewMockStatusGetter(ctrl)
+		mockGetter.EXPECT().GetFetch(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, cmd commands.GetFetch) (*results.GetFetch, error) {
+				require.Equal(t, "f1", cmd.ID)
+				require.Equal(t, int64(1), cmd.UserID)
+				return &results.GetFetch{Fetch: entities.Fetch{
+					ID:         "f1",
+					URL:        "https://ci.example.com/build.tar.gz",
+					Filename:   "build.tar.gz",
+					Status:     entities.FetchCompleted,
+					Size:       8,
+					Received:   8,
+					Alias:      "abc123",
+					CreatedAt:  finishedAt.Add(-time.Minute),
+					FinishedAt: finishedAt,
+				}}, nil
+			})
+
+		w := httptest.NewRecorder()
+		NewStatus(mockGetter, logger).ServeHTTP(w, newStatusRequest("f1", claims))
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp StatusResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		require.Equal(t, "completed", resp.Status)
+		require.Equal(t, "abc123", resp.Alias)
+		require.Equal(t, int64(8), resp.Received)
+		require.NotNil(t, resp.FinishedAt)
+	})
+
+	t.Run("unknown size and running", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockGetter := mocks.NewMockStatusGetter(ctrl)
+		mockGetter.EXPECT().GetFetch(gomock.Any(), gomock.Any()).
+			Return(&results.GetFetch{Fetch: entities.Fetch{ID: "f1", Status: entities.FetchRunning, Size: -1, Received: 10}}, nil)
+
+		w := httptest.NewRecorder()
+		NewStatus(mockGetter, logger).ServeHTTP(w, newStatusRequest("f1", claims))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NotContains(t, w.Body.String(), `"size"`)
+		require.NotContains(t, w.Body.String(), `"finished_at"`)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockGetter := mocks.NewMockStatusGetter(ctrl)
+		mockGetter.EXPECT().GetFetch(gomock.Any(), gomock.Any()).Return(nil, domainErrors.ErrFetchNotFound)
+
+		w := httptest.NewRecorder()
+		NewStatus(mockGetter, logger).ServeHTTP(w, newStatusRequest("f1", claims))
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func newFetchRequest(request Request, claims *middlewares.UserClaims) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/api/upload/url", nil)
+
+	ctx := context.WithValue(r.Context(), "request", request)
+	if claims != nil {
+		ctx = context.WithValue(ctx, "user_id", claims.UserID)
+		ctx = context.WithValue(ctx, "roles", claims.Roles)
+	}
+
+	return r.WithContext(ctx)
+}
+
+func newStatusRequest(id string, claims *middlewares.UserClaims) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/upload/url/"+id, nil)
+
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("id", id)
+
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx)
+	ctx = context.WithValue(ctx, "user_id", claims.UserID)
+	ctx = context.WithValue(ctx, "roles", claims.Roles)
+
+	return r.WithContext(ctx)
+}
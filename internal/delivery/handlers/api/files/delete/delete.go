@@ -28,12 +28,13 @@ type FileDeleter interface {
 
 // New @Summary Delete file
 //
-//	@Description	Deletes uploaded file by its alias. Requires authentication and file ownership.
+//	@Description	Deletes uploaded file by its alias. Requires authentication and file ownership, or the management token for files uploaded by guests.
 //	@Tags			file
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
 //	@Param			alias	path	string	true	"File alias"
+//	@Param			X-Management-Token	header	string	false	"Management token of a guest upload"
 //	@Success		204		"No content"
 //	@Failure		401		{object}	response.Response	"Unauthorized"
 //	@Failure		403		{object}	response.Response	"Forbidden (not file owner)"
@@ -60,8 +61,9 @@ func New(deleter FileDeleter, log *slog.Logger) http.HandlerFunc {
 		err = deleter.DeleteFile(r.Context(), commands.DeleteFile{
 			Alias: alias,
 			RequestingUserInfo: commands.RequestingUserInfo{
-				UserID: claims.UserID,
-				Roles:  claims.Roles,
+				UserID:          claims.UserID,
+				Roles:           claims.Roles,
+				ManagementToken: r.Header.Get("X-Management-Token"),
 			},
 		})
 
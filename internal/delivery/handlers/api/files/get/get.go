@@ -24,6 +24,20 @@ type Response struct {
 	response.Response
 	DownloadsLeft int16  `json:"downloads_left,omitempty"`
 	ExpiresIn     string `json:"expires_in,omitempty"`
+	Size          int64  `json:"size,omitempty" example:"1048576"`
+	ContentType   string `json:"content_type,omitempty" example:"application/pdf"`
+	// SHA256 is a hex encoded checksum of the file content
+	SHA256 string `json:"sha256,omitempty" example:"b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"`
+	// Files are the contents of a multi-file share
+	Files []File `json:"files,omitempty"`
+}
+
+// File is one of the files of a multi-file share
+type File struct {
+	Name        string `json:"name" example:"report.pdf"`
+	Size        int64  `json:"size" example:"1048576"`
+	ContentType string `json:"content_type,omitempty" example:"application/pdf"`
+	SHA256      string `json:"sha256,omitempty" example:"b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"`
 }
 
 type FileGetter interface {
@@ -32,12 +46,13 @@ type FileGetter interface {
 
 // New @Summary Get file info
 //
-//	@Description	Get info about uploaded file by its alias. Requires authentication and file ownership.
+//	@Description	Get info about uploaded file by its alias: downloads left, expiration, size, content type and SHA-256 checksum, and the files of a multi-file share. Requires authentication and file ownership, or the management token for files uploaded by guests.
 //	@Tags			file
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
 //	@Param			alias	path		string	true	"File alias"
+//	@Param			X-Management-Token	header	string	false	"Management token of a guest upload"
 //	@Success		200		{object}	Response
 //	@Failure		401		{object}	response.Response	"Unauthorized"
 //	@Failure		403		{object}	response.Response	"Forbidden (not file owner)"
@@ -64,8 +79,9 @@ func New(getter FileGetter, log *slog.Logger) http.HandlerFunc {
 		file, err := getter.GetFileByAlias(r.Context(), commands.GetFile{
 			Alias: alias,
 			RequestingUserInfo: commands.RequestingUserInfo{
-				UserID: claims.UserID,
-				Roles:  claims.Roles,
+				UserID:          claims.UserID,
+				Roles:           claims.Roles,
+				ManagementToken: r.Header.Get("X-Management-Token"),
 			},
 		})
 
@@ -87,8 +103,30 @@ func New(getter FileGetter, log *slog.Logger) http.HandlerFunc {
 		render.Status(r, http.StatusOK)
 		render.JSON(w, r, Response{
 			DownloadsLeft: file.DownloadsLeft,
-			ExpiresIn: fmt.Sprintf("%02dm%02dh%02ds",
+			ExpiresIn: fmt.Sprintf("%02dh%02dm%02ds",
 				int(file.ExpiresIn.Hours()), int(file.ExpiresIn.Minutes())%60, int(file.ExpiresIn.Seconds())%60),
+			Size:        file.Size,
+			ContentType: file.ContentType,
+			SHA256:      file.SHA256,
+			Files:       newFiles(file.Members),
 		})
 	}
 }
+
+func newFiles(members []results.ShareMember) []File {
+	if len(members) == 0 {
+		return nil
+	}
+
+	files := make([]File, 0, len(members))
+	for _, member := range members {
+		files = append(files, File{
+			Name:        member.Name,
+			Size:        member.Size,
+			ContentType: member.ContentType,
+			SHA256:      member.SHA256,
+		})
+	}
+
+	return files
+}
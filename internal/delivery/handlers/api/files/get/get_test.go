@@ -38,6 +38,9 @@ func TestHandler_Get(t *testing.T) {
 				return &results.GetFile{
 					DownloadsLeft: 3,
 					ExpiresIn:     2 * time.Hour,
+					Size:          11,
+					ContentType:   "text/plain; charset=utf-8",
+					SHA256:        "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
 				}, nil
 			})
 
@@ -51,6 +54,9 @@ func TestHandler_Get(t *testing.T) {
 		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
 		require.Equal(t, int16(3), resp.DownloadsLeft)
 		require.NotEmpty(t, resp.ExpiresIn)
+		require.Equal(t, int64(11), resp.Size)
+		require.Equal(t, "text/plain; charset=utf-8", resp.ContentType)
+		require.Equal(t, "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", resp.SHA256)
 	})
 
 	t.Run("missing user claims", func(t *testing.T) {
@@ -0,0 +1,176 @@
+package list
+
+import (
+	"context"
+	"errors"
+	"expire-share/internal/delivery/middlewares"
+	"expire-share/internal/delivery/util"
+	"expire-share/internal/delivery/util/response"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/domain/entities"
+	"expire-share/internal/lib/log/sl"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+)
+
+const maxLimit = 100
+
+type File struct {
+	Alias             string    `json:"alias" example:"aB3dE9"`
+	Filename          string    `json:"filename" example:"report.pdf"`
+	Size              int64     `json:"size" example:"1048576"`
+	DownloadsLeft     int16     `json:"downloads_left" example:"3"`
+	PasswordProtected bool      `json:"password_protected"`
+	Status            string    `json:"status" enums:"active,exhausted,expired"`
+	CreatedAt         time.Time `json:"created_at"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+// Response represents list of user files
+//
+//	@Description	Page of files uploaded by current user
+type Response struct {
+	response.Response
+	Files      []File `json:"files"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type FileLister interface {
+	ListFiles(ctx context.Context, command commands.ListFiles) (*results.ListFiles, error)
+}
+
+// New @Summary List my files
+//
+//	@Description	Returns files uploaded by current user page by page. Pass next_cursor from previous response as cursor to get the next page.
+//	@Tags			file
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			limit	query		int		false	"Page size (1-100)"	default(20)
+//	@Param			cursor	query		string	false	"Cursor of the next page"
+//	@Param			sort	query		string	false	"Sort field"		Enums(created, expires)	default(created)
+//	@Param			order	query		string	false	"Sort order"		Enums(asc, desc)		default(desc)
+//	@Param			status	query		string	false	"Filter by status"	Enums(all, active, exhausted, expired)	default(all)
+//	@Success		200		{object}	Response
+//	@Failure		400		{object}	response.Response	"Invalid query parameters or cursor"
+//	@Failure		401		{object}	response.Response	"Unauthorized"
+//	@Failure		500		{object}	response.Response	"Internal server error"
+//	@Router			/api/files [get]
+func New(lister FileLister, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http.file.api.list.New"
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())))
+
+		claims, err := middlewares.GetUserClaims(r)
+		if err != nil {
+			log.Error("failed to get user claims", sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		command, err := parseQuery(r.URL.Query())
+		if err != nil {
+			log.Info("failed to parse query", sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusBadRequest,
+				err.Error())
+			return
+		}
+
+		command.RequestingUserInfo = commands.RequestingUserInfo{
+			UserID: claims.UserID,
+			Roles:  claims.Roles,
+		}
+
+		result, err := lister.ListFiles(r.Context(), command)
+		if err != nil {
+			const msg = "failed to list files"
+			if response.RenderFileServiceError(w, r, err) || util.IsCtxError(err) {
+				log.Info(msg, sl.Error(err))
+				return
+			}
+
+			log.Error(msg, sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		files := make([]File, 0, len(result.Files))
+		for _, file := range result.Files {
+			files = append(files, File{
+				Alias:             file.Alias,
+				Filename:          file.Filename,
+				Size:              file.Size,
+				DownloadsLeft:     file.DownloadsLeft,
+				PasswordProtected: file.PasswordProtected,
+				Status:            string(file.Status),
+				CreatedAt:         file.LoadedAt,
+				ExpiresAt:         file.ExpiresAt,
+			})
+		}
+
+		log.Info("files list was sent", slog.Int("count", len(files)))
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, Response{
+			Files:      files,
+			NextCursor: result.NextCursor,
+		})
+	}
+}
+
+func parseQuery(query url.Values) (commands.ListFiles, error) {
+	command := commands.ListFiles{
+		Cursor: query.Get("cursor"),
+		SortBy: entities.FileSortCreated,
+		Desc:   true,
+		Status: entities.FileStatusAll,
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return commands.ListFiles{}, errors.New("limit must be a number between 1 and 100")
+		}
+
+		command.Limit = limit
+	}
+
+	switch sortBy := query.Get("sort"); sortBy {
+	case "":
+	case entities.FileSortCreated, entities.FileSortExpires:
+		command.SortBy = entities.FileSort(sortBy)
+	default:
+		return commands.ListFiles{}, errors.New("sort must be one of: created, expires")
+	}
+
+	switch order := query.Get("order"); order {
+	case "", "desc":
+	case "asc":
+		command.Desc = false
+	default:
+		return commands.ListFiles{}, errors.New("order must be one of: asc, desc")
+	}
+
+	switch status := query.Get("status"); status {
+	case "":
+	case entities.FileStatusAll, entities.FileStatusActive, entities.FileStatusExhausted, entities.FileStatusExpired:
+		command.Status = entities.FileStatus(status)
+	default:
+		return commands.ListFiles{}, errors.New("status must be one of: all, active, exhausted, expired")
+	}
+
+	return command, nil
+}
@@ -0,0 +1,155 @@
+package list
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expire-share/internal/delivery/middlewares"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_List(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	claims := &middlewares.UserClaims{UserID: 1, Roles: []entities.UserRole{entities.RoleUser}}
+
+	t.Run("success with defaults", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockLister := mocks.NewMockFileLister(ctrl)
+		mockLister.EXPECT().ListFiles(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, cmd commands.ListFiles) (*results.ListFiles, error) {
+				require.Equal(t, int64(1), cmd.UserID)
+				require.Equal(t, entities.FileSort(entities.FileSortCreated), cmd.SortBy)
+				require.True(t, cmd.Desc)
+				require.Equal(t, entities.FileStatus(entities.FileStatusAll), cmd.Status)
+				require.Zero(t, cmd.Limit)
+
+				return &results.ListFiles{
+					Files: []results.FileSummary{{
+						Alias:             "abc123",
+						Filename:          "file.txt",
+						Size:              42,
+						DownloadsLeft:     2,
+						PasswordProtected: true,
+						Status:            entities.FileStatusActive,
+						ExpiresAt:         time.Now().Add(time.Hour),
+					}},
+					NextCursor: "next",
+				}, nil
+			})
+
+		w := httptest.NewRecorder()
+		New(mockLister, logger).ServeHTTP(w, newListRequest("", claims))
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp Response
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		require.Len(t, resp.Files, 1)
+		require.Equal(t, "abc123", resp.Files[0].Alias)
+		require.Equal(t, int64(42), resp.Files[0].Size)
+		require.True(t, resp.Files[0].PasswordProtected)
+		require.Equal(t, "active", resp.Files[0].Status)
+		require.Equal(t, "next", resp.NextCursor)
+	})
+
+	t.Run("query parameters", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockLister := mocks.NewMockFileLister(ctrl)
+		mockLister.EXPECT().ListFiles(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, cmd commands.ListFiles) (*results.ListFiles, error) {
+				require.Equal(t, 5, cmd.Limit)
+				require.Equal(t, "cur", cmd.Cursor)
+				require.Equal(t, entities.FileSort(entities.FileSortExpires), cmd.SortBy)
+				require.False(t, cmd.Desc)
+				require.Equal(t, entities.FileStatus(entities.FileStatusExpired), cmd.Status)
+				return &results.ListFiles{}, nil
+			})
+
+		w := httptest.NewRecorder()
+		New(mockLister, logger).ServeHTTP(w, newListRequest("?limit=5&cursor=cur&sort=expires&order=asc&status=expired", claims))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"files":[]}`, w.Body.String())
+	})
+
+	t.Run("invalid query parameters", func(t *testing.T) {
+		for _, query := range []string{"?limit=0", "?limit=101", "?limit=abc", "?sort=name", "?order=up", "?status=deleted"} {
+			ctrl := gomock.NewController(t)
+			mockLister := mocks.NewMockFileLister(ctrl)
+
+			w := httptest.NewRecorder()
+			New(mockLister, logger).ServeHTTP(w, newListRequest(query, claims))
+
+			require.Equal(t, http.StatusBadRequest, w.Code, query)
+			ctrl.Finish()
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockLister := mocks.NewMockFileLister(ctrl)
+		mockLister.EXPECT().ListFiles(gomock.Any(), gomock.Any()).
+			Return(nil, domainErrors.ErrInvalidCursor)
+
+		w := httptest.NewRecorder()
+		New(mockLister, logger).ServeHTTP(w, newListRequest("?cursor=bad", claims))
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("missing user claims", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockLister := mocks.NewMockFileLister(ctrl)
+
+		w := httptest.NewRecorder()
+		New(mockLister, logger).ServeHTTP(w, newListRequest("", nil))
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("internal error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockLister := mocks.NewMockFileLister(ctrl)
+		mockLister.EXPECT().ListFiles(gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("db error"))
+
+		w := httptest.NewRecorder()
+		New(mockLister, logger).ServeHTTP(w, newListRequest("", claims))
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func newListRequest(query string, claims *middlewares.UserClaims) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/files"+query, nil)
+
+	if claims != nil {
+		ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
+		ctx = context.WithValue(ctx, "roles", claims.Roles)
+		r = r.WithContext(ctx)
+	}
+
+	return r
+}
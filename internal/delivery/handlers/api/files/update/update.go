@@ -0,0 +1,155 @@
+package update
+
+import (
+	"context"
+	"expire-share/internal/delivery/middlewares"
+	"expire-share/internal/delivery/util"
+	"expire-share/internal/delivery/util/response"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+)
+
+// Request represents file update request body
+//
+//	@Description	Fields to change, omitted fields stay as they are
+type Request struct {
+	TTL            *string `json:"ttl,omitempty" example:"24h"`
+	DownloadsLeft  *int16  `json:"downloads_left,omitempty" validate:"omitempty,min=1,max=10000" example:"5"`
+	Password       *string `json:"password,omitempty" validate:"omitempty,min=1" example:"1234"`
+	RemovePassword bool    `json:"remove_password,omitempty" example:"false"`
+}
+
+// Response represents updated file information
+//
+//	@Description	Response with updated file info
+type Response struct {
+	response.Response
+	DownloadsLeft int16  `json:"downloads_left,omitempty"`
+	ExpiresIn     string `json:"expires_in,omitempty"`
+}
+
+type FileUpdater interface {
+	UpdateFile(ctx context.Context, command commands.UpdateFile) (*results.GetFile, error)
+}
+
+// New @Summary Update file
+//
+//	@Description	Changes ttl (counted from now), remaining downloads or password of uploaded file. Requires authentication and file ownership.
+//	@Description	Values are limited by the role of the user, admins are not limited.
+//	@Tags			file
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			alias	path		string				true	"File alias"
+//	@Param			request	body		Request				true	"Fields to change"
+//	@Success		200		{object}	Response
+//	@Failure		400		{object}	response.Response	"Invalid request body"
+//	@Failure		401		{object}	response.Response	"Unauthorized"
+//	@Failure		403		{object}	response.Response	"Forbidden (not file owner)"
+//	@Failure		404		{object}	response.Response	"File not found"
+//	@Failure		409		{object}	response.Response	"Password of file encrypted with it can't be changed"
+//	@Failure		422		{object}	response.Response	"Validation error or role limit exceeded"
+//	@Failure		500		{object}	response.Response	"Internal server error"
+//	@Router			/api/file/{alias} [patch]
+func New(updater FileUpdater, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http.file.api.update.New"
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())))
+
+		alias := chi.URLParam(r, "alias")
+
+		claims, err := middlewares.GetUserClaims(r)
+		if err != nil {
+			log.Error("failed to get user claims", sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		request, ok := middlewares.GetParsedBodyRequest[Request](r)
+		if !ok {
+			log.Error("failed to parse request")
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		command, err := toCommand(request)
+		if err != nil {
+			log.Info("invalid request", sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusBadRequest,
+				err.Error())
+			return
+		}
+
+		command.Alias = alias
+		command.RequestingUserInfo = commands.RequestingUserInfo{
+			UserID: claims.UserID,
+			Roles:  claims.Roles,
+		}
+
+		file, err := updater.UpdateFile(r.Context(), command)
+		if err != nil {
+			const msg = "failed to update file"
+			if response.RenderFileServiceError(w, r, err) || util.IsCtxError(err) {
+				log.Info(msg, sl.Error(err), slog.String("alias", alias))
+				return
+			}
+
+			log.Error(msg, sl.Error(err), slog.String("alias", alias))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		log.Info("file was successfully updated", slog.String("alias", alias))
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, Response{
+			DownloadsLeft: file.DownloadsLeft,
+			ExpiresIn: fmt.Sprintf("%02dh%02dm%02ds",
+				int(file.ExpiresIn.Hours()), int(file.ExpiresIn.Minutes())%60, int(file.ExpiresIn.Seconds())%60),
+		})
+	}
+}
+
+func toCommand(request Request) (commands.UpdateFile, error) {
+	if request.TTL == nil && request.DownloadsLeft == nil && request.Password == nil && !request.RemovePassword {
+		return commands.UpdateFile{}, fmt.Errorf("nothing to update")
+	}
+
+	if request.Password != nil && request.RemovePassword {
+		return commands.UpdateFile{}, fmt.Errorf("password and remove_password can't be used together")
+	}
+
+	command := commands.UpdateFile{
+		DownloadsLeft:  request.DownloadsLeft,
+		Password:       request.Password,
+		RemovePassword: request.RemovePassword,
+	}
+
+	if request.TTL != nil {
+		ttl, err := time.ParseDuration(*request.TTL)
+		if err != nil || ttl <= 0 {
+			return commands.UpdateFile{}, fmt.Errorf("ttl must be like '1h30m'")
+		}
+
+		command.TTL = &ttl
+	}
+
+	return command, nil
+}
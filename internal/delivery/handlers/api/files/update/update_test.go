@@ -0,0 +1,185 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expire-share/internal/delivery/middlewares"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/mocks"
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_Update(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	claims := &middlewares.UserClaims{UserID: 1, Roles: []entities.UserRole{entities.RoleUser}}
+
+	ttl := "2h"
+	downloads := int16(5)
+	password := "secret"
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUpdater := mocks.NewMockFileUpdater(ctrl)
+		mockUpdater.EXPECT().UpdateFile(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, cmd commands.UpdateFile) (*results.GetFile, error) {
+				require.Equal(t, "abc123", cmd.Alias)
+				require.Equal(t, int64(1), cmd.UserID)
+				require.Equal(t, 2*time.Hour, *cmd.TTL)
+				require.Equal(t, int16(5), *cmd.DownloadsLeft)
+				require.Equal(t, "secret", *cmd.Password)
+				return &results.GetFile{DownloadsLeft: 5, ExpiresIn: 2 * time.Hour}, nil
+			})
+
+		w := httptest.NewRecorder()
+		New(mockUpdater, logger).ServeHTTP(w, newUpdateRequest("abc123", Request{
+			TTL:           &ttl,
+			DownloadsLeft: &downloads,
+			Password:      &password,
+		}, claims))
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp Response
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		require.Equal(t, int16(5), resp.DownloadsLeft)
+		require.Equal(t, "02h00m00s", resp.ExpiresIn)
+	})
+
+	t.Run("remove password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUpdater := mocks.NewMockFileUpdater(ctrl)
+		mockUpdater.EXPECT().UpdateFile(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, cmd commands.UpdateFile) (*results.GetFile, error) {
+				require.True(t, cmd.RemovePassword)
+				require.Nil(t, cmd.Password)
+				require.Nil(t, cmd.TTL)
+				return &results.GetFile{DownloadsLeft: 1, ExpiresIn: time.Hour}, nil
+			})
+
+		w := httptest.NewRecorder()
+		New(mockUpdater, logger).ServeHTTP(w, newUpdateRequest("abc123", Request{RemovePassword: true}, claims))
+
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		invalidTtl := "tomorrow"
+		negativeTtl := "-1h"
+
+		for name, request := range map[string]Request{
+			"empty":                  {},
+			"invalid ttl":            {TTL: &invalidTtl},
+			"negative ttl":           {TTL: &negativeTtl},
+			"password and remove it": {Password: &password, RemovePassword: true},
+		} {
+			ctrl := gomock.NewController(t)
+			mockUpdater := mocks.NewMockFileUpdater(ctrl)
+
+			w := httptest.NewRecorder()
+			New(mockUpdater, logger).ServeHTTP(w, newUpdateRequest("abc123", request, claims))
+
+			require.Equal(t, http.StatusBadRequest, w.Code, name)
+			ctrl.Finish()
+		}
+	})
+
+	t.Run("role limit exceeded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUpdater := mocks.NewMockFileUpdater(ctrl)
+		mockUpdater.EXPECT().UpdateFile(gomock.Any(), gomock.Any()).
+			Return(nil, domainErrors.ErrTTLTooLong)
+
+		w := httptest.NewRecorder()
+		New(mockUpdater, logger).ServeHTTP(w, newUpdateRequest("abc123", Request{TTL: &ttl}, claims))
+
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUpdater := mocks.NewMockFileUpdater(ctrl)
+		mockUpdater.EXPECT().UpdateFile(gomock.Any(), gomock.Any()).
+			Return(nil, domainErrors.ErrForbidden)
+
+		w := httptest.NewRecorder()
+		New(mockUpdater, logger).ServeHTTP(w, newUpdateRequest("abc123", Request{TTL: &ttl}, claims))
+
+		require.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("file not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUpdater := mocks.NewMockFileUpdater(ctrl)
+		mockUpdater.EXPECT().UpdateFile(gomock.Any(), gomock.Any()).
+			Return(nil, domainErrors.ErrFileNotFound)
+
+		w := httptest.NewRecorder()
+		New(mockUpdater, logger).ServeHTTP(w, newUpdateRequest("missing", Request{TTL: &ttl}, claims))
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("missing user claims", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUpdater := mocks.NewMockFileUpdater(ctrl)
+
+		w := httptest.NewRecorder()
+		New(mockUpdater, logger).ServeHTTP(w, newUpdateRequest("abc123", Request{TTL: &ttl}, nil))
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("internal error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUpdater := mocks.NewMockFileUpdater(ctrl)
+		mockUpdater.EXPECT().UpdateFile(gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("db error"))
+
+		w := httptest.NewRecorder()
+		New(mockUpdater, logger).ServeHTTP(w, newUpdateRequest("abc123", Request{TTL: &ttl}, claims))
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func newUpdateRequest(alias string, request Request, claims *middlewares.UserClaims) *http.Request {
+	r := httptest.NewRequest(http.MethodPatch, "/api/file/"+alias, nil)
+
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("alias", alias)
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx)
+	ctx = context.WithValue(ctx, "request", request)
+
+	if claims != nil {
+		ctx = context.WithValue(ctx, "user_id", claims.UserID)
+		ctx = context.WithValue(ctx, "roles", claims.Roles)
+	}
+
+	return r.WithContext(ctx)
+}
@@ -0,0 +1,155 @@
+package paste
+
+import (
+	"context"
+	"expire-share/internal/config"
+	"expire-share/internal/delivery/handlers/api/upload"
+	"expire-share/internal/delivery/middlewares"
+	"expire-share/internal/delivery/util"
+	"expire-share/internal/delivery/util/response"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/lib/log/sl"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+)
+
+// Request represents paste request body
+//
+//	@Description	Text to share, omitted options are taken from config
+type Request struct {
+	Content string `json:"content" validate:"required" example:"panic: runtime error: index out of range"`
+	// Language highlights the paste, e.g. go, python or json. Plain text when omitted
+	Language     string `json:"language,omitempty" example:"go"`
+	TTL          string `json:"ttl,omitempty" example:"2h30m"`
+	MaxDownloads int16  `json:"max_downloads,omitempty" validate:"omitempty,min=1,max=10000" example:"5"`
+	Password     string `json:"password,omitempty" example:"1234"`
+	Alias        string `json:"alias,omitempty" example:"build-2026-10"`
+}
+
+// Response represents paste response
+//
+//	@Description	Response after successful paste, it is viewed at /p/{alias}
+type Response struct {
+	response.Response
+	Alias string `json:"alias,omitempty"`
+	// ManagementToken is returned to guests only, send it in X-Management-Token
+	// header to get info about the paste or delete it
+	ManagementToken string `json:"management_token,omitempty"`
+	Language        string `json:"language,omitempty" example:"go"`
+	Size            int64  `json:"size,omitempty" example:"1024"`
+	SHA256          string `json:"sha256,omitempty" example:"b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"`
+}
+
+type TextPaster interface {
+	Paste(ctx context.Context, command commands.Paste) (*results.UploadFile, error)
+}
+
+// New @Summary Paste text
+//
+//	@Description	Shares text, e.g. a stack trace or a config snippet, without making a file of it. It has the same TTL, download limit,
+//	@Description	password and quotas as uploaded files and is viewed at /p/{alias} highlighted by its language or as plain text at /p/{alias}/raw.
+//	@Description	Requires authentication unless guest uploads are enabled.
+//	@Tags			file
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		Request				true	"Text and its options"
+//	@Success		201		{object}	Response
+//	@Failure		400		{object}	response.Response	"Invalid request body"
+//	@Failure		401		{object}	response.Response	"Unauthorized"
+//	@Failure		403		{object}	response.Response	"Forbidden (upload limit exceeded or custom alias not allowed)"
+//	@Failure		409		{object}	response.Response	"Alias is already taken"
+//	@Failure		422		{object}	response.Response	"Validation error, unknown language or paste too large"
+//	@Failure		500		{object}	response.Response	"Internal server error"
+//	@Router			/api/paste [post]
+func New(paster TextPaster, log *slog.Logger, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http.api.paste.New"
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())))
+
+		claims, err := middlewares.GetUserClaims(r)
+		if err != nil {
+			log.Error("failed to get user claims", sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		request, ok := middlewares.GetParsedBodyRequest[Request](r)
+		if !ok {
+			log.Error("failed to parse request")
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		options, err := upload.ParseRequest(cfg.Service, func(key string) string {
+			switch key {
+			case "ttl":
+				return request.TTL
+			case "max_downloads":
+				if request.MaxDownloads != 0 {
+					return strconv.Itoa(int(request.MaxDownloads))
+				}
+			case "password":
+				return request.Password
+			}
+
+			return ""
+		})
+
+		if err != nil {
+			log.Info("invalid request", sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusBadRequest,
+				err.Error())
+			return
+		}
+
+		pasted, err := paster.Paste(r.Context(), commands.Paste{
+			Content:      request.Content,
+			Language:     request.Language,
+			MaxDownloads: options.MaxDownloads,
+			Password:     options.Password,
+			TTL:          options.TTL,
+			Alias:        request.Alias,
+			RequestingUserInfo: commands.RequestingUserInfo{
+				UserID: claims.UserID,
+				Roles:  claims.Roles,
+				IP:     util.ClientIP(r),
+			},
+		})
+
+		if err != nil {
+			if response.RenderFileServiceError(w, r, err) || util.IsCtxError(err) {
+				log.Info("failed to paste text", sl.Error(err))
+				return
+			}
+
+			log.Error("failed to paste text", sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		log.Info("text was successfully pasted", slog.String("alias", pasted.Alias))
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, Response{
+			Alias:           pasted.Alias,
+			ManagementToken: pasted.ManagementToken,
+			Language:        pasted.Language,
+			Size:            pasted.Size,
+			SHA256:          pasted.SHA256,
+		})
+	}
+}
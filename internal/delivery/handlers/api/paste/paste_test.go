@@ -0,0 +1,145 @@
+package paste
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expire-share/internal/config"
+	"expire-share/internal/delivery/middlewares"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_Paste(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	claims := &middlewares.UserClaims{UserID: 1, Roles: []entities.UserRole{entities.RoleUser}}
+
+	cfg := config.Config{
+		Service: config.Service{
+			MaxDownloads: 5,
+			DefaultTtl:   2 * time.Hour,
+		},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPaster := mocks.NewMockTextPaster(ctrl)
+		mockPaster.EXPECT().Paste(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, cmd commands.Paste) (*results.UploadFile, error) {
+				require.Equal(t, "panic: oops", cmd.Content)
+				require.Equal(t, "go", cmd.Language)
+				require.Equal(t, time.Hour, cmd.TTL)
+				require.Equal(t, int16(3), cmd.MaxDownloads)
+				require.Equal(t, "secret", cmd.Password)
+				require.Equal(t, int64(1), cmd.UserID)
+				return &results.UploadFile{Alias: "abc123", Language: "go", Size: 11}, nil
+			})
+
+		w := httptest.NewRecorder()
+		New(mockPaster, logger, cfg).ServeHTTP(w, newPasteRequest(Request{
+			Content:      "panic: oops",
+			Language:     "go",
+			TTL:          "1h",
+			MaxDownloads: 3,
+			Password:     "secret",
+		}, claims))
+
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var resp Response
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		require.Equal(t, "abc123", resp.Alias)
+		require.Equal(t, "go", resp.Language)
+		require.Equal(t, int64(11), resp.Size)
+	})
+
+	t.Run("defaults from config", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPaster := mocks.NewMockTextPaster(ctrl)
+		mockPaster.EXPECT().Paste(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, cmd commands.Paste) (*results.UploadFile, error) {
+				require.Equal(t, 2*time.Hour, cmd.TTL)
+				require.Equal(t, int16(5), cmd.MaxDownloads)
+				require.Empty(t, cmd.Password)
+				return &results.UploadFile{Alias: "abc123", Language: "text"}, nil
+			})
+
+		w := httptest.NewRecorder()
+		New(mockPaster, logger, cfg).ServeHTTP(w, newPasteRequest(Request{Content: "text"}, claims))
+
+		require.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("invalid ttl", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		w := httptest.NewRecorder()
+		New(mocks.NewMockTextPaster(ctrl), logger, cfg).ServeHTTP(w, newPasteRequest(Request{Content: "text", TTL: "soon"}, claims))
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unknown language", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPaster := mocks.NewMockTextPaster(ctrl)
+		mockPaster.EXPECT().Paste(gomock.Any(), gomock.Any()).Return(nil, domainErrors.ErrUnknownLanguage)
+
+		w := httptest.NewRecorder()
+		New(mockPaster, logger, cfg).ServeHTTP(w, newPasteRequest(Request{Content: "text", Language: "klingon"}, claims))
+
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("missing user claims", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		w := httptest.NewRecorder()
+		New(mocks.NewMockTextPaster(ctrl), logger, cfg).ServeHTTP(w, newPasteRequest(Request{Content: "text"}, nil))
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("internal error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockPaster := mocks.NewMockTextPaster(ctrl)
+		mockPaster.EXPECT().Paste(gomock.Any(), gomock.Any()).Return(nil, errors.New("db error"))
+
+		w := httptest.NewRecorder()
+		New(mockPaster, logger, cfg).ServeHTTP(w, newPasteRequest(Request{Content: "text"}, claims))
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func newPasteRequest(request Request, claims *middlewares.UserClaims) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/api/paste", nil)
+
+	ctx := context.WithValue(r.Context(), "request", request)
+	if claims != nil {
+		ctx = context.WithValue(ctx, "user_id", claims.UserID)
+		ctx = context.WithValue(ctx, "roles", claims.Roles)
+	}
+
+	return r.WithContext(ctx)
+}
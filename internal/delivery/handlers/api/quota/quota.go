@@ -0,0 +1,103 @@
+package quota
+
+import (
+	"context"
+	"expire-share/internal/delivery/middlewares"
+	"expire-share/internal/delivery/util"
+	"expire-share/internal/delivery/util/response"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/lib/log/sl"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+)
+
+// Response represents storage usage of current user
+//
+//	@Description	Usage versus role limits. Zero max_bytes, max_ttl and max_downloads mean there is no limit. Limits are omitted when unlimited is true
+type Response struct {
+	response.Response
+	Unlimited    bool   `json:"unlimited"`
+	FilesCount   int    `json:"files_count" example:"1"`
+	MaxFiles     int    `json:"max_files,omitempty" example:"10"`
+	UsedBytes    int64  `json:"used_bytes" example:"1048576"`
+	MaxBytes     int64  `json:"max_bytes,omitempty" example:"1073741824"`
+	MaxFileSize  int64  `json:"max_file_size,omitempty" example:"524288000"`
+	MaxTTL       string `json:"max_ttl,omitempty" example:"24h0m0s"`
+	MaxDownloads int16  `json:"max_downloads,omitempty" example:"100"`
+}
+
+type QuotaGetter interface {
+	GetQuota(ctx context.Context, command commands.GetQuota) (*results.GetQuota, error)
+}
+
+// New @Summary Get my quota
+//
+//	@Description	Returns how much storage current user uses and the limits of the user role
+//	@Tags			file
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	Response
+//	@Failure		401	{object}	response.Response	"Unauthorized"
+//	@Failure		500	{object}	response.Response	"Internal server error"
+//	@Router			/api/quota [get]
+func New(getter QuotaGetter, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http.api.quota.New"
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())))
+
+		claims, err := middlewares.GetUserClaims(r)
+		if err != nil {
+			log.Error("failed to get user claims", sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		quota, err := getter.GetQuota(r.Context(), commands.GetQuota{
+			RequestingUserInfo: commands.RequestingUserInfo{
+				UserID: claims.UserID,
+				Roles:  claims.Roles,
+			},
+		})
+
+		if err != nil {
+			const msg = "failed to get quota"
+			if response.RenderFileServiceError(w, r, err) || util.IsCtxError(err) {
+				log.Info(msg, sl.Error(err), slog.Int64("user_id", claims.UserID))
+				return
+			}
+
+			log.Error(msg, sl.Error(err), slog.Int64("user_id", claims.UserID))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		resp := Response{
+			Unlimited:    quota.Unlimited,
+			FilesCount:   quota.FilesCount,
+			MaxFiles:     quota.MaxFiles,
+			UsedBytes:    quota.UsedBytes,
+			MaxBytes:     quota.MaxBytes,
+			MaxFileSize:  quota.MaxFileSize,
+			MaxDownloads: quota.MaxDownloads,
+		}
+
+		if quota.MaxTTL > 0 {
+			resp.MaxTTL = quota.MaxTTL.String()
+		}
+
+		log.Info("quota was sent", slog.Int64("user_id", claims.UserID))
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, resp)
+	}
+}
@@ -0,0 +1,119 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"expire-share/internal/delivery/middlewares"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/domain/entities"
+	"expire-share/internal/mocks"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_Quota(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	claims := &middlewares.UserClaims{UserID: 1, Roles: []entities.UserRole{entities.RoleUser}}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockGetter := mocks.NewMockQuotaGetter(ctrl)
+		mockGetter.EXPECT().GetQuota(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, cmd commands.GetQuota) (*results.GetQuota, error) {
+				require.Equal(t, int64(1), cmd.UserID)
+				require.Equal(t, claims.Roles, cmd.Roles)
+				return &results.GetQuota{
+					FilesCount:   1,
+					MaxFiles:     10,
+					UsedBytes:    42,
+					MaxBytes:     1024,
+					MaxFileSize:  512,
+					MaxTTL:       24 * time.Hour,
+					MaxDownloads: 100,
+				}, nil
+			})
+
+		handler := New(mockGetter, logger)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newQuotaRequest(claims))
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp Response
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		require.False(t, resp.Unlimited)
+		require.Equal(t, int64(42), resp.UsedBytes)
+		require.Equal(t, int64(1024), resp.MaxBytes)
+		require.Equal(t, "24h0m0s", resp.MaxTTL)
+		require.Equal(t, int16(100), resp.MaxDownloads)
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockGetter := mocks.NewMockQuotaGetter(ctrl)
+		mockGetter.EXPECT().GetQuota(gomock.Any(), gomock.Any()).
+			Return(&results.GetQuota{Unlimited: true, UsedBytes: 42}, nil)
+
+		handler := New(mockGetter, logger)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newQuotaRequest(claims))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NotContains(t, w.Body.String(), "max_ttl")
+
+		var resp Response
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		require.True(t, resp.Unlimited)
+	})
+
+	t.Run("missing user claims", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockGetter := mocks.NewMockQuotaGetter(ctrl)
+
+		handler := New(mockGetter, logger)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newQuotaRequest(nil))
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("internal error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockGetter := mocks.NewMockQuotaGetter(ctrl)
+		mockGetter.EXPECT().GetQuota(gomock.Any(), gomock.Any()).
+			Return(nil, fmt.Errorf("db error"))
+
+		handler := New(mockGetter, logger)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newQuotaRequest(claims))
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func newQuotaRequest(claims *middlewares.UserClaims) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/quota", nil)
+	if claims == nil {
+		return r
+	}
+
+	ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
+	ctx = context.WithValue(ctx, "roles", claims.Roles)
+	return r.WithContext(ctx)
+}
@@ -8,8 +8,10 @@ import (
 	"expire-share/internal/delivery/util"
 	"expire-share/internal/delivery/util/response"
 	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
 	"expire-share/internal/lib/log/sl"
 	"log/slog"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"strconv"
@@ -23,6 +25,7 @@ type Request struct {
 	MaxDownloads int16         `json:"max_downloads,omitempty" validate:"min=1;max=10000" example:"5"`
 	TTL          time.Duration `json:"ttl,omitempty" example:"2h30m"`
 	Password     string        `json:"password,omitempty" example:"1234"`
+	Alias        string        `json:"alias,omitempty" example:"build-2026-10"`
 }
 
 // Response represents file upload response
@@ -31,29 +34,51 @@ type Request struct {
 type Response struct {
 	response.Response
 	Alias string `json:"alias,omitempty"`
+	// ManagementToken is returned to guests only, send it in X-Management-Token
+	// header to get info about the file or delete it
+	ManagementToken string `json:"management_token,omitempty"`
+	Size            int64  `json:"size,omitempty" example:"1048576"`
+	ContentType     string `json:"content_type,omitempty" example:"application/pdf"`
+	// SHA256 is a hex encoded checksum of the file content
+	SHA256 string `json:"sha256,omitempty" example:"b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"`
+	// Files are returned for a multi-file share
+	Files []File `json:"files,omitempty"`
+}
+
+// File is one of the files of a multi-file share
+type File struct {
+	Name        string `json:"name" example:"report.pdf"`
+	Size        int64  `json:"size" example:"1048576"`
+	ContentType string `json:"content_type,omitempty" example:"application/pdf"`
+	SHA256      string `json:"sha256,omitempty" example:"b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"`
 }
 
 type FileUploader interface {
-	UploadFile(ctx context.Context, command commands.UploadFile) (string, error)
+	UploadFile(ctx context.Context, command commands.UploadFile) (*results.UploadFile, error)
 }
 
 // New @Summary Upload file
 //
-//	@Description	Uploads file to server with optional password protection, download limit, and expiration time. Requires authentication.
+//	@Description	Uploads file to server with optional password protection, download limit, and expiration time. Requires authentication
+//	@Description	unless guest uploads are enabled. Guests are limited per IP and get a management token to get info about the file or delete it.
+//	@Description	Several file parts make a multi-file share, which is downloaded as a ZIP bundle or one file at a time.
+//	@Description	Filenames of its parts may be relative paths, e.g. of a folder upload, the directory tree is kept.
 //	@Tags			file
 //	@Accept			multipart/form-data
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Param			file			formData	file				true	"File to upload"
+//	@Param			file			formData	file				true	"File to upload, repeat the part to upload several files or a folder"
 //	@Param			max_downloads	formData	int16				false	"Maximum number of downloads (max: 10000)"
 //	@Param			ttl				formData	string				false	"Time to live (e.g., '1h', '2h30m', '7d')"
 //	@Param			password		formData	string				false	"File password (optional, required for download if set)"
+//	@Param			alias			formData	string				false	"Custom alias (roles allowed by config only)"
 //	@Success		201				{object}	Response			"File uploaded successfully"
 //	@Failure		400				{object}	response.Response	"Invalid request"
 //	@Failure		401				{object}	response.Response	"Unauthorized"
-//	@Failure		403				{object}	response.Response	"Forbidden (upload limit exceeded)"
+//	@Failure		403				{object}	response.Response	"Forbidden (upload limit exceeded or custom alias not allowed)"
+//	@Failure		409				{object}	response.Response	"Alias is already taken"
 //	@Failure		413				{object}	response.Response	"File too large"
-//	@Failure		422				{object}	response.Response	"Unprocessable entity"
+//	@Failure		422				{object}	response.Response	"Unprocessable entity (e.g. too many files or invalid path)"
 //	@Failure		500				{object}	response.Response	"Internal server error"
 //	@Router			/api/upload [post]
 func New(uploader FileUploader, log *slog.Logger, cfg config.Config) http.HandlerFunc {
@@ -90,34 +115,63 @@ func New(uploader FileUploader, log *slog.Logger, cfg config.Config) http.Handle
 			return
 		}
 
-		file, header, err := r.FormFile("file")
-		if err != nil {
-			log.Info("file is required", sl.Error(err))
+		headers := r.MultipartForm.File["file"]
+		if len(headers) == 0 {
+			log.Info("file is required")
 			response.RenderError(w, r,
 				http.StatusBadRequest,
 				"file is required")
 			return
 		}
 
-		defer func(file multipart.File) {
-			if err := file.Close(); err != nil {
-				log.Error("failed to close file", sl.Error(err))
+		files := make([]multipart.File, 0, len(headers))
+		defer func() {
+			for _, file := range files {
+				if err := file.Close(); err != nil {
+					log.Error("failed to close file", sl.Error(err))
+				}
 			}
-		}(file)
+		}()
 
-		alias, err := uploader.UploadFile(r.Context(), commands.UploadFile{
-			File:         file,
-			FileSize:     header.Size,
-			Filename:     header.Filename,
+		command := commands.UploadFile{
 			Password:     request.Password,
 			MaxDownloads: request.MaxDownloads,
 			TTL:          request.TTL,
+			Alias:        request.Alias,
 			RequestingUserInfo: commands.RequestingUserInfo{
 				UserID: claims.UserID,
 				Roles:  claims.Roles,
+				IP:     util.ClientIP(r),
 			},
-		})
+		}
+
+		for _, header := range headers {
+			file, err := header.Open()
+			if err != nil {
+				log.Error("failed to open file", sl.Error(err))
+				response.RenderError(w, r,
+					http.StatusInternalServerError,
+					"internal server error")
+				return
+			}
+
+			files = append(files, file)
+			command.FileSize += header.Size
+			command.Members = append(command.Members, commands.UploadMember{
+				File:     file,
+				Filename: partFilename(header),
+				Size:     header.Size,
+			})
+		}
 
+		// a single file is a share of its own, not a bundle of one file
+		if len(command.Members) == 1 {
+			command.File = command.Members[0].File
+			command.Filename = headers[0].Filename
+			command.Members = nil
+		}
+
+		uploaded, err := uploader.UploadFile(r.Context(), command)
 		if err != nil {
 			if response.RenderFileServiceError(w, r, err) || util.IsCtxError(err) {
 				log.Info("failed to upload file", sl.Error(err))
@@ -131,20 +185,67 @@ func New(uploader FileUploader, log *slog.Logger, cfg config.Config) http.Handle
 			return
 		}
 
-		log.Info("file was successfully uploaded", slog.String("alias", alias))
+		log.Info("file was successfully uploaded", slog.String("alias", uploaded.Alias))
 		render.Status(r, http.StatusCreated)
 		render.JSON(w, r, Response{
-			Alias: alias,
+			Alias:           uploaded.Alias,
+			ManagementToken: uploaded.ManagementToken,
+			Size:            uploaded.Size,
+			ContentType:     uploaded.ContentType,
+			SHA256:          uploaded.SHA256,
+			Files:           newFiles(uploaded.Members),
 		})
 	}
 }
 
+func newFiles(members []results.ShareMember) []File {
+	if len(members) == 0 {
+		return nil
+	}
+
+	files := make([]File, 0, len(members))
+	for _, member := range members {
+		files = append(files, File{
+			Name:        member.Name,
+			Size:        member.Size,
+			ContentType: member.ContentType,
+			SHA256:      member.SHA256,
+		})
+	}
+
+	return files
+}
+
+// partFilename returns the filename of the part as the client sent it.
+// multipart keeps only its base name, which loses the directories of files
+// of an uploaded folder
+func partFilename(header *multipart.FileHeader) string {
+	_, params, err := mime.ParseMediaType(header.Header.Get("Content-Disposition"))
+	if err != nil || params["filename"] == "" {
+		return header.Filename
+	}
+
+	return params["filename"]
+}
+
 func getRequestFromForm(cfg config.Service, r *http.Request) (Request, error) {
+	request, err := ParseRequest(cfg, r.FormValue)
+	if err != nil {
+		return Request{}, err
+	}
+
+	request.Alias = r.FormValue("alias")
+	return request, nil
+}
+
+// ParseRequest builds upload parameters from named values and fills
+// omitted ones with defaults from config
+func ParseRequest(cfg config.Service, value func(key string) string) (Request, error) {
 	var maxDownloads int16
-	maxDownloadsStr := r.FormValue("max_downloads")
+	maxDownloadsStr := value("max_downloads")
 
 	if maxDownloadsStr != "" {
-		parsedDownloads, err := strconv.ParseInt(r.FormValue("max_downloads"), 10, 16)
+		parsedDownloads, err := strconv.ParseInt(maxDownloadsStr, 10, 16)
 		if err != nil {
 			return Request{}, errors.New("max_downloads must be a number")
 		}
@@ -160,7 +261,7 @@ func getRequestFromForm(cfg config.Service, r *http.Request) (Request, error) {
 	}
 
 	var ttl time.Duration
-	ttlStr := r.FormValue("ttl")
+	ttlStr := value("ttl")
 
 	if ttlStr != "" {
 		var err error
@@ -176,6 +277,6 @@ func getRequestFromForm(cfg config.Service, r *http.Request) (Request, error) {
 	return Request{
 		MaxDownloads: maxDownloads,
 		TTL:          ttl,
-		Password:     r.FormValue("password"),
+		Password:     value("password"),
 	}, nil
 }
@@ -8,6 +8,7 @@ import (
 	"expire-share/internal/delivery/handlers/api/upload"
 	"expire-share/internal/delivery/middlewares"
 	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
 	"expire-share/internal/domain/entities"
 	domainErrors "expire-share/internal/domain/entities/errors"
 	"expire-share/internal/mocks"
@@ -49,12 +50,12 @@ func TestHandler_Upload(t *testing.T) {
 		mockUploader := mocks.NewMockFileUploader(ctrl)
 		mockUploader.EXPECT().
 			UploadFile(gomock.Any(), gomock.Any()).
-			DoAndReturn(func(ctx context.Context, cmd commands.UploadFile) (string, error) {
+			DoAndReturn(func(ctx context.Context, cmd commands.UploadFile) (*results.UploadFile, error) {
 				require.Equal(t, "test.txt", cmd.Filename)
 				require.Equal(t, int64(1), cmd.RequestingUserInfo.UserID)
 				require.Equal(t, 2*time.Hour, cmd.TTL)
 				require.Equal(t, int16(3), cmd.MaxDownloads)
-				return "abc123", nil
+				return &results.UploadFile{Alias: "abc123"}, nil
 			})
 
 		r := buildMultipartRequest(t, "test.txt", "hello world", map[string]string{
@@ -80,9 +81,9 @@ func TestHandler_Upload(t *testing.T) {
 		mockUploader := mocks.NewMockFileUploader(ctrl)
 		mockUploader.EXPECT().
 			UploadFile(gomock.Any(), gomock.Any()).
-			DoAndReturn(func(ctx context.Context, cmd commands.UploadFile) (string, error) {
+			DoAndReturn(func(ctx context.Context, cmd commands.UploadFile) (*results.UploadFile, error) {
 				require.Equal(t, "secret", cmd.Password)
-				return "xyz789", nil
+				return &results.UploadFile{Alias: "xyz789"}, nil
 			})
 
 		r := buildMultipartRequest(t, "doc.pdf", "pdf content", map[string]string{
@@ -100,6 +101,57 @@ func TestHandler_Upload(t *testing.T) {
 		require.Equal(t, http.StatusCreated, w.Code)
 	})
 
+	t.Run("several files make one share keeping their paths", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUploader := mocks.NewMockFileUploader(ctrl)
+		mockUploader.EXPECT().
+			UploadFile(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, cmd commands.UploadFile) (*results.UploadFile, error) {
+				require.Nil(t, cmd.File)
+				require.Len(t, cmd.Members, 2)
+				require.Equal(t, "report/a.txt", cmd.Members[0].Filename)
+				require.Equal(t, "report/logs/b.txt", cmd.Members[1].Filename)
+				require.Equal(t, int64(4), cmd.FileSize)
+
+				content, err := io.ReadAll(cmd.Members[1].File)
+				require.NoError(t, err)
+				require.Equal(t, "bb", string(content))
+
+				return &results.UploadFile{
+					Alias: "abc123",
+					Members: []results.ShareMember{
+						{Name: "a.txt", Size: 2},
+						{Name: "b.txt", Size: 2},
+					},
+				}, nil
+			})
+
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		for _, part := range []struct{ name, content string }{{"report/a.txt", "aa"}, {"report/logs/b.txt", "bb"}} {
+			fw, err := mw.CreateFormFile("file", part.name)
+			require.NoError(t, err)
+			_, err = fw.Write([]byte(part.content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, mw.Close())
+
+		r := httptest.NewRequest(http.MethodPost, "/upload", &buf)
+		r.Header.Set("Content-Type", mw.FormDataContentType())
+		r = withClaims(r, claims)
+
+		handler := upload.New(mockUploader, logger, testCfg)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		resp := parseResponse(t, w)
+		require.Len(t, resp.Files, 2)
+		require.Equal(t, "b.txt", resp.Files[1].Name)
+	})
+
 	t.Run("success with defaults", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -107,10 +159,10 @@ func TestHandler_Upload(t *testing.T) {
 		mockUploader := mocks.NewMockFileUploader(ctrl)
 		mockUploader.EXPECT().
 			UploadFile(gomock.Any(), gomock.Any()).
-			DoAndReturn(func(ctx context.Context, cmd commands.UploadFile) (string, error) {
+			DoAndReturn(func(ctx context.Context, cmd commands.UploadFile) (*results.UploadFile, error) {
 				require.Equal(t, testCfg.Service.DefaultTtl, cmd.TTL)
 				require.Equal(t, testCfg.Service.MaxDownloads, cmd.MaxDownloads)
-				return "def456", nil
+				return &results.UploadFile{Alias: "def456"}, nil
 			})
 
 		r := buildMultipartRequest(t, "file.txt", "content", nil)
@@ -227,7 +279,7 @@ func TestHandler_Upload(t *testing.T) {
 		mockUploader := mocks.NewMockFileUploader(ctrl)
 		mockUploader.EXPECT().
 			UploadFile(gomock.Any(), gomock.Any()).
-			Return("", domainErrors.ErrFileSizeTooBig)
+			Return(nil, domainErrors.ErrFileSizeTooBig)
 
 		r := buildMultipartRequest(t, "big.bin", "lots of data", map[string]string{
 			"ttl": "1h",
@@ -242,6 +294,107 @@ func TestHandler_Upload(t *testing.T) {
 		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
 	})
 
+	t.Run("success with custom alias", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUploader := mocks.NewMockFileUploader(ctrl)
+		mockUploader.EXPECT().
+			UploadFile(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, cmd commands.UploadFile) (*results.UploadFile, error) {
+				require.Equal(t, "build-2026-10", cmd.Alias)
+				return &results.UploadFile{Alias: cmd.Alias}, nil
+			})
+
+		r := buildMultipartRequest(t, "build.zip", "zip content", map[string]string{
+			"alias": "build-2026-10",
+		})
+
+		r = withClaims(r, claims)
+
+		handler := upload.New(mockUploader, logger, testCfg)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		resp := parseResponse(t, w)
+		require.Equal(t, "build-2026-10", resp.Alias)
+	})
+
+	t.Run("guest upload returns management token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUploader := mocks.NewMockFileUploader(ctrl)
+		mockUploader.EXPECT().
+			UploadFile(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, cmd commands.UploadFile) (*results.UploadFile, error) {
+				require.Equal(t, []entities.UserRole{entities.RoleGuest}, cmd.Roles)
+				require.Equal(t, "10.0.0.1", cmd.IP)
+				return &results.UploadFile{Alias: "guest1", ManagementToken: "token"}, nil
+			})
+
+		r := buildMultipartRequest(t, "test.txt", "hello world", nil)
+		r.RemoteAddr = "10.0.0.1:54321"
+		r = withClaims(r, &middlewares.UserClaims{
+			UserID: entities.GuestUserID,
+			Roles:  []entities.UserRole{entities.RoleGuest},
+		})
+
+		handler := upload.New(mockUploader, logger, testCfg)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		resp := parseResponse(t, w)
+		require.Equal(t, "guest1", resp.Alias)
+		require.Equal(t, "token", resp.ManagementToken)
+	})
+
+	t.Run("custom alias taken", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUploader := mocks.NewMockFileUploader(ctrl)
+		mockUploader.EXPECT().
+			UploadFile(gomock.Any(), gomock.Any()).
+			Return(nil, domainErrors.ErrAliasTaken)
+
+		r := buildMultipartRequest(t, "build.zip", "zip content", map[string]string{
+			"alias": "build-2026-10",
+		})
+
+		r = withClaims(r, claims)
+
+		handler := upload.New(mockUploader, logger, testCfg)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("custom alias not allowed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUploader := mocks.NewMockFileUploader(ctrl)
+		mockUploader.EXPECT().
+			UploadFile(gomock.Any(), gomock.Any()).
+			Return(nil, domainErrors.ErrVanityAliasForbidden)
+
+		r := buildMultipartRequest(t, "build.zip", "zip content", map[string]string{
+			"alias": "build-2026-10",
+		})
+
+		r = withClaims(r, claims)
+
+		handler := upload.New(mockUploader, logger, testCfg)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusForbidden, w.Code)
+	})
+
 	t.Run("context canceled", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -249,7 +402,7 @@ func TestHandler_Upload(t *testing.T) {
 		mockUploader := mocks.NewMockFileUploader(ctrl)
 		mockUploader.EXPECT().
 			UploadFile(gomock.Any(), gomock.Any()).
-			Return("", context.Canceled)
+			Return(nil, context.Canceled)
 
 		ctx, cancel := context.WithCancel(context.Background())
 		cancel()
@@ -272,7 +425,7 @@ func TestHandler_Upload(t *testing.T) {
 		mockUploader := mocks.NewMockFileUploader(ctrl)
 		mockUploader.EXPECT().
 			UploadFile(gomock.Any(), gomock.Any()).
-			Return("", fmt.Errorf("storage unavailable"))
+			Return(nil, fmt.Errorf("storage unavailable"))
 
 		r := buildMultipartRequest(t, "test.txt", "data", map[string]string{"ttl": "1h"})
 		r = withClaims(r, claims)
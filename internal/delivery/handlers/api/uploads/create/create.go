@@ -0,0 +1,156 @@
+package create
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"expire-share/internal/config"
+	"expire-share/internal/delivery/handlers/api/upload"
+	"expire-share/internal/delivery/middlewares"
+	"expire-share/internal/delivery/util"
+	"expire-share/internal/delivery/util/response"
+	fileCommands "expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/uploads/commands"
+	"expire-share/internal/domain/dto/uploads/results"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+type UploadCreator interface {
+	CreateUpload(ctx context.Context, command commands.CreateUpload) (*results.CreateUpload, error)
+}
+
+// New @Summary Create resumable upload
+//
+//	@Description	Creates a tus 1.0 upload. File content is sent afterwards with PATCH requests. Upload-Metadata may contain base64 encoded filename (required), ttl, max_downloads and password. Requires authentication.
+//	@Tags			uploads
+//	@Security		BearerAuth
+//	@Param			Tus-Resumable	header	string	true	"Protocol version (1.0.0)"
+//	@Param			Upload-Length	header	int		true	"Size of the whole file in bytes"
+//	@Param			Upload-Metadata	header	string	true	"Comma separated 'key base64(value)' pairs"
+//	@Success		201				"Upload created"
+//	@Header			201				{string}	Location		"URL of the created upload"
+//	@Header			201				{string}	Upload-Expires	"Time when unfinished upload is removed"
+//	@Failure		400				{object}	response.Response	"Invalid request"
+//	@Failure		401				{object}	response.Response	"Unauthorized"
+//	@Failure		412				"Unsupported tus version"
+//	@Failure		422				{object}	response.Response	"File too large or password-protected while file keys are bound to passwords"
+//	@Failure		500				{object}	response.Response	"Internal server error"
+//	@Router			/api/uploads [post]
+func New(creator UploadCreator, log *slog.Logger, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http.api.uploads.create.New"
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())))
+
+		claims, err := middlewares.GetUserClaims(r)
+		if err != nil {
+			log.Error("failed to get user claims", sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+		if err != nil || length < 0 {
+			log.Info("invalid upload length", slog.String("upload_length", r.Header.Get("Upload-Length")))
+			response.RenderError(w, r,
+				http.StatusBadRequest,
+				"Upload-Length must be a non-negative number")
+			return
+		}
+
+		metadata, err := parseMetadata(r.Header.Get("Upload-Metadata"))
+		if err != nil {
+			log.Info("failed to parse metadata", sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusBadRequest,
+				err.Error())
+			return
+		}
+
+		filename := metadata["filename"]
+		if filename == "" {
+			log.Info("filename is required")
+			response.RenderError(w, r,
+				http.StatusBadRequest,
+				"filename is required in Upload-Metadata")
+			return
+		}
+
+		request, err := upload.ParseRequest(cfg.Service, func(key string) string {
+			return metadata[key]
+		})
+
+		if err != nil {
+			log.Info("failed to parse metadata", sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusBadRequest,
+				err.Error())
+			return
+		}
+
+		result, err := creator.CreateUpload(r.Context(), commands.CreateUpload{
+			Length:       length,
+			Filename:     filename,
+			MaxDownloads: request.MaxDownloads,
+			Password:     request.Password,
+			TTL:          request.TTL,
+			RequestingUserInfo: fileCommands.RequestingUserInfo{
+				UserID: claims.UserID,
+				Roles:  claims.Roles,
+			},
+		})
+
+		if err != nil {
+			if response.RenderFileServiceError(w, r, err) || util.IsCtxError(err) {
+				log.Info("failed to create upload", sl.Error(err))
+				return
+			}
+
+			log.Error("failed to create upload", sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		log.Info("upload was created", slog.String("upload_id", result.ID))
+		w.Header().Set("Location", fmt.Sprintf("%s/%s", strings.TrimSuffix(r.URL.Path, "/"), result.ID))
+		w.Header().Set("Upload-Expires", result.ExpiresAt.UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// parseMetadata decodes Upload-Metadata header: comma separated pairs of
+// key and base64 encoded value divided by space, value may be omitted
+func parseMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errors.New("Upload-Metadata contains empty key")
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("Upload-Metadata value of %s is not base64", key)
+		}
+
+		metadata[key] = string(decoded)
+	}
+
+	return metadata, nil
+}
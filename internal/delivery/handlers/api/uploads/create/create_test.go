@@ -0,0 +1,216 @@
+package create
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"expire-share/internal/config"
+	"expire-share/internal/delivery/middlewares"
+	"expire-share/internal/domain/dto/uploads/commands"
+	"expire-share/internal/domain/dto/uploads/results"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_Create(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	claims := &middlewares.UserClaims{UserID: 1, Roles: []entities.UserRole{entities.RoleUser}}
+
+	cfg := config.Config{
+		Service: config.Service{
+			MaxDownloads: 1,
+			DefaultTtl:   time.Hour,
+		},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockCreator := mocks.NewMockUploadCreator(ctrl)
+		mockCreator.EXPECT().CreateUpload(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, cmd commands.CreateUpload) (*results.CreateUpload, error) {
+				require.Equal(t, int64(2048), cmd.Length)
+				require.Equal(t, "movie.mkv", cmd.Filename)
+				require.Equal(t, int16(3), cmd.MaxDownloads)
+				require.Equal(t, 2*time.Hour, cmd.TTL)
+				require.Equal(t, "secret", cmd.Password)
+				require.Equal(t, int64(1), cmd.UserID)
+				return &results.CreateUpload{ID: "upload-id", ExpiresAt: time.Now().Add(time.Hour)}, nil
+			})
+
+		r := newCreateRequest("2048", metadata(map[string]string{
+			"filename":      "movie.mkv",
+			"max_downloads": "3",
+			"ttl":           "2h",
+			"password":      "secret",
+		}), claims)
+
+		w := httptest.NewRecorder()
+		New(mockCreator, logger, cfg).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Equal(t, "/api/uploads/upload-id", w.Header().Get("Location"))
+		require.NotEmpty(t, w.Header().Get("Upload-Expires"))
+	})
+
+	t.Run("success with defaults", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockCreator := mocks.NewMockUploadCreator(ctrl)
+		mockCreator.EXPECT().CreateUpload(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, cmd commands.CreateUpload) (*results.CreateUpload, error) {
+				require.Equal(t, cfg.MaxDownloads, cmd.MaxDownloads)
+				require.Equal(t, cfg.DefaultTtl, cmd.TTL)
+				require.Empty(t, cmd.Password)
+				return &results.CreateUpload{ID: "upload-id"}, nil
+			})
+
+		r := newCreateRequest("10", metadata(map[string]string{"filename": "a.txt"}), claims)
+		w := httptest.NewRecorder()
+		New(mockCreator, logger, cfg).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("missing upload length", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockCreator := mocks.NewMockUploadCreator(ctrl)
+
+		r := newCreateRequest("", metadata(map[string]string{"filename": "a.txt"}), claims)
+		w := httptest.NewRecorder()
+		New(mockCreator, logger, cfg).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("missing filename", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockCreator := mocks.NewMockUploadCreator(ctrl)
+
+		r := newCreateRequest("10", metadata(map[string]string{"ttl": "1h"}), claims)
+		w := httptest.NewRecorder()
+		New(mockCreator, logger, cfg).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockCreator := mocks.NewMockUploadCreator(ctrl)
+
+		r := newCreateRequest("10", "filename not-base64!", claims)
+		w := httptest.NewRecorder()
+		New(mockCreator, logger, cfg).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid ttl", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockCreator := mocks.NewMockUploadCreator(ctrl)
+
+		r := newCreateRequest("10", metadata(map[string]string{"filename": "a.txt", "ttl": "soon"}), claims)
+		w := httptest.NewRecorder()
+		New(mockCreator, logger, cfg).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("file size too big", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockCreator := mocks.NewMockUploadCreator(ctrl)
+		mockCreator.EXPECT().CreateUpload(gomock.Any(), gomock.Any()).
+			Return(nil, domainErrors.ErrFileSizeTooBig)
+
+		r := newCreateRequest("10", metadata(map[string]string{"filename": "a.txt"}), claims)
+		w := httptest.NewRecorder()
+		New(mockCreator, logger, cfg).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("missing user claims", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockCreator := mocks.NewMockUploadCreator(ctrl)
+
+		r := newCreateRequest("10", metadata(map[string]string{"filename": "a.txt"}), nil)
+		w := httptest.NewRecorder()
+		New(mockCreator, logger, cfg).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("internal error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockCreator := mocks.NewMockUploadCreator(ctrl)
+		mockCreator.EXPECT().CreateUpload(gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("disk full"))
+
+		r := newCreateRequest("10", metadata(map[string]string{"filename": "a.txt"}), claims)
+		w := httptest.NewRecorder()
+		New(mockCreator, logger, cfg).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestParseMetadata(t *testing.T) {
+	result, err := parseMetadata("filename " + base64.StdEncoding.EncodeToString([]byte("a b.txt")) + ",is_confidential")
+	require.NoError(t, err)
+	require.Equal(t, "a b.txt", result["filename"])
+	require.Contains(t, result, "is_confidential")
+	require.Empty(t, result["is_confidential"])
+}
+
+func metadata(values map[string]string) string {
+	header := ""
+	for key, value := range values {
+		if header != "" {
+			header += ","
+		}
+
+		header += key + " " + base64.StdEncoding.EncodeToString([]byte(value))
+	}
+
+	return header
+}
+
+func newCreateRequest(length, metadata string, claims *middlewares.UserClaims) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/api/uploads/", nil)
+	r.Header.Set("Tus-Resumable", middlewares.TusVersion)
+	r.Header.Set("Upload-Length", length)
+	r.Header.Set("Upload-Metadata", metadata)
+
+	if claims != nil {
+		ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
+		ctx = context.WithValue(ctx, "roles", claims.Roles)
+		r = r.WithContext(ctx)
+	}
+
+	return r
+}
@@ -0,0 +1,84 @@
+package head
+
+import (
+	"context"
+	"expire-share/internal/delivery/middlewares"
+	"expire-share/internal/delivery/util"
+	"expire-share/internal/delivery/util/response"
+	fileCommands "expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/uploads/commands"
+	"expire-share/internal/domain/dto/uploads/results"
+	"expire-share/internal/lib/log/sl"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+)
+
+type UploadGetter interface {
+	GetUpload(ctx context.Context, command commands.GetUpload) (*results.GetUpload, error)
+}
+
+// New @Summary Get resumable upload offset
+//
+//	@Description	Returns how many bytes of the upload the server has received, so an interrupted upload can be resumed from that offset. Requires authentication and upload ownership.
+//	@Tags			uploads
+//	@Security		BearerAuth
+//	@Param			id				path	string	true	"Upload id"
+//	@Param			Tus-Resumable	header	string	true	"Protocol version (1.0.0)"
+//	@Success		200				"Upload state"
+//	@Header			200				{int}		Upload-Offset	"Received bytes"
+//	@Header			200				{int}		Upload-Length	"Size of the whole file"
+//	@Header			200				{string}	Upload-Expires	"Time when unfinished upload is removed"
+//	@Failure		401				"Unauthorized"
+//	@Failure		403				"Forbidden (not upload owner)"
+//	@Failure		404				"Upload not found"
+//	@Router			/api/uploads/{id} [head]
+func New(getter UploadGetter, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http.api.uploads.head.New"
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())))
+
+		id := chi.URLParam(r, "id")
+
+		claims, err := middlewares.GetUserClaims(r)
+		if err != nil {
+			log.Error("failed to get user claims", sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		result, err := getter.GetUpload(r.Context(), commands.GetUpload{
+			ID: id,
+			RequestingUserInfo: fileCommands.RequestingUserInfo{
+				UserID: claims.UserID,
+				Roles:  claims.Roles,
+			},
+		})
+
+		if err != nil {
+			if response.RenderFileServiceError(w, r, err) || util.IsCtxError(err) {
+				log.Info("failed to get upload", sl.Error(err), slog.String("upload_id", id))
+				return
+			}
+
+			log.Error("failed to get upload", sl.Error(err), slog.String("upload_id", id))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Upload-Offset", strconv.FormatInt(result.Offset, 10))
+		w.Header().Set("Upload-Length", strconv.FormatInt(result.Length, 10))
+		w.Header().Set("Upload-Expires", result.ExpiresAt.UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,101 @@
+package head
+
+import (
+	"context"
+	"expire-share/internal/delivery/middlewares"
+	"expire-share/internal/domain/dto/uploads/commands"
+	"expire-share/internal/domain/dto/uploads/results"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/mocks"
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_Head(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	claims := &middlewares.UserClaims{UserID: 1, Roles: []entities.UserRole{entities.RoleUser}}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockGetter := mocks.NewMockUploadGetter(ctrl)
+		mockGetter.EXPECT().GetUpload(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, cmd commands.GetUpload) (*results.GetUpload, error) {
+				require.Equal(t, "upload-id", cmd.ID)
+				require.Equal(t, int64(1), cmd.UserID)
+				return &results.GetUpload{Offset: 512, Length: 1024, ExpiresAt: time.Now()}, nil
+			})
+
+		w := httptest.NewRecorder()
+		New(mockGetter, logger).ServeHTTP(w, newHeadRequest("upload-id", claims))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "512", w.Header().Get("Upload-Offset"))
+		require.Equal(t, "1024", w.Header().Get("Upload-Length"))
+		require.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("upload not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockGetter := mocks.NewMockUploadGetter(ctrl)
+		mockGetter.EXPECT().GetUpload(gomock.Any(), gomock.Any()).
+			Return(nil, domainErrors.ErrUploadNotFound)
+
+		w := httptest.NewRecorder()
+		New(mockGetter, logger).ServeHTTP(w, newHeadRequest("missing", claims))
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockGetter := mocks.NewMockUploadGetter(ctrl)
+		mockGetter.EXPECT().GetUpload(gomock.Any(), gomock.Any()).
+			Return(nil, domainErrors.ErrForbidden)
+
+		w := httptest.NewRecorder()
+		New(mockGetter, logger).ServeHTTP(w, newHeadRequest("upload-id", claims))
+
+		require.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("missing user claims", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockGetter := mocks.NewMockUploadGetter(ctrl)
+
+		w := httptest.NewRecorder()
+		New(mockGetter, logger).ServeHTTP(w, newHeadRequest("upload-id", nil))
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func newHeadRequest(id string, claims *middlewares.UserClaims) *http.Request {
+	r := httptest.NewRequest(http.MethodHead, "/api/uploads/"+id, nil)
+
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("id", id)
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx)
+
+	if claims != nil {
+		ctx = context.WithValue(ctx, "user_id", claims.UserID)
+		ctx = context.WithValue(ctx, "roles", claims.Roles)
+	}
+
+	return r.WithContext(ctx)
+}
@@ -0,0 +1,28 @@
+package options
+
+import (
+	"expire-share/internal/config"
+	"expire-share/internal/delivery/middlewares"
+	"net/http"
+	"strconv"
+)
+
+const extensions = "creation,expiration,termination"
+
+// New @Summary Discover tus capabilities
+//
+//	@Description	Returns supported tus versions, extensions and maximum upload size.
+//	@Tags			uploads
+//	@Success		204	"Capabilities"
+//	@Header			204	{string}	Tus-Version		"Supported protocol versions"
+//	@Header			204	{string}	Tus-Extension	"Supported extensions"
+//	@Header			204	{int}		Tus-Max-Size	"Maximum upload size in bytes"
+//	@Router			/api/uploads [options]
+func New(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Tus-Version", middlewares.TusVersion)
+		w.Header().Set("Tus-Extension", extensions)
+		w.Header().Set("Tus-Max-Size", strconv.FormatInt(cfg.MaxFileSizeInBytes, 10))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
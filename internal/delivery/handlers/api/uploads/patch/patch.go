@@ -0,0 +1,115 @@
+package patch
+
+import (
+	"context"
+	"expire-share/internal/delivery/middlewares"
+	"expire-share/internal/delivery/util"
+	"expire-share/internal/delivery/util/response"
+	fileCommands "expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/uploads/commands"
+	"expire-share/internal/domain/dto/uploads/results"
+	"expire-share/internal/lib/log/sl"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+)
+
+const offsetContentType = "application/offset+octet-stream"
+
+type ChunkWriter interface {
+	WriteChunk(ctx context.Context, command commands.WriteChunk) (*results.WriteChunk, error)
+}
+
+// New @Summary Upload chunk
+//
+//	@Description	Appends request body to the upload at Upload-Offset. When the last byte arrives the file is shared with the parameters given on creation and its alias is returned in X-Resource-Alias header. Requires authentication and upload ownership.
+//	@Tags			uploads
+//	@Accept			application/offset+octet-stream
+//	@Security		BearerAuth
+//	@Param			id				path	string	true	"Upload id"
+//	@Param			Tus-Resumable	header	string	true	"Protocol version (1.0.0)"
+//	@Param			Upload-Offset	header	int		true	"Offset the chunk starts at"
+//	@Success		204				"Chunk accepted"
+//	@Header			204				{int}		Upload-Offset		"New offset"
+//	@Header			204				{string}	X-Resource-Alias	"Alias of the shared file, set after the last chunk"
+//	@Failure		400				{object}	response.Response	"Invalid request"
+//	@Failure		401				{object}	response.Response	"Unauthorized"
+//	@Failure		403				{object}	response.Response	"Forbidden (not upload owner or upload limit exceeded)"
+//	@Failure		404				{object}	response.Response	"Upload not found"
+//	@Failure		409				{object}	response.Response	"Offset does not match or upload is being completed by another request"
+//	@Failure		413				{object}	response.Response	"Chunk exceeds upload length"
+//	@Failure		415				{object}	response.Response	"Unsupported content type"
+//	@Failure		500				{object}	response.Response	"Internal server error"
+//	@Router			/api/uploads/{id} [patch]
+func New(writer ChunkWriter, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http.api.uploads.patch.New"
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())))
+
+		id := chi.URLParam(r, "id")
+
+		claims, err := middlewares.GetUserClaims(r)
+		if err != nil {
+			log.Error("failed to get user claims", sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		if r.Header.Get("Content-Type") != offsetContentType {
+			log.Info("unsupported content type", slog.String("content_type", r.Header.Get("Content-Type")))
+			response.RenderError(w, r,
+				http.StatusUnsupportedMediaType,
+				"content type must be "+offsetContentType)
+			return
+		}
+
+		offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+		if err != nil || offset < 0 {
+			log.Info("invalid upload offset", slog.String("upload_offset", r.Header.Get("Upload-Offset")))
+			response.RenderError(w, r,
+				http.StatusBadRequest,
+				"Upload-Offset must be a non-negative number")
+			return
+		}
+
+		result, err := writer.WriteChunk(r.Context(), commands.WriteChunk{
+			ID:     id,
+			Offset: offset,
+			Chunk:  r.Body,
+			RequestingUserInfo: fileCommands.RequestingUserInfo{
+				UserID: claims.UserID,
+				Roles:  claims.Roles,
+			},
+		})
+
+		if err != nil {
+			if response.RenderFileServiceError(w, r, err) || util.IsCtxError(err) {
+				log.Info("failed to write chunk", sl.Error(err), slog.String("upload_id", id))
+				return
+			}
+
+			log.Error("failed to write chunk", sl.Error(err), slog.String("upload_id", id))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		w.Header().Set("Upload-Offset", strconv.FormatInt(result.Offset, 10))
+		w.Header().Set("Upload-Expires", result.ExpiresAt.UTC().Format(http.TimeFormat))
+
+		if result.Alias != "" {
+			log.Info("file was successfully uploaded", slog.String("upload_id", id), slog.String("alias", result.Alias))
+			w.Header().Set("X-Resource-Alias", result.Alias)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,163 @@
+package patch
+
+import (
+	"context"
+	"errors"
+	"expire-share/internal/delivery/middlewares"
+	"expire-share/internal/domain/dto/uploads/commands"
+	"expire-share/internal/domain/dto/uploads/results"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/mocks"
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler_Patch(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	claims := &middlewares.UserClaims{UserID: 1, Roles: []entities.UserRole{entities.RoleUser}}
+
+	t.Run("success partial chunk", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockWriter := mocks.NewMockChunkWriter(ctrl)
+		mockWriter.EXPECT().WriteChunk(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, cmd commands.WriteChunk) (*results.WriteChunk, error) {
+				require.Equal(t, "upload-id", cmd.ID)
+				require.Equal(t, int64(100), cmd.Offset)
+
+				chunk, err := io.ReadAll(cmd.Chunk)
+				require.NoError(t, err)
+				require.Equal(t, "chunk", string(chunk))
+				return &results.WriteChunk{Offset: 105, ExpiresAt: time.Now()}, nil
+			})
+
+		w := httptest.NewRecorder()
+		New(mockWriter, logger).ServeHTTP(w, newPatchRequest("upload-id", "100", offsetContentType, "chunk", claims))
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Equal(t, "105", w.Header().Get("Upload-Offset"))
+		require.Empty(t, w.Header().Get("X-Resource-Alias"))
+	})
+
+	t.Run("success last chunk", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockWriter := mocks.NewMockChunkWriter(ctrl)
+		mockWriter.EXPECT().WriteChunk(gomock.Any(), gomock.Any()).
+			Return(&results.WriteChunk{Offset: 110, Alias: "abc123"}, nil)
+
+		w := httptest.NewRecorder()
+		New(mockWriter, logger).ServeHTTP(w, newPatchRequest("upload-id", "105", offsetContentType, "chunk", claims))
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Equal(t, "abc123", w.Header().Get("X-Resource-Alias"))
+	})
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockWriter := mocks.NewMockChunkWriter(ctrl)
+
+		w := httptest.NewRecorder()
+		New(mockWriter, logger).ServeHTTP(w, newPatchRequest("upload-id", "0", "text/plain", "chunk", claims))
+
+		require.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	t.Run("invalid offset", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockWriter := mocks.NewMockChunkWriter(ctrl)
+
+		w := httptest.NewRecorder()
+		New(mockWriter, logger).ServeHTTP(w, newPatchRequest("upload-id", "-1", offsetContentType, "chunk", claims))
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("offset mismatch", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockWriter := mocks.NewMockChunkWriter(ctrl)
+		mockWriter.EXPECT().WriteChunk(gomock.Any(), gomock.Any()).
+			Return(nil, domainErrors.ErrUploadOffsetMismatch)
+
+		w := httptest.NewRecorder()
+		New(mockWriter, logger).ServeHTTP(w, newPatchRequest("upload-id", "3", offsetContentType, "chunk", claims))
+
+		require.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("chunk exceeds length", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockWriter := mocks.NewMockChunkWriter(ctrl)
+		mockWriter.EXPECT().WriteChunk(gomock.Any(), gomock.Any()).
+			Return(nil, domainErrors.ErrUploadLengthExceeded)
+
+		w := httptest.NewRecorder()
+		New(mockWriter, logger).ServeHTTP(w, newPatchRequest("upload-id", "0", offsetContentType, "chunk", claims))
+
+		require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("upload limit exceeded on completion", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockWriter := mocks.NewMockChunkWriter(ctrl)
+		mockWriter.EXPECT().WriteChunk(gomock.Any(), gomock.Any()).
+			Return(nil, domainErrors.ErrUploadLimitExceeded)
+
+		w := httptest.NewRecorder()
+		New(mockWriter, logger).ServeHTTP(w, newPatchRequest("upload-id", "0", offsetContentType, "chunk", claims))
+
+		require.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("internal error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockWriter := mocks.NewMockChunkWriter(ctrl)
+		mockWriter.EXPECT().WriteChunk(gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("disk full"))
+
+		w := httptest.NewRecorder()
+		New(mockWriter, logger).ServeHTTP(w, newPatchRequest("upload-id", "0", offsetContentType, "chunk", claims))
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func newPatchRequest(id, offset, contentType, body string, claims *middlewares.UserClaims) *http.Request {
+	r := httptest.NewRequest(http.MethodPatch, "/api/uploads/"+id, strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	r.Header.Set("Upload-Offset", offset)
+
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("id", id)
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx)
+
+	if claims != nil {
+		ctx = context.WithValue(ctx, "user_id", claims.UserID)
+		ctx = context.WithValue(ctx, "roles", claims.Roles)
+	}
+
+	return r.WithContext(ctx)
+}
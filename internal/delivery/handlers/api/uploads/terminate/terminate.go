@@ -0,0 +1,76 @@
+package terminate
+
+import (
+	"context"
+	"expire-share/internal/delivery/middlewares"
+	"expire-share/internal/delivery/util"
+	"expire-share/internal/delivery/util/response"
+	fileCommands "expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/uploads/commands"
+	"expire-share/internal/lib/log/sl"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+)
+
+type UploadTerminator interface {
+	TerminateUpload(ctx context.Context, command commands.TerminateUpload) error
+}
+
+// New @Summary Terminate resumable upload
+//
+//	@Description	Cancels unfinished upload and removes received data. Requires authentication and upload ownership.
+//	@Tags			uploads
+//	@Security		BearerAuth
+//	@Param			id				path	string	true	"Upload id"
+//	@Param			Tus-Resumable	header	string	true	"Protocol version (1.0.0)"
+//	@Success		204				"No content"
+//	@Failure		401				{object}	response.Response	"Unauthorized"
+//	@Failure		403				{object}	response.Response	"Forbidden (not upload owner)"
+//	@Failure		404				{object}	response.Response	"Upload not found"
+//	@Router			/api/uploads/{id} [delete]
+func New(terminator UploadTerminator, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http.api.uploads.terminate.New"
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())))
+
+		id := chi.URLParam(r, "id")
+
+		claims, err := middlewares.GetUserClaims(r)
+		if err != nil {
+			log.Error("failed to get user claims", sl.Error(err))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		err = terminator.TerminateUpload(r.Context(), commands.TerminateUpload{
+			ID: id,
+			RequestingUserInfo: fileCommands.RequestingUserInfo{
+				UserID: claims.UserID,
+				Roles:  claims.Roles,
+			},
+		})
+
+		if err != nil {
+			if response.RenderFileServiceError(w, r, err) || util.IsCtxError(err) {
+				log.Info("failed to terminate upload", sl.Error(err), slog.String("upload_id", id))
+				return
+			}
+
+			log.Error("failed to terminate upload", sl.Error(err), slog.String("upload_id", id))
+			response.RenderError(w, r,
+				http.StatusInternalServerError,
+				"internal server error")
+			return
+		}
+
+		log.Info("upload was terminated", slog.String("upload_id", id))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,85 @@
+package terminate
+
+import (
+	"context"
+	"errors"
+	"expire-share/internal/delivery/middlewares"
+	"expire-share/internal/domain/dto/uploads/commands"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/mocks"
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_Terminate(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	claims := &middlewares.UserClaims{UserID: 1, Roles: []entities.UserRole{entities.RoleUser}}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTerminator := mocks.NewMockUploadTerminator(ctrl)
+		mockTerminator.EXPECT().TerminateUpload(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, cmd commands.TerminateUpload) error {
+				require.Equal(t, "upload-id", cmd.ID)
+				require.Equal(t, int64(1), cmd.UserID)
+				return nil
+			})
+
+		w := httptest.NewRecorder()
+		New(mockTerminator, logger).ServeHTTP(w, newTerminateRequest("upload-id", claims))
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("upload not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTerminator := mocks.NewMockUploadTerminator(ctrl)
+		mockTerminator.EXPECT().TerminateUpload(gomock.Any(), gomock.Any()).
+			Return(domainErrors.ErrUploadNotFound)
+
+		w := httptest.NewRecorder()
+		New(mockTerminator, logger).ServeHTTP(w, newTerminateRequest("missing", claims))
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("internal error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTerminator := mocks.NewMockUploadTerminator(ctrl)
+		mockTerminator.EXPECT().TerminateUpload(gomock.Any(), gomock.Any()).
+			Return(errors.New("disk error"))
+
+		w := httptest.NewRecorder()
+		New(mockTerminator, logger).ServeHTTP(w, newTerminateRequest("upload-id", claims))
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func newTerminateRequest(id string, claims *middlewares.UserClaims) *http.Request {
+	r := httptest.NewRequest(http.MethodDelete, "/api/uploads/"+id, nil)
+
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("id", id)
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx)
+
+	if claims != nil {
+		ctx = context.WithValue(ctx, "user_id", claims.UserID)
+		ctx = context.WithValue(ctx, "roles", claims.Roles)
+	}
+
+	return r.WithContext(ctx)
+}
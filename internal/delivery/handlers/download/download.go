@@ -2,24 +2,33 @@ package download
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"expire-share/internal/delivery/util"
 	"expire-share/internal/delivery/util/response"
 	"expire-share/internal/domain/dto/files/commands"
 	"expire-share/internal/domain/dto/files/results"
+	domainErrors "expire-share/internal/domain/entities/errors"
 	"expire-share/internal/lib/log/sl"
 	"fmt"
-	"io"
 	"log/slog"
 	"mime"
 	"net/http"
+	"net/url"
 	"path/filepath"
-	"syscall"
+	"strings"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 )
 
+const (
+	sessionHeader = "X-Download-Session"
+	sessionCookie = "download_session"
+)
+
 // Response represents standard API error response
 //
 //	@Description	Standard error response structure
@@ -29,21 +38,48 @@ type Response struct {
 
 type FileDownloader interface {
 	DownloadFile(ctx context.Context, command commands.DownloadFile) (*results.DownloadFile, error)
+	ConfirmDownload(ctx context.Context, command commands.FinishDownload) error
+	ReleaseDownload(ctx context.Context, command commands.FinishDownload) error
 }
 
 // New @Summary Download file
 //
 //	@Description	Downloads uploaded file by its alias. If file is password-protected, provide password in X-Resource-Password header.
+//	@Description	A download is started with POST only. GET continues a download started before and requires its session in X-Download-Session header or cookie,
+//	@Description	a GET without a valid session is redirected to the landing page /s/{alias}, so link scanners and prefetchers never count a download.
+//	@Description	Supports Range, If-Range and conditional requests on GET. A download session is counted against the download limit once the whole file was sent,
+//	@Description	bytes of ranged transfers of the session add up, an interrupted transfer is not counted. Follow-up requests (e.g. resumed or ranged ones) that send the session back are not counted again
+//	@Description	until the download was counted, the session of a counted download can't be continued.
+//	@Description	Link previews, crawlers and HEAD requests are redirected to the landing page and never count a download.
+//	@Description	A multi-file share is downloaded as a ZIP bundle produced on the fly, which can't be ranged, or one file at a time by its path in the share.
+//	@Description	The whole share counts as one download, a session covers every file of it until bytes sent of its files add up to the size of the share.
 //	@Tags			file
 //	@Accept			json
 //	@Produce		application/octet-stream
 //	@Param			alias				path		string				true	"File alias"
+//	@Param			path				path		string				false	"Path of a file of a multi-file share, e.g. report/index.html"
 //	@Param			X-Resource-Password	header		string				false	"File password (required for password-protected files)"
+//	@Param			X-Download-Session	header		string				false	"Download session returned by previous request, required for GET"
+//	@Param			Range				header		string				false	"Byte range, e.g. bytes=0-1023"
 //	@Success		200					{file}		binary				"File content"
+//	@Success		206					{file}		binary				"Partial file content"
+//	@Header			200,206				{string}	X-Download-Session	"Download session"
+//	@Header			200,206				{string}	ETag				"File entity tag"
+//	@Header			200,206				{string}	Repr-Digest			"SHA-256 of the whole file, e.g. sha-256=:base64:"
+//	@Header			200,206				{string}	Content-Encoding	"gzip when the file is stored compressed and the client accepts it"
+//	@Success		302					{string}	string				"Link preview is redirected to the landing page"
+//	@Success		303					{string}	string				"GET without a valid session is redirected to the landing page"
 //	@Failure		403					{object}	response.Response	"File password required or invalid password"
 //	@Failure		404					{object}	response.Response	"File not found or has expired"
+//	@Failure		410					{object}	response.Response	"File has no downloads left"
+//	@Failure		416					{string}	string				"Range not satisfiable"
+//	@Failure		423					{object}	response.Response	"File is locked after too many wrong passwords"
+//	@Failure		429					{object}	response.Response	"Too many wrong passwords from client"
 //	@Failure		500					{object}	response.Response	"Internal server error"
+//	@Router			/download/{alias} [post]
 //	@Router			/download/{alias} [get]
+//	@Router			/download/{alias}/{path} [post]
+//	@Router			/download/{alias}/{path} [get]
 func New(downloader FileDownloader, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const fn = "http.download.New"
@@ -52,55 +88,334 @@ func New(downloader FileDownloader, log *slog.Logger) http.HandlerFunc {
 			slog.String("request_id", middleware.GetReqID(r.Context())))
 
 		alias := chi.URLParam(r, "alias")
-		password := r.Header.Get("X-Resource-Password")
-
-		file, err := downloader.DownloadFile(r.Context(), commands.DownloadFile{
-			Alias:    alias,
-			Password: password,
-		})
-
-		if err != nil {
-			const msg = "failed to get file info"
-			if response.RenderFileServiceError(w, r, err) || util.IsCtxError(err) {
-				log.Info(msg, sl.Error(err), slog.String("alias", alias))
-				return
-			}
 
-			log.Error(msg, sl.Error(err), slog.String("alias", alias))
-			response.RenderError(w, r,
-				http.StatusInternalServerError,
-				"internal server error")
+		// link previews and crawlers are sent to the landing page, which
+		// never counts a download
+		if util.IsPreviewRequest(r) {
+			http.Redirect(w, r, landingPath(alias), http.StatusFound)
+			return
+		}
+
+		// a plain GET may come from link scanners and prefetchers not known
+		// as previews, it only continues a download started with POST
+		explicit := r.Method == http.MethodPost
+		session := getSession(r)
+
+		if !explicit && session == "" {
+			log.Info("download was not started explicitly", slog.String("alias", alias))
+			http.Redirect(w, r, landingPath(alias), http.StatusSeeOther)
 			return
 		}
 
-		defer func() {
-			if err := file.Close(); err != nil {
-				log.Error("failed to close file", sl.Error(err))
+		serveFile(w, r, downloader, log, commands.DownloadFile{
+			Alias:          alias,
+			Password:       r.Header.Get("X-Resource-Password"),
+			Session:        session,
+			IP:             util.ClientIP(r),
+			Member:         pathParam(r),
+			RequireSession: !explicit,
+		}, func(w http.ResponseWriter, r *http.Request, err error) bool {
+			if errors.Is(err, domainErrors.ErrDownloadNotStarted) {
+				http.Redirect(w, r, landingPath(alias), http.StatusSeeOther)
+				return true
 			}
-		}()
 
-		contentType := mime.TypeByExtension(filepath.Ext(file.FileInfo.Name()))
-		if contentType == "" {
-			contentType = "application/octet-stream"
+			return response.RenderFileServiceError(w, r, err)
+		}, false)
+	}
+}
+
+// serveFile downloads the file and counts the download once the transfer
+// completes. Known service errors are rendered with renderError. With
+// inlineText the file is shown as plain text instead of saved, e.g. raw
+// pastes read with curl
+func serveFile(w http.ResponseWriter, r *http.Request, downloader FileDownloader, log *slog.Logger,
+	command commands.DownloadFile, renderError func(http.ResponseWriter, *http.Request, error) bool, inlineText bool) {
+	alias := command.Alias
+
+	file, err := downloader.DownloadFile(r.Context(), command)
+	if err != nil {
+		const msg = "failed to get file info"
+		if renderError(w, r, err) || util.IsCtxError(err) {
+			log.Info(msg, sl.Error(err), slog.String("alias", alias))
+			return
+		}
+
+		log.Error(msg, sl.Error(err), slog.String("alias", alias))
+		response.RenderError(w, r,
+			http.StatusInternalServerError,
+			"internal server error")
+		return
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Error("failed to close file", sl.Error(err))
 		}
+	}()
 
-		w.Header().Set("Content-Type", contentType)
-		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", file.FileInfo.Name()))
-		w.Header().Set("Content-Length", fmt.Sprintf("%d", file.FileInfo.Size()))
+	filename := downloadFilename(file)
 
-		if _, err := io.Copy(w, file.File); err != nil {
-			if errors.Is(err, syscall.EPIPE) || errors.Is(err, io.ErrClosedPipe) {
-				log.Info("client disconnected during download")
-				return
-			}
+	contentType := file.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(filename))
+	}
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
 
-			log.Error("failed to write response", sl.Error(err))
-			response.RenderError(w, r,
-				http.StatusInternalServerError,
-				"internal server error")
+	disposition := "attachment"
+	if inlineText {
+		// a multi-file share has no text of its own
+		if file.Bundle != nil {
+			finishDownload(r, downloader, log, alias, file, 0, 0, false)
+			renderError(w, r, domainErrors.ErrFileNotFound)
 			return
 		}
 
-		log.Info("file was successfully downloaded", slog.String("alias", alias))
+		contentType, disposition = "text/plain; charset=utf-8", "inline"
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("%s; filename=\"%s\"", disposition, filename))
+	w.Header().Set("Cache-Control", "private, no-cache")
+
+	// the session of a file of a multi-file share covers the others, the
+	// session of a raw paste covers its page
+	cookiePath := r.URL.Path
+	if command.Member != "" {
+		cookiePath = downloadPath(alias)
+	} else if inlineText {
+		cookiePath = pastePath(alias)
+	}
+
+	setSession(w, file, cookiePath)
+
+	tw := &transferWriter{ResponseWriter: w}
+	var bundleErr error
+	var size int64
+
+	if file.Bundle != nil {
+		// the bundle is produced while it is sent, so it has neither a
+		// length nor ranges and is whole once it was written
+		tw.WriteHeader(http.StatusOK)
+		bundleErr = file.Bundle(tw)
+		if bundleErr != nil && tw.err == nil && !util.IsCtxError(bundleErr) {
+			log.Error("failed to write bundle", sl.Error(bundleErr), slog.String("alias", alias))
+		}
+
+		size = tw.sent
+	} else {
+		size = serveContent(tw, r, file, filename)
+	}
+
+	// files of a multi-file share add up to one download. Their bytes are
+	// counted in the size they were uploaded with, as the share size is
+	sent := tw.sent
+	if file.ShareSize > 0 {
+		sent = 0
+		if size > 0 {
+			sent = int64(float64(tw.sent) * float64(file.FileInfo.Size()) / float64(size))
+		}
+
+		size = file.ShareSize
+	}
+
+	completed := tw.completed() && bundleErr == nil && r.Context().Err() == nil
+	finishDownload(r, downloader, log, alias, file, sent, size, completed)
+
+	if !completed {
+		log.Info("file transfer was not completed", slog.String("alias", alias), slog.Int("status", tw.status))
+		return
+	}
+
+	log.Info("file was successfully downloaded", slog.String("alias", alias))
+}
+
+// downloadFilename is the name the file was uploaded with, or the stored
+// one for files without it
+func downloadFilename(file *results.DownloadFile) string {
+	if file.Filename == "" && file.FileInfo != nil {
+		return file.FileInfo.Name()
+	}
+
+	return file.Filename
+}
+
+// setSession returns the download session in the header and in a cookie
+// sent back to path and below it
+func setSession(w http.ResponseWriter, file *results.DownloadFile, path string) {
+	if file.Session == "" {
+		return
+	}
+
+	w.Header().Set(sessionHeader, file.Session)
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    file.Session,
+		Path:     path,
+		Expires:  file.SessionExpiresAt,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// finishDownload counts sent bytes of a completed transfer, the download
+// is counted once they cover size, and gives the reserved slot back when
+// the transfer failed
+func finishDownload(r *http.Request, downloader FileDownloader, log *slog.Logger, alias string, file *results.DownloadFile,
+	sent, size int64, completed bool) {
+	finish := commands.FinishDownload{Alias: alias, Reservation: file.Reservation, Sent: sent, Size: size}
+
+	// the client is likely gone, the reservation must be finished anyway
+	ctx := context.WithoutCancel(r.Context())
+
+	switch {
+	case completed && file.Reservation != "":
+		if err := downloader.ConfirmDownload(ctx, finish); err != nil {
+			log.Error("failed to confirm download", sl.Error(err), slog.String("alias", alias))
+		}
+
+	case !completed && file.Reserved:
+		if err := downloader.ReleaseDownload(ctx, finish); err != nil {
+			log.Error("failed to release download", sl.Error(err), slog.String("alias", alias))
+		}
+	}
+}
+
+// serveContent sends the file with http.ServeContent, which answers Range,
+// If-Range and conditional requests and seeks the file to the requested
+// offset. It returns the size of the representation that was served
+func serveContent(w http.ResponseWriter, r *http.Request, file *results.DownloadFile, filename string) int64 {
+	// compressed files are sent as they are stored to clients accepting
+	// the encoding. The encoded representation has its own entity tag and
+	// the digest of the original content doesn't apply to it
+	content, etag, encoded, size := file.File, file.ETag, false, file.FileInfo.Size()
+	if file.ContentEncoding != "" {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		if util.AcceptsEncoding(r, file.ContentEncoding) {
+			content, etag, encoded, size = file.Encoded, encodedETag(file.ETag, file.ContentEncoding), true, file.EncodedSize
+			w.Header().Set("Content-Encoding", file.ContentEncoding)
+		}
+	}
+
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+
+	if digest, ok := sha256Digest(file.SHA256); ok && !encoded {
+		w.Header().Set("Repr-Digest", "sha-256=:"+digest+":")
+		w.Header().Set("Digest", "SHA-256="+digest)
+	}
+
+	http.ServeContent(w, r, filename, file.FileInfo.ModTime(), content)
+	return size
+}
+
+// transferWriter remembers the response status, the bytes sent and the
+// first write error, so the handler knows how much of the content the
+// client received. Parts of a multipart range response count with their
+// headers, which only counts such a download sooner
+type transferWriter struct {
+	http.ResponseWriter
+	status int
+	sent   int64
+	err    error
+}
+
+func (tw *transferWriter) WriteHeader(status int) {
+	if tw.status == 0 {
+		tw.status = status
+	}
+
+	tw.ResponseWriter.WriteHeader(status)
+}
+
+func (tw *transferWriter) Write(p []byte) (int, error) {
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+
+	n, err := tw.ResponseWriter.Write(p)
+	tw.sent += int64(n)
+	if err != nil && tw.err == nil {
+		tw.err = err
+	}
+
+	return n, err
+}
+
+func (tw *transferWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
+
+// completed reports whether content was sent without errors. Not modified
+// and unsatisfiable range responses transfer nothing and don't count
+func (tw *transferWriter) completed() bool {
+	if tw.status != http.StatusOK && tw.status != http.StatusPartialContent || tw.err != nil {
+		return false
+	}
+
+	// the tail of the content is still buffered until it is flushed
+	err := http.NewResponseController(tw.ResponseWriter).Flush()
+	return err == nil || errors.Is(err, http.ErrNotSupported)
+}
+
+// sha256Digest converts hex checksum to base64 used by digest headers. The
+// digest is of the whole file, so it is the same for range responses
+func sha256Digest(checksum string) (string, bool) {
+	sum, err := hex.DecodeString(checksum)
+	if err != nil || len(sum) != sha256.Size {
+		return "", false
+	}
+
+	return base64.StdEncoding.EncodeToString(sum), true
+}
+
+// encodedETag makes the entity tag of the encoded representation differ
+// from the tag of the original content
+func encodedETag(etag, encoding string) string {
+	if !strings.HasSuffix(etag, "\"") {
+		return etag
+	}
+
+	return strings.TrimSuffix(etag, "\"") + "-" + encoding + "\""
+}
+
+// pathParam returns the path of a file or a directory of a multi-file
+// share. Router matches the escaped path when the decoded one is ambiguous,
+// e.g. it has an escaped slash, so the path is unescaped then
+func pathParam(r *http.Request) string {
+	member := chi.URLParam(r, "*")
+
+	// URLFormat middleware routes the decoded path without its extension
+	if format, _ := r.Context().Value(middleware.URLFormatCtxKey).(string); format != "" {
+		return member + "." + format
+	}
+
+	if r.URL.RawPath == "" {
+		return member
 	}
+
+	if unescaped, err := url.PathUnescape(member); err == nil {
+		return unescaped
+	}
+
+	return member
+}
+
+func getSession(r *http.Request) string {
+	if session := r.Header.Get(sessionHeader); session != "" {
+		return session
+	}
+
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return ""
+	}
+
+	return cookie.Value
 }
@@ -1,7 +1,6 @@
 package download
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"expire-share/internal/domain/dto/files/commands"
@@ -9,6 +8,7 @@ import (
 	domainErrors "expire-share/internal/domain/entities/errors"
 	"expire-share/internal/mocks"
 	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 	"io"
@@ -16,6 +16,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -48,6 +50,469 @@ func TestHandler_Download(t *testing.T) {
 		require.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
 	})
 
+	t.Run("stored content type and digest are sent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		result := newFileResult("hello world", "test.bin")
+		result.ContentType = "text/plain; charset=utf-8"
+		result.SHA256 = "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().DownloadFile(gomock.Any(), gomock.Any()).Return(result, nil)
+
+		r := newRequest("abc123", "")
+		r.Header.Set("Range", "bytes=6-")
+
+		w := httptest.NewRecorder()
+		New(mockDownloader, logger).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusPartialContent, w.Code)
+		require.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		require.Equal(t, "sha-256=:uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek=:", w.Header().Get("Repr-Digest"))
+		require.Equal(t, "SHA-256=uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek=", w.Header().Get("Digest"))
+	})
+
+	t.Run("compressed file is sent encoded to clients accepting it", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			acceptEncoding string
+			encoded        bool
+		}{
+			{name: "gzip accepted", acceptEncoding: "br, gzip;q=0.8", encoded: true},
+			{name: "any encoding accepted", acceptEncoding: "*", encoded: true},
+			{name: "gzip refused", acceptEncoding: "gzip;q=0, *", encoded: false},
+			{name: "no accept encoding", encoded: false},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				result := newFileResult("hello world", "test.txt")
+				result.SHA256 = "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+				result.Encoded = strings.NewReader("gzipped")
+				result.EncodedSize = int64(len("gzipped"))
+				result.ContentEncoding = "gzip"
+
+				mockDownloader := mocks.NewMockFileDownloader(ctrl)
+				mockDownloader.EXPECT().DownloadFile(gomock.Any(), gomock.Any()).Return(result, nil)
+
+				r := newRequest("abc123", "")
+				if test.acceptEncoding != "" {
+					r.Header.Set("Accept-Encoding", test.acceptEncoding)
+				}
+
+				w := httptest.NewRecorder()
+				New(mockDownloader, logger).ServeHTTP(w, r)
+
+				require.Equal(t, http.StatusOK, w.Code)
+				require.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+				require.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+
+				if test.encoded {
+					require.Equal(t, "gzipped", w.Body.String())
+					require.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+					require.Equal(t, `"etag-gzip"`, w.Header().Get("ETag"))
+					require.Empty(t, w.Header().Get("Repr-Digest"))
+					return
+				}
+
+				require.Equal(t, "hello world", w.Body.String())
+				require.Empty(t, w.Header().Get("Content-Encoding"))
+				require.Equal(t, `"etag"`, w.Header().Get("ETag"))
+				require.NotEmpty(t, w.Header().Get("Repr-Digest"))
+			})
+		}
+	})
+
+	t.Run("range request", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().
+			DownloadFile(gomock.Any(), gomock.Any()).
+			Return(newFileResult("hello world", "test.txt"), nil)
+
+		r := newRequest("abc123", "")
+		r.Header.Set("Range", "bytes=6-")
+
+		w := httptest.NewRecorder()
+		New(mockDownloader, logger).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusPartialContent, w.Code)
+		require.Equal(t, "world", w.Body.String())
+		require.Equal(t, "bytes 6-10/11", w.Header().Get("Content-Range"))
+		require.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+	})
+
+	t.Run("if-range with stale etag returns whole file", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().
+			DownloadFile(gomock.Any(), gomock.Any()).
+			Return(newFileResult("hello world", "test.txt"), nil)
+
+		r := newResumeRequest("abc123", "session-token")
+		r.Header.Set("Range", "bytes=6-")
+		r.Header.Set("If-Range", `"stale"`)
+
+		w := httptest.NewRecorder()
+		New(mockDownloader, logger).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "hello world", w.Body.String())
+	})
+
+	t.Run("unsatisfiable range", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().
+			DownloadFile(gomock.Any(), gomock.Any()).
+			Return(newFileResult("hello world", "test.txt"), nil)
+
+		r := newRequest("abc123", "")
+		r.Header.Set("Range", "bytes=100-")
+
+		w := httptest.NewRecorder()
+		New(mockDownloader, logger).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.Code)
+	})
+
+	t.Run("if-none-match", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().
+			DownloadFile(gomock.Any(), gomock.Any()).
+			Return(newFileResult("hello world", "test.txt"), nil)
+
+		r := newResumeRequest("abc123", "session-token")
+		r.Header.Set("If-None-Match", `"etag"`)
+
+		w := httptest.NewRecorder()
+		New(mockDownloader, logger).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusNotModified, w.Code)
+		require.Empty(t, w.Body.String())
+	})
+
+	t.Run("session is returned and read back", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().
+			DownloadFile(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, command commands.DownloadFile) (*results.DownloadFile, error) {
+				require.Empty(t, command.Session)
+				return newFileResult("data", "file.bin"), nil
+			})
+
+		w := httptest.NewRecorder()
+		New(mockDownloader, logger).ServeHTTP(w, newRequest("abc123", ""))
+
+		require.Equal(t, "session-token", w.Header().Get("X-Download-Session"))
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+
+		mockDownloader.EXPECT().
+			DownloadFile(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, command commands.DownloadFile) (*results.DownloadFile, error) {
+				require.Equal(t, "session-token", command.Session)
+				require.True(t, command.RequireSession)
+				return newFileResult("data", "file.bin"), nil
+			})
+
+		r := newResumeRequest("abc123", "")
+		r.Header.Del("X-Download-Session")
+		r.AddCookie(cookies[0])
+
+		w = httptest.NewRecorder()
+		New(mockDownloader, logger).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("completed transfer confirms reservation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().
+			DownloadFile(gomock.Any(), gomock.Any()).
+			Return(newReservedResult("hello world", true), nil)
+
+		mockDownloader.EXPECT().
+			ConfirmDownload(gomock.Any(), commands.FinishDownload{Alias: "abc123", Reservation: "reservation", Sent: 11, Size: 11}).
+			Return(nil)
+
+		w := httptest.NewRecorder()
+		New(mockDownloader, logger).ServeHTTP(w, newRequest("abc123", ""))
+
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("range response counts only bytes of the range", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().
+			DownloadFile(gomock.Any(), gomock.Any()).
+			Return(newReservedResult("hello world", false), nil)
+
+		mockDownloader.EXPECT().
+			ConfirmDownload(gomock.Any(), commands.FinishDownload{Alias: "abc123", Reservation: "reservation", Sent: 1, Size: 11}).
+			Return(nil)
+
+		r := newResumeRequest("abc123", "session-token")
+		r.Header.Set("Range", "bytes=0-0")
+
+		w := httptest.NewRecorder()
+		New(mockDownloader, logger).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusPartialContent, w.Code)
+	})
+
+	t.Run("interrupted transfer releases reservation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().
+			DownloadFile(gomock.Any(), gomock.Any()).
+			Return(newReservedResult("hello world", true), nil)
+
+		mockDownloader.EXPECT().
+			ReleaseDownload(gomock.Any(), commands.FinishDownload{Alias: "abc123", Reservation: "reservation", Size: 11}).
+			Return(nil)
+
+		New(mockDownloader, logger).ServeHTTP(&brokenWriter{header: http.Header{}}, newRequest("abc123", ""))
+	})
+
+	t.Run("canceled request releases reservation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().
+			DownloadFile(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(context.Context, commands.DownloadFile) (*results.DownloadFile, error) {
+				cancel()
+				return newReservedResult("hello world", true), nil
+			})
+
+		mockDownloader.EXPECT().
+			ReleaseDownload(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, _ commands.FinishDownload) error {
+				require.NoError(t, ctx.Err())
+				return nil
+			})
+
+		r := newRequest("abc123", "")
+		New(mockDownloader, logger).ServeHTTP(httptest.NewRecorder(), r.WithContext(
+			context.WithValue(ctx, chi.RouteCtxKey, chi.RouteContext(r.Context()))))
+	})
+
+	t.Run("not modified response releases reservation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().
+			DownloadFile(gomock.Any(), gomock.Any()).
+			Return(newReservedResult("hello world", true), nil)
+
+		mockDownloader.EXPECT().ReleaseDownload(gomock.Any(), gomock.Any()).Return(nil)
+
+		r := newResumeRequest("abc123", "session-token")
+		r.Header.Set("If-None-Match", `"etag"`)
+
+		w := httptest.NewRecorder()
+		New(mockDownloader, logger).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("interrupted transfer keeps reservation of another request", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().
+			DownloadFile(gomock.Any(), gomock.Any()).
+			Return(newReservedResult("hello world", false), nil)
+
+		New(mockDownloader, logger).ServeHTTP(&brokenWriter{header: http.Header{}}, newRequest("abc123", ""))
+	})
+
+	t.Run("multi-file share is sent as zip bundle", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().
+			DownloadFile(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, command commands.DownloadFile) (*results.DownloadFile, error) {
+				require.Empty(t, command.Member)
+				return &results.DownloadFile{
+					Bundle: func(w io.Writer) error {
+						_, err := io.WriteString(w, "bundle")
+						return err
+					},
+					Close:       func() error { return nil },
+					Filename:    "files.zip",
+					ContentType: "application/zip",
+					Reservation: "reservation",
+					Reserved:    true,
+				}, nil
+			})
+
+		mockDownloader.EXPECT().
+			ConfirmDownload(gomock.Any(), commands.FinishDownload{Alias: "abc123", Reservation: "reservation", Sent: 6, Size: 6}).
+			Return(nil)
+
+		r := newRequest("abc123", "")
+		r.Header.Set("Range", "bytes=2-")
+
+		w := httptest.NewRecorder()
+		New(mockDownloader, logger).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "bundle", w.Body.String())
+		require.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+		require.Contains(t, w.Header().Get("Content-Disposition"), "files.zip")
+	})
+
+	t.Run("failed bundle releases reservation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().
+			DownloadFile(gomock.Any(), gomock.Any()).
+			Return(&results.DownloadFile{
+				Bundle:      func(io.Writer) error { return errors.New("storage error") },
+				Close:       func() error { return nil },
+				Filename:    "files.zip",
+				Reservation: "reservation",
+				Reserved:    true,
+			}, nil)
+
+		mockDownloader.EXPECT().
+			ReleaseDownload(gomock.Any(), commands.FinishDownload{Alias: "abc123", Reservation: "reservation"}).
+			Return(nil)
+
+		w := httptest.NewRecorder()
+		New(mockDownloader, logger).ServeHTTP(w, newRequest("abc123", ""))
+	})
+
+	t.Run("file of multi-file share is requested by name", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().
+			DownloadFile(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, command commands.DownloadFile) (*results.DownloadFile, error) {
+				require.Equal(t, "abc123", command.Alias)
+				require.Equal(t, "a/b.txt", command.Member)
+				return newFileResult("hello", "b.txt"), nil
+			})
+
+		r := httptest.NewRequest(http.MethodPost, "/download/abc123/a%2Fb.txt", nil)
+		routeCtx := chi.NewRouteContext()
+		routeCtx.URLParams.Add("alias", "abc123")
+		routeCtx.URLParams.Add("*", "a%2Fb.txt")
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx))
+
+		w := httptest.NewRecorder()
+		New(mockDownloader, logger).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "hello", w.Body.String())
+
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		require.Equal(t, "/download/abc123", cookies[0].Path)
+	})
+
+	t.Run("file of multi-file share counts against size of share", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		result := newReservedResult("hello", true)
+		result.ShareSize = 12
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().DownloadFile(gomock.Any(), gomock.Any()).Return(result, nil)
+		mockDownloader.EXPECT().ConfirmDownload(gomock.Any(), commands.FinishDownload{
+			Alias:       "abc123",
+			Reservation: "reservation",
+			Sent:        5,
+			Size:        12,
+		}).Return(nil)
+
+		r := httptest.NewRequest(http.MethodPost, "/download/abc123/a.txt", nil)
+		routeCtx := chi.NewRouteContext()
+		routeCtx.URLParams.Add("alias", "abc123")
+		routeCtx.URLParams.Add("*", "a.txt")
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx))
+
+		w := httptest.NewRecorder()
+		New(mockDownloader, logger).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("path of file keeps its extension behind url format middleware", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().
+			DownloadFile(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, command commands.DownloadFile) (*results.DownloadFile, error) {
+				require.Equal(t, "report/logs/run 1.tar.gz", command.Member)
+				return newFileResult("hello", "run 1.tar.gz"), nil
+			})
+
+		router := chi.NewRouter()
+		router.Use(middleware.URLFormat)
+		router.Post("/download/{alias}/*", New(mockDownloader, logger))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/download/abc123/report/logs/run%201.tar.gz", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("no downloads left", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().
+			DownloadFile(gomock.Any(), gomock.Any()).
+			Return(nil, domainErrors.ErrNoDownloadsLeft)
+
+		w := httptest.NewRecorder()
+		New(mockDownloader, logger).ServeHTTP(w, newRequest("abc123", ""))
+
+		require.Equal(t, http.StatusGone, w.Code)
+	})
+
 	t.Run("success with password", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -133,7 +598,7 @@ func TestHandler_Download(t *testing.T) {
 
 		routeCtx := chi.NewRouteContext()
 		routeCtx.URLParams.Add("alias", "abc")
-		r := httptest.NewRequest(http.MethodGet, "/download/abc", nil)
+		r := httptest.NewRequest(http.MethodPost, "/download/abc", nil)
 		r = r.WithContext(context.WithValue(ctx, chi.RouteCtxKey, routeCtx))
 
 		handler.ServeHTTP(w, r)
@@ -141,6 +606,73 @@ func TestHandler_Download(t *testing.T) {
 		require.NotEqual(t, http.StatusInternalServerError, w.Code)
 	})
 
+	t.Run("link preview is redirected to landing page", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// nothing is expected, a preview must not reach the service
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+
+		previews := []struct {
+			method    string
+			userAgent string
+		}{
+			{http.MethodGet, "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"},
+			{http.MethodGet, "Mozilla/5.0 (Windows NT 6.1; WOW64) SkypeUriPreview Preview/0.5"},
+			{http.MethodHead, "Mozilla/5.0 (X11; Linux x86_64) Firefox/128.0"},
+		}
+
+		for _, preview := range previews {
+			r := newRequest("abc123", "")
+			r.Method = preview.method
+			r.Header.Set("User-Agent", preview.userAgent)
+
+			w := httptest.NewRecorder()
+			New(mockDownloader, logger).ServeHTTP(w, r)
+
+			require.Equal(t, http.StatusFound, w.Code)
+			require.Equal(t, "/s/abc123", w.Header().Get("Location"))
+		}
+	})
+
+	t.Run("plain get is redirected to landing page without reserving download", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// nothing is expected, downloads left are not touched
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+
+		r := newResumeRequest("abc123", "")
+		r.Header.Del("X-Download-Session")
+		r.Header.Set("User-Agent", "UnknownLinkScanner/2.1")
+
+		w := httptest.NewRecorder()
+		New(mockDownloader, logger).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusSeeOther, w.Code)
+		require.Equal(t, "/s/abc123", w.Header().Get("Location"))
+		require.Empty(t, w.Header().Get("X-Download-Session"))
+	})
+
+	t.Run("get with invalid session is redirected to landing page", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().
+			DownloadFile(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, command commands.DownloadFile) (*results.DownloadFile, error) {
+				require.True(t, command.RequireSession)
+				return nil, domainErrors.ErrDownloadNotStarted
+			})
+
+		w := httptest.NewRecorder()
+		New(mockDownloader, logger).ServeHTTP(w, newResumeRequest("abc123", "forged"))
+
+		require.Equal(t, http.StatusSeeOther, w.Code)
+		require.Equal(t, "/s/abc123", w.Header().Get("Location"))
+	})
+
 	t.Run("internal error", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -159,7 +691,7 @@ func TestHandler_Download(t *testing.T) {
 }
 
 func newRequest(alias, password string) *http.Request {
-	r := httptest.NewRequest(http.MethodGet, "/download/"+alias, nil)
+	r := httptest.NewRequest(http.MethodPost, "/download/"+alias, nil)
 
 	routeCtx := chi.NewRouteContext()
 	routeCtx.URLParams.Add("alias", alias)
@@ -172,15 +704,44 @@ func newRequest(alias, password string) *http.Request {
 	return r
 }
 
+// newResumeRequest continues a download started before with its session
+func newResumeRequest(alias, session string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/download/"+alias, nil)
+	r.Header.Set("X-Download-Session", session)
+
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("alias", alias)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx))
+}
+
 func newFileResult(content string, filename string) *results.DownloadFile {
-	file := io.NopCloser(bytes.NewBufferString(content))
 	return &results.DownloadFile{
-		File:     file,
-		FileInfo: mockFileInfo{name: filename, size: int64(len(content))},
-		Close:    func() error { return file.Close() },
+		File:             strings.NewReader(content),
+		FileInfo:         mockFileInfo{name: filename, size: int64(len(content))},
+		ETag:             `"etag"`,
+		Close:            func() error { return nil },
+		Session:          "session-token",
+		SessionExpiresAt: time.Now().Add(time.Hour),
 	}
 }
 
+func newReservedResult(content string, reserved bool) *results.DownloadFile {
+	result := newFileResult(content, "test.txt")
+	result.Reservation = "reservation"
+	result.Reserved = reserved
+
+	return result
+}
+
+// brokenWriter fails like a connection closed by the client
+type brokenWriter struct {
+	header http.Header
+}
+
+func (b *brokenWriter) Header() http.Header       { return b.header }
+func (b *brokenWriter) WriteHeader(int)           {}
+func (b *brokenWriter) Write([]byte) (int, error) { return 0, syscall.EPIPE }
+
 type mockFileInfo struct {
 	name string
 	size int64
@@ -0,0 +1,307 @@
+package download
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"expire-share/internal/delivery/util"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/lib/log/sl"
+	"expire-share/internal/lib/sizes"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+)
+
+//go:embed landing.html
+var landingHTML string
+
+var landingPage = template.Must(template.New("landing").Parse(landingHTML))
+
+type ShareGetter interface {
+	GetShare(ctx context.Context, command commands.GetShare) (*results.GetShare, error)
+}
+
+type landingData struct {
+	Title             string
+	Found             bool
+	Size              string
+	ExpiresAt         string
+	DownloadsLeft     int16
+	PasswordProtected bool
+	MultiFile         bool
+	Error             string
+	// Action is where the form of a directory page is posted, the whole
+	// share is downloaded from any directory of it
+	Action string
+	// Parent links the parent directory of a listed subdirectory
+	Parent  string
+	Entries []landingEntry
+}
+
+// landingEntry is a directory or a file listed on the landing page of a
+// multi-file share
+type landingEntry struct {
+	Name  string
+	Link  string
+	Size  string
+	IsDir bool
+}
+
+// landingErrors are service errors shown on the landing page
+var landingErrors = []struct {
+	err     error
+	status  int
+	message string
+}{
+	{domainErrors.ErrFileNotFound, http.StatusNotFound, "This file does not exist or has expired."},
+	{domainErrors.ErrNoDownloadsLeft, http.StatusGone, "This file has no downloads left."},
+	{domainErrors.ErrFilePasswordRequired, http.StatusUnauthorized, "Enter the password to download this file."},
+	{domainErrors.ErrFilePasswordInvalid, http.StatusForbidden, "Wrong password."},
+	{domainErrors.ErrFileLocked, http.StatusLocked, "This file is locked after too many wrong passwords, try again later."},
+	{domainErrors.ErrTooManyPasswordAttempts, http.StatusTooManyRequests, "Too many wrong passwords, try again later."},
+}
+
+// NewLanding @Summary Show landing page of file
+//
+//	@Description	Shows an HTML page with size, expiration and downloads left of a file and a download button. Filename is shown only for files without a password.
+//	@Description	The page never counts a download, so link previews may fetch it safely. The download happens on the POST of its form.
+//	@Description	A multi-file share without a password is listed one directory at a time with links to download each file.
+//	@Tags			file
+//	@Produce		html
+//	@Param			alias	path		string	true	"File alias"
+//	@Param			path	path		string	false	"Directory of a multi-file share, e.g. report/logs"
+//	@Success		200		{string}	string	"Landing page"
+//	@Failure		404		{string}	string	"File or directory not found or file has expired"
+//	@Failure		500		{string}	string	"Internal server error"
+//	@Router			/s/{alias} [get]
+//	@Router			/s/{alias}/{path} [get]
+func NewLanding(getter ShareGetter, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http.download.NewLanding"
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())))
+
+		alias := chi.URLParam(r, "alias")
+		dir := strings.Trim(pathParam(r), "/")
+
+		share, err := getter.GetShare(r.Context(), commands.GetShare{Alias: alias})
+		if err != nil {
+			const msg = "failed to get file info"
+			if renderLandingError(w, log, err, landingData{}) || util.IsCtxError(err) {
+				log.Info(msg, sl.Error(err), slog.String("alias", alias))
+				return
+			}
+
+			log.Error(msg, sl.Error(err), slog.String("alias", alias))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		data := newLandingData(share)
+		if len(share.Members) > 0 {
+			data.Entries = listDir(alias, dir, share.Members)
+		}
+
+		if dir != "" {
+			data.Title = dir + "/"
+			data.Action = landingPath(alias)
+			data.Parent = parentPath(alias, dir)
+		}
+
+		// a directory of a listed share, other paths don't exist
+		if dir != "" && len(data.Entries) == 0 {
+			log.Info("directory not found", slog.String("alias", alias))
+			renderLandingError(w, log, domainErrors.ErrFileNotFound, landingData{})
+			return
+		}
+
+		if share.DownloadsLeft <= 0 {
+			data.Error = "This file has no downloads left."
+		}
+
+		renderLanding(w, log, http.StatusOK, data)
+	}
+}
+
+// NewConfirm @Summary Download file from landing page
+//
+//	@Description	Downloads the file after the form of the landing page is submitted, the password is sent in the form.
+//	@Description	Counts the download the same way as /download/{alias}. Errors are shown on the landing page.
+//	@Tags			file
+//	@Accept			x-www-form-urlencoded
+//	@Produce		application/octet-stream
+//	@Param			alias		path		string	true	"File alias"
+//	@Param			password	formData	string	false	"File password (required for password-protected files)"
+//	@Success		200			{file}		binary	"File content"
+//	@Failure		401			{string}	string	"File password required"
+//	@Failure		403			{string}	string	"Invalid password"
+//	@Failure		404			{string}	string	"File not found or has expired"
+//	@Failure		410			{string}	string	"File has no downloads left"
+//	@Failure		423			{string}	string	"File is locked after too many wrong passwords"
+//	@Failure		429			{string}	string	"Too many wrong passwords from client"
+//	@Failure		500			{object}	response.Response	"Internal server error"
+//	@Router			/s/{alias} [post]
+func NewConfirm(downloader FileDownloader, getter ShareGetter, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http.download.NewConfirm"
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())))
+
+		alias := chi.URLParam(r, "alias")
+
+		if util.IsPreviewRequest(r) {
+			http.Redirect(w, r, landingPath(alias), http.StatusSeeOther)
+			return
+		}
+
+		serveFile(w, r, downloader, log, commands.DownloadFile{
+			Alias:    alias,
+			Password: r.PostFormValue("password"),
+			Session:  getSession(r),
+			IP:       util.ClientIP(r),
+		}, func(w http.ResponseWriter, r *http.Request, err error) bool {
+			// the page is shown again with the error, so the password can be retried
+			var data landingData
+			if share, err := getter.GetShare(r.Context(), commands.GetShare{Alias: alias}); err == nil {
+				data = newLandingData(share)
+			}
+
+			return renderLandingError(w, log, err, data)
+		}, false)
+	}
+}
+
+func newLandingData(share *results.GetShare) landingData {
+	title := share.Filename
+	if title == "" {
+		title = "Password-protected file"
+	}
+
+	return landingData{
+		Title:             title,
+		Found:             true,
+		Size:              sizes.ToFormattedString(share.Size),
+		ExpiresAt:         share.ExpiresAt.UTC().Format(time.RFC1123),
+		DownloadsLeft:     share.DownloadsLeft,
+		PasswordProtected: share.PasswordProtected,
+		MultiFile:         share.MultiFile,
+	}
+}
+
+// listDir lists directories and files right inside dir of a multi-file
+// share, directories first. Files link to their download
+func listDir(alias, dir string, members []results.ShareMember) []landingEntry {
+	prefix := ""
+	if dir != "" {
+		prefix = dir + "/"
+	}
+
+	var dirs, files []landingEntry
+	dirSizes := make(map[string]int64)
+
+	for _, member := range members {
+		rest, ok := strings.CutPrefix(member.Name, prefix)
+		if !ok {
+			continue
+		}
+
+		if name, _, isDir := strings.Cut(rest, "/"); isDir {
+			if _, seen := dirSizes[name]; !seen {
+				dirs = append(dirs, landingEntry{
+					Name:  name,
+					Link:  landingPath(alias) + "/" + escapePath(prefix+name),
+					IsDir: true,
+				})
+			}
+
+			dirSizes[name] += member.Size
+			continue
+		}
+
+		files = append(files, landingEntry{
+			Name: rest,
+			Link: downloadPath(alias) + "/" + escapePath(member.Name),
+			Size: sizes.ToFormattedString(member.Size),
+		})
+	}
+
+	for i := range dirs {
+		dirs[i].Size = sizes.ToFormattedString(dirSizes[dirs[i].Name])
+	}
+
+	return append(dirs, files...)
+}
+
+// parentPath links the directory above dir, there is none above the root
+func parentPath(alias, dir string) string {
+	if dir == "" {
+		return ""
+	}
+
+	parent := path.Dir(dir)
+	if parent == "." {
+		return landingPath(alias)
+	}
+
+	return landingPath(alias) + "/" + escapePath(parent)
+}
+
+// escapePath escapes every segment of a slash-separated path
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// renderLandingError shows a known service error on the landing page
+func renderLandingError(w http.ResponseWriter, log *slog.Logger, err error, data landingData) bool {
+	for _, landingErr := range landingErrors {
+		if !errors.Is(err, landingErr.err) {
+			continue
+		}
+
+		if data.Title == "" {
+			data.Title = "File unavailable"
+		}
+
+		data.Error = landingErr.message
+		renderLanding(w, log, landingErr.status, data)
+		return true
+	}
+
+	return false
+}
+
+func renderLanding(w http.ResponseWriter, log *slog.Logger, status int, data landingData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+	w.WriteHeader(status)
+
+	if err := landingPage.Execute(w, data); err != nil {
+		log.Error("failed to render landing page", sl.Error(err))
+	}
+}
+
+func landingPath(alias string) string {
+	return "/s/" + url.PathEscape(alias)
+}
+
+func downloadPath(alias string) string {
+	return "/download/" + url.PathEscape(alias)
+}
@@ -0,0 +1,229 @@
+package download
+
+import (
+	"context"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/mocks"
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler_Landing(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	share := &results.GetShare{
+		Filename:      "report.pdf",
+		Size:          2048,
+		DownloadsLeft: 1,
+		ExpiresAt:     time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("page shows file without counting download", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockGetter := mocks.NewMockShareGetter(ctrl)
+		mockGetter.EXPECT().GetShare(gomock.Any(), commands.GetShare{Alias: "abc123"}).Return(share, nil)
+
+		w := httptest.NewRecorder()
+		NewLanding(mockGetter, logger).ServeHTTP(w, newLandingRequest(http.MethodGet, "abc123", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		require.Contains(t, w.Body.String(), "report.pdf")
+		require.Contains(t, w.Body.String(), "2.00kb")
+		require.Contains(t, w.Body.String(), `<form method="post">`)
+		require.NotContains(t, w.Body.String(), `name="password"`)
+	})
+
+	t.Run("multi-file share is listed one directory at a time", func(t *testing.T) {
+		multiFileShare := &results.GetShare{
+			Filename:      "files.zip",
+			Size:          6,
+			DownloadsLeft: 1,
+			MultiFile:     true,
+			Members: []results.ShareMember{
+				{Name: "report/index.html", Size: 1},
+				{Name: "report/logs/run 1.log", Size: 2},
+				{Name: "report/logs/run 2.log", Size: 2},
+				{Name: "readme.txt", Size: 1},
+			},
+		}
+
+		tests := []struct {
+			name        string
+			dir         string
+			contains    []string
+			notContains []string
+		}{
+			{
+				name:        "root",
+				contains:    []string{`<a href="/s/abc123/report">report/</a><span>5.00b</span>`, `<form method="post" action="/download/abc123/readme.txt"><button type="submit">readme.txt</button></form>`, "Download all"},
+				notContains: []string{"index.html", `action="/s/abc123"`},
+			},
+			{
+				name:        "subdirectory",
+				dir:         "report/logs",
+				contains:    []string{`<h1>report/logs/</h1>`, `<a href="/s/abc123/report">..</a>`, `action="/download/abc123/report/logs/run%201.log"><button type="submit">run 1.log</button>`, `action="/s/abc123"`},
+				notContains: []string{"readme.txt"},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				mockGetter := mocks.NewMockShareGetter(ctrl)
+				mockGetter.EXPECT().GetShare(gomock.Any(), commands.GetShare{Alias: "abc123"}).Return(multiFileShare, nil)
+
+				r := newLandingRequest(http.MethodGet, "abc123", nil)
+				chi.RouteContext(r.Context()).URLParams.Add("*", test.dir)
+
+				w := httptest.NewRecorder()
+				NewLanding(mockGetter, logger).ServeHTTP(w, r)
+
+				require.Equal(t, http.StatusOK, w.Code)
+				for _, s := range test.contains {
+					require.Contains(t, w.Body.String(), s)
+				}
+
+				for _, s := range test.notContains {
+					require.NotContains(t, w.Body.String(), s)
+				}
+			})
+		}
+
+		t.Run("unknown directory", func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockGetter := mocks.NewMockShareGetter(ctrl)
+			mockGetter.EXPECT().GetShare(gomock.Any(), commands.GetShare{Alias: "abc123"}).Return(multiFileShare, nil)
+
+			r := newLandingRequest(http.MethodGet, "abc123", nil)
+			chi.RouteContext(r.Context()).URLParams.Add("*", "report/index.html")
+
+			w := httptest.NewRecorder()
+			NewLanding(mockGetter, logger).ServeHTTP(w, r)
+
+			require.Equal(t, http.StatusNotFound, w.Code)
+		})
+	})
+
+	t.Run("password-protected file asks for password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockGetter := mocks.NewMockShareGetter(ctrl)
+		mockGetter.EXPECT().GetShare(gomock.Any(), gomock.Any()).
+			Return(&results.GetShare{Size: 10, DownloadsLeft: 1, PasswordProtected: true}, nil)
+
+		w := httptest.NewRecorder()
+		NewLanding(mockGetter, logger).ServeHTTP(w, newLandingRequest(http.MethodGet, "abc123", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), "Password-protected file")
+		require.Contains(t, w.Body.String(), `name="password"`)
+	})
+
+	t.Run("file not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockGetter := mocks.NewMockShareGetter(ctrl)
+		mockGetter.EXPECT().GetShare(gomock.Any(), gomock.Any()).Return(nil, domainErrors.ErrFileNotFound)
+
+		w := httptest.NewRecorder()
+		NewLanding(mockGetter, logger).ServeHTTP(w, newLandingRequest(http.MethodGet, "missing", nil))
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Contains(t, w.Body.String(), "does not exist or has expired")
+	})
+
+	t.Run("confirm downloads file with form password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().DownloadFile(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, command commands.DownloadFile) (*results.DownloadFile, error) {
+				require.Equal(t, "abc123", command.Alias)
+				require.Equal(t, "secret123", command.Password)
+				return newReservedResult("hello world", true), nil
+			})
+
+		mockDownloader.EXPECT().ConfirmDownload(gomock.Any(), gomock.Any()).Return(nil)
+
+		form := url.Values{"password": {"secret123"}}
+		w := httptest.NewRecorder()
+		NewConfirm(mockDownloader, mocks.NewMockShareGetter(ctrl), logger).
+			ServeHTTP(w, newLandingRequest(http.MethodPost, "abc123", form))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "hello world", w.Body.String())
+	})
+
+	t.Run("confirm with wrong password shows page again", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().DownloadFile(gomock.Any(), gomock.Any()).
+			Return(nil, domainErrors.ErrFilePasswordInvalid)
+
+		mockGetter := mocks.NewMockShareGetter(ctrl)
+		mockGetter.EXPECT().GetShare(gomock.Any(), gomock.Any()).
+			Return(&results.GetShare{Size: 10, DownloadsLeft: 1, PasswordProtected: true}, nil)
+
+		form := url.Values{"password": {"wrong"}}
+		w := httptest.NewRecorder()
+		NewConfirm(mockDownloader, mockGetter, logger).
+			ServeHTTP(w, newLandingRequest(http.MethodPost, "abc123", form))
+
+		require.Equal(t, http.StatusForbidden, w.Code)
+		require.Contains(t, w.Body.String(), "Wrong password.")
+		require.Contains(t, w.Body.String(), `name="password"`)
+	})
+
+	t.Run("confirm from crawler is not downloaded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		r := newLandingRequest(http.MethodPost, "abc123", url.Values{})
+		r.Header.Set("User-Agent", "facebookexternalhit/1.1")
+
+		w := httptest.NewRecorder()
+		NewConfirm(mocks.NewMockFileDownloader(ctrl), mocks.NewMockShareGetter(ctrl), logger).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusSeeOther, w.Code)
+		require.Equal(t, "/s/abc123", w.Header().Get("Location"))
+	})
+}
+
+func newLandingRequest(method, alias string, form url.Values) *http.Request {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+
+	r := httptest.NewRequest(method, "/s/"+alias, body)
+	if form != nil {
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("alias", alias)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx))
+}
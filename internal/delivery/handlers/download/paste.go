@@ -0,0 +1,333 @@
+package download
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"expire-share/internal/config"
+	"expire-share/internal/delivery/util"
+	"expire-share/internal/delivery/util/response"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/lib/language"
+	"expire-share/internal/lib/log/sl"
+	"html/template"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+)
+
+//go:embed paste.html
+var pasteHTML string
+
+var pastePage = template.Must(template.New("paste").Parse(pasteHTML))
+
+type pasteData struct {
+	Title string
+	// Language is a highlight.js language, plain text is not highlighted
+	Language string
+	Content  string
+	Found    bool
+	RawLink  string
+	Error    string
+	// AskPassword shows the password form
+	AskPassword bool
+	// AskConfirm shows the button which views a paste without a password
+	AskConfirm      bool
+	HighlightScript string
+	HighlightStyle  string
+}
+
+// NewPaste @Summary View paste
+//
+//	@Description	Shows text shared with /api/paste, or any other text file, as an HTML page highlighted by its language. Viewing the page counts a download
+//	@Description	the same way as /download/{alias}: the paste is shown on POST of the form of the page, which asks for a password when the paste has one.
+//	@Description	A GET shows only the form and never counts a download, unless it sends the session of a view started before.
+//	@Description	Link previews, crawlers and files that are not text or too large to be shown are redirected to the landing page /s/{alias}.
+//	@Tags			file
+//	@Accept			x-www-form-urlencoded
+//	@Produce		html
+//	@Param			alias		path		string	true	"Paste alias"
+//	@Param			password	formData	string	false	"Paste password (required for password-protected pastes)"
+//	@Success		200			{string}	string	"Paste page or its form"
+//	@Success		303			"Not shown as text, see landing page"
+//	@Failure		401			{string}	string	"Paste password required"
+//	@Failure		403			{string}	string	"Invalid password"
+//	@Failure		404			{string}	string	"Paste not found or has expired"
+//	@Failure		410			{string}	string	"Paste has no downloads left"
+//	@Failure		500			{string}	string	"Internal server error"
+//	@Router			/p/{alias} [get]
+//	@Router			/p/{alias} [post]
+func NewPaste(downloader FileDownloader, getter ShareGetter, log *slog.Logger, cfg config.Pastes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http.download.NewPaste"
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())))
+
+		alias := chi.URLParam(r, "alias")
+
+		if util.IsPreviewRequest(r) {
+			http.Redirect(w, r, landingPath(alias), http.StatusSeeOther)
+			return
+		}
+
+		data := pasteData{
+			Title:           "Paste",
+			RawLink:         pastePath(alias) + "/raw",
+			HighlightScript: cfg.HighlightScript,
+			HighlightStyle:  cfg.HighlightStyle,
+		}
+
+		// a plain GET may come from link unfurlers not known as previews,
+		// it only shows the form unless it continues a view started with POST
+		explicit := r.Method == http.MethodPost
+		session := getSession(r)
+
+		if !explicit && session == "" {
+			log.Info("paste view was not started explicitly", slog.String("alias", alias))
+			renderPasteForm(w, r, getter, log, alias, data)
+			return
+		}
+
+		password := r.Header.Get("X-Resource-Password")
+		if explicit {
+			password = r.PostFormValue("password")
+		}
+
+		file, err := downloader.DownloadFile(r.Context(), commands.DownloadFile{
+			Alias:          alias,
+			Password:       password,
+			Session:        session,
+			IP:             util.ClientIP(r),
+			RequireSession: !explicit,
+		})
+
+		if errors.Is(err, domainErrors.ErrDownloadNotStarted) {
+			log.Info("paste view was not started explicitly", slog.String("alias", alias))
+			renderPasteForm(w, r, getter, log, alias, data)
+			return
+		}
+
+		if err != nil {
+			const msg = "failed to get paste"
+			if renderPasteError(w, log, err, data) || util.IsCtxError(err) {
+				log.Info(msg, sl.Error(err), slog.String("alias", alias))
+				return
+			}
+
+			log.Error(msg, sl.Error(err), slog.String("alias", alias))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		defer func() {
+			if err := file.Close(); err != nil {
+				log.Error("failed to close file", sl.Error(err))
+			}
+		}()
+
+		content, ok, err := readPaste(file, cfg.MaxPasteSizeInBytes)
+		if err != nil || !ok {
+			finishDownload(r, downloader, log, alias, file, 0, 0, false)
+
+			if err != nil {
+				log.Error("failed to read paste", sl.Error(err), slog.String("alias", alias))
+				http.Error(w, "internal server error", http.StatusInternalServerError)
+				return
+			}
+
+			// the landing page offers a download of what can't be shown
+			log.Info("file is not shown as paste", slog.String("alias", alias))
+			http.Redirect(w, r, landingPath(alias), http.StatusSeeOther)
+			return
+		}
+
+		data.Found = true
+		data.Title = downloadFilename(file)
+		data.Content = content
+		if lang := language.FromFilename(data.Title); lang != language.Text {
+			data.Language = lang
+		}
+
+		// the page is rendered before it is sent, so the download is
+		// counted only when all of it was written
+		var page bytes.Buffer
+		if err := pastePage.Execute(&page, data); err != nil {
+			finishDownload(r, downloader, log, alias, file, 0, 0, false)
+			log.Error("failed to render paste page", sl.Error(err))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		setSession(w, file, pastePath(alias))
+		setPasteHeaders(w)
+		w.WriteHeader(http.StatusOK)
+
+		// the page shows the whole paste, so it is sent whole once written
+		n, err := w.Write(page.Bytes())
+		completed := err == nil && r.Context().Err() == nil
+		finishDownload(r, downloader, log, alias, file, int64(n), int64(page.Len()), completed)
+
+		if !completed {
+			log.Info("paste transfer was not completed", slog.String("alias", alias))
+			return
+		}
+
+		log.Info("paste was successfully viewed", slog.String("alias", alias))
+	}
+}
+
+// NewRawPaste @Summary Get raw paste
+//
+//	@Description	Sends the paste as plain text, e.g. for curl. Counts a download and supports the same headers as /download/{alias}.
+//	@Description	Like a download, it is started with POST only. A GET continues it with its session and is redirected to the paste page /p/{alias} without one.
+//	@Tags			file
+//	@Produce		plain
+//	@Param			alias				path		string				true	"Paste alias"
+//	@Param			X-Resource-Password	header		string				false	"Paste password (required for password-protected pastes)"
+//	@Param			X-Download-Session	header		string				false	"Download session returned by previous request"
+//	@Success		200					{string}	string				"Paste content"
+//	@Success		303					{string}	string				"GET without a valid session is redirected to the paste page"
+//	@Failure		401					{object}	response.Response	"Paste password required"
+//	@Failure		403					{object}	response.Response	"Invalid password"
+//	@Failure		404					{object}	response.Response	"Paste not found or has expired"
+//	@Failure		410					{object}	response.Response	"Paste has no downloads left"
+//	@Failure		500					{object}	response.Response	"Internal server error"
+//	@Router			/p/{alias}/raw [post]
+//	@Router			/p/{alias}/raw [get]
+func NewRawPaste(downloader FileDownloader, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http.download.NewRawPaste"
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())))
+
+		alias := chi.URLParam(r, "alias")
+
+		if util.IsPreviewRequest(r) {
+			http.Redirect(w, r, landingPath(alias), http.StatusFound)
+			return
+		}
+
+		explicit := r.Method == http.MethodPost
+		session := getSession(r)
+
+		if !explicit && session == "" {
+			log.Info("raw paste was not requested explicitly", slog.String("alias", alias))
+			http.Redirect(w, r, pastePath(alias), http.StatusSeeOther)
+			return
+		}
+
+		serveFile(w, r, downloader, log, commands.DownloadFile{
+			Alias:          alias,
+			Password:       r.Header.Get("X-Resource-Password"),
+			Session:        session,
+			IP:             util.ClientIP(r),
+			RequireSession: !explicit,
+		}, func(w http.ResponseWriter, r *http.Request, err error) bool {
+			if errors.Is(err, domainErrors.ErrDownloadNotStarted) {
+				http.Redirect(w, r, pastePath(alias), http.StatusSeeOther)
+				return true
+			}
+
+			return response.RenderFileServiceError(w, r, err)
+		}, true)
+	}
+}
+
+// readPaste reads the text of the file. Files larger than limit, not
+// UTF-8 or with NUL bytes are binary and are not shown
+func readPaste(file *results.DownloadFile, limit int64) (string, bool, error) {
+	if file.Bundle != nil {
+		return "", false, nil
+	}
+
+	reader := io.Reader(file.File)
+	if limit > 0 {
+		reader = io.LimitReader(file.File, limit+1)
+	}
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return "", false, err
+	}
+
+	if limit > 0 && int64(len(content)) > limit || !utf8.Valid(content) || bytes.IndexByte(content, 0) >= 0 {
+		return "", false, nil
+	}
+
+	return string(content), true, nil
+}
+
+// renderPasteForm shows the form which views the paste without counting
+// a download, with a password field for a paste with a password
+func renderPasteForm(w http.ResponseWriter, r *http.Request, getter ShareGetter, log *slog.Logger, alias string, data pasteData) {
+	share, err := getter.GetShare(r.Context(), commands.GetShare{Alias: alias})
+	if err != nil {
+		const msg = "failed to get paste info"
+		if renderPasteError(w, log, err, data) || util.IsCtxError(err) {
+			log.Info(msg, sl.Error(err), slog.String("alias", alias))
+			return
+		}
+
+		log.Error(msg, sl.Error(err), slog.String("alias", alias))
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if share.Filename != "" {
+		data.Title = share.Filename
+	}
+
+	data.AskPassword = share.PasswordProtected
+	data.AskConfirm = !share.PasswordProtected
+
+	setPasteHeaders(w)
+	w.WriteHeader(http.StatusOK)
+
+	if err := pastePage.Execute(w, data); err != nil {
+		log.Error("failed to render paste page", sl.Error(err))
+	}
+}
+
+// renderPasteError shows a known service error on the paste page, the
+// password form is shown again for a missing or wrong password
+func renderPasteError(w http.ResponseWriter, log *slog.Logger, err error, data pasteData) bool {
+	for _, landingErr := range landingErrors {
+		if !errors.Is(err, landingErr.err) {
+			continue
+		}
+
+		data.Error = landingErr.message
+		data.AskPassword = errors.Is(err, domainErrors.ErrFilePasswordRequired) ||
+			errors.Is(err, domainErrors.ErrFilePasswordInvalid)
+
+		setPasteHeaders(w)
+		w.WriteHeader(landingErr.status)
+
+		if err := pastePage.Execute(w, data); err != nil {
+			log.Error("failed to render paste page", sl.Error(err))
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func setPasteHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+}
+
+func pastePath(alias string) string {
+	return "/p/" + url.PathEscape(alias)
+}
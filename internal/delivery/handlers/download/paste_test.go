@@ -0,0 +1,281 @@
+package download
+
+import (
+	"context"
+	"expire-share/internal/config"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/mocks"
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestHandler_Paste(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	cfg := config.Pastes{
+		MaxPasteSizeInBytes: 64,
+		HighlightScript:     "https://cdn.example.com/highlight.min.js",
+	}
+
+	finish := commands.FinishDownload{Alias: "abc123", Reservation: "reservation"}
+
+	t.Run("paste is shown highlighted by its language", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		result := newReservedResult("if a < b {}", true)
+		result.Filename = "paste.go"
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().DownloadFile(gomock.Any(), gomock.Any()).Return(result, nil)
+		mockDownloader.EXPECT().ConfirmDownload(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, command commands.FinishDownload) error {
+				require.Equal(t, finish.Reservation, command.Reservation)
+				require.Positive(t, command.Sent)
+				require.Equal(t, command.Size, command.Sent)
+				return nil
+			})
+
+		w := httptest.NewRecorder()
+		NewPaste(mockDownloader, mocks.NewMockShareGetter(ctrl), logger, cfg).ServeHTTP(w, newPasteRequest(http.MethodPost, "abc123", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		require.Contains(t, w.Body.String(), `<code class="language-go">if a &lt; b {}</code>`)
+		require.Contains(t, w.Body.String(), `<script src="https://cdn.example.com/highlight.min.js">`)
+		require.Contains(t, w.Body.String(), `action="/p/abc123/raw"`)
+		require.Equal(t, "/p/abc123", w.Result().Cookies()[0].Path)
+	})
+
+	t.Run("plain text is not highlighted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		result := newFileResult("stack trace", "paste.txt")
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().DownloadFile(gomock.Any(), gomock.Any()).Return(result, nil)
+
+		w := httptest.NewRecorder()
+		NewPaste(mockDownloader, mocks.NewMockShareGetter(ctrl), logger, cfg).ServeHTTP(w, newPasteRequest(http.MethodPost, "abc123", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), `<code>stack trace</code>`)
+		require.NotContains(t, w.Body.String(), "<script")
+	})
+
+	t.Run("password form is shown", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().DownloadFile(gomock.Any(), gomock.Any()).Return(nil, domainErrors.ErrFilePasswordRequired)
+
+		w := httptest.NewRecorder()
+		NewPaste(mockDownloader, mocks.NewMockShareGetter(ctrl), logger, cfg).ServeHTTP(w, newPasteRequest(http.MethodPost, "abc123", nil))
+
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Contains(t, w.Body.String(), `name="password"`)
+	})
+
+	t.Run("password is sent in form", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().DownloadFile(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, command commands.DownloadFile) (*results.DownloadFile, error) {
+				require.Equal(t, "secret", command.Password)
+				return newFileResult("text", "paste.txt"), nil
+			})
+
+		w := httptest.NewRecorder()
+		NewPaste(mockDownloader, mocks.NewMockShareGetter(ctrl), logger, cfg).ServeHTTP(w,
+			newPasteRequest(http.MethodPost, "abc123", url.Values{"password": {"secret"}}))
+
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("file not shown as text is released and redirected to landing page", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			content string
+		}{
+			{name: "binary", content: "\x89PNG\r\n\x1a\n\x00\x00"},
+			{name: "too large", content: strings.Repeat("a", 65)},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				mockDownloader := mocks.NewMockFileDownloader(ctrl)
+				mockDownloader.EXPECT().DownloadFile(gomock.Any(), gomock.Any()).
+					Return(newReservedResult(test.content, true), nil)
+				mockDownloader.EXPECT().ReleaseDownload(gomock.Any(), finish).Return(nil)
+
+				w := httptest.NewRecorder()
+				NewPaste(mockDownloader, mocks.NewMockShareGetter(ctrl), logger, cfg).ServeHTTP(w, newPasteRequest(http.MethodPost, "abc123", nil))
+
+				require.Equal(t, http.StatusSeeOther, w.Code)
+				require.Equal(t, "/s/abc123", w.Header().Get("Location"))
+			})
+		}
+	})
+
+	t.Run("link preview is redirected to landing page", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		r := newPasteRequest(http.MethodPost, "abc123", nil)
+		r.Header.Set("User-Agent", "Slackbot-LinkExpanding 1.0")
+
+		w := httptest.NewRecorder()
+		NewPaste(mocks.NewMockFileDownloader(ctrl), mocks.NewMockShareGetter(ctrl), logger, cfg).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusSeeOther, w.Code)
+		require.Equal(t, "/s/abc123", w.Header().Get("Location"))
+	})
+
+	t.Run("plain get shows form without viewing paste", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockGetter := mocks.NewMockShareGetter(ctrl)
+		mockGetter.EXPECT().GetShare(gomock.Any(), commands.GetShare{Alias: "abc123"}).
+			Return(&results.GetShare{Filename: "paste.go", DownloadsLeft: 1}, nil)
+
+		r := newPasteRequest(http.MethodGet, "abc123", nil)
+		r.Header.Set("User-Agent", "unknown-unfurler/1.0")
+
+		w := httptest.NewRecorder()
+		NewPaste(mocks.NewMockFileDownloader(ctrl), mockGetter, logger, cfg).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), "Show paste")
+		require.NotContains(t, w.Body.String(), `name="password"`)
+		require.NotContains(t, w.Body.String(), "<code")
+	})
+
+	t.Run("plain get of password-protected paste shows password form", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockGetter := mocks.NewMockShareGetter(ctrl)
+		mockGetter.EXPECT().GetShare(gomock.Any(), gomock.Any()).
+			Return(&results.GetShare{PasswordProtected: true, DownloadsLeft: 1}, nil)
+
+		w := httptest.NewRecorder()
+		NewPaste(mocks.NewMockFileDownloader(ctrl), mockGetter, logger, cfg).
+			ServeHTTP(w, newPasteRequest(http.MethodGet, "abc123", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), `name="password"`)
+	})
+
+	t.Run("get with invalid session shows form", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().DownloadFile(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, command commands.DownloadFile) (*results.DownloadFile, error) {
+				require.True(t, command.RequireSession)
+				require.Equal(t, "stale", command.Session)
+				return nil, domainErrors.ErrDownloadNotStarted
+			})
+
+		mockGetter := mocks.NewMockShareGetter(ctrl)
+		mockGetter.EXPECT().GetShare(gomock.Any(), gomock.Any()).Return(&results.GetShare{DownloadsLeft: 1}, nil)
+
+		r := newPasteRequest(http.MethodGet, "abc123", nil)
+		r.Header.Set("X-Download-Session", "stale")
+
+		w := httptest.NewRecorder()
+		NewPaste(mockDownloader, mockGetter, logger, cfg).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), "Show paste")
+	})
+
+	t.Run("plain raw get is redirected to paste page", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		w := httptest.NewRecorder()
+		NewRawPaste(mocks.NewMockFileDownloader(ctrl), logger).
+			ServeHTTP(w, newPasteRequest(http.MethodGet, "abc123", nil))
+
+		require.Equal(t, http.StatusSeeOther, w.Code)
+		require.Equal(t, "/p/abc123", w.Header().Get("Location"))
+	})
+
+	t.Run("raw paste is sent as plain text", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		result := newFileResult("<script>alert(1)</script>", "paste.xml")
+		result.ContentType = "text/xml; charset=utf-8"
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().DownloadFile(gomock.Any(), gomock.Any()).Return(result, nil)
+
+		w := httptest.NewRecorder()
+		NewRawPaste(mockDownloader, logger).ServeHTTP(w, newPasteRequest(http.MethodPost, "abc123", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "<script>alert(1)</script>", w.Body.String())
+		require.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		require.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		require.True(t, strings.HasPrefix(w.Header().Get("Content-Disposition"), "inline"))
+		require.Equal(t, "/p/abc123", w.Result().Cookies()[0].Path)
+	})
+
+	t.Run("raw multi-file share is not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockDownloader := mocks.NewMockFileDownloader(ctrl)
+		mockDownloader.EXPECT().DownloadFile(gomock.Any(), gomock.Any()).
+			Return(&results.DownloadFile{
+				Bundle:      func(io.Writer) error { return nil },
+				Close:       func() error { return nil },
+				Filename:    "files.zip",
+				Reservation: "reservation",
+				Reserved:    true,
+			}, nil)
+		mockDownloader.EXPECT().ReleaseDownload(gomock.Any(), finish).Return(nil)
+
+		w := httptest.NewRecorder()
+		NewRawPaste(mockDownloader, logger).ServeHTTP(w, newPasteRequest(http.MethodPost, "abc123", nil))
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func newPasteRequest(method, alias string, form url.Values) *http.Request {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+
+	r := httptest.NewRequest(method, "/p/"+alias, body)
+	if form != nil {
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("alias", alias)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx))
+}
@@ -29,10 +29,28 @@ type TokenValidator interface {
 }
 
 func NewAuth(auth TokenValidator, log *slog.Logger) func(handler http.Handler) http.Handler {
+	return newAuth(auth, log, false)
+}
+
+// NewGuestAuth works as NewAuth, but lets requests without Authorization
+// header through as guests. A present but invalid token is still refused
+func NewGuestAuth(auth TokenValidator, log *slog.Logger) func(handler http.Handler) http.Handler {
+	return newAuth(auth, log, true)
+}
+
+func newAuth(auth TokenValidator, log *slog.Logger, allowGuests bool) func(handler http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		logger := log.With(slog.String("component", "middleware/auth"))
 
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if allowGuests && r.Header.Get("Authorization") == "" {
+				ctx := context.WithValue(r.Context(), userIDField, int64(entities.GuestUserID))
+				ctx = context.WithValue(ctx, rolesField, []entities.UserRole{entities.RoleGuest})
+
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			token := extractBearerToken(r.Header.Get("Authorization"))
 			if token == "" {
 				logger.Info("unauthorized request")
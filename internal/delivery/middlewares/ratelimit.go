@@ -0,0 +1,64 @@
+package middlewares
+
+import (
+	"context"
+	"expire-share/internal/delivery/util"
+	"expire-share/internal/delivery/util/response"
+	"expire-share/internal/domain/entities"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimiter counts requests per key. When the key is out of requests it
+// returns how long the client should wait
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// NewRateLimit answers 429 to clients out of requests. Authenticated users
+// are counted by user id, so it must go after auth middleware, others by IP.
+// Requests are let through when limiter fails
+func NewRateLimit(limiter RateLimiter, log *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		logger := log.With(slog.String("component", "middleware/ratelimit"))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := rateLimitKey(r)
+
+			allowed, retryAfter, err := limiter.Allow(r.Context(), key)
+			if err != nil {
+				if !util.IsCtxError(err) {
+					logger.Error("failed to check rate limit", sl.Error(err), slog.String("key", key))
+				}
+
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !allowed {
+				logger.Info("rate limit exceeded", slog.String("key", key))
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				response.RenderError(w, r,
+					http.StatusTooManyRequests,
+					"too many requests, try again later")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func rateLimitKey(r *http.Request) string {
+	claims, err := GetUserClaims(r)
+	if err == nil && claims.UserID != entities.GuestUserID {
+		return fmt.Sprintf("user:%d", claims.UserID)
+	}
+
+	return "ip:" + util.ClientIP(r)
+}
@@ -0,0 +1,92 @@
+package middlewares_test
+
+import (
+	"context"
+	"errors"
+	"expire-share/internal/delivery/middlewares"
+	"expire-share/internal/domain/dto/auth/results"
+	"expire-share/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/download/abc123", nil)
+		r.RemoteAddr = "10.0.0.1:54321"
+		return r
+	}
+
+	t.Run("allowed request is passed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		limiter := mocks.NewMockRateLimiter(ctrl)
+		limiter.EXPECT().Allow(gomock.Any(), "ip:10.0.0.1").Return(true, time.Duration(0), nil)
+
+		w := httptest.NewRecorder()
+		middlewares.NewRateLimit(limiter, logger)(next).ServeHTTP(w, newRequest())
+
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("limited request gets retry after", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		limiter := mocks.NewMockRateLimiter(ctrl)
+		limiter.EXPECT().Allow(gomock.Any(), "ip:10.0.0.1").Return(false, 2500*time.Millisecond, nil)
+
+		w := httptest.NewRecorder()
+		middlewares.NewRateLimit(limiter, logger)(next).ServeHTTP(w, newRequest())
+
+		require.Equal(t, http.StatusTooManyRequests, w.Code)
+		require.Equal(t, "3", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("limiter failure lets request through", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		limiter := mocks.NewMockRateLimiter(ctrl)
+		limiter.EXPECT().Allow(gomock.Any(), gomock.Any()).Return(false, time.Duration(0), errors.New("store unavailable"))
+
+		w := httptest.NewRecorder()
+		middlewares.NewRateLimit(limiter, logger)(next).ServeHTTP(w, newRequest())
+
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("authenticated user is counted by id", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		validator := mocks.NewMockTokenValidator(ctrl)
+		validator.EXPECT().ValidateToken(gomock.Any(), gomock.Any()).
+			Return(&results.Validate{UserID: 7}, nil)
+
+		limiter := mocks.NewMockRateLimiter(ctrl)
+		limiter.EXPECT().Allow(gomock.Any(), "user:7").Return(true, time.Duration(0), nil)
+
+		r := newRequest()
+		r.Header.Set("Authorization", "Bearer token")
+
+		w := httptest.NewRecorder()
+		handler := middlewares.NewAuth(validator, logger)(middlewares.NewRateLimit(limiter, logger)(next))
+		handler.ServeHTTP(w, r.WithContext(context.Background()))
+
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+}
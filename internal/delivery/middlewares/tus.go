@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+const TusVersion = "1.0.0"
+
+// NewTusResumable enforces the tus protocol version on every request except
+// OPTIONS, which is used by clients to discover supported versions
+func NewTusResumable(log *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		logger := log.With(slog.String("component", "middleware/tus"))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Tus-Resumable", TusVersion)
+
+			if r.Method != http.MethodOptions && r.Header.Get("Tus-Resumable") != TusVersion {
+				logger.Info("unsupported tus version", slog.String("version", r.Header.Get("Tus-Resumable")))
+				w.Header().Set("Tus-Version", TusVersion)
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -55,6 +55,13 @@ func RenderFileServiceError(w http.ResponseWriter, r *http.Request, err error) b
 		return true
 	}
 
+	if errors.Is(err, domainErrors.ErrNoDownloadsLeft) {
+		RenderError(w, r,
+			http.StatusGone,
+			"file has no downloads left")
+		return true
+	}
+
 	if errors.Is(err, domainErrors.ErrFilePasswordRequired) {
 		RenderError(w, r,
 			http.StatusUnauthorized,
@@ -69,6 +76,34 @@ func RenderFileServiceError(w http.ResponseWriter, r *http.Request, err error) b
 		return true
 	}
 
+	if errors.Is(err, domainErrors.ErrFilePasswordBound) {
+		RenderError(w, r,
+			http.StatusConflict,
+			"password can't be changed or removed, the file is encrypted with it")
+		return true
+	}
+
+	if errors.Is(err, domainErrors.ErrPasswordKeyResumable) {
+		RenderError(w, r,
+			http.StatusUnprocessableEntity,
+			"password-protected files can't be uploaded resumably, the password is not kept until the upload completes")
+		return true
+	}
+
+	if errors.Is(err, domainErrors.ErrFileLocked) {
+		RenderError(w, r,
+			http.StatusLocked,
+			"file is temporarily locked after too many wrong passwords")
+		return true
+	}
+
+	if errors.Is(err, domainErrors.ErrTooManyPasswordAttempts) {
+		RenderError(w, r,
+			http.StatusTooManyRequests,
+			"too many wrong passwords, try again later")
+		return true
+	}
+
 	if errors.Is(err, domainErrors.ErrFileSizeTooBig) {
 		RenderError(w, r,
 			http.StatusUnprocessableEntity,
@@ -76,6 +111,62 @@ func RenderFileServiceError(w http.ResponseWriter, r *http.Request, err error) b
 		return true
 	}
 
+	if errors.Is(err, domainErrors.ErrAliasTaken) {
+		RenderError(w, r,
+			http.StatusConflict,
+			"alias is already taken")
+		return true
+	}
+
+	if errors.Is(err, domainErrors.ErrInvalidAlias) {
+		RenderError(w, r,
+			http.StatusUnprocessableEntity,
+			"alias must consist of letters, digits, '-' and '_' and must not be reserved or blocked")
+		return true
+	}
+
+	if errors.Is(err, domainErrors.ErrVanityAliasForbidden) {
+		RenderError(w, r,
+			http.StatusForbidden,
+			"custom aliases are not available for your role")
+		return true
+	}
+
+	if errors.Is(err, domainErrors.ErrTTLTooLong) {
+		RenderError(w, r,
+			http.StatusUnprocessableEntity,
+			"ttl exceeds the limit of your role")
+		return true
+	}
+
+	if errors.Is(err, domainErrors.ErrTooManyDownloads) {
+		RenderError(w, r,
+			http.StatusUnprocessableEntity,
+			"downloads exceed the limit of your role")
+		return true
+	}
+
+	if errors.Is(err, domainErrors.ErrTooManyFiles) {
+		RenderError(w, r,
+			http.StatusUnprocessableEntity,
+			"too many files in share")
+		return true
+	}
+
+	if errors.Is(err, domainErrors.ErrInvalidFilePath) {
+		RenderError(w, r,
+			http.StatusUnprocessableEntity,
+			"invalid file path")
+		return true
+	}
+
+	if errors.Is(err, domainErrors.ErrUnknownLanguage) {
+		RenderError(w, r,
+			http.StatusUnprocessableEntity,
+			"unknown paste language")
+		return true
+	}
+
 	if errors.Is(err, domainErrors.ErrForbidden) {
 		RenderError(w, r,
 			http.StatusForbidden,
@@ -90,6 +181,69 @@ func RenderFileServiceError(w http.ResponseWriter, r *http.Request, err error) b
 		return true
 	}
 
+	if errors.Is(err, domainErrors.ErrStorageQuotaExceeded) {
+		RenderError(w, r,
+			http.StatusForbidden,
+			"your storage quota exceeded. delete unnecessary files to upload new")
+		return true
+	}
+
+	if errors.Is(err, domainErrors.ErrInvalidCursor) {
+		RenderError(w, r,
+			http.StatusBadRequest,
+			"invalid cursor")
+		return true
+	}
+
+	if errors.Is(err, domainErrors.ErrUploadNotFound) {
+		RenderError(w, r,
+			http.StatusNotFound,
+			"upload not found or has expired")
+		return true
+	}
+
+	if errors.Is(err, domainErrors.ErrUploadOffsetMismatch) {
+		RenderError(w, r,
+			http.StatusConflict,
+			"upload offset does not match current offset")
+		return true
+	}
+
+	if errors.Is(err, domainErrors.ErrUploadCompleting) {
+		RenderError(w, r,
+			http.StatusConflict,
+			"upload is being completed by another request")
+		return true
+	}
+
+	if errors.Is(err, domainErrors.ErrUploadLengthExceeded) {
+		RenderError(w, r,
+			http.StatusRequestEntityTooLarge,
+			"chunk exceeds declared upload length")
+		return true
+	}
+
+	if errors.Is(err, domainErrors.ErrFetchNotFound) {
+		RenderError(w, r,
+			http.StatusNotFound,
+			"remote upload not found or has expired")
+		return true
+	}
+
+	if errors.Is(err, domainErrors.ErrTooManyFetches) {
+		RenderError(w, r,
+			http.StatusTooManyRequests,
+			"too many remote uploads in progress, wait for them to finish")
+		return true
+	}
+
+	if errors.Is(err, domainErrors.ErrRemoteURLNotAllowed) {
+		RenderError(w, r,
+			http.StatusUnprocessableEntity,
+			"url must be an http or https url of a public server")
+		return true
+	}
+
 	return false
 }
 
@@ -3,8 +3,77 @@ package util
 import (
 	"context"
 	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
 )
 
+// crawlerAgents are lowercase parts of user agents of link unfurlers and
+// search crawlers which fetch links on their own
+var crawlerAgents = []string{
+	"slackbot", "slack-imgproxy", "skypeuripreview", "microsoftpreview", "teamsbot",
+	"discordbot", "telegrambot", "whatsapp", "twitterbot", "facebookexternalhit",
+	"facebot", "linkedinbot", "pinterest", "redditbot", "embedly", "iframely",
+	"vkshare", "mattermost", "googlebot", "bingbot", "yandexbot", "duckduckbot",
+	"applebot", "crawler", "spider",
+}
+
 func IsCtxError(err error) bool {
 	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
 }
+
+// ClientIP returns the request IP without port. RealIP middleware puts
+// the forwarded client IP into RemoteAddr
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// IsPreviewRequest reports whether the request comes from a link preview or
+// a crawler rather than a person, such requests must not consume downloads
+func IsPreviewRequest(r *http.Request) bool {
+	if r.Method == http.MethodHead {
+		return true
+	}
+
+	agent := strings.ToLower(r.UserAgent())
+	for _, crawler := range crawlerAgents {
+		if strings.Contains(agent, crawler) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AcceptsEncoding reports whether Accept-Encoding of the request allows
+// the content coding. An explicit coding wins over the wildcard
+func AcceptsEncoding(r *http.Request, encoding string) bool {
+	accepted, wildcard := false, false
+	for _, header := range r.Header.Values("Accept-Encoding") {
+		for _, part := range strings.Split(header, ",") {
+			name, params, _ := strings.Cut(part, ";")
+			name = strings.TrimSpace(name)
+
+			allowed := true
+			if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+				q, err := strconv.ParseFloat(value, 64)
+				allowed = err == nil && q > 0
+			}
+
+			switch {
+			case strings.EqualFold(name, encoding):
+				return allowed
+			case name == "*":
+				accepted, wildcard = allowed, true
+			}
+		}
+	}
+
+	return wildcard && accepted
+}
@@ -0,0 +1,22 @@
+package commands
+
+import (
+	"expire-share/internal/domain/dto/files/commands"
+	"time"
+)
+
+type CreateFetch struct {
+	URL string
+	// Filename overrides the name given by the remote server
+	Filename     string
+	MaxDownloads int16
+	Password     string
+	TTL          time.Duration
+	Alias        string
+	commands.RequestingUserInfo
+}
+
+type GetFetch struct {
+	ID string
+	commands.RequestingUserInfo
+}
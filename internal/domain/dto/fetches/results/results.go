@@ -0,0 +1,23 @@
+package results
+
+import (
+	"expire-share/internal/domain/entities"
+	"io"
+)
+
+type CreateFetch struct {
+	ID     string
+	Status entities.FetchStatus
+}
+
+type GetFetch struct {
+	entities.Fetch
+}
+
+// RemoteFile is a response of a remote server, Body must be closed
+type RemoteFile struct {
+	Body io.ReadCloser
+	// Size is -1 when the server doesn't announce it
+	Size     int64
+	Filename string
+}
@@ -9,6 +9,9 @@ import (
 type RequestingUserInfo struct {
 	UserID int64
 	Roles  []entities.UserRole
+	// IP and ManagementToken identify a guest, who has no user id
+	IP              string
+	ManagementToken string
 }
 
 type UploadFile struct {
@@ -17,13 +20,68 @@ type UploadFile struct {
 	Filename     string
 	MaxDownloads int16
 	Password     string
+	// PasswordHash is used instead of Password when the password was
+	// hashed before the file content arrived, e.g. for resumable uploads
+	PasswordHash string
+	TTL          time.Duration
+	// Alias is chosen by the user, a random one is generated when empty
+	Alias string
+	// Members make a multi-file share, File and Filename are not used
+	// then and FileSize is the size of all of them
+	Members []UploadMember
+	RequestingUserInfo
+}
+
+// UploadMember is one of the files of a multi-file share
+type UploadMember struct {
+	File     io.Reader
+	Filename string
+	Size     int64
+}
+
+// Paste is text shared as a file, its language decides the extension
+type Paste struct {
+	Content      string
+	Language     string
+	MaxDownloads int16
+	Password     string
 	TTL          time.Duration
+	Alias        string
+	RequestingUserInfo
+}
+
+type CheckUploadQuota struct {
+	FileSize     int64
+	MaxDownloads int16
+	TTL          time.Duration
+	RequestingUserInfo
+}
+
+type GetQuota struct {
 	RequestingUserInfo
 }
 
 type DownloadFile struct {
 	Alias    string
 	Password string
+	Session  string
+	IP       string
+	// Member is the name of a file of a multi-file share, the whole
+	// share is downloaded as a ZIP bundle when it is empty
+	Member string
+	// RequireSession refuses to start a new download without a valid
+	// session, e.g. for plain GET requests which may come from link
+	// scanners and prefetchers
+	RequireSession bool
+}
+
+type FinishDownload struct {
+	Alias       string
+	Reservation string
+	// Sent bytes of the content of Size were sent by the transfer, a
+	// ranged one may send only a part of it
+	Sent int64
+	Size int64
 }
 
 type GetFile struct {
@@ -31,16 +89,84 @@ type GetFile struct {
 	RequestingUserInfo
 }
 
+type GetShare struct {
+	Alias string
+}
+
 type DeleteFile struct {
 	Alias string
 	RequestingUserInfo
 }
 
+// UpdateFile changes only the fields that are set. TTL is counted from
+// the moment of update
+type UpdateFile struct {
+	Alias          string
+	TTL            *time.Duration
+	DownloadsLeft  *int16
+	Password       *string
+	RemovePassword bool
+	RequestingUserInfo
+}
+
+type ListFiles struct {
+	Cursor string
+	Limit  int
+	SortBy entities.FileSort
+	Desc   bool
+	Status entities.FileStatus
+	RequestingUserInfo
+}
+
 type AddFile struct {
 	Filename     string
 	Alias        string
+	Size         int64
 	MaxDownloads int16
 	PasswordHash string
 	TTL          time.Duration
 	UserID       int64
+	// Pending file is hidden until it is promoted
+	Pending             bool
+	GuestIP             string
+	ManagementTokenHash string
+	// StorageKey is set when content is not stored under the alias
+	StorageKey string
+	// PasswordKey is set when the content key is derived from the password
+	PasswordKey bool
+}
+
+// PromoteFile publishes a pending file with what was learned about its
+// content while it was uploaded
+type PromoteFile struct {
+	Alias       string
+	TTL         time.Duration
+	Size        int64
+	ContentType string
+	SHA256      string
+	// StorageKey points the file to the blob holding its content
+	StorageKey string
+	// Members are the files of a multi-file share
+	Members []entities.FileMember
+}
+
+type FileCursor struct {
+	Value time.Time
+	ID    int64
+}
+
+type ListUserFiles struct {
+	UserID int64
+	SortBy entities.FileSort
+	Desc   bool
+	Status entities.FileStatus
+	After  *FileCursor
+	Limit  int
+}
+
+type UpdateFileInfo struct {
+	Alias         string
+	ExpiresAt     *time.Time
+	DownloadsLeft *int16
+	PasswordHash  *string
 }
@@ -1,18 +1,123 @@
 package results
 
 import (
+	"expire-share/internal/domain/entities"
 	"io"
 	"os"
 	"time"
 )
 
+type UploadFile struct {
+	Alias string
+	// ManagementToken lets a guest inspect and delete the file, it is
+	// returned only once
+	ManagementToken string
+	Size            int64
+	ContentType     string
+	SHA256          string
+	// Members are set for a multi-file share
+	Members []ShareMember
+	// Language is set for a paste
+	Language string
+}
+
+// ShareMember describes one of the files of a multi-file share
+type ShareMember struct {
+	Name        string
+	Size        int64
+	ContentType string
+	SHA256      string
+}
+
 type DownloadFile struct {
-	File     io.Reader
+	File     io.ReadSeeker
 	FileInfo os.FileInfo
+	ETag     string
 	Close    func() error
+
+	// Bundle is set instead of File when a multi-file share is downloaded
+	// as a whole. It writes the files to w as a ZIP archive
+	Bundle func(w io.Writer) error
+
+	// Encoded is the stored compressed content in ContentEncoding, which
+	// may be sent as it is to clients accepting the encoding. It shares
+	// the file with File, so only one of them may be read. ContentEncoding
+	// is empty when the file is not compressed
+	Encoded         io.ReadSeeker
+	EncodedSize     int64
+	ContentEncoding string
+
+	// Filename is the name the file was uploaded with, storage may keep
+	// the file under another name
+	Filename string
+	// ContentType and SHA256 are stored on upload, they are empty for
+	// files uploaded before they were stored
+	ContentType string
+	SHA256      string
+
+	Session          string
+	SessionExpiresAt time.Time
+
+	// ShareSize is set for a file of a multi-file share. Bytes sent of
+	// every file of the share add up to it before the download is counted
+	ShareSize int64
+
+	// Reservation must be confirmed once the transfer completes
+	Reservation string
+	// Reserved tells that the reservation was made for this request and
+	// must be released when the transfer fails
+	Reserved bool
 }
 
 type GetFile struct {
 	DownloadsLeft int16
 	ExpiresIn     time.Duration
+	Size          int64
+	ContentType   string
+	SHA256        string
+	// Members are set for a multi-file share
+	Members []ShareMember
+}
+
+// GetShare is what anyone with the link may see about a file before
+// downloading it. Filename of a password-protected file is not revealed
+type GetShare struct {
+	Filename          string
+	Size              int64
+	DownloadsLeft     int16
+	PasswordProtected bool
+	ExpiresAt         time.Time
+	// MultiFile is set for a multi-file share, Members lists its files
+	// unless it has a password
+	MultiFile bool
+	Members   []ShareMember
+}
+
+type FileSummary struct {
+	Alias             string
+	Filename          string
+	Size              int64
+	DownloadsLeft     int16
+	PasswordProtected bool
+	Status            entities.FileStatus
+	LoadedAt          time.Time
+	ExpiresAt         time.Time
+}
+
+type ListFiles struct {
+	Files      []FileSummary
+	NextCursor string
+}
+
+// GetQuota describes usage of a user against limits of the user role.
+// Zero storage, ttl and downloads limits mean there is no limit
+type GetQuota struct {
+	Unlimited    bool
+	FilesCount   int
+	MaxFiles     int
+	UsedBytes    int64
+	MaxBytes     int64
+	MaxFileSize  int64
+	MaxTTL       time.Duration
+	MaxDownloads int16
 }
@@ -0,0 +1,33 @@
+package commands
+
+import (
+	"expire-share/internal/domain/dto/files/commands"
+	"io"
+	"time"
+)
+
+type CreateUpload struct {
+	Length       int64
+	Filename     string
+	MaxDownloads int16
+	Password     string
+	TTL          time.Duration
+	commands.RequestingUserInfo
+}
+
+type GetUpload struct {
+	ID string
+	commands.RequestingUserInfo
+}
+
+type WriteChunk struct {
+	ID     string
+	Offset int64
+	Chunk  io.Reader
+	commands.RequestingUserInfo
+}
+
+type TerminateUpload struct {
+	ID string
+	commands.RequestingUserInfo
+}
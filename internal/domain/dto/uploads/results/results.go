@@ -0,0 +1,21 @@
+package results
+
+import "time"
+
+type CreateUpload struct {
+	ID        string
+	ExpiresAt time.Time
+}
+
+type GetUpload struct {
+	Offset    int64
+	Length    int64
+	ExpiresAt time.Time
+}
+
+type WriteChunk struct {
+	Offset    int64
+	ExpiresAt time.Time
+	// Alias is set once the last chunk is written and the file is shared
+	Alias string
+}
@@ -0,0 +1,9 @@
+package entities
+
+// Blob is a content shared by files uploaded with the same SHA-256. It is
+// stored once under StorageKey and removed when no file refers to it
+type Blob struct {
+	SHA256     string
+	StorageKey string
+	Size       int64
+}
@@ -3,11 +3,32 @@ package errors
 import "errors"
 
 var (
-	ErrAliasTaken          = errors.New("current alias is already taken")
-	ErrFileNotFound        = errors.New("file does not exist")
-	ErrNoDownloadsLeft     = errors.New("there is no downloads left")
-	ErrFileSizeTooBig      = errors.New("file size too big")
-	ErrUploadLimitExceeded = errors.New("upload limit exceeded")
+	ErrAliasTaken           = errors.New("current alias is already taken")
+	ErrInvalidAlias         = errors.New("alias is invalid or not allowed")
+	ErrVanityAliasForbidden = errors.New("vanity alias is not allowed for role")
+	ErrFileNotFound         = errors.New("file does not exist")
+	ErrNoDownloadsLeft      = errors.New("there is no downloads left")
+	ErrFileSizeTooBig       = errors.New("file size too big")
+	ErrUploadLimitExceeded  = errors.New("upload limit exceeded")
+	ErrStorageQuotaExceeded = errors.New("storage quota exceeded")
+	ErrInvalidCursor        = errors.New("invalid cursor")
+	ErrTTLTooLong           = errors.New("ttl exceeds role limit")
+	ErrTooManyDownloads     = errors.New("downloads exceed role limit")
+	ErrTooManyFiles         = errors.New("too many files in share")
+	ErrInvalidFilePath      = errors.New("invalid file path")
+	ErrUnknownLanguage      = errors.New("unknown paste language")
+
+	ErrReservationNotFound = errors.New("download reservation does not exist")
+
+	ErrUploadNotFound       = errors.New("upload does not exist")
+	ErrUploadOffsetMismatch = errors.New("upload offset does not match")
+	ErrUploadLengthExceeded = errors.New("upload length exceeded")
+	ErrUploadCompleting     = errors.New("upload is being completed")
+
+	ErrFetchNotFound       = errors.New("remote upload does not exist")
+	ErrTooManyFetches      = errors.New("too many remote uploads in progress")
+	ErrRemoteURLNotAllowed = errors.New("remote url is not allowed")
+	ErrRemoteFetchFailed   = errors.New("failed to fetch remote file")
 
 	ErrForbidden           = errors.New("forbidden")
 	ErrUserAlreadyExists   = errors.New("user already exists")
@@ -17,6 +38,13 @@ var (
 	ErrInvalidRefreshToken = errors.New("invalid refresh token")
 	ErrInvalidCredentials  = errors.New("invalid login or password")
 
+	ErrDownloadNotStarted = errors.New("download was not started explicitly")
+
 	ErrFilePasswordRequired = errors.New("file password required for access")
 	ErrFilePasswordInvalid  = errors.New("invalid file password")
+	ErrFilePasswordBound    = errors.New("file is encrypted with its password")
+	ErrPasswordKeyResumable = errors.New("resumable upload can't be encrypted with its password")
+
+	ErrFileLocked              = errors.New("file is locked after too many wrong passwords")
+	ErrTooManyPasswordAttempts = errors.New("too many wrong passwords from client")
 )
@@ -0,0 +1,35 @@
+package entities
+
+import "time"
+
+type FetchStatus string
+
+const (
+	FetchPending   FetchStatus = "pending"
+	FetchRunning   FetchStatus = "running"
+	FetchCompleted FetchStatus = "completed"
+	FetchFailed    FetchStatus = "failed"
+)
+
+// Fetch is a remote upload, a file the server fetches from URL and shares
+// under Alias once it is stored
+type Fetch struct {
+	ID       string
+	URL      string
+	Filename string
+	Status   FetchStatus
+	// Size is the size announced by the remote server, -1 if unknown
+	Size     int64
+	Received int64
+	Alias    string
+	// Error is a message about why the fetch failed
+	Error      string
+	UserID     int64
+	CreatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Finished tells whether the fetch completed or failed
+func (f Fetch) Finished() bool {
+	return f.Status == FetchCompleted || f.Status == FetchFailed
+}
@@ -2,12 +2,77 @@ package entities
 
 import "time"
 
+const (
+	FileSortCreated = "created"
+	FileSortExpires = "expires"
+)
+
+type FileSort string
+
+const (
+	FileStatusAll       = "all"
+	FileStatusActive    = "active"
+	FileStatusExhausted = "exhausted"
+	FileStatusExpired   = "expired"
+)
+
+type FileStatus string
+
 type File struct {
-	Filename      string
-	Alias         string
+	ID          int64
+	Filename    string
+	Alias       string
+	Size        int64
+	ContentType string
+	// SHA256 is a hex encoded checksum of the content, empty for files
+	// uploaded before checksums were stored
+	SHA256        string
 	DownloadsLeft int16
 	PasswordHash  string
 	LoadedAt      time.Time
 	ExpiresAt     time.Time
 	UserID        int64
+	// GuestIP and ManagementTokenHash are set for files uploaded by guests
+	GuestIP             string
+	ManagementTokenHash string
+	// StorageKey is where the content is stored when it is not stored
+	// under the alias, e.g. a deduplicated blob
+	StorageKey string
+	// Members is the number of files of a multi-file share, it is zero
+	// for a share of a single file
+	Members int
+	// PasswordKey is set when the content key was derived from the
+	// password, so the content can't be read without it
+	PasswordKey bool
+}
+
+// FileMember is one of the files of a multi-file share
+type FileMember struct {
+	Name        string
+	Size        int64
+	ContentType string
+	SHA256      string
+	StorageKey  string
+}
+
+// ContentKey returns the key the content of the file is stored under
+func (f File) ContentKey() string {
+	if f.StorageKey != "" {
+		return f.StorageKey
+	}
+
+	return f.Alias
+}
+
+// Status tells whether file can still be downloaded at the given moment
+func (f File) Status(now time.Time) FileStatus {
+	if !f.ExpiresAt.After(now) {
+		return FileStatusExpired
+	}
+
+	if f.DownloadsLeft <= 0 {
+		return FileStatusExhausted
+	}
+
+	return FileStatusActive
 }
@@ -0,0 +1,6 @@
+package entities
+
+type StorageUsage struct {
+	FilesCount int
+	UsedBytes  int64
+}
@@ -0,0 +1,14 @@
+package entities
+
+import "time"
+
+// DownloadReservation holds a download slot of a file while the file is
+// transferred. An unconfirmed reservation gives the slot back when it is
+// released or expires, a confirmed one keeps the download session of a
+// counted download alive until it expires
+type DownloadReservation struct {
+	ID        string
+	FileID    int64
+	Confirmed bool
+	ExpiresAt time.Time
+}
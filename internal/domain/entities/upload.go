@@ -0,0 +1,16 @@
+package entities
+
+import "time"
+
+type Upload struct {
+	ID           string
+	Filename     string
+	Length       int64
+	Offset       int64
+	MaxDownloads int16
+	TTL          time.Duration
+	PasswordHash string
+	UserID       int64
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
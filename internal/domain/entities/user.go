@@ -6,8 +6,13 @@ const (
 	RoleUser  = "user"
 	RoleVip   = "vip"
 	RoleAdmin = "admin"
+	// RoleGuest is given to uploaders without an account
+	RoleGuest = "guest"
 )
 
+// GuestUserID is the owner of files uploaded by guests
+const GuestUserID = 0
+
 type UserRole string
 
 type User struct {
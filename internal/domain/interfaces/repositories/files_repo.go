@@ -5,16 +5,36 @@ import (
 	"expire-share/internal/domain/dto/files/commands"
 	"expire-share/internal/domain/entities"
 	"expire-share/internal/domain/interfaces/tx"
+	"time"
 )
 
 type FileRepo interface {
 	tx.Beginner
 
 	GetFileByAlias(ctx context.Context, alias string) (*entities.File, error)
-	CountByUserID(ctx context.Context, userID int64) (int, error)
+	GetFileMembers(ctx context.Context, fileID int64) ([]entities.FileMember, error)
+	GetUsageByUserID(ctx context.Context, userID int64) (entities.StorageUsage, error)
+	GetUsageByGuestIP(ctx context.Context, ip string) (entities.StorageUsage, error)
+	ListFilesByUserID(ctx context.Context, query commands.ListUserFiles) ([]entities.File, error)
 
 	AddFileTx(ctx context.Context, tx tx.Tx, command commands.AddFile) (int64, error)
+	PromoteFileTx(ctx context.Context, tx tx.Tx, command commands.PromoteFile) error
+	DeletePendingFileTx(ctx context.Context, tx tx.Tx, alias string) error
 	DecrementDownloadsByAliasTx(ctx context.Context, tx tx.Tx, alias string) (int16, error)
+	AddFailedPasswordAttemptTx(ctx context.Context, tx tx.Tx, fileID int64) (int, error)
+	// UpdateFileTx returns downloads left as stored, which differs from the
+	// requested value while downloads of the file are running
+	UpdateFileTx(ctx context.Context, tx tx.Tx, command commands.UpdateFileInfo) (int16, error)
 	DeleteFileTx(ctx context.Context, tx tx.Tx, alias string) error
 	DeleteExpiredFilesTx(ctx context.Context, tx tx.Tx, limit int) ([]string, error)
+
+	AcquireBlobTx(ctx context.Context, tx tx.Tx, blob entities.Blob) (string, error)
+	DeleteUnreferencedBlobsTx(ctx context.Context, tx tx.Tx, limit int) ([]string, error)
+
+	GetReservation(ctx context.Context, id string) (*entities.DownloadReservation, error)
+	AddReservationTx(ctx context.Context, tx tx.Tx, reservation entities.DownloadReservation) error
+	AddReservationSentTx(ctx context.Context, tx tx.Tx, id string, n int64) (int64, error)
+	ConfirmReservationTx(ctx context.Context, tx tx.Tx, id string, expiresAt time.Time) error
+	ReleaseReservationTx(ctx context.Context, tx tx.Tx, id string) error
+	ReleaseExpiredReservationsTx(ctx context.Context, tx tx.Tx, limit int) (int, error)
 }
@@ -11,3 +11,17 @@ type File interface {
 	Download(ctx context.Context, alias string) (*results.DownloadFile, error)
 	Upload(ctx context.Context, file io.Reader, alias string, filename string) error
 }
+
+type passwordKey struct{}
+
+// WithPassword passes the verified password of a file to storage, storages
+// encrypting files may derive the file key from it
+func WithPassword(ctx context.Context, password string) context.Context {
+	return context.WithValue(ctx, passwordKey{}, password)
+}
+
+// PasswordFromContext returns the password passed with WithPassword
+func PasswordFromContext(ctx context.Context) string {
+	password, _ := ctx.Value(passwordKey{}).(string)
+	return password
+}
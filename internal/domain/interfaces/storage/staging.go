@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"context"
+	"expire-share/internal/domain/entities"
+	"io"
+	"time"
+)
+
+type Staging interface {
+	Create(ctx context.Context, upload entities.Upload) error
+	Get(ctx context.Context, id string) (*entities.Upload, error)
+	Append(ctx context.Context, id string, offset int64, chunk io.Reader) (int64, error)
+	Open(ctx context.Context, id string) (io.ReadCloser, error)
+	// Claim marks the upload as being completed. Only one caller holds
+	// the claim, others get ErrUploadCompleting until it is released
+	Claim(ctx context.Context, id string) error
+	Unclaim(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string) error
+	DeleteExpired(ctx context.Context, now time.Time) ([]string, error)
+}
@@ -0,0 +1,117 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"expire-share/internal/domain/entities"
+	"expire-share/internal/domain/interfaces/tx"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"log/slog"
+)
+
+// AcquireBlobTx takes a reference to the blob with the same SHA-256 and
+// returns its storage key. When there is no such blob, the given one is
+// added and its own key is returned
+func (fr *FileRepo) AcquireBlobTx(ctx context.Context, tx tx.Tx, blob entities.Blob) (string, error) {
+	const fn = "repository.mysql.FileRepo.AcquireBlob"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return "", fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	_, err := sqlTx.ExecContext(ctx, `INSERT INTO blobs(sha256, storage_key, size, ref_count, created_at) VALUES(?, ?, ?, 1, NOW()) ON DUPLICATE KEY UPDATE ref_count = ref_count + 1`,
+		blob.SHA256,
+		blob.StorageKey,
+		blob.Size)
+
+	if err != nil {
+		return "", fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	// the upsert keeps the row locked, so the key can't change until commit
+	var storageKey string
+	err = sqlTx.QueryRowContext(ctx, `SELECT storage_key FROM blobs WHERE sha256 = ?`, blob.SHA256).Scan(&storageKey)
+	if err != nil {
+		return "", fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	return storageKey, nil
+}
+
+// DeleteUnreferencedBlobsTx deletes up to limit blobs no file refers to
+// and returns their storage keys
+func (fr *FileRepo) DeleteUnreferencedBlobsTx(ctx context.Context, tx tx.Tx, limit int) ([]string, error) {
+	const fn = "repository.mysql.FileRepo.DeleteUnreferencedBlobs"
+	log := fr.log.With(slog.String("fn", fn))
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return nil, fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	rows, err := sqlTx.QueryContext(ctx, `SELECT storage_key FROM blobs WHERE ref_count <= 0 LIMIT ? FOR UPDATE`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Warn("failed to close rows", sl.Error(err))
+		}
+	}(rows)
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan storage key: %w", fn, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if len(keys) == 0 {
+		return keys, nil
+	}
+
+	stmt, err := sqlTx.PrepareContext(ctx, `DELETE FROM blobs WHERE storage_key = ?`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to prepare stmt: %w", fn, err)
+	}
+
+	defer func(stmt *sql.Stmt) {
+		if err := stmt.Close(); err != nil {
+			log.Warn("failed to close stmt", sl.Error(err))
+		}
+	}(stmt)
+
+	for _, key := range keys {
+		if _, err := stmt.Exec(key); err != nil {
+			return nil, fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+		}
+	}
+
+	return keys, nil
+}
+
+// releaseBlob drops a reference to the blob stored under key. It reports
+// whether there is such a blob, content of other keys belongs to the file
+func releaseBlob(ctx context.Context, sqlTx *sql.Tx, key string) (bool, error) {
+	res, err := sqlTx.ExecContext(ctx, `UPDATE blobs SET ref_count = ref_count - 1 WHERE storage_key = ?`, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to exec sql: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to affect rows: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
@@ -11,6 +11,7 @@ import (
 	"expire-share/internal/lib/log/sl"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/go-sql-driver/mysql"
@@ -21,6 +22,11 @@ const (
 	duplicateEntryErrCode = 1062
 )
 
+var sortColumns = map[entities.FileSort]string{
+	entities.FileSortCreated: "loaded_at",
+	entities.FileSortExpires: "expires_at",
+}
+
 type FileRepo struct {
 	DB  *sql.DB
 	log *slog.Logger
@@ -43,14 +49,20 @@ func (fr *FileRepo) AddFileTx(ctx context.Context, tx tx.Tx, command commands.Ad
 	}
 
 	currentTime := time.Now()
-	res, err := sqlTx.ExecContext(ctx, `INSERT INTO files(file_name, alias, downloads_left, loaded_at, expires_at, password_hash, user_id) VALUES(?, ?, ?, ?, ?, ?, ?)`,
+	res, err := sqlTx.ExecContext(ctx, `INSERT INTO files(file_name, alias, size, downloads_left, loaded_at, expires_at, password_hash, user_id, pending, guest_ip, management_token_hash, storage_key, password_key) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		command.Filename,
 		command.Alias,
+		command.Size,
 		command.MaxDownloads,
 		currentTime,
 		currentTime.Add(command.TTL),
 		command.PasswordHash,
-		command.UserID)
+		command.UserID,
+		command.Pending,
+		command.GuestIP,
+		command.ManagementTokenHash,
+		command.StorageKey,
+		command.PasswordKey)
 
 	if err != nil {
 		var mysqlErr *mysql.MySQLError
@@ -69,18 +81,85 @@ func (fr *FileRepo) AddFileTx(ctx context.Context, tx tx.Tx, command commands.Ad
 	return id, nil
 }
 
+// PromoteFileTx makes pending file available for downloads. The file lives
+// for ttl from now, so time spent uploading it is not taken from its TTL
+func (fr *FileRepo) PromoteFileTx(ctx context.Context, tx tx.Tx, command commands.PromoteFile) error {
+	const fn = "repository.mysql.FileRepo.PromoteFile"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	currentTime := time.Now()
+	res, err := sqlTx.ExecContext(ctx, `UPDATE files SET pending = FALSE, loaded_at = ?, expires_at = ?, size = ?, content_type = ?, sha256 = ?, storage_key = ?, members = ? WHERE alias = ? AND pending = TRUE AND expires_at > NOW()`,
+		currentTime, currentTime.Add(command.TTL), command.Size, command.ContentType, command.SHA256, command.StorageKey, len(command.Members), command.Alias)
+	if err != nil {
+		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to affect rows: %w", fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return domainErrors.ErrFileNotFound
+	}
+
+	if err := addMembers(ctx, sqlTx, command.Alias, command.Members); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (fr *FileRepo) DeletePendingFileTx(ctx context.Context, tx tx.Tx, alias string) error {
+	const fn = "repository.mysql.FileRepo.DeletePendingFile"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	res, err := sqlTx.ExecContext(ctx, `DELETE FROM files WHERE alias = ? AND pending = TRUE`, alias)
+	if err != nil {
+		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to affect rows: %w", fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return domainErrors.ErrFileNotFound
+	}
+
+	return nil
+}
+
 func (fr *FileRepo) GetFileByAlias(ctx context.Context, alias string) (*entities.File, error) {
 	const fn = "repository.mysql.FileRepo.GetFileByAlias"
 
 	var file entities.File
-	err := fr.DB.QueryRowContext(ctx, `SELECT file_name, alias, downloads_left, loaded_at, expires_at, password_hash, user_id FROM files WHERE alias = ? AND expires_at > NOW()`, alias).Scan(
+	err := fr.DB.QueryRowContext(ctx, `SELECT id, file_name, alias, size, content_type, sha256, downloads_left, loaded_at, expires_at, password_hash, user_id, guest_ip, management_token_hash, storage_key, members, password_key FROM files WHERE alias = ? AND pending = FALSE AND expires_at > NOW()`, alias).Scan(
+		&file.ID,
 		&file.Filename,
 		&file.Alias,
+		&file.Size,
+		&file.ContentType,
+		&file.SHA256,
 		&file.DownloadsLeft,
 		&file.LoadedAt,
 		&file.ExpiresAt,
 		&file.PasswordHash,
-		&file.UserID)
+		&file.UserID,
+		&file.GuestIP,
+		&file.ManagementTokenHash,
+		&file.StorageKey,
+		&file.Members,
+		&file.PasswordKey)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -93,20 +172,109 @@ func (fr *FileRepo) GetFileByAlias(ctx context.Context, alias string) (*entities
 	return &file, nil
 }
 
-func (fr *FileRepo) CountByUserID(ctx context.Context, userId int64) (int, error) {
-	const fn = "repository.mysql.FileRepo.CountByUserId"
+func (fr *FileRepo) GetUsageByUserID(ctx context.Context, userID int64) (entities.StorageUsage, error) {
+	const fn = "repository.mysql.FileRepo.GetUsageByUserID"
 
-	var count int
-	err := fr.DB.QueryRowContext(ctx, `SELECT count(*) FROM files WHERE user_id = ?`, userId).
-		Scan(&count)
+	var usage entities.StorageUsage
+	err := fr.DB.QueryRowContext(ctx, `SELECT count(*), COALESCE(SUM(size), 0) FROM files WHERE user_id = ? AND expires_at > NOW()`, userID).
+		Scan(&usage.FilesCount, &usage.UsedBytes)
 
 	if err != nil {
-		return 0, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+		return entities.StorageUsage{}, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	return usage, nil
+}
+
+func (fr *FileRepo) GetUsageByGuestIP(ctx context.Context, ip string) (entities.StorageUsage, error) {
+	const fn = "repository.mysql.FileRepo.GetUsageByGuestIP"
+
+	var usage entities.StorageUsage
+	err := fr.DB.QueryRowContext(ctx, `SELECT count(*), COALESCE(SUM(size), 0) FROM files WHERE guest_ip = ? AND user_id = 0 AND expires_at > NOW()`, ip).
+		Scan(&usage.FilesCount, &usage.UsedBytes)
+
+	if err != nil {
+		return entities.StorageUsage{}, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	return usage, nil
+}
+
+func (fr *FileRepo) ListFilesByUserID(ctx context.Context, query commands.ListUserFiles) ([]entities.File, error) {
+	const fn = "repository.mysql.FileRepo.ListFilesByUserID"
+	log := fr.log.With(slog.String("fn", fn))
+
+	column, ok := sortColumns[query.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("%s: unknown sort column %s", fn, query.SortBy)
+	}
+
+	sqlQuery := `SELECT id, file_name, alias, size, downloads_left, loaded_at, expires_at, password_hash, user_id FROM files WHERE user_id = ? AND pending = FALSE`
+	args := []any{query.UserID}
+
+	switch query.Status {
+	case entities.FileStatusActive:
+		sqlQuery += ` AND downloads_left > 0 AND expires_at > NOW()`
+	case entities.FileStatusExhausted:
+		sqlQuery += ` AND downloads_left <= 0 AND expires_at > NOW()`
+	case entities.FileStatusExpired:
+		sqlQuery += ` AND expires_at <= NOW()`
+	}
+
+	direction, comparison := "ASC", ">"
+	if query.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if query.After != nil {
+		sqlQuery += fmt.Sprintf(` AND (%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))`, column, comparison)
+		args = append(args, query.After.Value, query.After.Value, query.After.ID)
+	}
+
+	sqlQuery += fmt.Sprintf(` ORDER BY %[1]s %[2]s, id %[2]s LIMIT ?`, column, direction)
+	args = append(args, query.Limit)
+
+	rows, err := fr.DB.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Warn("failed to close rows", sl.Error(err))
+		}
+	}(rows)
+
+	var files []entities.File
+	for rows.Next() {
+		var file entities.File
+		err := rows.Scan(
+			&file.ID,
+			&file.Filename,
+			&file.Alias,
+			&file.Size,
+			&file.DownloadsLeft,
+			&file.LoadedAt,
+			&file.ExpiresAt,
+			&file.PasswordHash,
+			&file.UserID)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan file: %w", fn, err)
+		}
+
+		files = append(files, file)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
 	}
 
-	return count, nil
+	return files, nil
 }
 
+// DecrementDownloadsByAliasTx decrements downloads left with a single
+// conditional update, so concurrent downloads can't both take the last one
 func (fr *FileRepo) DecrementDownloadsByAliasTx(ctx context.Context, tx tx.Tx, alias string) (int16, error) {
 	const fn = "repository.mysql.FileRepo.DecrementDownloadsByAlias"
 
@@ -115,8 +283,18 @@ func (fr *FileRepo) DecrementDownloadsByAliasTx(ctx context.Context, tx tx.Tx, a
 		return 0, fmt.Errorf("%s: failed to convert tx to sql", fn)
 	}
 
+	res, err := sqlTx.ExecContext(ctx, `UPDATE files SET downloads_left = downloads_left - 1 WHERE alias = ? AND pending = FALSE AND expires_at > NOW() AND downloads_left > 0`, alias)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to affect rows: %w", fn, err)
+	}
+
 	var downloadsLeft int16
-	err := sqlTx.QueryRowContext(ctx, `SELECT downloads_left FROM files WHERE alias = ? AND expires_at > NOW()`, alias).
+	err = sqlTx.QueryRowContext(ctx, `SELECT downloads_left FROM files WHERE alias = ? AND pending = FALSE AND expires_at > NOW()`, alias).
 		Scan(&downloadsLeft)
 
 	if err != nil {
@@ -124,19 +302,95 @@ func (fr *FileRepo) DecrementDownloadsByAliasTx(ctx context.Context, tx tx.Tx, a
 			return 0, domainErrors.ErrFileNotFound
 		}
 
-		return 0, fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+		return 0, fmt.Errorf("%s: failed to query sql: %w", fn, err)
 	}
 
-	if downloadsLeft == 0 {
+	if rowsAffected == 0 {
 		return 0, domainErrors.ErrNoDownloadsLeft
 	}
 
-	downloadsLeft--
-	_, err = sqlTx.ExecContext(ctx, `UPDATE files SET downloads_left = ? WHERE alias = ?`, downloadsLeft, alias)
+	return downloadsLeft, nil
+}
+
+// AddFailedPasswordAttemptTx counts a wrong password of the file and
+// returns how many of them the file has had
+func (fr *FileRepo) AddFailedPasswordAttemptTx(ctx context.Context, tx tx.Tx, fileID int64) (int, error) {
+	const fn = "repository.mysql.FileRepo.AddFailedPasswordAttempt"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return 0, fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	_, err := sqlTx.ExecContext(ctx, `UPDATE files SET failed_password_attempts = failed_password_attempts + 1 WHERE id = ?`, fileID)
 	if err != nil {
 		return 0, fmt.Errorf("%s: failed to exec sql: %w", fn, err)
 	}
 
+	var attempts int
+	err = sqlTx.QueryRowContext(ctx, `SELECT failed_password_attempts FROM files WHERE id = ?`, fileID).
+		Scan(&attempts)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domainErrors.ErrFileNotFound
+		}
+
+		return 0, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	return attempts, nil
+}
+
+func (fr *FileRepo) UpdateFileTx(ctx context.Context, tx tx.Tx, command commands.UpdateFileInfo) (int16, error) {
+	const fn = "repository.mysql.FileRepo.UpdateFile"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return 0, fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	var columns []string
+	var args []any
+
+	if command.ExpiresAt != nil {
+		columns = append(columns, "expires_at = ?")
+		args = append(args, *command.ExpiresAt)
+	}
+
+	if command.DownloadsLeft != nil {
+		// slots of unconfirmed reservations are given back when they are
+		// released, so they are taken from the new value right away
+		columns = append(columns, "downloads_left = ? - (SELECT COUNT(*) FROM download_reservations WHERE file_id = files.id AND confirmed = FALSE)")
+		args = append(args, *command.DownloadsLeft)
+	}
+
+	if command.PasswordHash != nil {
+		columns = append(columns, "password_hash = ?")
+		args = append(args, *command.PasswordHash)
+	}
+
+	if len(columns) > 0 {
+		args = append(args, command.Alias)
+		_, err := sqlTx.ExecContext(ctx, `UPDATE files SET `+strings.Join(columns, ", ")+` WHERE alias = ? AND pending = FALSE AND expires_at > NOW()`, args...)
+		if err != nil {
+			return 0, fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+		}
+	}
+
+	// the file is found by alias alone, an update may have expired it
+	var downloadsLeft int16
+	err := sqlTx.QueryRowContext(ctx, `SELECT downloads_left FROM files WHERE alias = ? AND pending = FALSE`, command.Alias).
+		Scan(&downloadsLeft)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domainErrors.ErrFileNotFound
+		}
+
+		return 0, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
 	return downloadsLeft, nil
 }
 
@@ -148,7 +402,26 @@ func (fr *FileRepo) DeleteFileTx(ctx context.Context, tx tx.Tx, alias string) er
 		return fmt.Errorf("%s: failed to convert tx to sql", fn)
 	}
 
-	res, err := sqlTx.ExecContext(ctx, `DELETE FROM files WHERE alias = ? AND expires_at > NOW()`, alias)
+	// a deduplicated content loses a reference, the worker removes it
+	// once no file refers to it
+	_, err := sqlTx.ExecContext(ctx, `UPDATE blobs SET ref_count = ref_count - 1 WHERE storage_key = (SELECT storage_key FROM files WHERE alias = ? AND pending = FALSE AND expires_at > NOW())`, alias)
+	if err != nil {
+		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	_, err = sqlTx.ExecContext(ctx, `DELETE FROM file_members WHERE file_id = (SELECT id FROM files WHERE alias = ? AND pending = FALSE AND expires_at > NOW())`, alias)
+	if err != nil {
+		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	// transfers of the file end with it, its reservations must not stay
+	// behind for a file reusing its id
+	_, err = sqlTx.ExecContext(ctx, `DELETE FROM download_reservations WHERE file_id = (SELECT id FROM files WHERE alias = ? AND pending = FALSE AND expires_at > NOW())`, alias)
+	if err != nil {
+		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	res, err := sqlTx.ExecContext(ctx, `DELETE FROM files WHERE alias = ? AND pending = FALSE AND expires_at > NOW()`, alias)
 	if err != nil {
 		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
 	}
@@ -165,6 +438,10 @@ func (fr *FileRepo) DeleteFileTx(ctx context.Context, tx tx.Tx, alias string) er
 	return nil
 }
 
+// DeleteExpiredFilesTx deletes up to limit expired files and exhausted files
+// no download reservation refers to anymore, so the last transfer of a file
+// and its download session can finish before the file is removed. It returns
+// storage keys of contents to remove, deduplicated contents are only released
 func (fr *FileRepo) DeleteExpiredFilesTx(ctx context.Context, tx tx.Tx, limit int) ([]string, error) {
 	const fn = "repository.mysql.FileRepo.DeleteExpiredFiles"
 	log := fr.log.With(slog.String("fn", fn))
@@ -174,7 +451,7 @@ func (fr *FileRepo) DeleteExpiredFilesTx(ctx context.Context, tx tx.Tx, limit in
 		return nil, fmt.Errorf("%s: failed to convert tx to sql", fn)
 	}
 
-	rows, err := sqlTx.QueryContext(ctx, `SELECT alias FROM files WHERE expires_at < NOW() LIMIT ? FOR UPDATE`, limit)
+	rows, err := sqlTx.QueryContext(ctx, `SELECT id, alias, storage_key, members FROM files WHERE expires_at < NOW() OR (downloads_left <= 0 AND NOT EXISTS (SELECT 1 FROM download_reservations WHERE file_id = files.id)) LIMIT ? FOR UPDATE`, limit)
 	if err != nil {
 		return nil, fmt.Errorf("%s: failed to exec sql: %w", fn, err)
 	}
@@ -185,22 +462,22 @@ func (fr *FileRepo) DeleteExpiredFilesTx(ctx context.Context, tx tx.Tx, limit in
 		}
 	}(rows)
 
-	var aliases []string
+	var files []entities.File
 	for rows.Next() {
-		var alias string
-		if err := rows.Scan(&alias); err != nil {
-			return nil, fmt.Errorf("%s: failed to scan alias: %w", fn, err)
+		var file entities.File
+		if err := rows.Scan(&file.ID, &file.Alias, &file.StorageKey, &file.Members); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan file: %w", fn, err)
 		}
 
-		aliases = append(aliases, alias)
+		files = append(files, file)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("%s: %w", fn, err)
 	}
 
-	if len(aliases) == 0 {
-		return aliases, nil
+	if len(files) == 0 {
+		return nil, nil
 	}
 
 	stmt, err := sqlTx.PrepareContext(ctx, `DELETE FROM files WHERE alias = ?`)
@@ -214,11 +491,37 @@ func (fr *FileRepo) DeleteExpiredFilesTx(ctx context.Context, tx tx.Tx, limit in
 		}
 	}(stmt)
 
-	for _, alias := range aliases {
-		if _, err := stmt.Exec(alias); err != nil {
+	for _, file := range files {
+		if _, err := stmt.Exec(file.Alias); err != nil {
 			return nil, fmt.Errorf("%s: failed to exec sql: %w", fn, err)
 		}
 	}
 
-	return aliases, nil
+	var keys []string
+	for _, file := range files {
+		if file.Members > 0 {
+			if err := deleteMembers(ctx, sqlTx, file.ID); err != nil {
+				return nil, fmt.Errorf("%s: %w", fn, err)
+			}
+		}
+
+		if err := deleteReservations(ctx, sqlTx, file.ID); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+
+		if file.StorageKey != "" {
+			isBlob, err := releaseBlob(ctx, sqlTx, file.StorageKey)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", fn, err)
+			}
+
+			if isBlob {
+				continue
+			}
+		}
+
+		keys = append(keys, file.ContentKey())
+	}
+
+	return keys, nil
 }
@@ -0,0 +1,26 @@
+package mysql
+
+import (
+	"expire-share/internal/config"
+	"expire-share/internal/domain/interfaces/repositories"
+	"expire-share/internal/infrastructure/repotest"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+// TestFileRepo needs a disposable database, e.g.
+// TEST_MYSQL_DSN=root:secret@tcp(localhost:3308)/ExpireShareTest?parseTime=True
+func TestFileRepo(t *testing.T) {
+	connectionString := os.Getenv("TEST_MYSQL_DSN")
+	if connectionString == "" {
+		t.Skip("TEST_MYSQL_DSN is not set")
+	}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	repotest.RunFileRepo(t, func(t *testing.T) repositories.FileRepo {
+		return NewFileRepo(repotest.OpenDB(t, config.DatabaseMySQL, connectionString), log)
+	})
+}
@@ -0,0 +1,69 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expire-share/internal/domain/entities"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// webhookTimeout keeps a slow receiver from holding the request which locked the file
+const webhookTimeout = 5 * time.Second
+
+// Webhook posts events about files to an url, the receiver delivers them
+// to the owners, e.g. by email
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{url: url, client: &http.Client{Timeout: webhookTimeout}}
+}
+
+type fileLockedEvent struct {
+	Event       string    `json:"event"`
+	Alias       string    `json:"alias"`
+	Filename    string    `json:"filename"`
+	UserID      int64     `json:"user_id"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+func (wh *Webhook) FileLocked(ctx context.Context, file entities.File, lockedUntil time.Time) error {
+	const fn = "notify.Webhook.FileLocked"
+
+	body, err := json.Marshal(fileLockedEvent{
+		Event:       "file_locked",
+		Alias:       file.Alias,
+		Filename:    file.Filename,
+		UserID:      file.UserID,
+		LockedUntil: lockedUntil.UTC(),
+	})
+
+	if err != nil {
+		return fmt.Errorf("%s: failed to marshal event: %w", fn, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: failed to create request: %w", fn, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := wh.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: failed to send request: %w", fn, err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: unexpected status %d", fn, resp.StatusCode)
+	}
+
+	return nil
+}
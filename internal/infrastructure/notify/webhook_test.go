@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"expire-share/internal/domain/entities"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhook_FileLocked(t *testing.T) {
+	lockedUntil := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	file := entities.File{Alias: "abc123", Filename: "report.pdf", UserID: 42}
+
+	t.Run("event is posted", func(t *testing.T) {
+		var event fileLockedEvent
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodPost, r.Method)
+			require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		err := NewWebhook(server.URL).FileLocked(context.Background(), file, lockedUntil)
+		require.NoError(t, err)
+		require.Equal(t, fileLockedEvent{
+			Event:       "file_locked",
+			Alias:       "abc123",
+			Filename:    "report.pdf",
+			UserID:      42,
+			LockedUntil: lockedUntil,
+		}, event)
+	})
+
+	t.Run("receiver error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		err := NewWebhook(server.URL).FileLocked(context.Background(), file, lockedUntil)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,500 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/domain/interfaces/tx"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	uniqueViolationErrCode = "23505"
+)
+
+var sortColumns = map[entities.FileSort]string{
+	entities.FileSortCreated: "loaded_at",
+	entities.FileSortExpires: "expires_at",
+}
+
+type FileRepo struct {
+	DB  *sql.DB
+	log *slog.Logger
+}
+
+func NewFileRepo(db *sql.DB, log *slog.Logger) *FileRepo {
+	return &FileRepo{DB: db, log: log}
+}
+
+func (fr *FileRepo) BeginTx(ctx context.Context) (tx.Tx, error) {
+	return fr.DB.BeginTx(ctx, nil)
+}
+
+func (fr *FileRepo) AddFileTx(ctx context.Context, tx tx.Tx, command commands.AddFile) (int64, error) {
+	const fn = "repository.postgres.FileRepo.AddFile"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return 0, fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	currentTime := time.Now()
+
+	var id int64
+	err := sqlTx.QueryRowContext(ctx, `INSERT INTO files(file_name, alias, size, downloads_left, loaded_at, expires_at, password_hash, user_id, pending, guest_ip, management_token_hash, storage_key, password_key) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id`,
+		command.Filename,
+		command.Alias,
+		command.Size,
+		command.MaxDownloads,
+		currentTime,
+		currentTime.Add(command.TTL),
+		command.PasswordHash,
+		command.UserID,
+		command.Pending,
+		command.GuestIP,
+		command.ManagementTokenHash,
+		command.StorageKey,
+		command.PasswordKey).Scan(&id)
+
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolationErrCode {
+			return 0, domainErrors.ErrAliasTaken
+		}
+
+		return 0, fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	return id, nil
+}
+
+// PromoteFileTx makes pending file available for downloads. The file lives
+// for ttl from now, so time spent uploading it is not taken from its TTL
+func (fr *FileRepo) PromoteFileTx(ctx context.Context, tx tx.Tx, command commands.PromoteFile) error {
+	const fn = "repository.postgres.FileRepo.PromoteFile"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	currentTime := time.Now()
+	res, err := sqlTx.ExecContext(ctx, `UPDATE files SET pending = FALSE, loaded_at = $1, expires_at = $2, size = $3, content_type = $4, sha256 = $5, storage_key = $6, members = $7 WHERE alias = $8 AND pending = TRUE AND expires_at > NOW()`,
+		currentTime, currentTime.Add(command.TTL), command.Size, command.ContentType, command.SHA256, command.StorageKey, len(command.Members), command.Alias)
+	if err != nil {
+		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to affect rows: %w", fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return domainErrors.ErrFileNotFound
+	}
+
+	if err := addMembers(ctx, sqlTx, command.Alias, command.Members); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (fr *FileRepo) DeletePendingFileTx(ctx context.Context, tx tx.Tx, alias string) error {
+	const fn = "repository.postgres.FileRepo.DeletePendingFile"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	res, err := sqlTx.ExecContext(ctx, `DELETE FROM files WHERE alias = $1 AND pending = TRUE`, alias)
+	if err != nil {
+		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to affect rows: %w", fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return domainErrors.ErrFileNotFound
+	}
+
+	return nil
+}
+
+func (fr *FileRepo) GetFileByAlias(ctx context.Context, alias string) (*entities.File, error) {
+	const fn = "repository.postgres.FileRepo.GetFileByAlias"
+
+	var file entities.File
+	err := fr.DB.QueryRowContext(ctx, `SELECT id, file_name, alias, size, content_type, sha256, downloads_left, loaded_at, expires_at, password_hash, user_id, guest_ip, management_token_hash, storage_key, members, password_key FROM files WHERE alias = $1 AND pending = FALSE AND expires_at > NOW()`, alias).Scan(
+		&file.ID,
+		&file.Filename,
+		&file.Alias,
+		&file.Size,
+		&file.ContentType,
+		&file.SHA256,
+		&file.DownloadsLeft,
+		&file.LoadedAt,
+		&file.ExpiresAt,
+		&file.PasswordHash,
+		&file.UserID,
+		&file.GuestIP,
+		&file.ManagementTokenHash,
+		&file.StorageKey,
+		&file.Members,
+		&file.PasswordKey)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domainErrors.ErrFileNotFound
+		}
+
+		return nil, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	return &file, nil
+}
+
+func (fr *FileRepo) GetUsageByUserID(ctx context.Context, userID int64) (entities.StorageUsage, error) {
+	const fn = "repository.postgres.FileRepo.GetUsageByUserID"
+
+	var usage entities.StorageUsage
+	err := fr.DB.QueryRowContext(ctx, `SELECT count(*), COALESCE(SUM(size), 0) FROM files WHERE user_id = $1 AND expires_at > NOW()`, userID).
+		Scan(&usage.FilesCount, &usage.UsedBytes)
+
+	if err != nil {
+		return entities.StorageUsage{}, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	return usage, nil
+}
+
+func (fr *FileRepo) GetUsageByGuestIP(ctx context.Context, ip string) (entities.StorageUsage, error) {
+	const fn = "repository.postgres.FileRepo.GetUsageByGuestIP"
+
+	var usage entities.StorageUsage
+	err := fr.DB.QueryRowContext(ctx, `SELECT count(*), COALESCE(SUM(size), 0) FROM files WHERE guest_ip = $1 AND user_id = 0 AND expires_at > NOW()`, ip).
+		Scan(&usage.FilesCount, &usage.UsedBytes)
+
+	if err != nil {
+		return entities.StorageUsage{}, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	return usage, nil
+}
+
+func (fr *FileRepo) ListFilesByUserID(ctx context.Context, query commands.ListUserFiles) ([]entities.File, error) {
+	const fn = "repository.postgres.FileRepo.ListFilesByUserID"
+	log := fr.log.With(slog.String("fn", fn))
+
+	column, ok := sortColumns[query.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("%s: unknown sort column %s", fn, query.SortBy)
+	}
+
+	sqlQuery := `SELECT id, file_name, alias, size, downloads_left, loaded_at, expires_at, password_hash, user_id FROM files WHERE user_id = $1 AND pending = FALSE`
+	args := []any{query.UserID}
+
+	switch query.Status {
+	case entities.FileStatusActive:
+		sqlQuery += ` AND downloads_left > 0 AND expires_at > NOW()`
+	case entities.FileStatusExhausted:
+		sqlQuery += ` AND downloads_left <= 0 AND expires_at > NOW()`
+	case entities.FileStatusExpired:
+		sqlQuery += ` AND expires_at <= NOW()`
+	}
+
+	direction, comparison := "ASC", ">"
+	if query.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if query.After != nil {
+		sqlQuery += fmt.Sprintf(` AND (%[1]s, id) %[2]s ($2, $3)`, column, comparison)
+		args = append(args, query.After.Value, query.After.ID)
+	}
+
+	sqlQuery += fmt.Sprintf(` ORDER BY %[1]s %[2]s, id %[2]s LIMIT $%[3]d`, column, direction, len(args)+1)
+	args = append(args, query.Limit)
+
+	rows, err := fr.DB.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Warn("failed to close rows", sl.Error(err))
+		}
+	}(rows)
+
+	var files []entities.File
+	for rows.Next() {
+		var file entities.File
+		err := rows.Scan(
+			&file.ID,
+			&file.Filename,
+			&file.Alias,
+			&file.Size,
+			&file.DownloadsLeft,
+			&file.LoadedAt,
+			&file.ExpiresAt,
+			&file.PasswordHash,
+			&file.UserID)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan file: %w", fn, err)
+		}
+
+		files = append(files, file)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return files, nil
+}
+
+// DecrementDownloadsByAliasTx decrements downloads left with a single
+// conditional update, so concurrent downloads can't both take the last one
+func (fr *FileRepo) DecrementDownloadsByAliasTx(ctx context.Context, tx tx.Tx, alias string) (int16, error) {
+	const fn = "repository.postgres.FileRepo.DecrementDownloadsByAlias"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return 0, fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	var downloadsLeft int16
+	err := sqlTx.QueryRowContext(ctx, `UPDATE files SET downloads_left = downloads_left - 1 WHERE alias = $1 AND pending = FALSE AND expires_at > NOW() AND downloads_left > 0 RETURNING downloads_left`, alias).
+		Scan(&downloadsLeft)
+
+	if err == nil {
+		return downloadsLeft, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	// nothing was decremented, the file either does not exist or is exhausted
+	err = sqlTx.QueryRowContext(ctx, `SELECT downloads_left FROM files WHERE alias = $1 AND pending = FALSE AND expires_at > NOW()`, alias).
+		Scan(&downloadsLeft)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domainErrors.ErrFileNotFound
+		}
+
+		return 0, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	return 0, domainErrors.ErrNoDownloadsLeft
+}
+
+// AddFailedPasswordAttemptTx counts a wrong password of the file and
+// returns how many of them the file has had
+func (fr *FileRepo) AddFailedPasswordAttemptTx(ctx context.Context, tx tx.Tx, fileID int64) (int, error) {
+	const fn = "repository.postgres.FileRepo.AddFailedPasswordAttempt"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return 0, fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	var attempts int
+	err := sqlTx.QueryRowContext(ctx, `UPDATE files SET failed_password_attempts = failed_password_attempts + 1 WHERE id = $1 RETURNING failed_password_attempts`, fileID).
+		Scan(&attempts)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domainErrors.ErrFileNotFound
+		}
+
+		return 0, fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	return attempts, nil
+}
+
+func (fr *FileRepo) UpdateFileTx(ctx context.Context, tx tx.Tx, command commands.UpdateFileInfo) (int16, error) {
+	const fn = "repository.postgres.FileRepo.UpdateFile"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return 0, fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	var columns []string
+	var args []any
+
+	set := func(column string, value any) {
+		args = append(args, value)
+		columns = append(columns, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if command.ExpiresAt != nil {
+		set("expires_at", *command.ExpiresAt)
+	}
+
+	if command.DownloadsLeft != nil {
+		// slots of unconfirmed reservations are given back when they are
+		// released, so they are taken from the new value right away
+		args = append(args, *command.DownloadsLeft)
+		columns = append(columns, fmt.Sprintf("downloads_left = $%d - (SELECT COUNT(*) FROM download_reservations WHERE file_id = files.id AND confirmed = FALSE)", len(args)))
+	}
+
+	if command.PasswordHash != nil {
+		set("password_hash", *command.PasswordHash)
+	}
+
+	if len(columns) > 0 {
+		args = append(args, command.Alias)
+		_, err := sqlTx.ExecContext(ctx, fmt.Sprintf(`UPDATE files SET %s WHERE alias = $%d AND pending = FALSE AND expires_at > NOW()`, strings.Join(columns, ", "), len(args)), args...)
+		if err != nil {
+			return 0, fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+		}
+	}
+
+	// the file is found by alias alone, an update may have expired it
+	var downloadsLeft int16
+	err := sqlTx.QueryRowContext(ctx, `SELECT downloads_left FROM files WHERE alias = $1 AND pending = FALSE`, command.Alias).
+		Scan(&downloadsLeft)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domainErrors.ErrFileNotFound
+		}
+
+		return 0, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	return downloadsLeft, nil
+}
+
+func (fr *FileRepo) DeleteFileTx(ctx context.Context, tx tx.Tx, alias string) error {
+	const fn = "repository.postgres.FileRepo.DeleteFile"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	// a deduplicated content loses a reference, the worker removes it
+	// once no file refers to it
+	_, err := sqlTx.ExecContext(ctx, `UPDATE blobs SET ref_count = ref_count - 1 WHERE storage_key = (SELECT storage_key FROM files WHERE alias = $1 AND pending = FALSE AND expires_at > NOW())`, alias)
+	if err != nil {
+		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	_, err = sqlTx.ExecContext(ctx, `DELETE FROM file_members WHERE file_id = (SELECT id FROM files WHERE alias = $1 AND pending = FALSE AND expires_at > NOW())`, alias)
+	if err != nil {
+		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	// transfers of the file end with it, its reservations must not stay
+	// behind for a file reusing its id
+	_, err = sqlTx.ExecContext(ctx, `DELETE FROM download_reservations WHERE file_id = (SELECT id FROM files WHERE alias = $1 AND pending = FALSE AND expires_at > NOW())`, alias)
+	if err != nil {
+		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	res, err := sqlTx.ExecContext(ctx, `DELETE FROM files WHERE alias = $1 AND pending = FALSE AND expires_at > NOW()`, alias)
+	if err != nil {
+		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to affect rows: %w", fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return domainErrors.ErrFileNotFound
+	}
+
+	return nil
+}
+
+// DeleteExpiredFilesTx deletes up to limit expired files and exhausted files
+// no download reservation refers to anymore, so the last transfer of a file
+// and its download session can finish before the file is removed. It returns
+// storage keys of contents to remove, deduplicated contents are only released
+func (fr *FileRepo) DeleteExpiredFilesTx(ctx context.Context, tx tx.Tx, limit int) ([]string, error) {
+	const fn = "repository.postgres.FileRepo.DeleteExpiredFiles"
+	log := fr.log.With(slog.String("fn", fn))
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return nil, fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	// a single statement keeps select and delete consistent, locked rows
+	// are left for another worker instead of waiting for them
+	rows, err := sqlTx.QueryContext(ctx, `DELETE FROM files WHERE id IN (SELECT id FROM files WHERE expires_at < NOW() OR (downloads_left <= 0 AND NOT EXISTS (SELECT 1 FROM download_reservations WHERE file_id = files.id)) LIMIT $1 FOR UPDATE SKIP LOCKED) RETURNING id, alias, storage_key, members`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Warn("failed to close rows", sl.Error(err))
+		}
+	}(rows)
+
+	var files []entities.File
+	for rows.Next() {
+		var file entities.File
+		if err := rows.Scan(&file.ID, &file.Alias, &file.StorageKey, &file.Members); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan file: %w", fn, err)
+		}
+
+		files = append(files, file)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var keys []string
+	for _, file := range files {
+		if file.Members > 0 {
+			if err := deleteMembers(ctx, sqlTx, file.ID); err != nil {
+				return nil, fmt.Errorf("%s: %w", fn, err)
+			}
+		}
+
+		if err := deleteReservations(ctx, sqlTx, file.ID); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+
+		if file.StorageKey != "" {
+			isBlob, err := releaseBlob(ctx, sqlTx, file.StorageKey)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", fn, err)
+			}
+
+			if isBlob {
+				continue
+			}
+		}
+
+		keys = append(keys, file.ContentKey())
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"expire-share/internal/config"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often buckets of idle keys are forgotten
+const sweepInterval = time.Minute
+
+// MemoryLimiter keeps a token bucket per key in memory. Every replica
+// counts requests on its own
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	rate      float64
+	burst     float64
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+func NewMemoryLimiter(policy config.RatePolicy) *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets: make(map[string]*bucket),
+		rate:    float64(policy.Requests) / policy.Period.Seconds(),
+		burst:   float64(policy.Burst),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of key. When the bucket is empty it
+// returns how long to wait for the next token
+func (ml *MemoryLimiter) Allow(_ context.Context, key string) (bool, time.Duration, error) {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+
+	now := ml.now()
+	ml.sweep(now)
+
+	b, ok := ml.buckets[key]
+	if !ok {
+		b = &bucket{tokens: ml.burst, updatedAt: now}
+		ml.buckets[key] = b
+	}
+
+	b.tokens = ml.refill(b, now)
+	b.updatedAt = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+
+	wait := time.Duration((1 - b.tokens) / ml.rate * float64(time.Second))
+	return false, wait, nil
+}
+
+func (ml *MemoryLimiter) refill(b *bucket, now time.Time) float64 {
+	return min(ml.burst, b.tokens+now.Sub(b.updatedAt).Seconds()*ml.rate)
+}
+
+// sweep forgets full buckets, they are no different from new ones
+func (ml *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(ml.lastSweep) < sweepInterval {
+		return
+	}
+
+	ml.lastSweep = now
+	for key, b := range ml.buckets {
+		if ml.refill(b, now) >= ml.burst {
+			delete(ml.buckets, key)
+		}
+	}
+}
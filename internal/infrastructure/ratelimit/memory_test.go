@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"expire-share/internal/config"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	newLimiter := func(policy config.RatePolicy) (*MemoryLimiter, *time.Time) {
+		now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		limiter := NewMemoryLimiter(policy)
+		limiter.now = func() time.Time { return now }
+		return limiter, &now
+	}
+
+	t.Run("burst is allowed then requests wait for refill", func(t *testing.T) {
+		limiter, now := newLimiter(config.RatePolicy{Requests: 6, Period: time.Minute, Burst: 3})
+
+		for range 3 {
+			allowed, _, err := limiter.Allow(ctx, "ip:10.0.0.1")
+			require.NoError(t, err)
+			require.True(t, allowed)
+		}
+
+		allowed, retryAfter, err := limiter.Allow(ctx, "ip:10.0.0.1")
+		require.NoError(t, err)
+		require.False(t, allowed)
+		require.InDelta(t, 10*time.Second, retryAfter, float64(time.Millisecond))
+
+		*now = now.Add(4 * time.Second)
+		allowed, retryAfter, err = limiter.Allow(ctx, "ip:10.0.0.1")
+		require.NoError(t, err)
+		require.False(t, allowed)
+		require.InDelta(t, 6*time.Second, retryAfter, float64(time.Millisecond))
+
+		*now = now.Add(6 * time.Second)
+		allowed, _, err = limiter.Allow(ctx, "ip:10.0.0.1")
+		require.NoError(t, err)
+		require.True(t, allowed)
+	})
+
+	t.Run("keys have separate buckets", func(t *testing.T) {
+		limiter, _ := newLimiter(config.RatePolicy{Requests: 1, Period: time.Minute, Burst: 1})
+
+		allowed, _, err := limiter.Allow(ctx, "user:1")
+		require.NoError(t, err)
+		require.True(t, allowed)
+
+		allowed, _, err = limiter.Allow(ctx, "user:1")
+		require.NoError(t, err)
+		require.False(t, allowed)
+
+		allowed, _, err = limiter.Allow(ctx, "user:2")
+		require.NoError(t, err)
+		require.True(t, allowed)
+	})
+
+	t.Run("refill does not exceed burst", func(t *testing.T) {
+		limiter, now := newLimiter(config.RatePolicy{Requests: 60, Period: time.Minute, Burst: 2})
+
+		_, _, _ = limiter.Allow(ctx, "ip:10.0.0.1")
+		*now = now.Add(time.Hour)
+
+		for range 2 {
+			allowed, _, _ := limiter.Allow(ctx, "ip:10.0.0.1")
+			require.True(t, allowed)
+		}
+
+		allowed, _, _ := limiter.Allow(ctx, "ip:10.0.0.1")
+		require.False(t, allowed)
+	})
+
+	t.Run("idle buckets are forgotten", func(t *testing.T) {
+		limiter, now := newLimiter(config.RatePolicy{Requests: 60, Period: time.Minute, Burst: 5})
+
+		_, _, _ = limiter.Allow(ctx, "ip:10.0.0.1")
+		*now = now.Add(2 * sweepInterval)
+		_, _, _ = limiter.Allow(ctx, "ip:10.0.0.2")
+
+		require.Len(t, limiter.buckets, 1)
+		require.Contains(t, limiter.buckets, "ip:10.0.0.2")
+	})
+}
@@ -0,0 +1,178 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"expire-share/internal/config"
+	"expire-share/internal/domain/dto/fetches/results"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"syscall"
+	"time"
+)
+
+const (
+	dialTimeout           = 10 * time.Second
+	responseHeaderTimeout = 30 * time.Second
+	userAgent             = "expire-share"
+)
+
+// blockedPrefixes are special-purpose ranges which are not caught by
+// netip.Addr methods but still don't lead to public servers
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+}
+
+// Client fetches files of remote uploads. Addresses are checked when a
+// connection is dialed, so hostnames resolving to private addresses and
+// redirects to them are refused as well
+type Client struct {
+	client  *http.Client
+	allowed []netip.Prefix
+}
+
+func NewClient(cfg config.RemoteUploads) *Client {
+	c := &Client{allowed: cfg.AllowedPrefixes}
+
+	dialer := &net.Dialer{Timeout: dialTimeout, Control: c.checkAddress}
+
+	// no proxy from environment, the guard would check the proxy address
+	// instead of the address of the remote server
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   dialTimeout,
+		ResponseHeaderTimeout: responseHeaderTimeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+	}
+
+	c.client = &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > cfg.MaxRedirects {
+				return fmt.Errorf("%w: stopped after %d redirects", domainErrors.ErrRemoteFetchFailed, cfg.MaxRedirects)
+			}
+
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("%w: redirect to %s scheme", domainErrors.ErrRemoteURLNotAllowed, req.URL.Scheme)
+			}
+
+			return nil
+		},
+	}
+
+	return c
+}
+
+// Fetch requests the url and returns its body once the server responded
+// with success
+func (c *Client) Fetch(ctx context.Context, rawURL string) (*results.RemoteFile, error) {
+	const fn = "remote.Client.Fetch"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %w", fn, domainErrors.ErrRemoteURLNotAllowed, err)
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+	// the file is stored as it is sent, transparent decompression would
+	// make the announced size wrong
+	req.Header.Set("Accept-Encoding", "identity")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if errors.Is(err, domainErrors.ErrRemoteURLNotAllowed) ||
+			errors.Is(err, domainErrors.ErrRemoteFetchFailed) ||
+			errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+
+		return nil, fmt.Errorf("%s: %w: %w", fn, domainErrors.ErrRemoteFetchFailed, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("%s: %w: unexpected status %d", fn, domainErrors.ErrRemoteFetchFailed, resp.StatusCode)
+	}
+
+	return &results.RemoteFile{
+		Body:     resp.Body,
+		Size:     resp.ContentLength,
+		Filename: filename(resp),
+	}, nil
+}
+
+// checkAddress refuses connections to addresses which are not public
+// unless they are allowed in config
+func (c *Client) checkAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %w", domainErrors.ErrRemoteURLNotAllowed, err)
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: %w", domainErrors.ErrRemoteURLNotAllowed, err)
+	}
+
+	if !c.isAllowed(addr.Unmap()) {
+		return fmt.Errorf("%w: address %s is not public", domainErrors.ErrRemoteURLNotAllowed, addr)
+	}
+
+	return nil
+}
+
+func (c *Client) isAllowed(addr netip.Addr) bool {
+	for _, prefix := range c.allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filename is taken from Content-Disposition, otherwise from the last
+// segment of the url the file was got from after redirects
+func filename(resp *http.Response) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		if name := path.Base(params["filename"]); params["filename"] != "" && name != "/" && name != "." {
+			return name
+		}
+	}
+
+	name, err := url.PathUnescape(path.Base(resp.Request.URL.EscapedPath()))
+	if err != nil {
+		return ""
+	}
+
+	// an escaped slash is not a directory of the file
+	if name = path.Base(name); name == "/" || name == "." {
+		return ""
+	}
+
+	return name
+}
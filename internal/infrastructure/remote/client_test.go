@@ -0,0 +1,112 @@
+package remote
+
+import (
+	"context"
+	"expire-share/internal/config"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClient_Fetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/artifacts/build%20log.txt", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("build ok"))
+	})
+	mux.HandleFunc("/named", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Disposition", `attachment; filename="../report.pdf"`)
+		_, _ = w.Write([]byte("%PDF"))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/redirect", http.StatusFound)
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	allowed := NewClient(config.RemoteUploads{
+		MaxRedirects:    3,
+		AllowedPrefixes: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+	})
+
+	t.Run("filename from url", func(t *testing.T) {
+		file, err := allowed.Fetch(context.Background(), server.URL+"/artifacts/build%20log.txt")
+		require.NoError(t, err)
+		defer func() { _ = file.Body.Close() }()
+
+		content, err := io.ReadAll(file.Body)
+		require.NoError(t, err)
+		require.Equal(t, "build ok", string(content))
+		require.Equal(t, int64(8), file.Size)
+		require.Equal(t, "build log.txt", file.Filename)
+	})
+
+	t.Run("filename from content disposition", func(t *testing.T) {
+		file, err := allowed.Fetch(context.Background(), server.URL+"/named")
+		require.NoError(t, err)
+		defer func() { _ = file.Body.Close() }()
+
+		require.Equal(t, "report.pdf", file.Filename)
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		_, err := allowed.Fetch(context.Background(), server.URL+"/missing")
+		require.ErrorIs(t, err, domainErrors.ErrRemoteFetchFailed)
+	})
+
+	t.Run("too many redirects", func(t *testing.T) {
+		_, err := allowed.Fetch(context.Background(), server.URL+"/redirect")
+		require.ErrorIs(t, err, domainErrors.ErrRemoteFetchFailed)
+	})
+
+	t.Run("loopback is refused unless allowed", func(t *testing.T) {
+		client := NewClient(config.RemoteUploads{MaxRedirects: 3})
+
+		_, err := client.Fetch(context.Background(), server.URL+"/artifacts/build%20log.txt")
+		require.ErrorIs(t, err, domainErrors.ErrRemoteURLNotAllowed)
+	})
+}
+
+func TestClient_isAllowed(t *testing.T) {
+	client := NewClient(config.RemoteUploads{
+		AllowedPrefixes: []netip.Prefix{netip.MustParsePrefix("10.1.0.0/16")},
+	})
+
+	tests := []struct {
+		addr    string
+		allowed bool
+	}{
+		{addr: "93.184.216.34", allowed: true},
+		{addr: "2606:2800:220:1:248:1893:25c8:1946", allowed: true},
+		{addr: "10.1.2.3", allowed: true},
+		{addr: "10.2.0.1", allowed: false},
+		{addr: "127.0.0.1", allowed: false},
+		{addr: "192.168.1.1", allowed: false},
+		{addr: "172.16.0.1", allowed: false},
+		{addr: "169.254.169.254", allowed: false},
+		{addr: "100.64.0.1", allowed: false},
+		{addr: "0.0.0.0", allowed: false},
+		{addr: "::1", allowed: false},
+		{addr: "fd00::1", allowed: false},
+		{addr: "fe80::1", allowed: false},
+		{addr: "64:ff9b::7f00:1", allowed: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.addr, func(t *testing.T) {
+			require.Equal(t, test.allowed, client.isAllowed(netip.MustParseAddr(test.addr)))
+		})
+	}
+}
+
+func TestClient_checkAddress(t *testing.T) {
+	client := NewClient(config.RemoteUploads{})
+
+	require.NoError(t, client.checkAddress("tcp", "93.184.216.34:443", nil))
+	require.ErrorIs(t, client.checkAddress("tcp", "[::ffff:127.0.0.1]:80", nil), domainErrors.ErrRemoteURLNotAllowed)
+}
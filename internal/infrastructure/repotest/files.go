@@ -0,0 +1,1026 @@
+// Package repotest is a conformance test suite every FileRepo backend
+// must pass, so services behave the same on any supported database
+package repotest
+
+import (
+	"context"
+	"database/sql"
+	"expire-share/internal/app/database"
+	"expire-share/internal/config"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/domain/interfaces/repositories"
+	"expire-share/internal/domain/interfaces/tx"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// precision covers databases storing timestamps in whole seconds
+const precision = 2 * time.Second
+
+// OpenDB opens database of the driver, applies migrations and removes
+// rows left by previous tests
+func OpenDB(t *testing.T, driver string, connectionString string) *sql.DB {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	_, file, _, _ := runtime.Caller(0)
+	dir := filepath.Join(filepath.Dir(file), "..", "..", "..", database.MigrationsDir)
+	require.NoError(t, database.Migrate(log, dir, driver, connectionString))
+
+	app, err := database.New(log, driver, connectionString)
+	require.NoError(t, err)
+	require.NoError(t, app.Connect())
+	t.Cleanup(func() { _ = app.Close() })
+
+	for _, table := range []string{"files", "download_reservations", "blobs", "file_members"} {
+		_, err = app.DB.Exec(`DELETE FROM ` + table)
+		require.NoError(t, err)
+	}
+
+	return app.DB
+}
+
+// OpenSQLite opens migrated sqlite database in a temporary file
+func OpenSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+
+	connectionString := filepath.Join(t.TempDir(), "expire-share.db") + "?_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate"
+	return OpenDB(t, config.DatabaseSQLite, connectionString)
+}
+
+// RunFileRepo runs the suite, newRepo must return repository over an empty database
+func RunFileRepo(t *testing.T, newRepo func(t *testing.T) repositories.FileRepo) {
+	ctx := context.Background()
+
+	t.Run("add and get file", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id := addFile(t, repo, commands.AddFile{
+			Filename:     "report.pdf",
+			Alias:        "abc123",
+			Size:         1024,
+			MaxDownloads: 3,
+			PasswordHash: "hash",
+			TTL:          time.Hour,
+			UserID:       1,
+			PasswordKey:  true,
+		})
+
+		file, err := repo.GetFileByAlias(ctx, "abc123")
+		require.NoError(t, err)
+		require.Equal(t, id, file.ID)
+		require.Equal(t, "report.pdf", file.Filename)
+		require.Equal(t, "abc123", file.Alias)
+		require.Equal(t, int64(1024), file.Size)
+		require.Equal(t, int16(3), file.DownloadsLeft)
+		require.Equal(t, "hash", file.PasswordHash)
+		require.True(t, file.PasswordKey)
+		require.Equal(t, int64(1), file.UserID)
+		require.WithinDuration(t, time.Now(), file.LoadedAt, precision)
+		require.WithinDuration(t, file.LoadedAt.Add(time.Hour), file.ExpiresAt, precision)
+	})
+
+	t.Run("duplicate alias", func(t *testing.T) {
+		repo := newRepo(t)
+		addFile(t, repo, newFile("taken", 1, time.Hour))
+
+		tx, err := repo.BeginTx(ctx)
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback() }()
+
+		_, err = repo.AddFileTx(ctx, tx, newFile("taken", 2, time.Hour))
+		require.ErrorIs(t, err, domainErrors.ErrAliasTaken)
+	})
+
+	t.Run("rollback discards file", func(t *testing.T) {
+		repo := newRepo(t)
+
+		tx, err := repo.BeginTx(ctx)
+		require.NoError(t, err)
+
+		_, err = repo.AddFileTx(ctx, tx, newFile("rolled", 1, time.Hour))
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback())
+
+		_, err = repo.GetFileByAlias(ctx, "rolled")
+		require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
+	})
+
+	t.Run("expired file is not found", func(t *testing.T) {
+		repo := newRepo(t)
+		addFile(t, repo, newFile("expired", 1, -time.Minute))
+
+		_, err := repo.GetFileByAlias(ctx, "expired")
+		require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
+	})
+
+	t.Run("pending file is hidden until promoted", func(t *testing.T) {
+		repo := newRepo(t)
+
+		pending := newFile("pending", 1, time.Hour)
+		pending.Pending = true
+		addFile(t, repo, pending)
+
+		_, err := repo.GetFileByAlias(ctx, "pending")
+		require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
+
+		_, err = decrement(t, repo, "pending")
+		require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
+
+		files, err := repo.ListFilesByUserID(ctx, commands.ListUserFiles{
+			UserID: 1,
+			SortBy: entities.FileSortCreated,
+			Status: entities.FileStatusAll,
+			Limit:  10,
+		})
+		require.NoError(t, err)
+		require.Empty(t, files)
+
+		usage, err := repo.GetUsageByUserID(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, 1, usage.FilesCount)
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			return repo.PromoteFileTx(ctx, tx, commands.PromoteFile{
+				Alias:       "pending",
+				TTL:         2 * time.Hour,
+				Size:        11,
+				ContentType: "text/plain; charset=utf-8",
+				SHA256:      "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+			})
+		})
+
+		file, err := repo.GetFileByAlias(ctx, "pending")
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now(), file.LoadedAt, precision)
+		require.WithinDuration(t, time.Now().Add(2*time.Hour), file.ExpiresAt, precision)
+		require.Equal(t, int64(11), file.Size)
+		require.Equal(t, "text/plain; charset=utf-8", file.ContentType)
+		require.Equal(t, "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", file.SHA256)
+
+		tx, err := repo.BeginTx(ctx)
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback() }()
+
+		require.ErrorIs(t, repo.PromoteFileTx(ctx, tx, commands.PromoteFile{Alias: "pending", TTL: time.Hour}), domainErrors.ErrFileNotFound)
+	})
+
+	t.Run("abandoned pending file is not promoted and expires", func(t *testing.T) {
+		repo := newRepo(t)
+
+		abandoned := newFile("abandoned", 1, -time.Minute)
+		abandoned.Pending = true
+		addFile(t, repo, abandoned)
+
+		promoteTx, err := repo.BeginTx(ctx)
+		require.NoError(t, err)
+		require.ErrorIs(t, repo.PromoteFileTx(ctx, promoteTx, commands.PromoteFile{Alias: "abandoned", TTL: time.Hour}), domainErrors.ErrFileNotFound)
+		require.NoError(t, promoteTx.Rollback())
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			aliases, err := repo.DeleteExpiredFilesTx(ctx, tx, 10)
+			require.Equal(t, []string{"abandoned"}, aliases)
+			return err
+		})
+	})
+
+	t.Run("delete pending file", func(t *testing.T) {
+		repo := newRepo(t)
+
+		pending := newFile("pending", 1, time.Hour)
+		pending.Pending = true
+		addFile(t, repo, pending)
+		addFile(t, repo, newFile("active", 1, time.Hour))
+
+		deleteTx, err := repo.BeginTx(ctx)
+		require.NoError(t, err)
+		require.ErrorIs(t, repo.DeleteFileTx(ctx, deleteTx, "pending"), domainErrors.ErrFileNotFound)
+		require.ErrorIs(t, repo.DeletePendingFileTx(ctx, deleteTx, "active"), domainErrors.ErrFileNotFound)
+		require.NoError(t, deleteTx.Rollback())
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			return repo.DeletePendingFileTx(ctx, tx, "pending")
+		})
+
+		addFile(t, repo, newFile("pending", 1, time.Hour))
+	})
+
+	t.Run("usage counts active files of user", func(t *testing.T) {
+		repo := newRepo(t)
+
+		for _, file := range []commands.AddFile{
+			{Alias: "first", Size: 10, UserID: 1, TTL: time.Hour, MaxDownloads: 1},
+			{Alias: "second", Size: 20, UserID: 1, TTL: time.Hour, MaxDownloads: 1},
+			{Alias: "expired", Size: 100, UserID: 1, TTL: -time.Minute, MaxDownloads: 1},
+			{Alias: "foreign", Size: 5, UserID: 2, TTL: time.Hour, MaxDownloads: 1},
+		} {
+			file.Filename = file.Alias + ".txt"
+			addFile(t, repo, file)
+		}
+
+		usage, err := repo.GetUsageByUserID(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, entities.StorageUsage{FilesCount: 2, UsedBytes: 30}, usage)
+
+		usage, err = repo.GetUsageByUserID(ctx, 3)
+		require.NoError(t, err)
+		require.Zero(t, usage)
+	})
+
+	t.Run("usage counts active files of guest ip", func(t *testing.T) {
+		repo := newRepo(t)
+
+		for _, file := range []commands.AddFile{
+			{Alias: "first", Size: 10, GuestIP: "10.0.0.1", TTL: time.Hour, MaxDownloads: 1},
+			{Alias: "second", Size: 20, GuestIP: "10.0.0.1", TTL: time.Hour, MaxDownloads: 1},
+			{Alias: "expired", Size: 100, GuestIP: "10.0.0.1", TTL: -time.Minute, MaxDownloads: 1},
+			{Alias: "other", Size: 5, GuestIP: "10.0.0.2", TTL: time.Hour, MaxDownloads: 1},
+			{Alias: "user", Size: 7, UserID: 1, TTL: time.Hour, MaxDownloads: 1},
+		} {
+			file.Filename = file.Alias + ".txt"
+			addFile(t, repo, file)
+		}
+
+		usage, err := repo.GetUsageByGuestIP(ctx, "10.0.0.1")
+		require.NoError(t, err)
+		require.Equal(t, entities.StorageUsage{FilesCount: 2, UsedBytes: 30}, usage)
+
+		usage, err = repo.GetUsageByGuestIP(ctx, "10.0.0.3")
+		require.NoError(t, err)
+		require.Zero(t, usage)
+	})
+
+	t.Run("guest file keeps management token", func(t *testing.T) {
+		repo := newRepo(t)
+
+		addFile(t, repo, commands.AddFile{
+			Filename:            "guest.txt",
+			Alias:               "guest1",
+			MaxDownloads:        1,
+			TTL:                 time.Hour,
+			GuestIP:             "10.0.0.1",
+			ManagementTokenHash: "token-hash",
+		})
+
+		file, err := repo.GetFileByAlias(ctx, "guest1")
+		require.NoError(t, err)
+		require.Zero(t, file.UserID)
+		require.Equal(t, "10.0.0.1", file.GuestIP)
+		require.Equal(t, "token-hash", file.ManagementTokenHash)
+	})
+
+	t.Run("list files pages with cursor", func(t *testing.T) {
+		repo := newRepo(t)
+
+		var aliases []string
+		for i := range 5 {
+			alias := fmt.Sprintf("file%d", i)
+			addFile(t, repo, newFile(alias, 1, time.Duration(i+1)*time.Hour))
+			aliases = append(aliases, alias)
+		}
+
+		addFile(t, repo, newFile("foreign", 2, time.Hour))
+
+		reversed := make([]string, len(aliases))
+		for i, alias := range aliases {
+			reversed[len(aliases)-1-i] = alias
+		}
+
+		for _, tc := range []struct {
+			sort     entities.FileSort
+			desc     bool
+			expected []string
+		}{
+			{entities.FileSortCreated, false, aliases},
+			{entities.FileSortCreated, true, reversed},
+			{entities.FileSortExpires, false, aliases},
+			{entities.FileSortExpires, true, reversed},
+		} {
+			query := commands.ListUserFiles{
+				UserID: 1,
+				SortBy: tc.sort,
+				Desc:   tc.desc,
+				Status: entities.FileStatusAll,
+				Limit:  2,
+			}
+
+			var listed []string
+			for {
+				files, err := repo.ListFilesByUserID(ctx, query)
+				require.NoError(t, err)
+
+				for _, file := range files {
+					listed = append(listed, file.Alias)
+				}
+
+				if len(files) < query.Limit {
+					break
+				}
+
+				last := files[len(files)-1]
+				query.After = &commands.FileCursor{Value: last.LoadedAt, ID: last.ID}
+				if tc.sort == entities.FileSortExpires {
+					query.After.Value = last.ExpiresAt
+				}
+			}
+
+			require.Equal(t, tc.expected, listed, "sort %s desc %t", tc.sort, tc.desc)
+		}
+	})
+
+	t.Run("list files filters by status", func(t *testing.T) {
+		repo := newRepo(t)
+
+		addFile(t, repo, newFile("active", 1, time.Hour))
+		addFile(t, repo, newFile("expired", 1, -time.Minute))
+
+		exhausted := newFile("exhausted", 1, time.Hour)
+		exhausted.MaxDownloads = 0
+		addFile(t, repo, exhausted)
+
+		for status, expected := range map[entities.FileStatus][]string{
+			entities.FileStatusAll:       {"active", "expired", "exhausted"},
+			entities.FileStatusActive:    {"active"},
+			entities.FileStatusExhausted: {"exhausted"},
+			entities.FileStatusExpired:   {"expired"},
+		} {
+			files, err := repo.ListFilesByUserID(ctx, commands.ListUserFiles{
+				UserID: 1,
+				SortBy: entities.FileSortCreated,
+				Status: status,
+				Limit:  10,
+			})
+			require.NoError(t, err)
+
+			var listed []string
+			for _, file := range files {
+				listed = append(listed, file.Alias)
+			}
+
+			require.Equal(t, expected, listed, "status %s", status)
+		}
+	})
+
+	t.Run("decrement downloads", func(t *testing.T) {
+		repo := newRepo(t)
+
+		file := newFile("limited", 1, time.Hour)
+		file.MaxDownloads = 2
+		addFile(t, repo, file)
+
+		for _, expected := range []int16{1, 0} {
+			left, err := decrement(t, repo, "limited")
+			require.NoError(t, err)
+			require.Equal(t, expected, left)
+		}
+
+		_, err := decrement(t, repo, "limited")
+		require.ErrorIs(t, err, domainErrors.ErrNoDownloadsLeft)
+
+		_, err = decrement(t, repo, "missing")
+		require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
+	})
+
+	t.Run("failed password attempts are counted", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id := addFile(t, repo, newFile("guarded", 1, time.Hour))
+
+		for _, expected := range []int{1, 2} {
+			var attempts int
+			inTx(t, repo, func(tx tx.Tx) error {
+				var err error
+				attempts, err = repo.AddFailedPasswordAttemptTx(ctx, tx, id)
+				return err
+			})
+
+			require.Equal(t, expected, attempts)
+		}
+
+		tx, err := repo.BeginTx(ctx)
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback() }()
+
+		_, err = repo.AddFailedPasswordAttemptTx(ctx, tx, id+1000)
+		require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
+	})
+
+	t.Run("concurrent decrements never oversell", func(t *testing.T) {
+		repo := newRepo(t)
+
+		file := newFile("popular", 1, time.Hour)
+		file.MaxDownloads = 3
+		addFile(t, repo, file)
+
+		const attempts = 20
+		errs := make(chan error, attempts)
+
+		var wg sync.WaitGroup
+		for range attempts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				tx, err := repo.BeginTx(ctx)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				_, err = repo.DecrementDownloadsByAliasTx(ctx, tx, "popular")
+				if err != nil {
+					_ = tx.Rollback()
+					errs <- err
+					return
+				}
+
+				errs <- tx.Commit()
+			}()
+		}
+
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+
+			require.ErrorIs(t, err, domainErrors.ErrNoDownloadsLeft)
+		}
+
+		require.Equal(t, int(file.MaxDownloads), succeeded)
+	})
+
+	t.Run("update file", func(t *testing.T) {
+		repo := newRepo(t)
+		addFile(t, repo, newFile("updated", 1, time.Hour))
+
+		expiresAt := time.Now().Add(3 * time.Hour)
+		downloads := int16(7)
+		passwordHash := "new-hash"
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			stored, err := repo.UpdateFileTx(ctx, tx, commands.UpdateFileInfo{
+				Alias:         "updated",
+				ExpiresAt:     &expiresAt,
+				DownloadsLeft: &downloads,
+				PasswordHash:  &passwordHash,
+			})
+			require.Equal(t, downloads, stored)
+			return err
+		})
+
+		file, err := repo.GetFileByAlias(ctx, "updated")
+		require.NoError(t, err)
+		require.WithinDuration(t, expiresAt, file.ExpiresAt, precision)
+		require.Equal(t, downloads, file.DownloadsLeft)
+		require.Equal(t, passwordHash, file.PasswordHash)
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			stored, err := repo.UpdateFileTx(ctx, tx, commands.UpdateFileInfo{Alias: "updated"})
+			require.Equal(t, downloads, stored)
+			return err
+		})
+
+		tx, err := repo.BeginTx(ctx)
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback() }()
+
+		_, err = repo.UpdateFileTx(ctx, tx, commands.UpdateFileInfo{Alias: "missing"})
+		require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
+	})
+
+	t.Run("update downloads counts unconfirmed reservations", func(t *testing.T) {
+		repo := newRepo(t)
+
+		file := newFile("busy", 1, time.Hour)
+		file.MaxDownloads = 3
+		fileID := addFile(t, repo, file)
+
+		reserve(t, repo, "busy", entities.DownloadReservation{ID: "running", FileID: fileID, ExpiresAt: time.Now().Add(time.Hour)})
+		reserve(t, repo, "busy", entities.DownloadReservation{ID: "done", FileID: fileID, ExpiresAt: time.Now().Add(time.Hour)})
+		inTx(t, repo, func(tx tx.Tx) error {
+			return repo.ConfirmReservationTx(ctx, tx, "done", time.Now().Add(time.Hour))
+		})
+
+		update := func(downloads int16) (stored int16) {
+			inTx(t, repo, func(tx tx.Tx) error {
+				var err error
+				stored, err = repo.UpdateFileTx(ctx, tx, commands.UpdateFileInfo{Alias: "busy", DownloadsLeft: &downloads})
+				return err
+			})
+
+			return stored
+		}
+
+		// the running download holds one of the new slots until released
+		require.Equal(t, int16(4), update(5))
+		requireDownloadsLeft(t, repo, "busy", 4)
+		inTx(t, repo, func(tx tx.Tx) error {
+			return repo.ReleaseReservationTx(ctx, tx, "running")
+		})
+		requireDownloadsLeft(t, repo, "busy", 5)
+
+		reserve(t, repo, "busy", entities.DownloadReservation{ID: "stale", FileID: fileID, ExpiresAt: time.Now().Add(-time.Minute)})
+		update(0)
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			_, err := repo.ReleaseExpiredReservationsTx(ctx, tx, 10)
+			return err
+		})
+		requireDownloadsLeft(t, repo, "busy", 0)
+	})
+
+	t.Run("reserve, confirm and release downloads", func(t *testing.T) {
+		repo := newRepo(t)
+
+		file := newFile("reserved", 1, time.Hour)
+		file.MaxDownloads = 2
+		fileID := addFile(t, repo, file)
+
+		reserve(t, repo, "reserved", entities.DownloadReservation{ID: "released", FileID: fileID, ExpiresAt: time.Now().Add(time.Hour)})
+
+		reservation, err := repo.GetReservation(ctx, "released")
+		require.NoError(t, err)
+		require.Equal(t, fileID, reservation.FileID)
+		require.False(t, reservation.Confirmed)
+		require.WithinDuration(t, time.Now().Add(time.Hour), reservation.ExpiresAt, precision)
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			return repo.ReleaseReservationTx(ctx, tx, "released")
+		})
+
+		requireDownloadsLeft(t, repo, "reserved", 2)
+
+		_, err = repo.GetReservation(ctx, "released")
+		require.ErrorIs(t, err, domainErrors.ErrReservationNotFound)
+		require.ErrorIs(t, release(t, repo, "released"), domainErrors.ErrReservationNotFound)
+
+		reserve(t, repo, "reserved", entities.DownloadReservation{ID: "confirmed", FileID: fileID, ExpiresAt: time.Now().Add(time.Hour)})
+
+		// ranged transfers of the download add up
+		for _, total := range []int64{512, 1024} {
+			inTx(t, repo, func(tx tx.Tx) error {
+				sent, err := repo.AddReservationSentTx(ctx, tx, "confirmed", 512)
+				require.Equal(t, total, sent)
+				return err
+			})
+		}
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			return repo.ConfirmReservationTx(ctx, tx, "confirmed", time.Now().Add(2*time.Hour))
+		})
+
+		reservation, err = repo.GetReservation(ctx, "confirmed")
+		require.NoError(t, err)
+		require.True(t, reservation.Confirmed)
+		require.WithinDuration(t, time.Now().Add(2*time.Hour), reservation.ExpiresAt, precision)
+
+		require.ErrorIs(t, confirm(t, repo, "confirmed"), domainErrors.ErrReservationNotFound)
+		require.ErrorIs(t, release(t, repo, "confirmed"), domainErrors.ErrReservationNotFound)
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			_, err := repo.AddReservationSentTx(ctx, tx, "confirmed", 1)
+			require.ErrorIs(t, err, domainErrors.ErrReservationNotFound)
+			return nil
+		})
+		requireDownloadsLeft(t, repo, "reserved", 1)
+
+		reserve(t, repo, "reserved", entities.DownloadReservation{ID: "late", FileID: fileID, ExpiresAt: time.Now().Add(-time.Minute)})
+		require.ErrorIs(t, confirm(t, repo, "late"), domainErrors.ErrReservationNotFound)
+
+		_, err = repo.GetReservation(ctx, "late")
+		require.ErrorIs(t, err, domainErrors.ErrReservationNotFound)
+
+		_, err = repo.GetReservation(ctx, "missing")
+		require.ErrorIs(t, err, domainErrors.ErrReservationNotFound)
+	})
+
+	t.Run("expired reservations are released", func(t *testing.T) {
+		repo := newRepo(t)
+
+		file := newFile("held", 1, time.Hour)
+		file.MaxDownloads = 3
+		fileID := addFile(t, repo, file)
+
+		reserve(t, repo, "held", entities.DownloadReservation{ID: "stale", FileID: fileID, ExpiresAt: time.Now().Add(-time.Minute)})
+		reserve(t, repo, "held", entities.DownloadReservation{ID: "counted", FileID: fileID, ExpiresAt: time.Now().Add(time.Hour)})
+		reserve(t, repo, "held", entities.DownloadReservation{ID: "active", FileID: fileID, ExpiresAt: time.Now().Add(time.Hour)})
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			return repo.ConfirmReservationTx(ctx, tx, "counted", time.Now().Add(-time.Minute))
+		})
+
+		var released int
+		inTx(t, repo, func(tx tx.Tx) error {
+			var err error
+			released, err = repo.ReleaseExpiredReservationsTx(ctx, tx, 10)
+			return err
+		})
+
+		require.Equal(t, 2, released)
+		requireDownloadsLeft(t, repo, "held", 1)
+
+		reservation, err := repo.GetReservation(ctx, "active")
+		require.NoError(t, err)
+		require.False(t, reservation.Confirmed)
+	})
+
+	t.Run("exhausted file is deleted after its last reservation", func(t *testing.T) {
+		repo := newRepo(t)
+
+		fileID := addFile(t, repo, newFile("exhausted", 1, time.Hour))
+		reserve(t, repo, "exhausted", entities.DownloadReservation{ID: "last", FileID: fileID, ExpiresAt: time.Now().Add(-time.Minute)})
+
+		deleteExpired := func() []string {
+			var aliases []string
+			inTx(t, repo, func(tx tx.Tx) error {
+				var err error
+				aliases, err = repo.DeleteExpiredFilesTx(ctx, tx, 10)
+				return err
+			})
+
+			return aliases
+		}
+
+		require.Empty(t, deleteExpired())
+
+		var released int
+		inTx(t, repo, func(tx tx.Tx) error {
+			var err error
+			released, err = repo.ReleaseExpiredReservationsTx(ctx, tx, 10)
+			return err
+		})
+
+		require.Equal(t, 1, released)
+		requireDownloadsLeft(t, repo, "exhausted", 1)
+		require.Empty(t, deleteExpired())
+
+		reserve(t, repo, "exhausted", entities.DownloadReservation{ID: "counted", FileID: fileID, ExpiresAt: time.Now().Add(time.Hour)})
+		inTx(t, repo, func(tx tx.Tx) error {
+			return repo.ConfirmReservationTx(ctx, tx, "counted", time.Now().Add(-time.Minute))
+		})
+
+		require.Empty(t, deleteExpired())
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			_, err := repo.ReleaseExpiredReservationsTx(ctx, tx, 10)
+			return err
+		})
+
+		require.Equal(t, []string{"exhausted"}, deleteExpired())
+	})
+
+	t.Run("delete file", func(t *testing.T) {
+		repo := newRepo(t)
+		addFile(t, repo, newFile("deleted", 1, time.Hour))
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			return repo.DeleteFileTx(ctx, tx, "deleted")
+		})
+
+		_, err := repo.GetFileByAlias(ctx, "deleted")
+		require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
+
+		tx, err := repo.BeginTx(ctx)
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback() }()
+
+		require.ErrorIs(t, repo.DeleteFileTx(ctx, tx, "deleted"), domainErrors.ErrFileNotFound)
+	})
+
+	t.Run("delete expired files", func(t *testing.T) {
+		repo := newRepo(t)
+
+		for i := range 3 {
+			addFile(t, repo, newFile(fmt.Sprintf("expired%d", i), 1, -time.Minute))
+		}
+
+		addFile(t, repo, newFile("active", 1, time.Hour))
+
+		var deleted []string
+		for _, expected := range []int{2, 1, 0} {
+			inTx(t, repo, func(tx tx.Tx) error {
+				aliases, err := repo.DeleteExpiredFilesTx(ctx, tx, 2)
+				require.Len(t, aliases, expected)
+				deleted = append(deleted, aliases...)
+				return err
+			})
+		}
+
+		require.ElementsMatch(t, []string{"expired0", "expired1", "expired2"}, deleted)
+
+		_, err := repo.GetFileByAlias(ctx, "active")
+		require.NoError(t, err)
+	})
+
+	t.Run("deleted files take their reservations along", func(t *testing.T) {
+		repo := newRepo(t)
+
+		deletedID := addFile(t, repo, newFile("deleted", 1, time.Hour))
+		expiredID := addFile(t, repo, newFile("expired", 1, -time.Minute))
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			for id, fileID := range map[string]int64{"of-deleted": deletedID, "of-expired": expiredID} {
+				err := repo.AddReservationTx(ctx, tx, entities.DownloadReservation{ID: id, FileID: fileID, ExpiresAt: time.Now().Add(time.Hour)})
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			return repo.DeleteFileTx(ctx, tx, "deleted")
+		})
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			_, err := repo.DeleteExpiredFilesTx(ctx, tx, 10)
+			return err
+		})
+
+		for _, id := range []string{"of-deleted", "of-expired"} {
+			_, err := repo.GetReservation(ctx, id)
+			require.ErrorIs(t, err, domainErrors.ErrReservationNotFound)
+		}
+	})
+
+	t.Run("multi-file share keeps its members", func(t *testing.T) {
+		repo := newRepo(t)
+
+		members := []entities.FileMember{
+			{Name: "b.txt", Size: 2, ContentType: "text/plain; charset=utf-8", SHA256: "hash-b", StorageKey: "share/0"},
+			{Name: "a.png", Size: 3, ContentType: "image/png", SHA256: "hash-a", StorageKey: "share/1"},
+		}
+
+		pending := newFile("share", 1, time.Hour)
+		pending.Pending = true
+		addFile(t, repo, pending)
+		addFile(t, repo, newFile("single", 1, time.Hour))
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			return repo.PromoteFileTx(ctx, tx, commands.PromoteFile{Alias: "share", TTL: time.Hour, Size: 5, Members: members})
+		})
+
+		file, err := repo.GetFileByAlias(ctx, "share")
+		require.NoError(t, err)
+		require.Equal(t, 2, file.Members)
+
+		stored, err := repo.GetFileMembers(ctx, file.ID)
+		require.NoError(t, err)
+		require.Equal(t, members, stored)
+
+		single, err := repo.GetFileByAlias(ctx, "single")
+		require.NoError(t, err)
+		require.Zero(t, single.Members)
+
+		stored, err = repo.GetFileMembers(ctx, single.ID)
+		require.NoError(t, err)
+		require.Empty(t, stored)
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			return repo.DeleteFileTx(ctx, tx, "share")
+		})
+
+		stored, err = repo.GetFileMembers(ctx, file.ID)
+		require.NoError(t, err)
+		require.Empty(t, stored)
+	})
+
+	t.Run("expired multi-file share loses its members", func(t *testing.T) {
+		repo := newRepo(t)
+
+		pending := newFile("share", 1, time.Hour)
+		pending.Pending = true
+		id := addFile(t, repo, pending)
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			return repo.PromoteFileTx(ctx, tx, commands.PromoteFile{
+				Alias:   "share",
+				TTL:     -time.Minute,
+				Members: []entities.FileMember{{Name: "a.txt", Size: 1, StorageKey: "share/0"}},
+			})
+		})
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			keys, err := repo.DeleteExpiredFilesTx(ctx, tx, 10)
+			require.Equal(t, []string{"share"}, keys)
+			return err
+		})
+
+		stored, err := repo.GetFileMembers(ctx, id)
+		require.NoError(t, err)
+		require.Empty(t, stored)
+	})
+
+	t.Run("deduplicated content is shared and collected once unreferenced", func(t *testing.T) {
+		repo := newRepo(t)
+
+		require.Equal(t, "key1", addBlobFile(t, repo, "first", "key1", "hash"))
+		require.Equal(t, "key1", addBlobFile(t, repo, "second", "key2", "hash"))
+		require.Equal(t, "key3", addBlobFile(t, repo, "other", "key3", "other-hash"))
+
+		file, err := repo.GetFileByAlias(ctx, "second")
+		require.NoError(t, err)
+		require.Equal(t, "key1", file.StorageKey)
+		require.Equal(t, "key1", file.ContentKey())
+
+		deleteBlobs := func() []string {
+			var keys []string
+			inTx(t, repo, func(tx tx.Tx) error {
+				var err error
+				keys, err = repo.DeleteUnreferencedBlobsTx(ctx, tx, 10)
+				return err
+			})
+
+			return keys
+		}
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			return repo.DeleteFileTx(ctx, tx, "first")
+		})
+		require.Empty(t, deleteBlobs())
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			return repo.DeleteFileTx(ctx, tx, "second")
+		})
+		require.Equal(t, []string{"key1"}, deleteBlobs())
+		require.Empty(t, deleteBlobs())
+
+		require.Equal(t, "key4", addBlobFile(t, repo, "again", "key4", "hash"))
+	})
+
+	t.Run("expired files release deduplicated content", func(t *testing.T) {
+		repo := newRepo(t)
+
+		require.Equal(t, "shared", addBlobFile(t, repo, "kept", "shared", "hash"))
+		require.Equal(t, "shared", addBlobFile(t, repo, "expired", "own", "hash"))
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			expires := time.Now().Add(-time.Minute)
+			_, err := repo.UpdateFileTx(ctx, tx, commands.UpdateFileInfo{Alias: "expired", ExpiresAt: &expires})
+			return err
+		})
+
+		abandoned := newFile("abandoned", 1, -time.Minute)
+		abandoned.Pending = true
+		abandoned.StorageKey = "uploaded"
+		addFile(t, repo, abandoned)
+		addFile(t, repo, newFile("plain", 1, -time.Minute))
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			keys, err := repo.DeleteExpiredFilesTx(ctx, tx, 10)
+			require.ElementsMatch(t, []string{"uploaded", "plain"}, keys)
+			return err
+		})
+
+		inTx(t, repo, func(tx tx.Tx) error {
+			keys, err := repo.DeleteUnreferencedBlobsTx(ctx, tx, 10)
+			require.Empty(t, keys)
+			return err
+		})
+
+		file, err := repo.GetFileByAlias(ctx, "kept")
+		require.NoError(t, err)
+		require.Equal(t, "shared", file.StorageKey)
+	})
+}
+
+// addBlobFile uploads a deduplicated file the way the service does and
+// returns the storage key the file refers to
+func addBlobFile(t *testing.T, repo repositories.FileRepo, alias, key, sha string) string {
+	t.Helper()
+
+	pending := newFile(alias, 1, time.Hour)
+	pending.Pending = true
+	pending.StorageKey = key
+	addFile(t, repo, pending)
+
+	var storageKey string
+	inTx(t, repo, func(tx tx.Tx) error {
+		var err error
+		storageKey, err = repo.AcquireBlobTx(context.Background(), tx, entities.Blob{SHA256: sha, StorageKey: key, Size: 1})
+		if err != nil {
+			return err
+		}
+
+		return repo.PromoteFileTx(context.Background(), tx, commands.PromoteFile{
+			Alias:      alias,
+			TTL:        time.Hour,
+			Size:       1,
+			SHA256:     sha,
+			StorageKey: storageKey,
+		})
+	})
+
+	return storageKey
+}
+
+func newFile(alias string, userID int64, ttl time.Duration) commands.AddFile {
+	return commands.AddFile{
+		Filename:     alias + ".txt",
+		Alias:        alias,
+		Size:         1,
+		MaxDownloads: 1,
+		TTL:          ttl,
+		UserID:       userID,
+	}
+}
+
+func addFile(t *testing.T, repo repositories.FileRepo, command commands.AddFile) int64 {
+	t.Helper()
+
+	var id int64
+	inTx(t, repo, func(tx tx.Tx) error {
+		var err error
+		id, err = repo.AddFileTx(context.Background(), tx, command)
+		return err
+	})
+
+	return id
+}
+
+func decrement(t *testing.T, repo repositories.FileRepo, alias string) (int16, error) {
+	t.Helper()
+
+	tx, err := repo.BeginTx(context.Background())
+	require.NoError(t, err)
+
+	left, err := repo.DecrementDownloadsByAliasTx(context.Background(), tx, alias)
+	if err != nil {
+		require.NoError(t, tx.Rollback())
+		return 0, err
+	}
+
+	require.NoError(t, tx.Commit())
+	return left, nil
+}
+
+func reserve(t *testing.T, repo repositories.FileRepo, alias string, reservation entities.DownloadReservation) {
+	t.Helper()
+
+	inTx(t, repo, func(tx tx.Tx) error {
+		if _, err := repo.DecrementDownloadsByAliasTx(context.Background(), tx, alias); err != nil {
+			return err
+		}
+
+		return repo.AddReservationTx(context.Background(), tx, reservation)
+	})
+}
+
+func confirm(t *testing.T, repo repositories.FileRepo, id string) error {
+	t.Helper()
+
+	tx, err := repo.BeginTx(context.Background())
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+
+	return repo.ConfirmReservationTx(context.Background(), tx, id, time.Now().Add(time.Hour))
+}
+
+func release(t *testing.T, repo repositories.FileRepo, id string) error {
+	t.Helper()
+
+	tx, err := repo.BeginTx(context.Background())
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+
+	return repo.ReleaseReservationTx(context.Background(), tx, id)
+}
+
+func requireDownloadsLeft(t *testing.T, repo repositories.FileRepo, alias string, expected int16) {
+	t.Helper()
+
+	file, err := repo.GetFileByAlias(context.Background(), alias)
+	require.NoError(t, err)
+	require.Equal(t, expected, file.DownloadsLeft)
+}
+
+func inTx(t *testing.T, repo repositories.FileRepo, fn func(tx tx.Tx) error) {
+	t.Helper()
+
+	tx, err := repo.BeginTx(context.Background())
+	require.NoError(t, err)
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tx.Commit())
+}
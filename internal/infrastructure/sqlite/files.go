@@ -0,0 +1,504 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/domain/interfaces/tx"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+var sortColumns = map[entities.FileSort]string{
+	entities.FileSortCreated: "loaded_at",
+	entities.FileSortExpires: "expires_at",
+}
+
+// FileRepo keeps files in sqlite. Timestamps are stored as text, so
+// every time is written and compared in UTC to keep them ordered
+type FileRepo struct {
+	DB  *sql.DB
+	log *slog.Logger
+	now func() time.Time
+}
+
+func NewFileRepo(db *sql.DB, log *slog.Logger) *FileRepo {
+	return &FileRepo{DB: db, log: log, now: func() time.Time {
+		return time.Now().UTC()
+	}}
+}
+
+func (fr *FileRepo) BeginTx(ctx context.Context) (tx.Tx, error) {
+	return fr.DB.BeginTx(ctx, nil)
+}
+
+func (fr *FileRepo) AddFileTx(ctx context.Context, tx tx.Tx, command commands.AddFile) (int64, error) {
+	const fn = "repository.sqlite.FileRepo.AddFile"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return 0, fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	currentTime := fr.now()
+	res, err := sqlTx.ExecContext(ctx, `INSERT INTO files(file_name, alias, size, downloads_left, loaded_at, expires_at, password_hash, user_id, pending, guest_ip, management_token_hash, storage_key, password_key) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		command.Filename,
+		command.Alias,
+		command.Size,
+		command.MaxDownloads,
+		currentTime,
+		currentTime.Add(command.TTL),
+		command.PasswordHash,
+		command.UserID,
+		command.Pending,
+		command.GuestIP,
+		command.ManagementTokenHash,
+		command.StorageKey,
+		command.PasswordKey)
+
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return 0, domainErrors.ErrAliasTaken
+		}
+
+		return 0, fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get last insert id: %w", fn, err)
+	}
+
+	return id, nil
+}
+
+// PromoteFileTx makes pending file available for downloads. The file lives
+// for ttl from now, so time spent uploading it is not taken from its TTL
+func (fr *FileRepo) PromoteFileTx(ctx context.Context, tx tx.Tx, command commands.PromoteFile) error {
+	const fn = "repository.sqlite.FileRepo.PromoteFile"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	currentTime := fr.now()
+	res, err := sqlTx.ExecContext(ctx, `UPDATE files SET pending = FALSE, loaded_at = ?, expires_at = ?, size = ?, content_type = ?, sha256 = ?, storage_key = ?, members = ? WHERE alias = ? AND pending = TRUE AND expires_at > ?`,
+		currentTime, currentTime.Add(command.TTL), command.Size, command.ContentType, command.SHA256, command.StorageKey, len(command.Members), command.Alias, currentTime)
+	if err != nil {
+		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to affect rows: %w", fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return domainErrors.ErrFileNotFound
+	}
+
+	if err := addMembers(ctx, sqlTx, command.Alias, command.Members); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (fr *FileRepo) DeletePendingFileTx(ctx context.Context, tx tx.Tx, alias string) error {
+	const fn = "repository.sqlite.FileRepo.DeletePendingFile"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	res, err := sqlTx.ExecContext(ctx, `DELETE FROM files WHERE alias = ? AND pending = TRUE`, alias)
+	if err != nil {
+		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to affect rows: %w", fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return domainErrors.ErrFileNotFound
+	}
+
+	return nil
+}
+
+func (fr *FileRepo) GetFileByAlias(ctx context.Context, alias string) (*entities.File, error) {
+	const fn = "repository.sqlite.FileRepo.GetFileByAlias"
+
+	var file entities.File
+	err := fr.DB.QueryRowContext(ctx, `SELECT id, file_name, alias, size, content_type, sha256, downloads_left, loaded_at, expires_at, password_hash, user_id, guest_ip, management_token_hash, storage_key, members, password_key FROM files WHERE alias = ? AND pending = FALSE AND expires_at > ?`, alias, fr.now()).Scan(
+		&file.ID,
+		&file.Filename,
+		&file.Alias,
+		&file.Size,
+		&file.ContentType,
+		&file.SHA256,
+		&file.DownloadsLeft,
+		&file.LoadedAt,
+		&file.ExpiresAt,
+		&file.PasswordHash,
+		&file.UserID,
+		&file.GuestIP,
+		&file.ManagementTokenHash,
+		&file.StorageKey,
+		&file.Members,
+		&file.PasswordKey)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domainErrors.ErrFileNotFound
+		}
+
+		return nil, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	return &file, nil
+}
+
+func (fr *FileRepo) GetUsageByUserID(ctx context.Context, userID int64) (entities.StorageUsage, error) {
+	const fn = "repository.sqlite.FileRepo.GetUsageByUserID"
+
+	var usage entities.StorageUsage
+	err := fr.DB.QueryRowContext(ctx, `SELECT count(*), COALESCE(SUM(size), 0) FROM files WHERE user_id = ? AND expires_at > ?`, userID, fr.now()).
+		Scan(&usage.FilesCount, &usage.UsedBytes)
+
+	if err != nil {
+		return entities.StorageUsage{}, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	return usage, nil
+}
+
+func (fr *FileRepo) GetUsageByGuestIP(ctx context.Context, ip string) (entities.StorageUsage, error) {
+	const fn = "repository.sqlite.FileRepo.GetUsageByGuestIP"
+
+	var usage entities.StorageUsage
+	err := fr.DB.QueryRowContext(ctx, `SELECT count(*), COALESCE(SUM(size), 0) FROM files WHERE guest_ip = ? AND user_id = 0 AND expires_at > ?`, ip, fr.now()).
+		Scan(&usage.FilesCount, &usage.UsedBytes)
+
+	if err != nil {
+		return entities.StorageUsage{}, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	return usage, nil
+}
+
+func (fr *FileRepo) ListFilesByUserID(ctx context.Context, query commands.ListUserFiles) ([]entities.File, error) {
+	const fn = "repository.sqlite.FileRepo.ListFilesByUserID"
+	log := fr.log.With(slog.String("fn", fn))
+
+	column, ok := sortColumns[query.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("%s: unknown sort column %s", fn, query.SortBy)
+	}
+
+	sqlQuery := `SELECT id, file_name, alias, size, downloads_left, loaded_at, expires_at, password_hash, user_id FROM files WHERE user_id = ? AND pending = FALSE`
+	args := []any{query.UserID}
+
+	switch query.Status {
+	case entities.FileStatusActive:
+		sqlQuery += ` AND downloads_left > 0 AND expires_at > ?`
+		args = append(args, fr.now())
+	case entities.FileStatusExhausted:
+		sqlQuery += ` AND downloads_left <= 0 AND expires_at > ?`
+		args = append(args, fr.now())
+	case entities.FileStatusExpired:
+		sqlQuery += ` AND expires_at <= ?`
+		args = append(args, fr.now())
+	}
+
+	direction, comparison := "ASC", ">"
+	if query.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if query.After != nil {
+		after := query.After.Value.UTC()
+		sqlQuery += fmt.Sprintf(` AND (%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))`, column, comparison)
+		args = append(args, after, after, query.After.ID)
+	}
+
+	sqlQuery += fmt.Sprintf(` ORDER BY %[1]s %[2]s, id %[2]s LIMIT ?`, column, direction)
+	args = append(args, query.Limit)
+
+	rows, err := fr.DB.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Warn("failed to close rows", sl.Error(err))
+		}
+	}(rows)
+
+	var files []entities.File
+	for rows.Next() {
+		var file entities.File
+		err := rows.Scan(
+			&file.ID,
+			&file.Filename,
+			&file.Alias,
+			&file.Size,
+			&file.DownloadsLeft,
+			&file.LoadedAt,
+			&file.ExpiresAt,
+			&file.PasswordHash,
+			&file.UserID)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan file: %w", fn, err)
+		}
+
+		files = append(files, file)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return files, nil
+}
+
+// DecrementDownloadsByAliasTx decrements downloads left with a single
+// conditional update, so concurrent downloads can't both take the last one
+func (fr *FileRepo) DecrementDownloadsByAliasTx(ctx context.Context, tx tx.Tx, alias string) (int16, error) {
+	const fn = "repository.sqlite.FileRepo.DecrementDownloadsByAlias"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return 0, fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	var downloadsLeft int16
+	err := sqlTx.QueryRowContext(ctx, `UPDATE files SET downloads_left = downloads_left - 1 WHERE alias = ? AND pending = FALSE AND expires_at > ? AND downloads_left > 0 RETURNING downloads_left`, alias, fr.now()).
+		Scan(&downloadsLeft)
+
+	if err == nil {
+		return downloadsLeft, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	// nothing was decremented, the file either does not exist or is exhausted
+	err = sqlTx.QueryRowContext(ctx, `SELECT downloads_left FROM files WHERE alias = ? AND pending = FALSE AND expires_at > ?`, alias, fr.now()).
+		Scan(&downloadsLeft)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domainErrors.ErrFileNotFound
+		}
+
+		return 0, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	return 0, domainErrors.ErrNoDownloadsLeft
+}
+
+// AddFailedPasswordAttemptTx counts a wrong password of the file and
+// returns how many of them the file has had
+func (fr *FileRepo) AddFailedPasswordAttemptTx(ctx context.Context, tx tx.Tx, fileID int64) (int, error) {
+	const fn = "repository.sqlite.FileRepo.AddFailedPasswordAttempt"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return 0, fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	var attempts int
+	err := sqlTx.QueryRowContext(ctx, `UPDATE files SET failed_password_attempts = failed_password_attempts + 1 WHERE id = ? RETURNING failed_password_attempts`, fileID).
+		Scan(&attempts)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domainErrors.ErrFileNotFound
+		}
+
+		return 0, fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	return attempts, nil
+}
+
+func (fr *FileRepo) UpdateFileTx(ctx context.Context, tx tx.Tx, command commands.UpdateFileInfo) (int16, error) {
+	const fn = "repository.sqlite.FileRepo.UpdateFile"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return 0, fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	var columns []string
+	var args []any
+
+	if command.ExpiresAt != nil {
+		columns = append(columns, "expires_at = ?")
+		args = append(args, command.ExpiresAt.UTC())
+	}
+
+	if command.DownloadsLeft != nil {
+		// slots of unconfirmed reservations are given back when they are
+		// released, so they are taken from the new value right away
+		columns = append(columns, "downloads_left = ? - (SELECT COUNT(*) FROM download_reservations WHERE file_id = files.id AND confirmed = FALSE)")
+		args = append(args, *command.DownloadsLeft)
+	}
+
+	if command.PasswordHash != nil {
+		columns = append(columns, "password_hash = ?")
+		args = append(args, *command.PasswordHash)
+	}
+
+	if len(columns) > 0 {
+		args = append(args, command.Alias, fr.now())
+		_, err := sqlTx.ExecContext(ctx, `UPDATE files SET `+strings.Join(columns, ", ")+` WHERE alias = ? AND pending = FALSE AND expires_at > ?`, args...)
+		if err != nil {
+			return 0, fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+		}
+	}
+
+	// the file is found by alias alone, an update may have expired it
+	var downloadsLeft int16
+	err := sqlTx.QueryRowContext(ctx, `SELECT downloads_left FROM files WHERE alias = ? AND pending = FALSE`, command.Alias).
+		Scan(&downloadsLeft)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domainErrors.ErrFileNotFound
+		}
+
+		return 0, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	return downloadsLeft, nil
+}
+
+func (fr *FileRepo) DeleteFileTx(ctx context.Context, tx tx.Tx, alias string) error {
+	const fn = "repository.sqlite.FileRepo.DeleteFile"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	// a deduplicated content loses a reference, the worker removes it
+	// once no file refers to it
+	_, err := sqlTx.ExecContext(ctx, `UPDATE blobs SET ref_count = ref_count - 1 WHERE storage_key = (SELECT storage_key FROM files WHERE alias = ? AND pending = FALSE AND expires_at > ?)`, alias, fr.now())
+	if err != nil {
+		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	_, err = sqlTx.ExecContext(ctx, `DELETE FROM file_members WHERE file_id = (SELECT id FROM files WHERE alias = ? AND pending = FALSE AND expires_at > ?)`, alias, fr.now())
+	if err != nil {
+		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	// transfers of the file end with it, its reservations must not stay
+	// behind for a file reusing its id
+	_, err = sqlTx.ExecContext(ctx, `DELETE FROM download_reservations WHERE file_id = (SELECT id FROM files WHERE alias = ? AND pending = FALSE AND expires_at > ?)`, alias, fr.now())
+	if err != nil {
+		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	res, err := sqlTx.ExecContext(ctx, `DELETE FROM files WHERE alias = ? AND pending = FALSE AND expires_at > ?`, alias, fr.now())
+	if err != nil {
+		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to affect rows: %w", fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return domainErrors.ErrFileNotFound
+	}
+
+	return nil
+}
+
+// DeleteExpiredFilesTx deletes up to limit expired files and exhausted files
+// no download reservation refers to anymore, so the last transfer of a file
+// and its download session can finish before the file is removed. It returns
+// storage keys of contents to remove, deduplicated contents are only released
+func (fr *FileRepo) DeleteExpiredFilesTx(ctx context.Context, tx tx.Tx, limit int) ([]string, error) {
+	const fn = "repository.sqlite.FileRepo.DeleteExpiredFiles"
+	log := fr.log.With(slog.String("fn", fn))
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return nil, fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	// sqlite has no row locks, the write transaction locks the whole database
+	rows, err := sqlTx.QueryContext(ctx, `DELETE FROM files WHERE id IN (SELECT id FROM files WHERE expires_at < ? OR (downloads_left <= 0 AND NOT EXISTS (SELECT 1 FROM download_reservations WHERE file_id = files.id)) LIMIT ?) RETURNING id, alias, storage_key, members`, fr.now(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Warn("failed to close rows", sl.Error(err))
+		}
+	}(rows)
+
+	var files []entities.File
+	for rows.Next() {
+		var file entities.File
+		if err := rows.Scan(&file.ID, &file.Alias, &file.StorageKey, &file.Members); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan file: %w", fn, err)
+		}
+
+		files = append(files, file)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var keys []string
+	for _, file := range files {
+		if file.Members > 0 {
+			if err := deleteMembers(ctx, sqlTx, file.ID); err != nil {
+				return nil, fmt.Errorf("%s: %w", fn, err)
+			}
+		}
+
+		if err := deleteReservations(ctx, sqlTx, file.ID); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+
+		if file.StorageKey != "" {
+			isBlob, err := releaseBlob(ctx, sqlTx, file.StorageKey)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", fn, err)
+			}
+
+			if isBlob {
+				continue
+			}
+		}
+
+		keys = append(keys, file.ContentKey())
+	}
+
+	return keys, nil
+}
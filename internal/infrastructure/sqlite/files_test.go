@@ -0,0 +1,17 @@
+package sqlite
+
+import (
+	"expire-share/internal/domain/interfaces/repositories"
+	"expire-share/internal/infrastructure/repotest"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestFileRepo(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	repotest.RunFileRepo(t, func(t *testing.T) repositories.FileRepo {
+		return NewFileRepo(repotest.OpenSQLite(t), log)
+	})
+}
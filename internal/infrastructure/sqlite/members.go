@@ -0,0 +1,78 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"expire-share/internal/domain/entities"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"log/slog"
+)
+
+// GetFileMembers returns files of a multi-file share in upload order
+func (fr *FileRepo) GetFileMembers(ctx context.Context, fileID int64) ([]entities.FileMember, error) {
+	const fn = "repository.sqlite.FileRepo.GetFileMembers"
+	log := fr.log.With(slog.String("fn", fn))
+
+	rows, err := fr.DB.QueryContext(ctx, `SELECT name, size, content_type, sha256, storage_key FROM file_members WHERE file_id = ? ORDER BY position`, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Warn("failed to close rows", sl.Error(err))
+		}
+	}(rows)
+
+	var members []entities.FileMember
+	for rows.Next() {
+		var member entities.FileMember
+		err := rows.Scan(
+			&member.Name,
+			&member.Size,
+			&member.ContentType,
+			&member.SHA256,
+			&member.StorageKey)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan member: %w", fn, err)
+		}
+
+		members = append(members, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return members, nil
+}
+
+// addMembers stores files of the multi-file share with the given alias
+func addMembers(ctx context.Context, sqlTx *sql.Tx, alias string, members []entities.FileMember) error {
+	for position, member := range members {
+		_, err := sqlTx.ExecContext(ctx, `INSERT INTO file_members(file_id, position, name, size, content_type, sha256, storage_key) SELECT id, ?, ?, ?, ?, ?, ? FROM files WHERE alias = ?`,
+			position,
+			member.Name,
+			member.Size,
+			member.ContentType,
+			member.SHA256,
+			member.StorageKey,
+			alias)
+
+		if err != nil {
+			return fmt.Errorf("failed to exec sql: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func deleteMembers(ctx context.Context, sqlTx *sql.Tx, fileID int64) error {
+	if _, err := sqlTx.ExecContext(ctx, `DELETE FROM file_members WHERE file_id = ?`, fileID); err != nil {
+		return fmt.Errorf("failed to exec sql: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,211 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/domain/interfaces/tx"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+func (fr *FileRepo) GetReservation(ctx context.Context, id string) (*entities.DownloadReservation, error) {
+	const fn = "repository.sqlite.FileRepo.GetReservation"
+
+	var reservation entities.DownloadReservation
+	err := fr.DB.QueryRowContext(ctx, `SELECT id, file_id, confirmed, expires_at FROM download_reservations WHERE id = ? AND expires_at > ?`, id, fr.now()).Scan(
+		&reservation.ID,
+		&reservation.FileID,
+		&reservation.Confirmed,
+		&reservation.ExpiresAt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domainErrors.ErrReservationNotFound
+		}
+
+		return nil, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	return &reservation, nil
+}
+
+func (fr *FileRepo) AddReservationTx(ctx context.Context, tx tx.Tx, reservation entities.DownloadReservation) error {
+	const fn = "repository.sqlite.FileRepo.AddReservation"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	_, err := sqlTx.ExecContext(ctx, `INSERT INTO download_reservations(id, file_id, confirmed, expires_at) VALUES(?, ?, ?, ?)`,
+		reservation.ID,
+		reservation.FileID,
+		reservation.Confirmed,
+		reservation.ExpiresAt.UTC())
+
+	if err != nil {
+		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	return nil
+}
+
+// AddReservationSentTx adds n bytes sent within the download of an
+// unconfirmed reservation and returns how many were sent in total
+func (fr *FileRepo) AddReservationSentTx(ctx context.Context, tx tx.Tx, id string, n int64) (int64, error) {
+	const fn = "repository.sqlite.FileRepo.AddReservationSent"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return 0, fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	var sent int64
+	err := sqlTx.QueryRowContext(ctx, `UPDATE download_reservations SET sent = sent + ? WHERE id = ? AND confirmed = FALSE AND expires_at > ? RETURNING sent`, n, id, fr.now()).Scan(&sent)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domainErrors.ErrReservationNotFound
+		}
+
+		return 0, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	return sent, nil
+}
+
+// ConfirmReservationTx keeps the downloads slot taken and extends the
+// reservation to expiresAt. A released or expired reservation can't be confirmed
+func (fr *FileRepo) ConfirmReservationTx(ctx context.Context, tx tx.Tx, id string, expiresAt time.Time) error {
+	const fn = "repository.sqlite.FileRepo.ConfirmReservation"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	res, err := sqlTx.ExecContext(ctx, `UPDATE download_reservations SET confirmed = TRUE, expires_at = ? WHERE id = ? AND confirmed = FALSE AND expires_at > ?`, expiresAt.UTC(), id, fr.now())
+	if err != nil {
+		return fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to affect rows: %w", fn, err)
+	}
+
+	if rowsAffected == 0 {
+		return domainErrors.ErrReservationNotFound
+	}
+
+	return nil
+}
+
+// ReleaseReservationTx deletes unconfirmed reservation and gives its
+// downloads slot back to the file
+func (fr *FileRepo) ReleaseReservationTx(ctx context.Context, tx tx.Tx, id string) error {
+	const fn = "repository.sqlite.FileRepo.ReleaseReservation"
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	if err := releaseReservation(ctx, sqlTx, id); err != nil {
+		if errors.Is(err, domainErrors.ErrReservationNotFound) {
+			return err
+		}
+
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// ReleaseExpiredReservationsTx removes up to limit expired reservations.
+// Slots of unconfirmed ones, left by transfers that never finished, are
+// given back to their files
+func (fr *FileRepo) ReleaseExpiredReservationsTx(ctx context.Context, tx tx.Tx, limit int) (int, error) {
+	const fn = "repository.sqlite.FileRepo.ReleaseExpiredReservations"
+	log := fr.log.With(slog.String("fn", fn))
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return 0, fmt.Errorf("%s: failed to convert tx to sql", fn)
+	}
+
+	rows, err := sqlTx.QueryContext(ctx, `SELECT id, confirmed FROM download_reservations WHERE expires_at < ? LIMIT ?`, fr.now(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to query sql: %w", fn, err)
+	}
+
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			log.Warn("failed to close rows", sl.Error(err))
+		}
+	}(rows)
+
+	var reservations []entities.DownloadReservation
+	for rows.Next() {
+		var reservation entities.DownloadReservation
+		if err := rows.Scan(&reservation.ID, &reservation.Confirmed); err != nil {
+			return 0, fmt.Errorf("%s: failed to scan reservation: %w", fn, err)
+		}
+
+		reservations = append(reservations, reservation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	for _, reservation := range reservations {
+		if !reservation.Confirmed {
+			if err := releaseReservation(ctx, sqlTx, reservation.ID); err != nil {
+				return 0, fmt.Errorf("%s: %w", fn, err)
+			}
+
+			continue
+		}
+
+		if _, err := sqlTx.ExecContext(ctx, `DELETE FROM download_reservations WHERE id = ?`, reservation.ID); err != nil {
+			return 0, fmt.Errorf("%s: failed to exec sql: %w", fn, err)
+		}
+	}
+
+	return len(reservations), nil
+}
+
+func releaseReservation(ctx context.Context, sqlTx *sql.Tx, id string) error {
+	var fileID int64
+	err := sqlTx.QueryRowContext(ctx, `DELETE FROM download_reservations WHERE id = ? AND confirmed = FALSE RETURNING file_id`, id).
+		Scan(&fileID)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domainErrors.ErrReservationNotFound
+		}
+
+		return fmt.Errorf("failed to exec sql: %w", err)
+	}
+
+	if _, err := sqlTx.ExecContext(ctx, `UPDATE files SET downloads_left = downloads_left + 1 WHERE id = ?`, fileID); err != nil {
+		return fmt.Errorf("failed to exec sql: %w", err)
+	}
+
+	return nil
+}
+
+// deleteReservations removes reservations of a deleted file, an expired
+// file is deleted even while its transfers are still running
+func deleteReservations(ctx context.Context, sqlTx *sql.Tx, fileID int64) error {
+	if _, err := sqlTx.ExecContext(ctx, `DELETE FROM download_reservations WHERE file_id = ?`, fileID); err != nil {
+		return fmt.Errorf("failed to exec sql: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package compressed
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"expire-share/internal/config"
+	"expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/domain/interfaces/storage"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const magic = "ESZ1"
+
+// encodings of stored content, the encoding follows the magic
+const (
+	encodingIdentity byte = iota
+	encodingGzip
+)
+
+// sniffLen is how much content is looked at to decide whether it is
+// compressible. Smaller files are not worth compressing
+const sniffLen = 512
+
+// trailerSize is the size of the original content length written after
+// compressed content
+const trailerSize = 8
+
+var errCorrupted = errors.New("compressed file is corrupted")
+
+// FileStorage compresses files which look compressible before they reach
+// the next storage. Stored content starts with a header telling its
+// encoding, compressed content is followed by its original size, so the
+// size is known without decompressing the file
+type FileStorage struct {
+	next  storage.File
+	level int
+	log   *slog.Logger
+}
+
+func NewFileStorage(next storage.File, cfg config.Compression, log *slog.Logger) *FileStorage {
+	level := cfg.CompressionLevel
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+
+	return &FileStorage{next: next, level: level, log: log}
+}
+
+func (fs *FileStorage) Upload(ctx context.Context, file io.Reader, alias string, filename string) error {
+	const fn = "storage.compressed.FileStorage.Upload"
+
+	content := bufio.NewReaderSize(file, sniffLen)
+	head, err := content.Peek(sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%s: read file failed: %w", fn, err)
+	}
+
+	if len(head) < sniffLen || !isCompressible(head, filename) {
+		header := bytes.NewReader([]byte{magic[0], magic[1], magic[2], magic[3], encodingIdentity})
+		return fs.next.Upload(ctx, io.MultiReader(header, content), alias, filename)
+	}
+
+	reader, err := newCompressReader(content, fs.level)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return fs.next.Upload(ctx, reader, alias, filename)
+}
+
+func (fs *FileStorage) Download(ctx context.Context, alias string) (*results.DownloadFile, error) {
+	const fn = "storage.compressed.FileStorage.Download"
+	log := fs.log.With(slog.String("fn", fn))
+
+	result, err := fs.next.Download(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			if err := result.Close(); err != nil {
+				log.Error("failed to close file", sl.Error(err))
+			}
+		}
+	}()
+
+	header := make([]byte, len(magic)+1)
+	n, err := io.ReadFull(result.File, header)
+	if n < len(header) || !bytes.Equal(header[:len(magic)], []byte(magic)) {
+		// files stored before compression was enabled are served as they are
+		if _, err := result.File.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("%s: seek plain file failed: %w", fn, err)
+		}
+
+		success = true
+		return result, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: read header failed: %w", fn, err)
+	}
+
+	start := int64(len(header))
+	storedSize := result.FileInfo.Size()
+
+	switch header[len(magic)] {
+	case encodingIdentity:
+		result.File = newSectionReader(result.File, start, storedSize-start)
+		result.FileInfo = fileInfo{FileInfo: result.FileInfo, size: storedSize - start}
+
+	case encodingGzip:
+		encodedSize := storedSize - start - trailerSize
+		if encodedSize < 0 {
+			return nil, fmt.Errorf("%s: %w: unexpected size %d", fn, errCorrupted, storedSize)
+		}
+
+		size, err := readSize(result.File, storedSize-trailerSize)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+
+		result.Encoded = newSectionReader(result.File, start, encodedSize)
+		result.EncodedSize = encodedSize
+		result.ContentEncoding = "gzip"
+		result.File = newDecompressReader(result.File, start, size)
+		result.FileInfo = fileInfo{FileInfo: result.FileInfo, size: size}
+
+	default:
+		return nil, fmt.Errorf("%s: %w: unknown encoding %d", fn, errCorrupted, header[len(magic)])
+	}
+
+	success = true
+	return result, nil
+}
+
+func (fs *FileStorage) Delete(ctx context.Context, alias string) error {
+	return fs.next.Delete(ctx, alias)
+}
+
+// isCompressible tells whether the content looks like text or another
+// format which compresses well. Content sniffed as binary is judged by
+// the extension
+func isCompressible(head []byte, filename string) bool {
+	contentType := http.DetectContentType(head)
+	if strings.HasPrefix(contentType, "text/") {
+		return true
+	}
+
+	if strings.HasPrefix(contentType, "application/octet-stream") {
+		contentType = mime.TypeByExtension(filepath.Ext(filename))
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "+xml") || strings.HasSuffix(mediaType, "+json") {
+		return true
+	}
+
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript", "application/x-ndjson",
+		"application/yaml", "application/x-yaml", "application/sql", "application/x-tar",
+		"application/wasm", "image/bmp":
+		return true
+	}
+
+	return false
+}
+
+func readSize(file io.ReadSeeker, offset int64) (int64, error) {
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("seek size failed: %w", err)
+	}
+
+	trailer := make([]byte, trailerSize)
+	if _, err := io.ReadFull(file, trailer); err != nil {
+		return 0, fmt.Errorf("%w: read size failed: %w", errCorrupted, err)
+	}
+
+	size := int64(binary.BigEndian.Uint64(trailer))
+	if size < 0 {
+		return 0, fmt.Errorf("%w: unexpected size %d", errCorrupted, size)
+	}
+
+	return size, nil
+}
+
+// fileInfo reports the size of content as it was uploaded
+type fileInfo struct {
+	os.FileInfo
+	size int64
+}
+
+func (fi fileInfo) Size() int64 { return fi.size }
@@ -0,0 +1,216 @@
+package compressed
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"expire-share/internal/config"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/infrastructure/storage/encrypted"
+	"expire-share/internal/infrastructure/storage/local"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileStorage(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	newStorage := func(path string) *FileStorage {
+		return NewFileStorage(local.NewFileStorage(config.Storage{Path: path}, log), config.Compression{}, log)
+	}
+
+	storedSize := func(t *testing.T, path, alias, filename string) int64 {
+		info, err := os.Stat(filepath.Join(path, alias, filename))
+		require.NoError(t, err)
+		return info.Size()
+	}
+
+	csv := []byte(strings.Repeat("2026-10-18,info,request served,200\n", 1000))
+
+	t.Run("text is compressed", func(t *testing.T) {
+		path := t.TempDir()
+		fs := newStorage(path)
+
+		require.NoError(t, fs.Upload(context.Background(), bytes.NewReader(csv), "abc", "log.csv"))
+		require.Less(t, storedSize(t, path, "abc", "log.csv"), int64(len(csv)/10))
+
+		result, err := fs.Download(context.Background(), "abc")
+		require.NoError(t, err)
+		defer func() { _ = result.Close() }()
+
+		require.Equal(t, "gzip", result.ContentEncoding)
+		require.Equal(t, int64(len(csv)), result.FileInfo.Size())
+
+		content, err := io.ReadAll(result.File)
+		require.NoError(t, err)
+		require.Equal(t, csv, content)
+	})
+
+	t.Run("encoded content is valid gzip", func(t *testing.T) {
+		fs := newStorage(t.TempDir())
+		require.NoError(t, fs.Upload(context.Background(), bytes.NewReader(csv), "abc", "log.csv"))
+
+		result, err := fs.Download(context.Background(), "abc")
+		require.NoError(t, err)
+		defer func() { _ = result.Close() }()
+
+		size, err := result.Encoded.Seek(0, io.SeekEnd)
+		require.NoError(t, err)
+		require.Equal(t, result.EncodedSize, size)
+
+		_, err = result.Encoded.Seek(0, io.SeekStart)
+		require.NoError(t, err)
+
+		zr, err := gzip.NewReader(result.Encoded)
+		require.NoError(t, err)
+
+		content, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		require.Equal(t, csv, content)
+	})
+
+	t.Run("decompressed content is seekable", func(t *testing.T) {
+		fs := newStorage(t.TempDir())
+		require.NoError(t, fs.Upload(context.Background(), bytes.NewReader(csv), "abc", "log.csv"))
+
+		result, err := fs.Download(context.Background(), "abc")
+		require.NoError(t, err)
+		defer func() { _ = result.Close() }()
+
+		size, err := result.File.Seek(0, io.SeekEnd)
+		require.NoError(t, err)
+		require.Equal(t, int64(len(csv)), size)
+
+		for _, offset := range []int64{20000, 100, 0, 34999} {
+			_, err = result.File.Seek(offset, io.SeekStart)
+			require.NoError(t, err)
+
+			part := make([]byte, 1)
+			_, err = io.ReadFull(result.File, part)
+			require.NoError(t, err)
+			require.Equal(t, csv[offset], part[0])
+		}
+	})
+
+	t.Run("binary and small files are not compressed", func(t *testing.T) {
+		random := make([]byte, 4096)
+		_, _ = rand.Read(random)
+
+		files := map[string][]byte{
+			"random.bin": random,
+			"small.txt":  []byte("hello world"),
+			"empty.txt":  {},
+		}
+
+		for filename, content := range files {
+			path := t.TempDir()
+			fs := newStorage(path)
+
+			require.NoError(t, fs.Upload(context.Background(), bytes.NewReader(content), "abc", filename))
+			require.Equal(t, int64(len(content)+len(magic)+1), storedSize(t, path, "abc", filename))
+
+			result, err := fs.Download(context.Background(), "abc")
+			require.NoError(t, err)
+
+			require.Empty(t, result.ContentEncoding)
+			require.Equal(t, int64(len(content)), result.FileInfo.Size())
+
+			downloaded, err := io.ReadAll(result.File)
+			require.NoError(t, err)
+			require.Equal(t, content, downloaded)
+			require.NoError(t, result.Close())
+		}
+	})
+
+	t.Run("files stored before compression are served as they are", func(t *testing.T) {
+		path := t.TempDir()
+		plain := local.NewFileStorage(config.Storage{Path: path}, log)
+		require.NoError(t, plain.Upload(context.Background(), bytes.NewReader(csv), "abc", "log.csv"))
+
+		result, err := newStorage(path).Download(context.Background(), "abc")
+		require.NoError(t, err)
+		defer func() { _ = result.Close() }()
+
+		content, err := io.ReadAll(result.File)
+		require.NoError(t, err)
+		require.Equal(t, csv, content)
+		require.Empty(t, result.ContentEncoding)
+	})
+
+	t.Run("truncated file is refused", func(t *testing.T) {
+		path := t.TempDir()
+		fs := newStorage(path)
+		require.NoError(t, fs.Upload(context.Background(), bytes.NewReader(csv), "abc", "log.csv"))
+
+		// the size trailer is kept while compressed content is cut
+		dataPath := filepath.Join(path, "abc", "log.csv")
+		stored, err := os.ReadFile(dataPath)
+		require.NoError(t, err)
+
+		cut := append(stored[:len(stored)/2], stored[len(stored)-trailerSize:]...)
+		require.NoError(t, os.WriteFile(dataPath, cut, 0644))
+
+		result, err := fs.Download(context.Background(), "abc")
+		require.NoError(t, err)
+		defer func() { _ = result.Close() }()
+
+		_, err = io.ReadAll(result.File)
+		require.Error(t, err)
+	})
+
+	t.Run("compressed content is encrypted", func(t *testing.T) {
+		path := t.TempDir()
+		key := make([]byte, 32)
+		_, _ = rand.Read(key)
+
+		next := encrypted.NewFileStorage(local.NewFileStorage(config.Storage{Path: path}, log), config.Encryption{
+			EncryptionKeyID: "key",
+			MasterKeys:      map[string][]byte{"key": key},
+		}, log)
+
+		fs := NewFileStorage(next, config.Compression{}, log)
+		require.NoError(t, fs.Upload(context.Background(), bytes.NewReader(csv), "abc", "log.csv"))
+		require.Less(t, storedSize(t, path, "abc", "data.enc"), int64(len(csv)/10))
+
+		result, err := fs.Download(context.Background(), "abc")
+		require.NoError(t, err)
+		defer func() { _ = result.Close() }()
+
+		content, err := io.ReadAll(result.File)
+		require.NoError(t, err)
+		require.Equal(t, csv, content)
+	})
+
+	t.Run("download not existing file", func(t *testing.T) {
+		_, err := newStorage(t.TempDir()).Download(context.Background(), "missing")
+		require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
+	})
+}
+
+func Test_isCompressible(t *testing.T) {
+	tests := []struct {
+		name     string
+		head     []byte
+		filename string
+		want     bool
+	}{
+		{name: "plain text", head: []byte("some log line\n"), filename: "app.log", want: true},
+		{name: "json", head: []byte(`{"key": "value"}`), filename: "data", want: true},
+		{name: "png", head: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), filename: "image.png", want: false},
+		{name: "zip", head: []byte("PK\x03\x04\x14\x00"), filename: "archive.zip", want: false},
+		{name: "binary with text extension", head: []byte{0x00, 0x01, 0x02, 0xff}, filename: "dump.xml", want: true},
+		{name: "binary", head: []byte{0x00, 0x01, 0x02, 0xff}, filename: "dump.bin", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.want, isCompressible(test.head, test.filename))
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package compressed
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// compressReader reads the header followed by gzipped src and its size.
+// Content is compressed as it is read, so nothing is buffered beyond a
+// single read of src
+type compressReader struct {
+	src  io.Reader
+	zw   *gzip.Writer
+	out  bytes.Buffer
+	buf  []byte
+	size int64
+	done bool
+}
+
+func newCompressReader(src io.Reader, level int) (*compressReader, error) {
+	cr := &compressReader{src: src, buf: make([]byte, 32*1024)}
+	cr.out.WriteString(magic)
+	cr.out.WriteByte(encodingGzip)
+
+	zw, err := gzip.NewWriterLevel(&cr.out, level)
+	if err != nil {
+		return nil, fmt.Errorf("create gzip writer failed: %w", err)
+	}
+
+	cr.zw = zw
+	return cr, nil
+}
+
+func (cr *compressReader) Read(p []byte) (int, error) {
+	for cr.out.Len() == 0 {
+		if cr.done {
+			return 0, io.EOF
+		}
+
+		if err := cr.fill(); err != nil {
+			return 0, err
+		}
+	}
+
+	return cr.out.Read(p)
+}
+
+func (cr *compressReader) fill() error {
+	n, err := cr.src.Read(cr.buf)
+	if n > 0 {
+		cr.size += int64(n)
+		if _, err := cr.zw.Write(cr.buf[:n]); err != nil {
+			return fmt.Errorf("compress failed: %w", err)
+		}
+	}
+
+	if errors.Is(err, io.EOF) {
+		if err := cr.zw.Close(); err != nil {
+			return fmt.Errorf("compress failed: %w", err)
+		}
+
+		cr.out.Write(binary.BigEndian.AppendUint64(nil, uint64(cr.size)))
+		cr.done = true
+		return nil
+	}
+
+	return err
+}
+
+// decompressReader is a seekable view of decompressed content. Seeking
+// forward skips decompressed bytes, seeking back starts decompressing
+// from the beginning, so ranges far into big files are slow
+type decompressReader struct {
+	src    io.ReadSeeker
+	start  int64
+	size   int64
+	offset int64
+	pos    int64
+	zr     *gzip.Reader
+}
+
+func newDecompressReader(src io.ReadSeeker, start, size int64) *decompressReader {
+	return &decompressReader{src: src, start: start, size: size}
+}
+
+func (dr *decompressReader) Read(p []byte) (int, error) {
+	if dr.offset >= dr.size {
+		return 0, io.EOF
+	}
+
+	if dr.zr == nil || dr.pos > dr.offset {
+		if err := dr.restart(); err != nil {
+			return 0, err
+		}
+	}
+
+	if dr.pos < dr.offset {
+		skipped, err := io.CopyN(io.Discard, dr.zr, dr.offset-dr.pos)
+		dr.pos += skipped
+		if err != nil {
+			return 0, dr.unexpected(err)
+		}
+	}
+
+	n, err := dr.zr.Read(p[:min(int64(len(p)), dr.size-dr.offset)])
+	dr.pos += int64(n)
+	dr.offset = dr.pos
+
+	if err != nil {
+		return n, dr.unexpected(err)
+	}
+
+	return n, nil
+}
+
+func (dr *decompressReader) restart() error {
+	if _, err := dr.src.Seek(dr.start, io.SeekStart); err != nil {
+		return fmt.Errorf("seek compressed content failed: %w", err)
+	}
+
+	var err error
+	if dr.zr == nil {
+		dr.zr, err = gzip.NewReader(dr.src)
+	} else {
+		err = dr.zr.Reset(dr.src)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", errCorrupted, err)
+	}
+
+	// the original size follows compressed content
+	dr.zr.Multistream(false)
+	dr.pos = 0
+	return nil
+}
+
+// unexpected turns the end of compressed content before the original size
+// into an error
+func (dr *decompressReader) unexpected(err error) error {
+	if errors.Is(err, io.EOF) {
+		if dr.pos < dr.size {
+			return io.ErrUnexpectedEOF
+		}
+
+		return io.EOF
+	}
+
+	return fmt.Errorf("%w: %w", errCorrupted, err)
+}
+
+func (dr *decompressReader) Seek(offset int64, whence int) (int64, error) {
+	abs, err := seekOffset(dr.offset, dr.size, offset, whence)
+	if err != nil {
+		return 0, err
+	}
+
+	dr.offset = abs
+	return abs, nil
+}
+
+// sectionReader is a seekable view of size bytes of src from start
+type sectionReader struct {
+	src    io.ReadSeeker
+	start  int64
+	size   int64
+	offset int64
+}
+
+func newSectionReader(src io.ReadSeeker, start, size int64) *sectionReader {
+	return &sectionReader{src: src, start: start, size: size}
+}
+
+func (sr *sectionReader) Read(p []byte) (int, error) {
+	if sr.offset >= sr.size {
+		return 0, io.EOF
+	}
+
+	if _, err := sr.src.Seek(sr.start+sr.offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	n, err := sr.src.Read(p[:min(int64(len(p)), sr.size-sr.offset)])
+	sr.offset += int64(n)
+
+	if errors.Is(err, io.EOF) && sr.offset < sr.size {
+		return n, io.ErrUnexpectedEOF
+	}
+
+	return n, err
+}
+
+func (sr *sectionReader) Seek(offset int64, whence int) (int64, error) {
+	abs, err := seekOffset(sr.offset, sr.size, offset, whence)
+	if err != nil {
+		return 0, err
+	}
+
+	sr.offset = abs
+	return abs, nil
+}
+
+func seekOffset(current, size, offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = current + offset
+	case io.SeekEnd:
+		abs = size + offset
+	default:
+		return 0, errors.New("compressed: invalid whence")
+	}
+
+	if abs < 0 {
+		return 0, errors.New("compressed: negative position")
+	}
+
+	return abs, nil
+}
@@ -0,0 +1,277 @@
+package encrypted
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"expire-share/internal/config"
+	"expire-share/internal/domain/dto/files/results"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/domain/interfaces/storage"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	magic = "ESE1"
+	// dataName replaces the original filename in storage, the original
+	// one is kept only in the database
+	dataName = "data.enc"
+	keySize  = 32
+	saltSize = 16
+)
+
+// key modes tell what wraps the file key
+const (
+	modeMasterKey byte = iota
+	modePassword
+)
+
+var (
+	errUnknownKey = errors.New("unknown master key")
+	errCorrupted  = errors.New("encrypted file is corrupted")
+	errPlain      = errors.New("file is not encrypted")
+)
+
+// FileStorage encrypts files before they reach the next storage. Every
+// file gets a random key which encrypts its content in chunks with
+// AES-GCM. The file key is wrapped by a master key and kept in the header
+// of the file together with the master key id, so master keys can be
+// rotated while older files stay readable. With password keys the file
+// key of a password-protected file is also wrapped by a key derived from
+// its password, so the master key alone can't decrypt it
+type FileStorage struct {
+	next         storage.File
+	keys         map[string][]byte
+	keyID        string
+	passwordKeys bool
+	log          *slog.Logger
+}
+
+func NewFileStorage(next storage.File, cfg config.Encryption, log *slog.Logger) *FileStorage {
+	return &FileStorage{
+		next:         next,
+		keys:         cfg.MasterKeys,
+		keyID:        cfg.EncryptionKeyID,
+		passwordKeys: cfg.PasswordKeys,
+		log:          log,
+	}
+}
+
+func (fs *FileStorage) Upload(ctx context.Context, file io.Reader, alias string, _ string) error {
+	const fn = "storage.encrypted.FileStorage.Upload"
+
+	mode := modeMasterKey
+	password := storage.PasswordFromContext(ctx)
+	if fs.passwordKeys && password != "" {
+		mode = modePassword
+	}
+
+	fileKey := make([]byte, keySize)
+	salt := make([]byte, saltSize)
+	_, _ = rand.Read(fileKey)
+	_, _ = rand.Read(salt)
+
+	wrappingKey, err := fs.wrappingKey(fs.keyID, mode, salt, password)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	header, err := sealHeader(mode, fs.keyID, salt, wrappingKey, fileKey)
+	if err != nil {
+		return fmt.Errorf("%s: seal header failed: %w", fn, err)
+	}
+
+	aead, err := newGCM(fileKey)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return fs.next.Upload(ctx, newEncryptReader(file, aead, header), alias, dataName)
+}
+
+func (fs *FileStorage) Download(ctx context.Context, alias string) (*results.DownloadFile, error) {
+	const fn = "storage.encrypted.FileStorage.Download"
+	log := fs.log.With(slog.String("fn", fn))
+
+	result, err := fs.next.Download(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			if err := result.Close(); err != nil {
+				log.Error("failed to close file", sl.Error(err))
+			}
+		}
+	}()
+
+	header, fileKey, err := fs.openHeader(ctx, result.File)
+	if errors.Is(err, errPlain) {
+		// files stored before encryption was enabled are served as they are
+		if _, err := result.File.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("%s: seek plain file failed: %w", fn, err)
+		}
+
+		success = true
+		return result, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	aead, err := newGCM(fileKey)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	reader, err := newDecryptReader(result.File, aead, header, result.FileInfo.Size())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	success = true
+	return &results.DownloadFile{
+		File:     reader,
+		FileInfo: fileInfo{FileInfo: result.FileInfo, size: reader.size},
+		ETag:     result.ETag,
+		Close:    result.Close,
+	}, nil
+}
+
+func (fs *FileStorage) Delete(ctx context.Context, alias string) error {
+	return fs.next.Delete(ctx, alias)
+}
+
+// wrappingKey returns the key which wraps the file key. Password keys mix
+// the master key with the password, so both are needed to read the file
+func (fs *FileStorage) wrappingKey(keyID string, mode byte, salt []byte, password string) ([]byte, error) {
+	masterKey, ok := fs.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errUnknownKey, keyID)
+	}
+
+	switch mode {
+	case modeMasterKey:
+		return masterKey, nil
+
+	case modePassword:
+		if password == "" {
+			return nil, domainErrors.ErrFilePasswordRequired
+		}
+
+		passwordKey := argon2.IDKey([]byte(password), salt, 2, 19*1024, 1, keySize)
+		mac := hmac.New(sha256.New, masterKey)
+		mac.Write(passwordKey)
+		return mac.Sum(nil), nil
+
+	default:
+		return nil, fmt.Errorf("%w: unknown key mode %d", errCorrupted, mode)
+	}
+}
+
+// openHeader reads the header from the start of the file and unwraps the
+// file key. The header is returned as it is authenticated with every chunk
+func (fs *FileStorage) openHeader(ctx context.Context, file io.Reader) ([]byte, []byte, error) {
+	// magic, key mode and length of the key id
+	prefix := make([]byte, len(magic)+2)
+	n, err := io.ReadFull(file, prefix)
+	if n < len(magic) || !bytes.Equal(prefix[:len(magic)], []byte(magic)) {
+		return nil, nil, errPlain
+	}
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: read header failed: %w", errCorrupted, err)
+	}
+
+	rest := make([]byte, int(prefix[len(magic)+1])+saltSize+wrappedKeySize)
+	if _, err := io.ReadFull(file, rest); err != nil {
+		return nil, nil, fmt.Errorf("%w: read header failed: %w", errCorrupted, err)
+	}
+
+	header := append(prefix, rest...)
+	mode, keyID, salt, wrapped := parseHeader(header)
+
+	wrappingKey, err := fs.wrappingKey(keyID, mode, salt, storage.PasswordFromContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aead, err := newGCM(wrappingKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce, sealed := wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():]
+	fileKey, err := aead.Open(nil, nonce, sealed, header[:len(header)-wrappedKeySize])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: unwrap file key failed", errCorrupted)
+	}
+
+	return header, fileKey, nil
+}
+
+// wrappedKeySize is the size of a nonce and a sealed file key
+const wrappedKeySize = 12 + keySize + 16
+
+// sealHeader builds the header:
+// magic | key mode | key id length | key id | salt | nonce | sealed file key.
+// Everything before the sealed file key is authenticated by the wrapping
+func sealHeader(mode byte, keyID string, salt, wrappingKey, fileKey []byte) ([]byte, error) {
+	aead, err := newGCM(wrappingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, _ = rand.Read(nonce)
+
+	header := make([]byte, 0, len(magic)+2+len(keyID)+saltSize+wrappedKeySize)
+	header = append(header, magic...)
+	header = append(header, mode, byte(len(keyID)))
+	header = append(header, keyID...)
+	header = append(header, salt...)
+
+	authenticated := header
+	header = append(header, nonce...)
+	return aead.Seal(header, nonce, fileKey, authenticated), nil
+}
+
+func parseHeader(header []byte) (mode byte, keyID string, salt, wrapped []byte) {
+	mode = header[len(magic)]
+	idEnd := len(magic) + 2 + int(header[len(magic)+1])
+	keyID = string(header[len(magic)+2 : idEnd])
+	salt = header[idEnd : idEnd+saltSize]
+	wrapped = header[idEnd+saltSize:]
+	return mode, keyID, salt, wrapped
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher failed: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// fileInfo reports the size of decrypted content
+type fileInfo struct {
+	os.FileInfo
+	size int64
+}
+
+func (fi fileInfo) Size() int64 { return fi.size }
@@ -0,0 +1,201 @@
+package encrypted
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"expire-share/internal/config"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/domain/interfaces/storage"
+	"expire-share/internal/infrastructure/storage/local"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStorage(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	oldKey, newKey := make([]byte, keySize), make([]byte, keySize)
+	_, _ = rand.Read(oldKey)
+	_, _ = rand.Read(newKey)
+
+	newStorage := func(path, keyID string, passwordKeys bool) *FileStorage {
+		next := local.NewFileStorage(config.Storage{Path: path}, log)
+		return NewFileStorage(next, config.Encryption{
+			EncryptionEnabled: true,
+			EncryptionKeyID:   keyID,
+			PasswordKeys:      passwordKeys,
+			MasterKeys:        map[string][]byte{"old": oldKey, "new": newKey},
+		}, log)
+	}
+
+	download := func(t *testing.T, fs *FileStorage, ctx context.Context, alias string) []byte {
+		result, err := fs.Download(ctx, alias)
+		require.NoError(t, err)
+		defer func() { _ = result.Close() }()
+
+		content, err := io.ReadAll(result.File)
+		require.NoError(t, err)
+		require.Equal(t, int64(len(content)), result.FileInfo.Size())
+		return content
+	}
+
+	sizes := []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3*chunkSize + 17}
+	for _, size := range sizes {
+		t.Run(fmt.Sprintf("upload and download of %d bytes", size), func(t *testing.T) {
+			fs := newStorage(t.TempDir(), "new", false)
+
+			content := make([]byte, size)
+			_, _ = rand.Read(content)
+
+			require.NoError(t, fs.Upload(context.Background(), bytes.NewReader(content), "abc", "file.bin"))
+			require.Equal(t, content, download(t, fs, context.Background(), "abc"))
+		})
+	}
+
+	t.Run("content and filename are not stored in plain", func(t *testing.T) {
+		path := t.TempDir()
+		fs := newStorage(path, "new", false)
+
+		content := bytes.Repeat([]byte("secret "), 100)
+		require.NoError(t, fs.Upload(context.Background(), bytes.NewReader(content), "abc", "report.txt"))
+
+		entries, err := os.ReadDir(filepath.Join(path, "abc"))
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, dataName, entries[0].Name())
+
+		stored, err := os.ReadFile(filepath.Join(path, "abc", dataName))
+		require.NoError(t, err)
+		require.NotContains(t, string(stored), "secret")
+	})
+
+	t.Run("download is seekable", func(t *testing.T) {
+		fs := newStorage(t.TempDir(), "new", false)
+
+		content := make([]byte, 3*chunkSize+100)
+		_, _ = rand.Read(content)
+		require.NoError(t, fs.Upload(context.Background(), bytes.NewReader(content), "abc", "file.bin"))
+
+		result, err := fs.Download(context.Background(), "abc")
+		require.NoError(t, err)
+		defer func() { _ = result.Close() }()
+
+		size, err := result.File.Seek(0, io.SeekEnd)
+		require.NoError(t, err)
+		require.Equal(t, int64(len(content)), size)
+
+		offset := int64(2*chunkSize - 10)
+		_, err = result.File.Seek(offset, io.SeekStart)
+		require.NoError(t, err)
+
+		part := make([]byte, 30)
+		_, err = io.ReadFull(result.File, part)
+		require.NoError(t, err)
+		require.Equal(t, content[offset:offset+30], part)
+	})
+
+	t.Run("files of rotated key stay readable", func(t *testing.T) {
+		path := t.TempDir()
+		content := []byte("hello world")
+
+		require.NoError(t, newStorage(path, "old", false).Upload(context.Background(), bytes.NewReader(content), "abc", "a.txt"))
+		require.Equal(t, content, download(t, newStorage(path, "new", false), context.Background(), "abc"))
+	})
+
+	t.Run("unknown key id", func(t *testing.T) {
+		path := t.TempDir()
+		require.NoError(t, newStorage(path, "new", false).Upload(context.Background(), bytes.NewReader([]byte("hi")), "abc", "a.txt"))
+
+		fs := newStorage(path, "old", false)
+		delete(fs.keys, "new")
+
+		_, err := fs.Download(context.Background(), "abc")
+		require.ErrorIs(t, err, errUnknownKey)
+	})
+
+	t.Run("tampered content is refused", func(t *testing.T) {
+		path := t.TempDir()
+		fs := newStorage(path, "new", false)
+		require.NoError(t, fs.Upload(context.Background(), bytes.NewReader(make([]byte, 2*chunkSize)), "abc", "a.bin"))
+
+		dataPath := filepath.Join(path, "abc", dataName)
+		stored, err := os.ReadFile(dataPath)
+		require.NoError(t, err)
+
+		stored[len(stored)-100] ^= 1
+		require.NoError(t, os.WriteFile(dataPath, stored, 0644))
+
+		result, err := fs.Download(context.Background(), "abc")
+		require.NoError(t, err)
+		defer func() { _ = result.Close() }()
+
+		_, err = io.ReadAll(result.File)
+		require.ErrorIs(t, err, errCorrupted)
+	})
+
+	t.Run("truncated file is refused", func(t *testing.T) {
+		path := t.TempDir()
+		fs := newStorage(path, "new", false)
+		require.NoError(t, fs.Upload(context.Background(), bytes.NewReader(make([]byte, 2*chunkSize)), "abc", "a.bin"))
+
+		dataPath := filepath.Join(path, "abc", dataName)
+		info, err := os.Stat(dataPath)
+		require.NoError(t, err)
+
+		// drop the whole last chunk
+		require.NoError(t, os.Truncate(dataPath, info.Size()-chunkSize-16))
+
+		result, err := fs.Download(context.Background(), "abc")
+		require.NoError(t, err)
+		defer func() { _ = result.Close() }()
+
+		_, err = io.ReadAll(result.File)
+		require.ErrorIs(t, err, errCorrupted)
+	})
+
+	t.Run("password key requires password", func(t *testing.T) {
+		fs := newStorage(t.TempDir(), "new", true)
+		content := []byte("hello world")
+
+		ctx := storage.WithPassword(context.Background(), "qwerty")
+		require.NoError(t, fs.Upload(ctx, bytes.NewReader(content), "abc", "a.txt"))
+
+		_, err := fs.Download(context.Background(), "abc")
+		require.ErrorIs(t, err, domainErrors.ErrFilePasswordRequired)
+
+		_, err = fs.Download(storage.WithPassword(context.Background(), "wrong"), "abc")
+		require.ErrorIs(t, err, errCorrupted)
+
+		require.Equal(t, content, download(t, fs, ctx, "abc"))
+	})
+
+	t.Run("password is ignored without password keys", func(t *testing.T) {
+		fs := newStorage(t.TempDir(), "new", false)
+		content := []byte("hello world")
+
+		ctx := storage.WithPassword(context.Background(), "qwerty")
+		require.NoError(t, fs.Upload(ctx, bytes.NewReader(content), "abc", "a.txt"))
+		require.Equal(t, content, download(t, fs, context.Background(), "abc"))
+	})
+
+	t.Run("files stored before encryption are served as they are", func(t *testing.T) {
+		path := t.TempDir()
+		for alias, content := range map[string]string{"abc": "hello world", "short": "hi"} {
+			plain := local.NewFileStorage(config.Storage{Path: path}, log)
+			require.NoError(t, plain.Upload(context.Background(), bytes.NewReader([]byte(content)), alias, "a.txt"))
+
+			require.Equal(t, []byte(content), download(t, newStorage(path, "new", false), context.Background(), alias))
+		}
+	})
+
+	t.Run("download not existing file", func(t *testing.T) {
+		_, err := newStorage(t.TempDir(), "new", false).Download(context.Background(), "missing")
+		require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
+	})
+}
@@ -0,0 +1,192 @@
+package encrypted
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// chunkSize is the size of plaintext sealed at once. Every chunk is
+// sealed separately, so a range of the file is decrypted without reading
+// the whole file
+const chunkSize = 64 * 1024
+
+// chunkNonce is the chunk index and a flag of the last chunk. File keys
+// are never reused, so the nonces are unique. The flag makes a file cut
+// at a chunk boundary fail to decrypt
+func chunkNonce(index int64, last bool) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[3:11], uint64(index))
+	if last {
+		nonce[11] = 1
+	}
+
+	return nonce
+}
+
+// encryptReader reads the header followed by sealed chunks of src. One
+// byte is read ahead to find out whether a chunk is the last one
+type encryptReader struct {
+	src      io.Reader
+	aead     cipher.AEAD
+	header   []byte
+	buf      []byte
+	buffered int
+	sealed   []byte
+	out      []byte
+	index    int64
+	done     bool
+}
+
+func newEncryptReader(src io.Reader, aead cipher.AEAD, header []byte) *encryptReader {
+	return &encryptReader{
+		src:    src,
+		aead:   aead,
+		header: header,
+		buf:    make([]byte, chunkSize+1),
+		sealed: make([]byte, 0, chunkSize+aead.Overhead()),
+		out:    header,
+	}
+}
+
+func (er *encryptReader) Read(p []byte) (int, error) {
+	for len(er.out) == 0 {
+		if er.done {
+			return 0, io.EOF
+		}
+
+		if err := er.sealNext(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, er.out)
+	er.out = er.out[n:]
+	return n, nil
+}
+
+func (er *encryptReader) sealNext() error {
+	n, err := io.ReadFull(er.src, er.buf[er.buffered:])
+	er.buffered += n
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+
+	last := er.buffered <= chunkSize
+	size := min(er.buffered, chunkSize)
+
+	er.out = er.aead.Seal(er.sealed[:0], chunkNonce(er.index, last), er.buf[:size], er.header)
+	er.index++
+
+	if last {
+		er.done = true
+		return nil
+	}
+
+	er.buf[0] = er.buf[chunkSize]
+	er.buffered = 1
+	return nil
+}
+
+// decryptReader is a seekable view of decrypted content. Only the chunk
+// holding the current offset is read and decrypted
+type decryptReader struct {
+	src    io.ReadSeeker
+	aead   cipher.AEAD
+	header []byte
+	size   int64
+	chunks int64
+	offset int64
+	index  int64
+	chunk  []byte
+	sealed []byte
+}
+
+func newDecryptReader(src io.ReadSeeker, aead cipher.AEAD, header []byte, encryptedSize int64) (*decryptReader, error) {
+	sealedChunkSize := int64(chunkSize + aead.Overhead())
+	body := encryptedSize - int64(len(header))
+
+	// an empty file is a single empty chunk
+	chunks := (body + sealedChunkSize - 1) / sealedChunkSize
+	size := body - chunks*int64(aead.Overhead())
+	if chunks < 1 || size < 0 {
+		return nil, fmt.Errorf("%w: unexpected size %d", errCorrupted, encryptedSize)
+	}
+
+	return &decryptReader{
+		src:    src,
+		aead:   aead,
+		header: header,
+		size:   size,
+		chunks: chunks,
+		index:  -1,
+		sealed: make([]byte, sealedChunkSize),
+	}, nil
+}
+
+func (dr *decryptReader) Read(p []byte) (int, error) {
+	if dr.offset >= dr.size {
+		return 0, io.EOF
+	}
+
+	index := dr.offset / chunkSize
+	if index != dr.index {
+		if err := dr.load(index); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, dr.chunk[dr.offset-index*chunkSize:])
+	dr.offset += int64(n)
+	return n, nil
+}
+
+func (dr *decryptReader) load(index int64) error {
+	overhead := int64(dr.aead.Overhead())
+	start := int64(len(dr.header)) + index*(chunkSize+overhead)
+	plainSize := min(chunkSize, dr.size-index*chunkSize)
+
+	// sequential reads are already at the start of the chunk, seeking
+	// there keeps the open stream of remote storages
+	if _, err := dr.src.Seek(start, io.SeekStart); err != nil {
+		return fmt.Errorf("seek chunk %d failed: %w", index, err)
+	}
+
+	sealed := dr.sealed[:plainSize+overhead]
+	if _, err := io.ReadFull(dr.src, sealed); err != nil {
+		return fmt.Errorf("read chunk %d failed: %w", index, err)
+	}
+
+	chunk, err := dr.aead.Open(dr.chunk[:0], chunkNonce(index, index == dr.chunks-1), sealed, dr.header)
+	if err != nil {
+		dr.index = -1
+		return fmt.Errorf("%w: chunk %d failed authentication", errCorrupted, index)
+	}
+
+	dr.chunk = chunk
+	dr.index = index
+	return nil
+}
+
+func (dr *decryptReader) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = dr.offset + offset
+	case io.SeekEnd:
+		abs = dr.size + offset
+	default:
+		return 0, errors.New("encrypted: invalid whence")
+	}
+
+	if abs < 0 {
+		return 0, errors.New("encrypted: negative position")
+	}
+
+	dr.offset = abs
+	return abs, nil
+}
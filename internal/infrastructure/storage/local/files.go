@@ -82,14 +82,16 @@ func (fs *FileStorage) Download(_ context.Context, alias string) (*results.Downl
 		return nil, fmt.Errorf("%s: open file failed: %w", fn, err)
 	}
 
-	fileInfo, err := result[0].Info()
+	fileInfo, err := file.Stat()
 	if err != nil {
+		_ = file.Close()
 		return nil, fmt.Errorf("%s: get file info failed: %w", fn, err)
 	}
 
 	return &results.DownloadFile{
 		File:     file,
 		FileInfo: fileInfo,
+		ETag:     fmt.Sprintf("\"%x-%x\"", fileInfo.ModTime().UnixNano(), fileInfo.Size()),
 		Close:    file.Close,
 	}, nil
 }
@@ -0,0 +1,339 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	infoExt  = ".info"
+	dataExt  = ".bin"
+	claimExt = ".claim"
+)
+
+// UploadStaging keeps unfinished resumable uploads on the local disk.
+// Every upload is a pair of files: {id}.info with metadata and {id}.bin
+// with the bytes received so far, so the current offset is the size of
+// the data file. An upload being completed also has {id}.claim, which is
+// created exclusively, so replicas sharing the folder never complete an
+// upload twice
+type UploadStaging struct {
+	path  string
+	locks sync.Map
+	log   *slog.Logger
+}
+
+type uploadInfo struct {
+	ID           string        `json:"id"`
+	Filename     string        `json:"filename"`
+	Length       int64         `json:"length"`
+	MaxDownloads int16         `json:"max_downloads"`
+	TTL          time.Duration `json:"ttl"`
+	PasswordHash string        `json:"password_hash,omitempty"`
+	UserID       int64         `json:"user_id"`
+	CreatedAt    time.Time     `json:"created_at"`
+	ExpiresAt    time.Time     `json:"expires_at"`
+}
+
+func NewUploadStaging(path string, log *slog.Logger) *UploadStaging {
+	return &UploadStaging{path: path, log: log}
+}
+
+func (us *UploadStaging) Create(_ context.Context, upload entities.Upload) error {
+	const fn = "storage.local.UploadStaging.Create"
+
+	if err := os.MkdirAll(us.path, 0755); err != nil {
+		return fmt.Errorf("%s: create staging folder failed: %w", fn, err)
+	}
+
+	info, err := json.Marshal(uploadInfo{
+		ID:           upload.ID,
+		Filename:     upload.Filename,
+		Length:       upload.Length,
+		MaxDownloads: upload.MaxDownloads,
+		TTL:          upload.TTL,
+		PasswordHash: upload.PasswordHash,
+		UserID:       upload.UserID,
+		CreatedAt:    upload.CreatedAt,
+		ExpiresAt:    upload.ExpiresAt,
+	})
+
+	if err != nil {
+		return fmt.Errorf("%s: marshal upload info failed: %w", fn, err)
+	}
+
+	dataFile, err := os.OpenFile(us.dataPath(upload.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("%s: create data file failed: %w", fn, err)
+	}
+
+	if err := dataFile.Close(); err != nil {
+		return fmt.Errorf("%s: close data file failed: %w", fn, err)
+	}
+
+	if err := os.WriteFile(us.infoPath(upload.ID), info, 0644); err != nil {
+		_ = os.Remove(us.dataPath(upload.ID))
+		return fmt.Errorf("%s: write upload info failed: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (us *UploadStaging) Get(_ context.Context, id string) (*entities.Upload, error) {
+	const fn = "storage.local.UploadStaging.Get"
+
+	upload, err := us.read(id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return upload, nil
+}
+
+func (us *UploadStaging) Append(ctx context.Context, id string, offset int64, chunk io.Reader) (int64, error) {
+	const fn = "storage.local.UploadStaging.Append"
+	log := us.log.With(slog.String("fn", fn))
+
+	unlock := us.lock(id)
+	defer unlock()
+
+	upload, err := us.read(id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if upload.Offset != offset {
+		return upload.Offset, domainErrors.ErrUploadOffsetMismatch
+	}
+
+	dataFile, err := os.OpenFile(us.dataPath(id), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("%s: open data file failed: %w", fn, err)
+	}
+
+	defer func(dataFile *os.File) {
+		if err := dataFile.Close(); err != nil {
+			log.Error("failed to close data file", sl.Error(err))
+		}
+	}(dataFile)
+
+	if err := ctx.Err(); err != nil {
+		return offset, err
+	}
+
+	// one extra byte is read to find out whether the chunk overflows the declared length
+	remaining := upload.Length - offset
+	written, err := io.Copy(dataFile, io.LimitReader(chunk, remaining+1))
+	if written > remaining {
+		_ = dataFile.Truncate(upload.Length)
+		return upload.Length, domainErrors.ErrUploadLengthExceeded
+	}
+
+	// bytes received before an interrupted request are kept, the client
+	// resumes from the offset it discovers with HEAD
+	if err != nil {
+		return offset + written, fmt.Errorf("%s: write chunk failed: %w", fn, err)
+	}
+
+	if err := dataFile.Sync(); err != nil {
+		return offset + written, fmt.Errorf("%s: sync data file failed: %w", fn, err)
+	}
+
+	return offset + written, nil
+}
+
+func (us *UploadStaging) Open(_ context.Context, id string) (io.ReadCloser, error) {
+	const fn = "storage.local.UploadStaging.Open"
+
+	file, err := os.Open(us.dataPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, domainErrors.ErrUploadNotFound
+		}
+
+		return nil, fmt.Errorf("%s: open data file failed: %w", fn, err)
+	}
+
+	return file, nil
+}
+
+func (us *UploadStaging) Claim(_ context.Context, id string) error {
+	const fn = "storage.local.UploadStaging.Claim"
+
+	unlock := us.lock(id)
+	defer unlock()
+
+	if _, err := us.read(id); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	claimFile, err := os.OpenFile(us.claimPath(id), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return domainErrors.ErrUploadCompleting
+		}
+
+		return fmt.Errorf("%s: create claim file failed: %w", fn, err)
+	}
+
+	if err := claimFile.Close(); err != nil {
+		return fmt.Errorf("%s: close claim file failed: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (us *UploadStaging) Unclaim(_ context.Context, id string) error {
+	const fn = "storage.local.UploadStaging.Unclaim"
+
+	unlock := us.lock(id)
+	defer unlock()
+
+	if err := os.Remove(us.claimPath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("%s: remove claim file failed: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (us *UploadStaging) Delete(_ context.Context, id string) error {
+	const fn = "storage.local.UploadStaging.Delete"
+
+	unlock := us.lock(id)
+	defer unlock()
+
+	if err := us.remove(id); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (us *UploadStaging) DeleteExpired(ctx context.Context, now time.Time) ([]string, error) {
+	const fn = "storage.local.UploadStaging.DeleteExpired"
+
+	entries, err := os.ReadDir(us.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("%s: read dir failed: %w", fn, err)
+	}
+
+	var deleted []string
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+
+		id, ok := strings.CutSuffix(entry.Name(), infoExt)
+		if !ok {
+			continue
+		}
+
+		upload, err := us.read(id)
+		if err != nil || upload.ExpiresAt.After(now) {
+			continue
+		}
+
+		unlock := us.lock(id)
+		err = us.remove(id)
+		unlock()
+
+		if err != nil {
+			return deleted, fmt.Errorf("%s: %w", fn, err)
+		}
+
+		deleted = append(deleted, id)
+	}
+
+	return deleted, nil
+}
+
+func (us *UploadStaging) read(id string) (*entities.Upload, error) {
+	data, err := os.ReadFile(us.infoPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, domainErrors.ErrUploadNotFound
+		}
+
+		return nil, fmt.Errorf("read upload info failed: %w", err)
+	}
+
+	var info uploadInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("unmarshal upload info failed: %w", err)
+	}
+
+	stat, err := os.Stat(us.dataPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, domainErrors.ErrUploadNotFound
+		}
+
+		return nil, fmt.Errorf("stat data file failed: %w", err)
+	}
+
+	return &entities.Upload{
+		ID:           info.ID,
+		Filename:     info.Filename,
+		Length:       info.Length,
+		Offset:       stat.Size(),
+		MaxDownloads: info.MaxDownloads,
+		TTL:          info.TTL,
+		PasswordHash: info.PasswordHash,
+		UserID:       info.UserID,
+		CreatedAt:    info.CreatedAt,
+		ExpiresAt:    info.ExpiresAt,
+	}, nil
+}
+
+func (us *UploadStaging) remove(id string) error {
+	defer us.locks.Delete(id)
+
+	infoErr := os.Remove(us.infoPath(id))
+	dataErr := os.Remove(us.dataPath(id))
+	claimErr := os.Remove(us.claimPath(id))
+
+	if os.IsNotExist(infoErr) && os.IsNotExist(dataErr) {
+		return domainErrors.ErrUploadNotFound
+	}
+
+	for _, err := range []error{infoErr, dataErr, claimErr} {
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove upload failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (us *UploadStaging) lock(id string) func() {
+	mu, _ := us.locks.LoadOrStore(id, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+func (us *UploadStaging) infoPath(id string) string {
+	return filepath.Join(us.path, filepath.Base(id)+infoExt)
+}
+
+func (us *UploadStaging) dataPath(id string) string {
+	return filepath.Join(us.path, filepath.Base(id)+dataExt)
+}
+
+func (us *UploadStaging) claimPath(id string) string {
+	return filepath.Join(us.path, filepath.Base(id)+claimExt)
+}
@@ -0,0 +1,440 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"expire-share/internal/config"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	amzDateFormat   = "20060102T150405Z"
+	amzShortFormat  = "20060102"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	emptyPayload    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	signAlgorithm   = "AWS4-HMAC-SHA256"
+)
+
+var errNoSuchKey = errors.New("no such key")
+
+// client is a minimal S3 REST client covering only the operations the
+// file storage needs. Requests are signed with AWS Signature V4 when
+// credentials are configured and sent anonymously otherwise.
+type client struct {
+	http *http.Client
+	// stream is http without the overall timeout, an object is streamed
+	// back to downloaders for as long as they read it
+	stream *http.Client
+	cfg    config.S3Storage
+	base   *url.URL
+	now    func() time.Time
+}
+
+type object struct {
+	Key          string    `xml:"Key"`
+	Size         int64     `xml:"Size"`
+	LastModified time.Time `xml:"LastModified"`
+	ETag         string    `xml:"ETag"`
+}
+
+type listBucketResult struct {
+	Contents              []object `xml:"Contents"`
+	IsTruncated           bool     `xml:"IsTruncated"`
+	NextContinuationToken string   `xml:"NextContinuationToken"`
+}
+
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+type errorResponse struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func newClient(cfg config.S3Storage, httpClient *http.Client) (*client, error) {
+	base, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+
+	if base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint: %s", cfg.Endpoint)
+	}
+
+	stream := *httpClient
+	stream.Timeout = 0
+
+	return &client{
+		http:   httpClient,
+		stream: &stream,
+		cfg:    cfg,
+		base:   base,
+		now:    time.Now,
+	}, nil
+}
+
+func (c *client) putObject(ctx context.Context, key string, body []byte) error {
+	resp, err := c.do(ctx, http.MethodPut, key, nil, bytes.NewReader(body), int64(len(body)), nil)
+	if err != nil {
+		return err
+	}
+
+	return drain(resp)
+}
+
+func (c *client) getObject(ctx context.Context, key string, header http.Header) (*http.Response, error) {
+	return c.send(ctx, c.stream, http.MethodGet, key, nil, nil, 0, header)
+}
+
+func (c *client) deleteObject(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, http.MethodDelete, key, nil, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+
+	return drain(resp)
+}
+
+func (c *client) listObjects(ctx context.Context, prefix string) ([]object, error) {
+	var objects []object
+	token := ""
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := c.do(ctx, http.MethodGet, "", query, nil, 0, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result listBucketResult
+		err = decodeXML(resp, &result)
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, result.Contents...)
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+
+		token = result.NextContinuationToken
+	}
+}
+
+func (c *client) createMultipartUpload(ctx context.Context, key string) (string, error) {
+	resp, err := c.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, 0, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var result initiateMultipartUploadResult
+	if err := decodeXML(resp, &result); err != nil {
+		return "", err
+	}
+
+	return result.UploadID, nil
+}
+
+func (c *client) uploadPart(ctx context.Context, key, uploadID string, partNumber int, body []byte) (string, error) {
+	query := url.Values{}
+	query.Set("partNumber", strconv.Itoa(partNumber))
+	query.Set("uploadId", uploadID)
+
+	resp, err := c.do(ctx, http.MethodPut, key, query, bytes.NewReader(body), int64(len(body)), nil)
+	if err != nil {
+		return "", err
+	}
+
+	etag := resp.Header.Get("ETag")
+	return etag, drain(resp)
+}
+
+func (c *client) completeMultipartUpload(ctx context.Context, key, uploadID string, parts []completedPart) error {
+	body, err := xml.Marshal(completeMultipartUpload{Parts: parts})
+	if err != nil {
+		return fmt.Errorf("failed to marshal parts: %w", err)
+	}
+
+	resp, err := c.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, bytes.NewReader(body), int64(len(body)), nil)
+	if err != nil {
+		return err
+	}
+
+	// S3 may answer 200 OK and still report failure in the body
+	var result errorResponse
+	if err := decodeXML(resp, &result); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	if result.Code != "" {
+		return fmt.Errorf("s3 error %s: %s", result.Code, result.Message)
+	}
+
+	return nil
+}
+
+func (c *client) abortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	resp, err := c.do(ctx, http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+
+	return drain(resp)
+}
+
+func (c *client) do(ctx context.Context, method, key string, query url.Values, body io.Reader, length int64, header http.Header) (*http.Response, error) {
+	return c.send(ctx, c.http, method, key, query, body, length, header)
+}
+
+func (c *client) send(ctx context.Context, httpClient *http.Client, method, key string, query url.Values, body io.Reader, length int64, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.objectURL(key, query), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	if body != nil {
+		req.ContentLength = length
+	}
+
+	c.sign(req)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		defer func() { _ = resp.Body.Close() }()
+		return nil, readError(resp)
+	}
+
+	return resp, nil
+}
+
+func (c *client) objectURL(key string, query url.Values) string {
+	u := *c.base
+	escapedKey := escapePath(key)
+
+	if c.cfg.UsePathStyle {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + c.cfg.Bucket
+		if key != "" {
+			u.Path += "/" + key
+		}
+
+		u.RawPath = strings.TrimSuffix(c.base.EscapedPath(), "/") + "/" + escapePath(c.cfg.Bucket)
+		if key != "" {
+			u.RawPath += "/" + escapedKey
+		}
+	} else {
+		u.Host = c.cfg.Bucket + "." + u.Host
+		u.Path = "/" + key
+		u.RawPath = "/" + escapedKey
+	}
+
+	u.RawQuery = encodeQuery(query)
+	return u.String()
+}
+
+func (c *client) sign(req *http.Request) {
+	if req.Header.Get("X-Amz-Content-Sha256") == "" {
+		payloadHash := unsignedPayload
+		if req.Body == nil {
+			payloadHash = emptyPayload
+		}
+
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	if c.cfg.AccessKeyID == "" || c.cfg.SecretAccessKey == "" {
+		return
+	}
+
+	now := c.now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+
+	signedHeaders, canonicalHeaders := canonicalizeHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		encodeQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		req.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+
+	scope := strings.Join([]string{now.Format(amzShortFormat), c.cfg.Region, "s3", "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		signAlgorithm,
+		now.Format(amzDateFormat),
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.cfg.SecretAccessKey), now.Format(amzShortFormat))
+	key = hmacSHA256(key, c.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signAlgorithm, c.cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func canonicalizeHeaders(req *http.Request) (string, string) {
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "range" || lower == "content-md5" || lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name)
+		canonical.WriteByte(':')
+		canonical.WriteString(headers[name])
+		canonical.WriteByte('\n')
+	}
+
+	return strings.Join(names, ";"), canonical.String()
+}
+
+func encodeQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+
+		for _, value := range values {
+			pairs = append(pairs, escape(key)+"="+escape(value))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// escape encodes value as required by SigV4: everything except
+// unreserved characters from RFC 3986 is percent-encoded
+func escape(value string) string {
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		b := value[i]
+		if isUnreserved(b) {
+			builder.WriteByte(b)
+			continue
+		}
+
+		_, _ = fmt.Fprintf(&builder, "%%%02X", b)
+	}
+
+	return builder.String()
+}
+
+func isUnreserved(b byte) bool {
+	return 'A' <= b && b <= 'Z' || 'a' <= b && b <= 'z' || '0' <= b && b <= '9' ||
+		b == '-' || b == '_' || b == '.' || b == '~'
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func readError(resp *http.Response) error {
+	var result errorResponse
+	_ = xml.NewDecoder(resp.Body).Decode(&result)
+
+	if resp.StatusCode == http.StatusNotFound && (result.Code == "" || result.Code == "NoSuchKey") {
+		return errNoSuchKey
+	}
+
+	if result.Code == "" {
+		return fmt.Errorf("s3 responded with status %d", resp.StatusCode)
+	}
+
+	return fmt.Errorf("s3 error %s (status %d): %s", result.Code, resp.StatusCode, result.Message)
+}
+
+func decodeXML(resp *http.Response, v any) error {
+	defer func() { _ = resp.Body.Close() }()
+
+	if err := xml.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode s3 response: %w", err)
+	}
+
+	return nil
+}
+
+func drain(resp *http.Response) error {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
@@ -0,0 +1,184 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"expire-share/internal/config"
+	"expire-share/internal/domain/dto/files/results"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const dialTimeout = 10 * time.Second
+
+type FileStorage struct {
+	client *client
+	cfg    config.S3Storage
+	log    *slog.Logger
+}
+
+func NewFileStorage(cfg config.S3Storage, log *slog.Logger) (*FileStorage, error) {
+	return NewFileStorageWithClient(cfg, newHTTPClient(cfg), log)
+}
+
+// newHTTPClient bounds every stage of a request, so a stalled endpoint
+// can't hold uploads or the worker's transactions forever
+func newHTTPClient(cfg config.S3Storage) *http.Client {
+	dialer := &net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}
+
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   dialTimeout,
+		ResponseHeaderTimeout: cfg.Timeout,
+		ExpectContinueTimeout: time.Second,
+		MaxIdleConns:          cfg.MaxConns,
+		MaxIdleConnsPerHost:   cfg.MaxConns,
+		MaxConnsPerHost:       cfg.MaxConns,
+		IdleConnTimeout:       90 * time.Second,
+	}
+
+	return &http.Client{Transport: transport, Timeout: cfg.Timeout}
+}
+
+func NewFileStorageWithClient(cfg config.S3Storage, httpClient *http.Client, log *slog.Logger) (*FileStorage, error) {
+	c, err := newClient(cfg, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("storage.s3.NewFileStorage: %w", err)
+	}
+
+	return &FileStorage{client: c, cfg: cfg, log: log}, nil
+}
+
+func (fs *FileStorage) Upload(ctx context.Context, file io.Reader, alias string, filename string) error {
+	const fn = "storage.s3.FileStorage.Upload"
+	log := fs.log.With(slog.String("fn", fn))
+
+	key := fs.aliasPrefix(alias) + filepath.Base(filename)
+
+	buffer := make([]byte, fs.cfg.PartSizeInBytes)
+	n, err := io.ReadFull(file, buffer)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%s: read file failed: %w", fn, err)
+	}
+
+	if err != nil {
+		if err := fs.client.putObject(ctx, key, buffer[:n]); err != nil {
+			return fmt.Errorf("%s: put object failed: %w", fn, err)
+		}
+
+		return nil
+	}
+
+	uploadID, err := fs.client.createMultipartUpload(ctx, key)
+	if err != nil {
+		return fmt.Errorf("%s: create multipart upload failed: %w", fn, err)
+	}
+
+	if err := fs.uploadParts(ctx, file, key, uploadID, buffer); err != nil {
+		// the caller's context may already be done, abort must still reach S3
+		if err := fs.client.abortMultipartUpload(context.WithoutCancel(ctx), key, uploadID); err != nil {
+			log.Error("failed to abort multipart upload", sl.Error(err), slog.String("key", key))
+		}
+
+		return fmt.Errorf("%s: upload file failed: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (fs *FileStorage) Download(ctx context.Context, alias string) (*results.DownloadFile, error) {
+	const fn = "storage.s3.FileStorage.Download"
+
+	objects, err := fs.client.listObjects(ctx, fs.aliasPrefix(alias))
+	if err != nil {
+		return nil, fmt.Errorf("%s: list objects failed: %w", fn, err)
+	}
+
+	if len(objects) == 0 {
+		return nil, domainErrors.ErrFileNotFound
+	}
+
+	reader := newObjectReader(ctx, fs.client, objects[0].Key, objects[0].Size)
+	return &results.DownloadFile{
+		File: reader,
+		FileInfo: objectInfo{
+			name:    path.Base(objects[0].Key),
+			size:    objects[0].Size,
+			modTime: objects[0].LastModified,
+		},
+		ETag:  objects[0].ETag,
+		Close: reader.Close,
+	}, nil
+}
+
+func (fs *FileStorage) Delete(ctx context.Context, alias string) error {
+	const fn = "storage.s3.FileStorage.Delete"
+
+	objects, err := fs.client.listObjects(ctx, fs.aliasPrefix(alias))
+	if err != nil {
+		return fmt.Errorf("%s: list objects failed: %w", fn, err)
+	}
+
+	for _, obj := range objects {
+		if err := fs.client.deleteObject(ctx, obj.Key); err != nil && !errors.Is(err, errNoSuchKey) {
+			return fmt.Errorf("%s: delete object failed: %w", fn, err)
+		}
+	}
+
+	return nil
+}
+
+func (fs *FileStorage) uploadParts(ctx context.Context, file io.Reader, key, uploadID string, buffer []byte) error {
+	var parts []completedPart
+	n := len(buffer)
+
+	for partNumber := 1; n > 0; partNumber++ {
+		etag, err := fs.client.uploadPart(ctx, key, uploadID, partNumber, buffer[:n])
+		if err != nil {
+			return fmt.Errorf("upload part %d failed: %w", partNumber, err)
+		}
+
+		parts = append(parts, completedPart{PartNumber: partNumber, ETag: etag})
+
+		n, err = io.ReadFull(file, buffer)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("read file failed: %w", err)
+		}
+	}
+
+	return fs.client.completeMultipartUpload(ctx, key, uploadID, parts)
+}
+
+func (fs *FileStorage) aliasPrefix(alias string) string {
+	prefix := strings.Trim(fs.cfg.Prefix, "/")
+	if prefix == "" {
+		return alias + "/"
+	}
+
+	return prefix + "/" + alias + "/"
+}
+
+type objectInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (oi objectInfo) Name() string       { return oi.name }
+func (oi objectInfo) Size() int64        { return oi.size }
+func (oi objectInfo) Mode() os.FileMode  { return 0444 }
+func (oi objectInfo) ModTime() time.Time { return oi.modTime }
+func (oi objectInfo) IsDir() bool        { return false }
+func (oi objectInfo) Sys() any           { return nil }
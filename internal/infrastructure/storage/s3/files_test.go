@@ -0,0 +1,366 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"expire-share/internal/config"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const partSize = 5 * 1024 * 1024
+
+func TestFileStorage(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	newStorage := func(t *testing.T, prefix string) (*FileStorage, *fakeS3) {
+		fake := newFakeS3("bucket")
+		server := httptest.NewServer(fake)
+		t.Cleanup(server.Close)
+
+		fs, err := NewFileStorageWithClient(config.S3Storage{
+			Endpoint:        server.URL,
+			Region:          "us-east-1",
+			Bucket:          "bucket",
+			Prefix:          prefix,
+			UsePathStyle:    true,
+			PartSizeInBytes: partSize,
+			AccessKeyID:     "access",
+			SecretAccessKey: "secret",
+		}, server.Client(), log)
+		require.NoError(t, err)
+
+		return fs, fake
+	}
+
+	t.Run("upload and download small file", func(t *testing.T) {
+		fs, fake := newStorage(t, "files")
+
+		err := fs.Upload(context.Background(), strings.NewReader("hello world"), "abc123", "../hello.txt")
+		require.NoError(t, err)
+		require.Contains(t, fake.keys(), "files/abc123/hello.txt")
+		require.Zero(t, fake.multipartUploads)
+
+		result, err := fs.Download(context.Background(), "abc123")
+		require.NoError(t, err)
+		defer func() { _ = result.Close() }()
+
+		content, err := io.ReadAll(result.File)
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(content))
+		require.Equal(t, "hello.txt", result.FileInfo.Name())
+		require.Equal(t, int64(len("hello world")), result.FileInfo.Size())
+	})
+
+	t.Run("download is seekable", func(t *testing.T) {
+		fs, fake := newStorage(t, "")
+
+		require.NoError(t, fs.Upload(context.Background(), strings.NewReader("hello world"), "seek", "a.txt"))
+
+		result, err := fs.Download(context.Background(), "seek")
+		require.NoError(t, err)
+		defer func() { _ = result.Close() }()
+
+		require.NotEmpty(t, result.ETag)
+
+		size, err := result.File.Seek(0, io.SeekEnd)
+		require.NoError(t, err)
+		require.Equal(t, int64(11), size)
+		require.Zero(t, fake.gets)
+
+		_, err = result.File.Seek(6, io.SeekStart)
+		require.NoError(t, err)
+
+		content, err := io.ReadAll(result.File)
+		require.NoError(t, err)
+		require.Equal(t, "world", string(content))
+		require.Equal(t, 1, fake.gets)
+	})
+
+	t.Run("upload big file with multipart", func(t *testing.T) {
+		fs, fake := newStorage(t, "")
+
+		content := bytes.Repeat([]byte("0123456789"), partSize/10*2+7)
+		err := fs.Upload(context.Background(), bytes.NewReader(content), "big", "big.bin")
+		require.NoError(t, err)
+		require.Equal(t, 1, fake.multipartUploads)
+
+		result, err := fs.Download(context.Background(), "big")
+		require.NoError(t, err)
+		defer func() { _ = result.Close() }()
+
+		downloaded, err := io.ReadAll(result.File)
+		require.NoError(t, err)
+		require.Equal(t, content, downloaded)
+	})
+
+	t.Run("failed multipart upload is aborted", func(t *testing.T) {
+		fs, fake := newStorage(t, "")
+		fake.failParts = true
+
+		content := bytes.Repeat([]byte("a"), partSize+1)
+		err := fs.Upload(context.Background(), bytes.NewReader(content), "broken", "broken.bin")
+		require.Error(t, err)
+		require.Equal(t, 1, fake.abortedUploads)
+		require.Empty(t, fake.keys())
+	})
+
+	t.Run("download not existing file", func(t *testing.T) {
+		fs, _ := newStorage(t, "files")
+
+		result, err := fs.Download(context.Background(), "missing")
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
+	})
+
+	t.Run("delete removes only alias objects", func(t *testing.T) {
+		fs, fake := newStorage(t, "files")
+
+		require.NoError(t, fs.Upload(context.Background(), strings.NewReader("a"), "first", "a.txt"))
+		require.NoError(t, fs.Upload(context.Background(), strings.NewReader("b"), "second", "b.txt"))
+
+		require.NoError(t, fs.Delete(context.Background(), "first"))
+		require.Equal(t, []string{"files/second/b.txt"}, fake.keys())
+
+		_, err := fs.Download(context.Background(), "first")
+		require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
+	})
+
+	t.Run("delete not existing file", func(t *testing.T) {
+		fs, _ := newStorage(t, "files")
+		require.NoError(t, fs.Delete(context.Background(), "missing"))
+	})
+
+	t.Run("requests are signed", func(t *testing.T) {
+		fs, fake := newStorage(t, "")
+
+		require.NoError(t, fs.Upload(context.Background(), strings.NewReader("a"), "signed", "a.txt"))
+		require.True(t, strings.HasPrefix(fake.lastAuthorization, "AWS4-HMAC-SHA256 Credential=access/"))
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		fs, _ := newStorage(t, "")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := fs.Upload(ctx, strings.NewReader("a"), "canceled", "a.txt")
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("stalled endpoint times out", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		t.Cleanup(server.Close)
+		t.Cleanup(func() { close(release) })
+
+		cfg := config.S3Storage{
+			Endpoint:        server.URL,
+			Region:          "us-east-1",
+			Bucket:          "bucket",
+			UsePathStyle:    true,
+			PartSizeInBytes: partSize,
+			Timeout:         50 * time.Millisecond,
+			MaxConns:        1,
+		}
+		fs, err := NewFileStorage(cfg, log)
+		require.NoError(t, err)
+
+		err = fs.Delete(context.Background(), "stalled")
+		require.Error(t, err)
+
+		_, err = fs.Download(context.Background(), "stalled")
+		require.Error(t, err)
+	})
+}
+
+// fakeS3 is an in-memory S3-compatible server supporting path-style
+// addressing and the subset of the API used by FileStorage
+type fakeS3 struct {
+	mu                sync.Mutex
+	bucket            string
+	objects           map[string][]byte
+	uploads           map[string]map[int][]byte
+	multipartUploads  int
+	abortedUploads    int
+	gets              int
+	failParts         bool
+	lastAuthorization string
+}
+
+func newFakeS3(bucket string) *fakeS3 {
+	return &fakeS3{
+		bucket:  bucket,
+		objects: make(map[string][]byte),
+		uploads: make(map[string]map[int][]byte),
+	}
+}
+
+func (f *fakeS3) keys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]string, 0, len(f.objects))
+	for key := range f.objects {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lastAuthorization = r.Header.Get("Authorization")
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	bucket, key, _ := strings.Cut(path, "/")
+	if bucket != f.bucket {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	query := r.URL.Query()
+
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		f.list(w, query.Get("prefix"))
+
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.multipartUploads++
+		uploadID := strconv.Itoa(f.multipartUploads)
+		f.uploads[uploadID] = make(map[int][]byte)
+		writeXML(w, initiateMultipartUploadResult{UploadID: uploadID})
+
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		if f.failParts {
+			writeS3Error(w, http.StatusInternalServerError, "InternalError")
+			return
+		}
+
+		parts, ok := f.uploads[query.Get("uploadId")]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+
+		partNumber, _ := strconv.Atoi(query.Get("partNumber"))
+		body, _ := io.ReadAll(r.Body)
+		parts[partNumber] = body
+		w.Header().Set("ETag", fmt.Sprintf("\"part-%d\"", partNumber))
+
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		parts, ok := f.uploads[query.Get("uploadId")]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+
+		var complete completeMultipartUpload
+		if err := xml.NewDecoder(r.Body).Decode(&complete); err != nil {
+			writeS3Error(w, http.StatusBadRequest, "MalformedXML")
+			return
+		}
+
+		var content []byte
+		for _, part := range complete.Parts {
+			content = append(content, parts[part.PartNumber]...)
+		}
+
+		f.objects[key] = content
+		delete(f.uploads, query.Get("uploadId"))
+		writeXML(w, struct {
+			XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+			Key     string   `xml:"Key"`
+		}{Key: key})
+
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		f.abortedUploads++
+		delete(f.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[key] = body
+
+	case r.Method == http.MethodGet:
+		content, ok := f.objects[key]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+
+		f.gets++
+		if start, ok := strings.CutPrefix(r.Header.Get("Range"), "bytes="); ok {
+			offset, _ := strconv.Atoi(strings.TrimSuffix(start, "-"))
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(content)-1, len(content)))
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)-offset))
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write(content[offset:])
+			return
+		}
+
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		_, _ = w.Write(content)
+
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeS3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, prefix string) {
+	var result listBucketResult
+	for key, content := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			result.Contents = append(result.Contents, object{
+				Key:          key,
+				Size:         int64(len(content)),
+				LastModified: time.Now().UTC(),
+				ETag:         fmt.Sprintf("\"%x\"", len(content)),
+			})
+		}
+	}
+
+	sort.Slice(result.Contents, func(i, j int) bool {
+		return result.Contents[i].Key < result.Contents[j].Key
+	})
+
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"ListBucketResult"`
+		listBucketResult
+	}{listBucketResult: result})
+}
+
+func writeXML(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(v)
+}
+
+func writeS3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_ = xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		errorResponse
+	}{errorResponse: errorResponse{Code: code, Message: code}})
+}
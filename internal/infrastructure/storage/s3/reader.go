@@ -0,0 +1,94 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// objectReader is a seekable view of an S3 object. Nothing is fetched
+// until the first Read, then the object is streamed with a ranged GET
+// from the current offset. Seeking drops the open stream so the next
+// Read starts a new request at the new position
+type objectReader struct {
+	ctx    context.Context
+	client *client
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func newObjectReader(ctx context.Context, c *client, key string, size int64) *objectReader {
+	return &objectReader{ctx: ctx, client: c, key: key, size: size}
+}
+
+func (or *objectReader) Read(p []byte) (int, error) {
+	if or.offset >= or.size {
+		return 0, io.EOF
+	}
+
+	if or.body == nil {
+		header := http.Header{}
+		header.Set("Range", fmt.Sprintf("bytes=%d-", or.offset))
+
+		resp, err := or.client.getObject(or.ctx, or.key, header)
+		if err != nil {
+			return 0, err
+		}
+
+		if resp.StatusCode != http.StatusPartialContent && or.offset > 0 {
+			_ = resp.Body.Close()
+			return 0, fmt.Errorf("s3 ignored range request for %s", or.key)
+		}
+
+		or.body = resp.Body
+	}
+
+	n, err := or.body.Read(p)
+	or.offset += int64(n)
+
+	if errors.Is(err, io.EOF) && or.offset < or.size {
+		return n, io.ErrUnexpectedEOF
+	}
+
+	return n, err
+}
+
+func (or *objectReader) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = or.offset + offset
+	case io.SeekEnd:
+		abs = or.size + offset
+	default:
+		return 0, errors.New("s3: invalid whence")
+	}
+
+	if abs < 0 {
+		return 0, errors.New("s3: negative position")
+	}
+
+	if abs != or.offset && or.body != nil {
+		_ = or.body.Close()
+		or.body = nil
+	}
+
+	or.offset = abs
+	return abs, nil
+}
+
+func (or *objectReader) Close() error {
+	if or.body == nil {
+		return nil
+	}
+
+	err := or.body.Close()
+	or.body = nil
+	return err
+}
@@ -1,33 +1,89 @@
 package alias
 
 import (
-	"math/rand"
-	"unsafe"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
 )
 
-const (
-	letterBytes   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	letterIdxBits = 6
-	letterIdxMask = 1<<letterIdxBits - 1
-	letterIdxMax  = 63 / letterIdxBits
-)
+// DefaultAlphabet is used when no alphabet is configured
+const DefaultAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// allowedChars keep aliases safe in URL paths and storage keys
+const allowedChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_"
+
+var ErrInvalidAlphabet = errors.New("invalid alias alphabet")
 
-func Gen(length int16) string {
-	result := make([]byte, length)
+// ValidateAlphabet checks that alphabet has at least two distinct characters
+// and consists only of letters, digits, '-' and '_'
+func ValidateAlphabet(alphabet string) error {
+	if len(alphabet) < 2 {
+		return fmt.Errorf("%w: at least 2 characters are required", ErrInvalidAlphabet)
+	}
+
+	for i := 0; i < len(alphabet); i++ {
+		if !strings.ContainsRune(allowedChars, rune(alphabet[i])) {
+			return fmt.Errorf("%w: character %q is not allowed", ErrInvalidAlphabet, alphabet[i])
+		}
 
-	for i, cache, remain := length-1, rand.Int63(), letterIdxMax; i >= 0; {
-		if remain == 0 {
-			cache, remain = rand.Int63(), letterIdxMax
+		if strings.IndexByte(alphabet[i+1:], alphabet[i]) != -1 {
+			return fmt.Errorf("%w: character %q is repeated", ErrInvalidAlphabet, alphabet[i])
 		}
+	}
+
+	return nil
+}
+
+// Gen returns a random alias of the given length built from crypto/rand.
+// Alphabet must be valid, an empty one means DefaultAlphabet
+func Gen(alphabet string, length int16) string {
+	if alphabet == "" {
+		alphabet = DefaultAlphabet
+	}
 
-		if idx := int(cache & letterIdxMask); idx < len(letterBytes) {
-			result[i] = letterBytes[idx]
-			i--
+	// bytes above the largest multiple of the alphabet size are rejected,
+	// otherwise the first characters would be picked more often
+	limit := 256 - 256%len(alphabet)
+
+	result := make([]byte, 0, length)
+	buf := make([]byte, length)
+
+	for len(result) < int(length) {
+		_, _ = rand.Read(buf)
+
+		for _, b := range buf {
+			if int(b) >= limit {
+				continue
+			}
+
+			result = append(result, alphabet[int(b)%len(alphabet)])
+			if len(result) == int(length) {
+				break
+			}
 		}
+	}
 
-		cache >>= letterIdxBits
-		remain--
+	return string(result)
+}
+
+// Entropy returns the number of random bits in an alias of the given length
+func Entropy(alphabet string, length int16) float64 {
+	if alphabet == "" {
+		alphabet = DefaultAlphabet
 	}
 
-	return *(*string)(unsafe.Pointer(&result))
+	return float64(length) * math.Log2(float64(len(alphabet)))
+}
+
+// Random generates aliases of random characters
+type Random struct {
+	Alphabet string
+	Length   int16
+}
+
+// Gen returns an alias longer than Length by extra characters
+func (r Random) Gen(extra int16) string {
+	return Gen(r.Alphabet, r.Length+extra)
 }
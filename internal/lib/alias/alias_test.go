@@ -2,12 +2,14 @@ package alias
 
 import (
 	"github.com/stretchr/testify/require"
+	"strings"
 	"testing"
 )
 
 func Test_Gen(t *testing.T) {
 	tests := []struct {
 		name         string
+		alphabet     string
 		length       int16
 		resultLength int16
 	}{
@@ -21,12 +23,77 @@ func Test_Gen(t *testing.T) {
 			length:       512,
 			resultLength: 512,
 		},
+		{
+			name:         "generate alias with custom alphabet",
+			alphabet:     "abcdefghjkmnpqrstuvwxyz23456789",
+			length:       64,
+			resultLength: 64,
+		},
+		{
+			name:         "generate alias with odd alphabet size",
+			alphabet:     "xyz",
+			length:       64,
+			resultLength: 64,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			result := Gen(test.length)
+			result := Gen(test.alphabet, test.length)
 			require.Len(t, result, int(test.resultLength))
+
+			alphabet := test.alphabet
+			if alphabet == "" {
+				alphabet = DefaultAlphabet
+			}
+
+			for _, char := range result {
+				require.True(t, strings.ContainsRune(alphabet, char), "unexpected character %q", char)
+			}
+		})
+	}
+
+	t.Run("aliases are not repeated", func(t *testing.T) {
+		seen := make(map[string]struct{})
+		for range 1000 {
+			result := Gen("", 12)
+			require.NotContains(t, seen, result)
+			seen[result] = struct{}{}
+		}
+	})
+}
+
+func Test_ValidateAlphabet(t *testing.T) {
+	tests := []struct {
+		name     string
+		alphabet string
+		wantErr  bool
+	}{
+		{name: "default alphabet", alphabet: DefaultAlphabet},
+		{name: "unambiguous alphabet", alphabet: "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"},
+		{name: "url safe symbols", alphabet: "ab-_"},
+		{name: "empty alphabet", alphabet: "", wantErr: true},
+		{name: "single character", alphabet: "a", wantErr: true},
+		{name: "repeated character", alphabet: "abca", wantErr: true},
+		{name: "path separator", alphabet: "ab/", wantErr: true},
+		{name: "dot", alphabet: "ab.", wantErr: true},
+		{name: "non ascii", alphabet: "abж", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateAlphabet(test.alphabet)
+			if test.wantErr {
+				require.ErrorIs(t, err, ErrInvalidAlphabet)
+				return
+			}
+
+			require.NoError(t, err)
 		})
 	}
 }
+
+func Test_Entropy(t *testing.T) {
+	require.InDelta(t, 32.0, Entropy("ab", 32), 0.001)
+	require.InDelta(t, 35.725, Entropy("", 6), 0.001)
+}
@@ -0,0 +1,61 @@
+package alias
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+//go:embed words.txt
+var wordList string
+
+var words = strings.Fields(wordList)
+
+// wordSuffix bounds the random number appended to a word alias
+const wordSuffix = 100
+
+// Words generates aliases of random words
+type Words struct {
+	Count     int16
+	Separator string
+}
+
+// Gen returns an alias with extra words more than Count
+func (w Words) Gen(extra int16) string {
+	return GenWords(w.Count+extra, w.Separator)
+}
+
+// GenWords returns an alias of count random words followed by a random
+// two-digit number, e.g. calm-orange-tiger-42
+func GenWords(count int16, separator string) string {
+	parts := make([]string, 0, count+1)
+	for range count {
+		parts = append(parts, words[randInt(len(words))])
+	}
+
+	parts = append(parts, fmt.Sprintf("%02d", randInt(wordSuffix)))
+	return strings.Join(parts, separator)
+}
+
+// WordsEntropy returns the number of random bits in an alias of count words
+func WordsEntropy(count int16) float64 {
+	return float64(count)*math.Log2(float64(len(words))) + math.Log2(wordSuffix)
+}
+
+// MaxWordsLength returns the length of the longest possible alias of count words
+func MaxWordsLength(count int16, separator string) int {
+	longest := 0
+	for _, word := range words {
+		longest = max(longest, len(word))
+	}
+
+	return int(count)*(longest+len(separator)) + len(fmt.Sprint(wordSuffix-1))
+}
+
+func randInt(n int) int {
+	i, _ := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	return int(i.Int64())
+}
@@ -0,0 +1,54 @@
+package alias
+
+import (
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func Test_GenWords(t *testing.T) {
+	tests := []struct {
+		name      string
+		count     int16
+		separator string
+		pattern   string
+	}{
+		{
+			name:      "generate word alias",
+			count:     3,
+			separator: "-",
+			pattern:   `^[a-z]+-[a-z]+-[a-z]+-[0-9]{2}$`,
+		},
+		{
+			name:      "generate word alias with underscore",
+			count:     1,
+			separator: "_",
+			pattern:   `^[a-z]+_[0-9]{2}$`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := GenWords(test.count, test.separator)
+			require.Regexp(t, regexp.MustCompile(test.pattern), result)
+			require.LessOrEqual(t, len(result), MaxWordsLength(test.count, test.separator))
+		})
+	}
+
+	t.Run("extra words on retry", func(t *testing.T) {
+		result := Words{Count: 2, Separator: "-"}.Gen(2)
+		require.Len(t, strings.Split(result, "-"), 5)
+	})
+
+	t.Run("word list is valid", func(t *testing.T) {
+		seen := make(map[string]struct{}, len(words))
+		for _, word := range words {
+			require.Regexp(t, `^[a-z]+$`, word)
+			require.NotContains(t, seen, word)
+			seen[word] = struct{}{}
+		}
+
+		require.GreaterOrEqual(t, len(words), 512)
+	})
+}
@@ -0,0 +1,93 @@
+// Package language maps languages of pastes to filename extensions, so the
+// language declared for a paste is kept in its filename and found again
+// for any shared file by its extension
+package language
+
+import (
+	"path"
+	"strings"
+)
+
+// Text is plain text, it isn't highlighted
+const Text = "text"
+
+// extensions of languages, names are those of highlight.js
+var extensions = map[string]string{
+	Text:         ".txt",
+	"bash":       ".sh",
+	"c":          ".c",
+	"cpp":        ".cpp",
+	"csharp":     ".cs",
+	"css":        ".css",
+	"diff":       ".diff",
+	"dockerfile": ".dockerfile",
+	"go":         ".go",
+	"graphql":    ".graphql",
+	"ini":        ".ini",
+	"java":       ".java",
+	"javascript": ".js",
+	"json":       ".json",
+	"kotlin":     ".kt",
+	"lua":        ".lua",
+	"makefile":   ".mk",
+	"markdown":   ".md",
+	"nginx":      ".nginxconf",
+	"perl":       ".pl",
+	"php":        ".php",
+	"powershell": ".ps1",
+	"protobuf":   ".proto",
+	"python":     ".py",
+	"ruby":       ".rb",
+	"rust":       ".rs",
+	"scala":      ".scala",
+	"sql":        ".sql",
+	"swift":      ".swift",
+	"toml":       ".toml",
+	"typescript": ".ts",
+	"xml":        ".xml",
+	"yaml":       ".yaml",
+}
+
+// aliases are other extensions of the languages
+var aliases = map[string]string{
+	".bash": "bash",
+	".h":    "c",
+	".hpp":  "cpp",
+	".htm":  "xml",
+	".html": "xml",
+	".jsx":  "javascript",
+	".log":  Text,
+	".mjs":  "javascript",
+	".tsx":  "typescript",
+	".yml":  "yaml",
+}
+
+var byExtension = func() map[string]string {
+	languages := make(map[string]string, len(extensions)+len(aliases))
+	for name, ext := range extensions {
+		languages[ext] = name
+	}
+
+	for ext, name := range aliases {
+		languages[ext] = name
+	}
+
+	return languages
+}()
+
+// Extension returns the filename extension of the language, the name is
+// case-insensitive
+func Extension(name string) (string, bool) {
+	ext, ok := extensions[strings.ToLower(name)]
+	return ext, ok
+}
+
+// FromFilename returns the language of the file by its extension, Text
+// when the extension is unknown
+func FromFilename(filename string) string {
+	if name, ok := byExtension[strings.ToLower(path.Ext(filename))]; ok {
+		return name
+	}
+
+	return Text
+}
@@ -0,0 +1,40 @@
+package language
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_Extension(t *testing.T) {
+	ext, ok := Extension("Go")
+	require.True(t, ok)
+	require.Equal(t, ".go", ext)
+
+	_, ok = Extension("klingon")
+	require.False(t, ok)
+}
+
+func Test_FromFilename(t *testing.T) {
+	tests := []struct {
+		filename string
+		expected string
+	}{
+		{filename: "paste.go", expected: "go"},
+		{filename: "config.YML", expected: "yaml"},
+		{filename: "index.html", expected: "xml"},
+		{filename: "trace.log", expected: Text},
+		{filename: "archive.zip", expected: Text},
+		{filename: "Makefile", expected: Text},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filename, func(t *testing.T) {
+			require.Equal(t, test.expected, FromFilename(test.filename))
+		})
+	}
+
+	// every language is found by its own extension
+	for name, ext := range extensions {
+		require.Equal(t, name, FromFilename("paste"+ext))
+	}
+}
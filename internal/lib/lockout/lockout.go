@@ -0,0 +1,104 @@
+package lockout
+
+import (
+	"sync"
+	"time"
+)
+
+// Counter counts failures of keys within a window and locks a key once it
+// fails too often. Failures are kept in memory, so every replica counts
+// them on its own
+type Counter struct {
+	mu        sync.Mutex
+	entries   map[string]*entry
+	limit     int
+	window    time.Duration
+	lockFor   time.Duration
+	lastSweep time.Time
+}
+
+type entry struct {
+	failures    int
+	windowStart time.Time
+	lockedUntil time.Time
+}
+
+// NewCounter locks a key for lockFor after limit failures within window.
+// Zero limit never locks
+func NewCounter(limit int, window, lockFor time.Duration) *Counter {
+	return &Counter{
+		entries: make(map[string]*entry),
+		limit:   limit,
+		window:  window,
+		lockFor: lockFor,
+	}
+}
+
+// LockedFor returns how long key stays locked, zero if it is not locked
+func (c *Counter) LockedFor(key string, now time.Time) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || !now.Before(e.lockedUntil) {
+		return 0
+	}
+
+	return e.lockedUntil.Sub(now)
+}
+
+// Fail counts a failure of key and reports whether it locked the key
+func (c *Counter) Fail(key string, now time.Time) bool {
+	if c.limit <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sweep(now)
+
+	e, ok := c.entries[key]
+	if !ok {
+		e = &entry{windowStart: now}
+		c.entries[key] = e
+	}
+
+	if now.Sub(e.windowStart) >= c.window {
+		e.failures = 0
+		e.windowStart = now
+	}
+
+	e.failures++
+	if e.failures < c.limit {
+		return false
+	}
+
+	// the next failures start a new window once the lock is over
+	e.failures = 0
+	e.windowStart = now
+	e.lockedUntil = now.Add(c.lockFor)
+	return true
+}
+
+// Reset forgets failures of key, e.g. after the right password
+func (c *Counter) Reset(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+// sweep forgets keys which are neither locked nor within a window
+func (c *Counter) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < c.window {
+		return
+	}
+
+	c.lastSweep = now
+	for key, e := range c.entries {
+		if now.Sub(e.windowStart) >= c.window && !now.Before(e.lockedUntil) {
+			delete(c.entries, key)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package lockout
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCounter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("key is locked after limit failures", func(t *testing.T) {
+		counter := NewCounter(3, time.Minute, 10*time.Minute)
+
+		require.False(t, counter.Fail("file:1", now))
+		require.False(t, counter.Fail("file:1", now.Add(time.Second)))
+		require.Zero(t, counter.LockedFor("file:1", now.Add(time.Second)))
+
+		require.True(t, counter.Fail("file:1", now.Add(2*time.Second)))
+		require.Equal(t, 10*time.Minute, counter.LockedFor("file:1", now.Add(2*time.Second)))
+		require.Equal(t, time.Minute, counter.LockedFor("file:1", now.Add(9*time.Minute+2*time.Second)))
+		require.Zero(t, counter.LockedFor("file:1", now.Add(10*time.Minute+2*time.Second)))
+	})
+
+	t.Run("failures outside window are not counted", func(t *testing.T) {
+		counter := NewCounter(2, time.Minute, 10*time.Minute)
+
+		require.False(t, counter.Fail("file:1", now))
+		require.False(t, counter.Fail("file:1", now.Add(time.Minute)))
+		require.True(t, counter.Fail("file:1", now.Add(time.Minute+time.Second)))
+	})
+
+	t.Run("keys are counted separately", func(t *testing.T) {
+		counter := NewCounter(2, time.Minute, 10*time.Minute)
+
+		require.False(t, counter.Fail("file:1", now))
+		require.False(t, counter.Fail("file:2", now))
+		require.Zero(t, counter.LockedFor("file:2", now))
+	})
+
+	t.Run("reset forgets failures", func(t *testing.T) {
+		counter := NewCounter(2, time.Minute, 10*time.Minute)
+
+		require.False(t, counter.Fail("file:1", now))
+		counter.Reset("file:1")
+		require.False(t, counter.Fail("file:1", now))
+	})
+
+	t.Run("zero limit never locks", func(t *testing.T) {
+		counter := NewCounter(0, time.Minute, 10*time.Minute)
+
+		for range 100 {
+			require.False(t, counter.Fail("file:1", now))
+		}
+
+		require.Zero(t, counter.LockedFor("file:1", now))
+	})
+
+	t.Run("idle keys are forgotten", func(t *testing.T) {
+		counter := NewCounter(2, time.Minute, time.Minute)
+
+		counter.Fail("file:1", now)
+		counter.Fail("file:2", now.Add(2*time.Minute))
+
+		require.Len(t, counter.entries, 1)
+		require.Contains(t, counter.entries, "file:2")
+	})
+}
@@ -0,0 +1,69 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signer issues download session tokens. A token is bound to a file alias
+// and to the download reservation it was issued for and carries its own
+// expiration, so a forged or expired token is rejected by any replica
+// sharing the same secret without looking up the reservation
+type Signer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewSigner(secret string, ttl time.Duration) *Signer {
+	return &Signer{secret: []byte(secret), ttl: ttl}
+}
+
+// Issue returns a new token for reservation of alias and the moment it expires
+func (s *Signer) Issue(alias, reservation string, now time.Time) (string, time.Time) {
+	expiresAt := now.Add(s.ttl).Truncate(time.Second)
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+
+	return reservation + "." + expires + "." + s.sign(alias, reservation, expires), expiresAt
+}
+
+// Verify reports whether token was issued for alias and is not expired yet
+// and returns the reservation it was issued for
+func (s *Signer) Verify(token, alias string, now time.Time) (string, time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] == "" {
+		return "", time.Time{}, false
+	}
+
+	reservation, expires, signature := parts[0], parts[1], parts[2]
+
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(s.sign(alias, reservation, expires))) {
+		return "", time.Time{}, false
+	}
+
+	expiresAt := time.Unix(unix, 0)
+	if !now.Before(expiresAt) {
+		return "", time.Time{}, false
+	}
+
+	return reservation, expiresAt, true
+}
+
+func (s *Signer) sign(alias, reservation, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(alias))
+	mac.Write([]byte{0})
+	mac.Write([]byte(reservation))
+	mac.Write([]byte{0})
+	mac.Write([]byte(expires))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,67 @@
+package session
+
+import (
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigner(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	signer := NewSigner("secret", time.Hour)
+
+	t.Run("issued token is valid", func(t *testing.T) {
+		token, expiresAt := signer.Issue("abc123", "reservation", now)
+		require.Equal(t, now.Add(time.Hour), expiresAt.UTC())
+
+		reservation, verifiedExpiresAt, ok := signer.Verify(token, "abc123", now.Add(time.Minute))
+		require.True(t, ok)
+		require.Equal(t, "reservation", reservation)
+		require.True(t, expiresAt.Equal(verifiedExpiresAt))
+	})
+
+	t.Run("token is bound to alias", func(t *testing.T) {
+		token, _ := signer.Issue("abc123", "reservation", now)
+
+		_, _, ok := signer.Verify(token, "other", now)
+		require.False(t, ok)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		token, _ := signer.Issue("abc123", "reservation", now)
+
+		_, _, ok := signer.Verify(token, "abc123", now.Add(time.Hour))
+		require.False(t, ok)
+	})
+
+	t.Run("token signed with another secret", func(t *testing.T) {
+		token, _ := NewSigner("another", time.Hour).Issue("abc123", "reservation", now)
+
+		_, _, ok := signer.Verify(token, "abc123", now)
+		require.False(t, ok)
+	})
+
+	t.Run("tampered expiration", func(t *testing.T) {
+		token, _ := signer.Issue("abc123", "reservation", now)
+		parts := strings.Split(token, ".")
+
+		_, _, ok := signer.Verify(parts[0]+".9999999999."+parts[2], "abc123", now)
+		require.False(t, ok)
+	})
+
+	t.Run("tampered reservation", func(t *testing.T) {
+		token, _ := signer.Issue("abc123", "reservation", now)
+		_, rest, _ := strings.Cut(token, ".")
+
+		_, _, ok := signer.Verify("another."+rest, "abc123", now)
+		require.False(t, ok)
+	})
+
+	t.Run("malformed tokens", func(t *testing.T) {
+		for _, token := range []string{"", "garbage", "abc.def", ".", "1.", "..", ".1.sig", "a.b.c.d"} {
+			_, _, ok := signer.Verify(token, "abc123", now)
+			require.False(t, ok, token)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/delivery/handlers/api/uploads/create/create.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	commands "expire-share/internal/domain/dto/uploads/commands"
+	results "expire-share/internal/domain/dto/uploads/results"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockUploadCreator is a mock of UploadCreator interface.
+type MockUploadCreator struct {
+	ctrl     *gomock.Controller
+	recorder *MockUploadCreatorMockRecorder
+}
+
+// MockUploadCreatorMockRecorder is the mock recorder for MockUploadCreator.
+type MockUploadCreatorMockRecorder struct {
+	mock *MockUploadCreator
+}
+
+// NewMockUploadCreator creates a new mock instance.
+func NewMockUploadCreator(ctrl *gomock.Controller) *MockUploadCreator {
+	mock := &MockUploadCreator{ctrl: ctrl}
+	mock.recorder = &MockUploadCreatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUploadCreator) EXPECT() *MockUploadCreatorMockRecorder {
+	return m.recorder
+}
+
+// CreateUpload mocks base method.
+func (m *MockUploadCreator) CreateUpload(ctx context.Context, command commands.CreateUpload) (*results.CreateUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUpload", ctx, command)
+	ret0, _ := ret[0].(*results.CreateUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUpload indicates an expected call of CreateUpload.
+func (mr *MockUploadCreatorMockRecorder) CreateUpload(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUpload", reflect.TypeOf((*MockUploadCreator)(nil).CreateUpload), ctx, command)
+}
@@ -36,6 +36,20 @@ func (m *MockFileDownloader) EXPECT() *MockFileDownloaderMockRecorder {
 	return m.recorder
 }
 
+// ConfirmDownload mocks base method.
+func (m *MockFileDownloader) ConfirmDownload(ctx context.Context, command commands.FinishDownload) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmDownload", ctx, command)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmDownload indicates an expected call of ConfirmDownload.
+func (mr *MockFileDownloaderMockRecorder) ConfirmDownload(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmDownload", reflect.TypeOf((*MockFileDownloader)(nil).ConfirmDownload), ctx, command)
+}
+
 // DownloadFile mocks base method.
 func (m *MockFileDownloader) DownloadFile(ctx context.Context, command commands.DownloadFile) (*results.DownloadFile, error) {
 	m.ctrl.T.Helper()
@@ -50,3 +64,17 @@ func (mr *MockFileDownloaderMockRecorder) DownloadFile(ctx, command interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadFile", reflect.TypeOf((*MockFileDownloader)(nil).DownloadFile), ctx, command)
 }
+
+// ReleaseDownload mocks base method.
+func (m *MockFileDownloader) ReleaseDownload(ctx context.Context, command commands.FinishDownload) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseDownload", ctx, command)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseDownload indicates an expected call of ReleaseDownload.
+func (mr *MockFileDownloaderMockRecorder) ReleaseDownload(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseDownload", reflect.TypeOf((*MockFileDownloader)(nil).ReleaseDownload), ctx, command)
+}
@@ -0,0 +1,90 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/delivery/handlers/api/fetch/fetch.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	commands "expire-share/internal/domain/dto/fetches/commands"
+	results "expire-share/internal/domain/dto/fetches/results"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockURLUploader is a mock of URLUploader interface.
+type MockURLUploader struct {
+	ctrl     *gomock.Controller
+	recorder *MockURLUploaderMockRecorder
+}
+
+// MockURLUploaderMockRecorder is the mock recorder for MockURLUploader.
+type MockURLUploaderMockRecorder struct {
+	mock *MockURLUploader
+}
+
+// NewMockURLUploader creates a new mock instance.
+func NewMockURLUploader(ctrl *gomock.Controller) *MockURLUploader {
+	mock := &MockURLUploader{ctrl: ctrl}
+	mock.recorder = &MockURLUploaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockURLUploader) EXPECT() *MockURLUploaderMockRecorder {
+	return m.recorder
+}
+
+// CreateFetch mocks base method.
+func (m *MockURLUploader) CreateFetch(ctx context.Context, command commands.CreateFetch) (*results.CreateFetch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFetch", ctx, command)
+	ret0, _ := ret[0].(*results.CreateFetch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFetch indicates an expected call of CreateFetch.
+func (mr *MockURLUploaderMockRecorder) CreateFetch(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFetch", reflect.TypeOf((*MockURLUploader)(nil).CreateFetch), ctx, command)
+}
+
+// MockStatusGetter is a mock of StatusGetter interface.
+type MockStatusGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockStatusGetterMockRecorder
+}
+
+// MockStatusGetterMockRecorder is the mock recorder for MockStatusGetter.
+type MockStatusGetterMockRecorder struct {
+	mock *MockStatusGetter
+}
+
+// NewMockStatusGetter creates a new mock instance.
+func NewMockStatusGetter(ctrl *gomock.Controller) *MockStatusGetter {
+	mock := &MockStatusGetter{ctrl: ctrl}
+	mock.recorder = &MockStatusGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStatusGetter) EXPECT() *MockStatusGetterMockRecorder {
+	return m.recorder
+}
+
+// GetFetch mocks base method.
+func (m *MockStatusGetter) GetFetch(ctx context.Context, command commands.GetFetch) (*results.GetFetch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFetch", ctx, command)
+	ret0, _ := ret[0].(*results.GetFetch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFetch indicates an expected call of GetFetch.
+func (mr *MockStatusGetterMockRecorder) GetFetch(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFetch", reflect.TypeOf((*MockStatusGetter)(nil).GetFetch), ctx, command)
+}
@@ -0,0 +1,120 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/services/fetches/service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	results "expire-share/internal/domain/dto/fetches/results"
+	commands "expire-share/internal/domain/dto/files/commands"
+	results0 "expire-share/internal/domain/dto/files/results"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockUploader is a mock of Uploader interface.
+type MockUploader struct {
+	ctrl     *gomock.Controller
+	recorder *MockUploaderMockRecorder
+}
+
+// MockUploaderMockRecorder is the mock recorder for MockUploader.
+type MockUploaderMockRecorder struct {
+	mock *MockUploader
+}
+
+// NewMockUploader creates a new mock instance.
+func NewMockUploader(ctrl *gomock.Controller) *MockUploader {
+	mock := &MockUploader{ctrl: ctrl}
+	mock.recorder = &MockUploaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUploader) EXPECT() *MockUploaderMockRecorder {
+	return m.recorder
+}
+
+// CheckUploadQuota mocks base method.
+func (m *MockUploader) CheckUploadQuota(ctx context.Context, command commands.CheckUploadQuota) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckUploadQuota", ctx, command)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckUploadQuota indicates an expected call of CheckUploadQuota.
+func (mr *MockUploaderMockRecorder) CheckUploadQuota(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckUploadQuota", reflect.TypeOf((*MockUploader)(nil).CheckUploadQuota), ctx, command)
+}
+
+// GetQuota mocks base method.
+func (m *MockUploader) GetQuota(ctx context.Context, command commands.GetQuota) (*results0.GetQuota, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQuota", ctx, command)
+	ret0, _ := ret[0].(*results0.GetQuota)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQuota indicates an expected call of GetQuota.
+func (mr *MockUploaderMockRecorder) GetQuota(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuota", reflect.TypeOf((*MockUploader)(nil).GetQuota), ctx, command)
+}
+
+// UploadFile mocks base method.
+func (m *MockUploader) UploadFile(ctx context.Context, command commands.UploadFile) (*results0.UploadFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadFile", ctx, command)
+	ret0, _ := ret[0].(*results0.UploadFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadFile indicates an expected call of UploadFile.
+func (mr *MockUploaderMockRecorder) UploadFile(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadFile", reflect.TypeOf((*MockUploader)(nil).UploadFile), ctx, command)
+}
+
+// MockRemoteFetcher is a mock of RemoteFetcher interface.
+type MockRemoteFetcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockRemoteFetcherMockRecorder
+}
+
+// MockRemoteFetcherMockRecorder is the mock recorder for MockRemoteFetcher.
+type MockRemoteFetcherMockRecorder struct {
+	mock *MockRemoteFetcher
+}
+
+// NewMockRemoteFetcher creates a new mock instance.
+func NewMockRemoteFetcher(ctrl *gomock.Controller) *MockRemoteFetcher {
+	mock := &MockRemoteFetcher{ctrl: ctrl}
+	mock.recorder = &MockRemoteFetcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRemoteFetcher) EXPECT() *MockRemoteFetcherMockRecorder {
+	return m.recorder
+}
+
+// Fetch mocks base method.
+func (m *MockRemoteFetcher) Fetch(ctx context.Context, rawURL string) (*results.RemoteFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fetch", ctx, rawURL)
+	ret0, _ := ret[0].(*results.RemoteFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Fetch indicates an expected call of Fetch.
+func (mr *MockRemoteFetcherMockRecorder) Fetch(ctx, rawURL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fetch", reflect.TypeOf((*MockRemoteFetcher)(nil).Fetch), ctx, rawURL)
+}
@@ -10,6 +10,7 @@ import (
 	entities "expire-share/internal/domain/entities"
 	tx "expire-share/internal/domain/interfaces/tx"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -37,6 +38,36 @@ func (m *MockFileRepo) EXPECT() *MockFileRepoMockRecorder {
 	return m.recorder
 }
 
+// AcquireBlobTx mocks base method.
+func (m *MockFileRepo) AcquireBlobTx(ctx context.Context, tx tx.Tx, blob entities.Blob) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcquireBlobTx", ctx, tx, blob)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcquireBlobTx indicates an expected call of AcquireBlobTx.
+func (mr *MockFileRepoMockRecorder) AcquireBlobTx(ctx, tx, blob interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireBlobTx", reflect.TypeOf((*MockFileRepo)(nil).AcquireBlobTx), ctx, tx, blob)
+}
+
+// AddFailedPasswordAttemptTx mocks base method.
+func (m *MockFileRepo) AddFailedPasswordAttemptTx(ctx context.Context, tx tx.Tx, fileID int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddFailedPasswordAttemptTx", ctx, tx, fileID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddFailedPasswordAttemptTx indicates an expected call of AddFailedPasswordAttemptTx.
+func (mr *MockFileRepoMockRecorder) AddFailedPasswordAttemptTx(ctx, tx, fileID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFailedPasswordAttemptTx", reflect.TypeOf((*MockFileRepo)(nil).AddFailedPasswordAttemptTx), ctx, tx, fileID)
+}
+
 // AddFileTx mocks base method.
 func (m *MockFileRepo) AddFileTx(ctx context.Context, tx tx.Tx, command commands.AddFile) (int64, error) {
 	m.ctrl.T.Helper()
@@ -52,6 +83,35 @@ func (mr *MockFileRepoMockRecorder) AddFileTx(ctx, tx, command interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFileTx", reflect.TypeOf((*MockFileRepo)(nil).AddFileTx), ctx, tx, command)
 }
 
+// AddReservationSentTx mocks base method.
+func (m *MockFileRepo) AddReservationSentTx(ctx context.Context, tx tx.Tx, id string, n int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddReservationSentTx", ctx, tx, id, n)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddReservationSentTx indicates an expected call of AddReservationSentTx.
+func (mr *MockFileRepoMockRecorder) AddReservationSentTx(ctx, tx, id, n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddReservationSentTx", reflect.TypeOf((*MockFileRepo)(nil).AddReservationSentTx), ctx, tx, id, n)
+}
+
+// AddReservationTx mocks base method.
+func (m *MockFileRepo) AddReservationTx(ctx context.Context, tx tx.Tx, reservation entities.DownloadReservation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddReservationTx", ctx, tx, reservation)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddReservationTx indicates an expected call of AddReservationTx.
+func (mr *MockFileRepoMockRecorder) AddReservationTx(ctx, tx, reservation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddReservationTx", reflect.TypeOf((*MockFileRepo)(nil).AddReservationTx), ctx, tx, reservation)
+}
+
 // BeginTx mocks base method.
 func (m *MockFileRepo) BeginTx(ctx context.Context) (tx.Tx, error) {
 	m.ctrl.T.Helper()
@@ -67,19 +127,18 @@ func (mr *MockFileRepoMockRecorder) BeginTx(ctx interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTx", reflect.TypeOf((*MockFileRepo)(nil).BeginTx), ctx)
 }
 
-// CountByUserID mocks base method.
-func (m *MockFileRepo) CountByUserID(ctx context.Context, userID int64) (int, error) {
+// ConfirmReservationTx mocks base method.
+func (m *MockFileRepo) ConfirmReservationTx(ctx context.Context, tx tx.Tx, id string, expiresAt time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CountByUserID", ctx, userID)
-	ret0, _ := ret[0].(int)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "ConfirmReservationTx", ctx, tx, id, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// CountByUserID indicates an expected call of CountByUserID.
-func (mr *MockFileRepoMockRecorder) CountByUserID(ctx, userID interface{}) *gomock.Call {
+// ConfirmReservationTx indicates an expected call of ConfirmReservationTx.
+func (mr *MockFileRepoMockRecorder) ConfirmReservationTx(ctx, tx, id, expiresAt interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByUserID", reflect.TypeOf((*MockFileRepo)(nil).CountByUserID), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmReservationTx", reflect.TypeOf((*MockFileRepo)(nil).ConfirmReservationTx), ctx, tx, id, expiresAt)
 }
 
 // DecrementDownloadsByAliasTx mocks base method.
@@ -126,6 +185,35 @@ func (mr *MockFileRepoMockRecorder) DeleteFileTx(ctx, tx, alias interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFileTx", reflect.TypeOf((*MockFileRepo)(nil).DeleteFileTx), ctx, tx, alias)
 }
 
+// DeletePendingFileTx mocks base method.
+func (m *MockFileRepo) DeletePendingFileTx(ctx context.Context, tx tx.Tx, alias string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePendingFileTx", ctx, tx, alias)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePendingFileTx indicates an expected call of DeletePendingFileTx.
+func (mr *MockFileRepoMockRecorder) DeletePendingFileTx(ctx, tx, alias interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePendingFileTx", reflect.TypeOf((*MockFileRepo)(nil).DeletePendingFileTx), ctx, tx, alias)
+}
+
+// DeleteUnreferencedBlobsTx mocks base method.
+func (m *MockFileRepo) DeleteUnreferencedBlobsTx(ctx context.Context, tx tx.Tx, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUnreferencedBlobsTx", ctx, tx, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUnreferencedBlobsTx indicates an expected call of DeleteUnreferencedBlobsTx.
+func (mr *MockFileRepoMockRecorder) DeleteUnreferencedBlobsTx(ctx, tx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUnreferencedBlobsTx", reflect.TypeOf((*MockFileRepo)(nil).DeleteUnreferencedBlobsTx), ctx, tx, limit)
+}
+
 // GetFileByAlias mocks base method.
 func (m *MockFileRepo) GetFileByAlias(ctx context.Context, alias string) (*entities.File, error) {
 	m.ctrl.T.Helper()
@@ -140,3 +228,136 @@ func (mr *MockFileRepoMockRecorder) GetFileByAlias(ctx, alias interface{}) *gomo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileByAlias", reflect.TypeOf((*MockFileRepo)(nil).GetFileByAlias), ctx, alias)
 }
+
+// GetFileMembers mocks base method.
+func (m *MockFileRepo) GetFileMembers(ctx context.Context, fileID int64) ([]entities.FileMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileMembers", ctx, fileID)
+	ret0, _ := ret[0].([]entities.FileMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFileMembers indicates an expected call of GetFileMembers.
+func (mr *MockFileRepoMockRecorder) GetFileMembers(ctx, fileID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileMembers", reflect.TypeOf((*MockFileRepo)(nil).GetFileMembers), ctx, fileID)
+}
+
+// GetReservation mocks base method.
+func (m *MockFileRepo) GetReservation(ctx context.Context, id string) (*entities.DownloadReservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReservation", ctx, id)
+	ret0, _ := ret[0].(*entities.DownloadReservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReservation indicates an expected call of GetReservation.
+func (mr *MockFileRepoMockRecorder) GetReservation(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReservation", reflect.TypeOf((*MockFileRepo)(nil).GetReservation), ctx, id)
+}
+
+// GetUsageByGuestIP mocks base method.
+func (m *MockFileRepo) GetUsageByGuestIP(ctx context.Context, ip string) (entities.StorageUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsageByGuestIP", ctx, ip)
+	ret0, _ := ret[0].(entities.StorageUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsageByGuestIP indicates an expected call of GetUsageByGuestIP.
+func (mr *MockFileRepoMockRecorder) GetUsageByGuestIP(ctx, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsageByGuestIP", reflect.TypeOf((*MockFileRepo)(nil).GetUsageByGuestIP), ctx, ip)
+}
+
+// GetUsageByUserID mocks base method.
+func (m *MockFileRepo) GetUsageByUserID(ctx context.Context, userID int64) (entities.StorageUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsageByUserID", ctx, userID)
+	ret0, _ := ret[0].(entities.StorageUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsageByUserID indicates an expected call of GetUsageByUserID.
+func (mr *MockFileRepoMockRecorder) GetUsageByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsageByUserID", reflect.TypeOf((*MockFileRepo)(nil).GetUsageByUserID), ctx, userID)
+}
+
+// ListFilesByUserID mocks base method.
+func (m *MockFileRepo) ListFilesByUserID(ctx context.Context, query commands.ListUserFiles) ([]entities.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFilesByUserID", ctx, query)
+	ret0, _ := ret[0].([]entities.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFilesByUserID indicates an expected call of ListFilesByUserID.
+func (mr *MockFileRepoMockRecorder) ListFilesByUserID(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFilesByUserID", reflect.TypeOf((*MockFileRepo)(nil).ListFilesByUserID), ctx, query)
+}
+
+// PromoteFileTx mocks base method.
+func (m *MockFileRepo) PromoteFileTx(ctx context.Context, tx tx.Tx, command commands.PromoteFile) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PromoteFileTx", ctx, tx, command)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PromoteFileTx indicates an expected call of PromoteFileTx.
+func (mr *MockFileRepoMockRecorder) PromoteFileTx(ctx, tx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PromoteFileTx", reflect.TypeOf((*MockFileRepo)(nil).PromoteFileTx), ctx, tx, command)
+}
+
+// ReleaseExpiredReservationsTx mocks base method.
+func (m *MockFileRepo) ReleaseExpiredReservationsTx(ctx context.Context, tx tx.Tx, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseExpiredReservationsTx", ctx, tx, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseExpiredReservationsTx indicates an expected call of ReleaseExpiredReservationsTx.
+func (mr *MockFileRepoMockRecorder) ReleaseExpiredReservationsTx(ctx, tx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseExpiredReservationsTx", reflect.TypeOf((*MockFileRepo)(nil).ReleaseExpiredReservationsTx), ctx, tx, limit)
+}
+
+// ReleaseReservationTx mocks base method.
+func (m *MockFileRepo) ReleaseReservationTx(ctx context.Context, tx tx.Tx, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseReservationTx", ctx, tx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseReservationTx indicates an expected call of ReleaseReservationTx.
+func (mr *MockFileRepoMockRecorder) ReleaseReservationTx(ctx, tx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseReservationTx", reflect.TypeOf((*MockFileRepo)(nil).ReleaseReservationTx), ctx, tx, id)
+}
+
+// UpdateFileTx mocks base method.
+func (m *MockFileRepo) UpdateFileTx(ctx context.Context, tx tx.Tx, command commands.UpdateFileInfo) (int16, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFileTx", ctx, tx, command)
+	ret0, _ := ret[0].(int16)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateFileTx indicates an expected call of UpdateFileTx.
+func (mr *MockFileRepoMockRecorder) UpdateFileTx(ctx, tx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFileTx", reflect.TypeOf((*MockFileRepo)(nil).UpdateFileTx), ctx, tx, command)
+}
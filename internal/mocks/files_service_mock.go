@@ -0,0 +1,88 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/services/files/service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	entities "expire-share/internal/domain/entities"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAliasGenerator is a mock of AliasGenerator interface.
+type MockAliasGenerator struct {
+	ctrl     *gomock.Controller
+	recorder *MockAliasGeneratorMockRecorder
+}
+
+// MockAliasGeneratorMockRecorder is the mock recorder for MockAliasGenerator.
+type MockAliasGeneratorMockRecorder struct {
+	mock *MockAliasGenerator
+}
+
+// NewMockAliasGenerator creates a new mock instance.
+func NewMockAliasGenerator(ctrl *gomock.Controller) *MockAliasGenerator {
+	mock := &MockAliasGenerator{ctrl: ctrl}
+	mock.recorder = &MockAliasGeneratorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAliasGenerator) EXPECT() *MockAliasGeneratorMockRecorder {
+	return m.recorder
+}
+
+// Gen mocks base method.
+func (m *MockAliasGenerator) Gen(extra int16) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Gen", extra)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Gen indicates an expected call of Gen.
+func (mr *MockAliasGeneratorMockRecorder) Gen(extra interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Gen", reflect.TypeOf((*MockAliasGenerator)(nil).Gen), extra)
+}
+
+// MockLockNotifier is a mock of LockNotifier interface.
+type MockLockNotifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockLockNotifierMockRecorder
+}
+
+// MockLockNotifierMockRecorder is the mock recorder for MockLockNotifier.
+type MockLockNotifierMockRecorder struct {
+	mock *MockLockNotifier
+}
+
+// NewMockLockNotifier creates a new mock instance.
+func NewMockLockNotifier(ctrl *gomock.Controller) *MockLockNotifier {
+	mock := &MockLockNotifier{ctrl: ctrl}
+	mock.recorder = &MockLockNotifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLockNotifier) EXPECT() *MockLockNotifierMockRecorder {
+	return m.recorder
+}
+
+// FileLocked mocks base method.
+func (m *MockLockNotifier) FileLocked(ctx context.Context, file entities.File, lockedUntil time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FileLocked", ctx, file, lockedUntil)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FileLocked indicates an expected call of FileLocked.
+func (mr *MockLockNotifierMockRecorder) FileLocked(ctx, file, lockedUntil interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FileLocked", reflect.TypeOf((*MockLockNotifier)(nil).FileLocked), ctx, file, lockedUntil)
+}
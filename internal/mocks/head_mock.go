@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/delivery/handlers/api/uploads/head/head.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	commands "expire-share/internal/domain/dto/uploads/commands"
+	results "expire-share/internal/domain/dto/uploads/results"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockUploadGetter is a mock of UploadGetter interface.
+type MockUploadGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockUploadGetterMockRecorder
+}
+
+// MockUploadGetterMockRecorder is the mock recorder for MockUploadGetter.
+type MockUploadGetterMockRecorder struct {
+	mock *MockUploadGetter
+}
+
+// NewMockUploadGetter creates a new mock instance.
+func NewMockUploadGetter(ctrl *gomock.Controller) *MockUploadGetter {
+	mock := &MockUploadGetter{ctrl: ctrl}
+	mock.recorder = &MockUploadGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUploadGetter) EXPECT() *MockUploadGetterMockRecorder {
+	return m.recorder
+}
+
+// GetUpload mocks base method.
+func (m *MockUploadGetter) GetUpload(ctx context.Context, command commands.GetUpload) (*results.GetUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUpload", ctx, command)
+	ret0, _ := ret[0].(*results.GetUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUpload indicates an expected call of GetUpload.
+func (mr *MockUploadGetterMockRecorder) GetUpload(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpload", reflect.TypeOf((*MockUploadGetter)(nil).GetUpload), ctx, command)
+}
@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/delivery/handlers/download/landing.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	commands "expire-share/internal/domain/dto/files/commands"
+	results "expire-share/internal/domain/dto/files/results"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockShareGetter is a mock of ShareGetter interface.
+type MockShareGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockShareGetterMockRecorder
+}
+
+// MockShareGetterMockRecorder is the mock recorder for MockShareGetter.
+type MockShareGetterMockRecorder struct {
+	mock *MockShareGetter
+}
+
+// NewMockShareGetter creates a new mock instance.
+func NewMockShareGetter(ctrl *gomock.Controller) *MockShareGetter {
+	mock := &MockShareGetter{ctrl: ctrl}
+	mock.recorder = &MockShareGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockShareGetter) EXPECT() *MockShareGetterMockRecorder {
+	return m.recorder
+}
+
+// GetShare mocks base method.
+func (m *MockShareGetter) GetShare(ctx context.Context, command commands.GetShare) (*results.GetShare, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShare", ctx, command)
+	ret0, _ := ret[0].(*results.GetShare)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShare indicates an expected call of GetShare.
+func (mr *MockShareGetterMockRecorder) GetShare(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShare", reflect.TypeOf((*MockShareGetter)(nil).GetShare), ctx, command)
+}
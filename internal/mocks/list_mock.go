@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/delivery/handlers/api/files/list/list.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	commands "expire-share/internal/domain/dto/files/commands"
+	results "expire-share/internal/domain/dto/files/results"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockFileLister is a mock of FileLister interface.
+type MockFileLister struct {
+	ctrl     *gomock.Controller
+	recorder *MockFileListerMockRecorder
+}
+
+// MockFileListerMockRecorder is the mock recorder for MockFileLister.
+type MockFileListerMockRecorder struct {
+	mock *MockFileLister
+}
+
+// NewMockFileLister creates a new mock instance.
+func NewMockFileLister(ctrl *gomock.Controller) *MockFileLister {
+	mock := &MockFileLister{ctrl: ctrl}
+	mock.recorder = &MockFileListerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFileLister) EXPECT() *MockFileListerMockRecorder {
+	return m.recorder
+}
+
+// ListFiles mocks base method.
+func (m *MockFileLister) ListFiles(ctx context.Context, command commands.ListFiles) (*results.ListFiles, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFiles", ctx, command)
+	ret0, _ := ret[0].(*results.ListFiles)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFiles indicates an expected call of ListFiles.
+func (mr *MockFileListerMockRecorder) ListFiles(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFiles", reflect.TypeOf((*MockFileLister)(nil).ListFiles), ctx, command)
+}
@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/delivery/handlers/api/paste/paste.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	commands "expire-share/internal/domain/dto/files/commands"
+	results "expire-share/internal/domain/dto/files/results"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTextPaster is a mock of TextPaster interface.
+type MockTextPaster struct {
+	ctrl     *gomock.Controller
+	recorder *MockTextPasterMockRecorder
+}
+
+// MockTextPasterMockRecorder is the mock recorder for MockTextPaster.
+type MockTextPasterMockRecorder struct {
+	mock *MockTextPaster
+}
+
+// NewMockTextPaster creates a new mock instance.
+func NewMockTextPaster(ctrl *gomock.Controller) *MockTextPaster {
+	mock := &MockTextPaster{ctrl: ctrl}
+	mock.recorder = &MockTextPasterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTextPaster) EXPECT() *MockTextPasterMockRecorder {
+	return m.recorder
+}
+
+// Paste mocks base method.
+func (m *MockTextPaster) Paste(ctx context.Context, command commands.Paste) (*results.UploadFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Paste", ctx, command)
+	ret0, _ := ret[0].(*results.UploadFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Paste indicates an expected call of Paste.
+func (mr *MockTextPasterMockRecorder) Paste(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Paste", reflect.TypeOf((*MockTextPaster)(nil).Paste), ctx, command)
+}
@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/delivery/handlers/api/uploads/patch/patch.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	commands "expire-share/internal/domain/dto/uploads/commands"
+	results "expire-share/internal/domain/dto/uploads/results"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockChunkWriter is a mock of ChunkWriter interface.
+type MockChunkWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockChunkWriterMockRecorder
+}
+
+// MockChunkWriterMockRecorder is the mock recorder for MockChunkWriter.
+type MockChunkWriterMockRecorder struct {
+	mock *MockChunkWriter
+}
+
+// NewMockChunkWriter creates a new mock instance.
+func NewMockChunkWriter(ctrl *gomock.Controller) *MockChunkWriter {
+	mock := &MockChunkWriter{ctrl: ctrl}
+	mock.recorder = &MockChunkWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockChunkWriter) EXPECT() *MockChunkWriterMockRecorder {
+	return m.recorder
+}
+
+// WriteChunk mocks base method.
+func (m *MockChunkWriter) WriteChunk(ctx context.Context, command commands.WriteChunk) (*results.WriteChunk, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteChunk", ctx, command)
+	ret0, _ := ret[0].(*results.WriteChunk)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WriteChunk indicates an expected call of WriteChunk.
+func (mr *MockChunkWriterMockRecorder) WriteChunk(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteChunk", reflect.TypeOf((*MockChunkWriter)(nil).WriteChunk), ctx, command)
+}
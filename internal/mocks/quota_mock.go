@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/delivery/handlers/api/quota/quota.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	commands "expire-share/internal/domain/dto/files/commands"
+	results "expire-share/internal/domain/dto/files/results"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockQuotaGetter is a mock of QuotaGetter interface.
+type MockQuotaGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockQuotaGetterMockRecorder
+}
+
+// MockQuotaGetterMockRecorder is the mock recorder for MockQuotaGetter.
+type MockQuotaGetterMockRecorder struct {
+	mock *MockQuotaGetter
+}
+
+// NewMockQuotaGetter creates a new mock instance.
+func NewMockQuotaGetter(ctrl *gomock.Controller) *MockQuotaGetter {
+	mock := &MockQuotaGetter{ctrl: ctrl}
+	mock.recorder = &MockQuotaGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQuotaGetter) EXPECT() *MockQuotaGetterMockRecorder {
+	return m.recorder
+}
+
+// GetQuota mocks base method.
+func (m *MockQuotaGetter) GetQuota(ctx context.Context, command commands.GetQuota) (*results.GetQuota, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQuota", ctx, command)
+	ret0, _ := ret[0].(*results.GetQuota)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQuota indicates an expected call of GetQuota.
+func (mr *MockQuotaGetterMockRecorder) GetQuota(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuota", reflect.TypeOf((*MockQuotaGetter)(nil).GetQuota), ctx, command)
+}
@@ -1,5 +1,66 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: internal/services/files/service.go
+// Source: internal/services/uploads/service.go
 
 // Package mocks is a generated GoMock package.
 package mocks
+
+import (
+	context "context"
+	commands "expire-share/internal/domain/dto/files/commands"
+	results "expire-share/internal/domain/dto/files/results"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockFileService is a mock of FileService interface.
+type MockFileService struct {
+	ctrl     *gomock.Controller
+	recorder *MockFileServiceMockRecorder
+}
+
+// MockFileServiceMockRecorder is the mock recorder for MockFileService.
+type MockFileServiceMockRecorder struct {
+	mock *MockFileService
+}
+
+// NewMockFileService creates a new mock instance.
+func NewMockFileService(ctrl *gomock.Controller) *MockFileService {
+	mock := &MockFileService{ctrl: ctrl}
+	mock.recorder = &MockFileServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFileService) EXPECT() *MockFileServiceMockRecorder {
+	return m.recorder
+}
+
+// CheckUploadQuota mocks base method.
+func (m *MockFileService) CheckUploadQuota(ctx context.Context, command commands.CheckUploadQuota) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckUploadQuota", ctx, command)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckUploadQuota indicates an expected call of CheckUploadQuota.
+func (mr *MockFileServiceMockRecorder) CheckUploadQuota(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckUploadQuota", reflect.TypeOf((*MockFileService)(nil).CheckUploadQuota), ctx, command)
+}
+
+// UploadFile mocks base method.
+func (m *MockFileService) UploadFile(ctx context.Context, command commands.UploadFile) (*results.UploadFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadFile", ctx, command)
+	ret0, _ := ret[0].(*results.UploadFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadFile indicates an expected call of UploadFile.
+func (mr *MockFileServiceMockRecorder) UploadFile(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadFile", reflect.TypeOf((*MockFileService)(nil).UploadFile), ctx, command)
+}
@@ -0,0 +1,154 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/domain/interfaces/storage/staging.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	entities "expire-share/internal/domain/entities"
+	io "io"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockStaging is a mock of Staging interface.
+type MockStaging struct {
+	ctrl     *gomock.Controller
+	recorder *MockStagingMockRecorder
+}
+
+// MockStagingMockRecorder is the mock recorder for MockStaging.
+type MockStagingMockRecorder struct {
+	mock *MockStaging
+}
+
+// NewMockStaging creates a new mock instance.
+func NewMockStaging(ctrl *gomock.Controller) *MockStaging {
+	mock := &MockStaging{ctrl: ctrl}
+	mock.recorder = &MockStagingMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStaging) EXPECT() *MockStagingMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockStaging) Append(ctx context.Context, id string, offset int64, chunk io.Reader) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", ctx, id, offset, chunk)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockStagingMockRecorder) Append(ctx, id, offset, chunk interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockStaging)(nil).Append), ctx, id, offset, chunk)
+}
+
+// Claim mocks base method.
+func (m *MockStaging) Claim(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockStagingMockRecorder) Claim(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockStaging)(nil).Claim), ctx, id)
+}
+
+// Create mocks base method.
+func (m *MockStaging) Create(ctx context.Context, upload entities.Upload) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, upload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockStagingMockRecorder) Create(ctx, upload interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockStaging)(nil).Create), ctx, upload)
+}
+
+// Delete mocks base method.
+func (m *MockStaging) Delete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockStagingMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockStaging)(nil).Delete), ctx, id)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockStaging) DeleteExpired(ctx context.Context, now time.Time) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx, now)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockStagingMockRecorder) DeleteExpired(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockStaging)(nil).DeleteExpired), ctx, now)
+}
+
+// Get mocks base method.
+func (m *MockStaging) Get(ctx context.Context, id string) (*entities.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*entities.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockStagingMockRecorder) Get(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockStaging)(nil).Get), ctx, id)
+}
+
+// Open mocks base method.
+func (m *MockStaging) Open(ctx context.Context, id string) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Open", ctx, id)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Open indicates an expected call of Open.
+func (mr *MockStagingMockRecorder) Open(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockStaging)(nil).Open), ctx, id)
+}
+
+// Unclaim mocks base method.
+func (m *MockStaging) Unclaim(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unclaim", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unclaim indicates an expected call of Unclaim.
+func (mr *MockStagingMockRecorder) Unclaim(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unclaim", reflect.TypeOf((*MockStaging)(nil).Unclaim), ctx, id)
+}
@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/delivery/handlers/api/uploads/terminate/terminate.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	commands "expire-share/internal/domain/dto/uploads/commands"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockUploadTerminator is a mock of UploadTerminator interface.
+type MockUploadTerminator struct {
+	ctrl     *gomock.Controller
+	recorder *MockUploadTerminatorMockRecorder
+}
+
+// MockUploadTerminatorMockRecorder is the mock recorder for MockUploadTerminator.
+type MockUploadTerminatorMockRecorder struct {
+	mock *MockUploadTerminator
+}
+
+// NewMockUploadTerminator creates a new mock instance.
+func NewMockUploadTerminator(ctrl *gomock.Controller) *MockUploadTerminator {
+	mock := &MockUploadTerminator{ctrl: ctrl}
+	mock.recorder = &MockUploadTerminatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUploadTerminator) EXPECT() *MockUploadTerminatorMockRecorder {
+	return m.recorder
+}
+
+// TerminateUpload mocks base method.
+func (m *MockUploadTerminator) TerminateUpload(ctx context.Context, command commands.TerminateUpload) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TerminateUpload", ctx, command)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TerminateUpload indicates an expected call of TerminateUpload.
+func (mr *MockUploadTerminatorMockRecorder) TerminateUpload(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TerminateUpload", reflect.TypeOf((*MockUploadTerminator)(nil).TerminateUpload), ctx, command)
+}
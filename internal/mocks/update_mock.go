@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/delivery/handlers/api/files/update/update.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	commands "expire-share/internal/domain/dto/files/commands"
+	results "expire-share/internal/domain/dto/files/results"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockFileUpdater is a mock of FileUpdater interface.
+type MockFileUpdater struct {
+	ctrl     *gomock.Controller
+	recorder *MockFileUpdaterMockRecorder
+}
+
+// MockFileUpdaterMockRecorder is the mock recorder for MockFileUpdater.
+type MockFileUpdaterMockRecorder struct {
+	mock *MockFileUpdater
+}
+
+// NewMockFileUpdater creates a new mock instance.
+func NewMockFileUpdater(ctrl *gomock.Controller) *MockFileUpdater {
+	mock := &MockFileUpdater{ctrl: ctrl}
+	mock.recorder = &MockFileUpdaterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFileUpdater) EXPECT() *MockFileUpdaterMockRecorder {
+	return m.recorder
+}
+
+// UpdateFile mocks base method.
+func (m *MockFileUpdater) UpdateFile(ctx context.Context, command commands.UpdateFile) (*results.GetFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFile", ctx, command)
+	ret0, _ := ret[0].(*results.GetFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateFile indicates an expected call of UpdateFile.
+func (mr *MockFileUpdaterMockRecorder) UpdateFile(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFile", reflect.TypeOf((*MockFileUpdater)(nil).UpdateFile), ctx, command)
+}
@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	commands "expire-share/internal/domain/dto/files/commands"
+	results "expire-share/internal/domain/dto/files/results"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -36,10 +37,10 @@ func (m *MockFileUploader) EXPECT() *MockFileUploaderMockRecorder {
 }
 
 // UploadFile mocks base method.
-func (m *MockFileUploader) UploadFile(ctx context.Context, command commands.UploadFile) (string, error) {
+func (m *MockFileUploader) UploadFile(ctx context.Context, command commands.UploadFile) (*results.UploadFile, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UploadFile", ctx, command)
-	ret0, _ := ret[0].(string)
+	ret0, _ := ret[0].(*results.UploadFile)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -0,0 +1,103 @@
+package fetches
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"expire-share/internal/domain/dto/fetches/commands"
+	"expire-share/internal/domain/dto/fetches/results"
+	fileCommands "expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+const fetchIDBytes = 16
+
+// CreateFetch starts a remote upload of the url. Quotas known before the
+// file is fetched are checked right away, the rest are reported in the
+// status of the fetch
+func (s *Service) CreateFetch(ctx context.Context, command commands.CreateFetch) (*results.CreateFetch, error) {
+	const fn = "services.fetches.Service.CreateFetch"
+	log := s.log.With(slog.String("fn", fn))
+
+	target, err := url.Parse(command.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		log.Info("invalid remote url", slog.Int64("user_id", command.UserID))
+		return nil, domainErrors.ErrRemoteURLNotAllowed
+	}
+
+	err = s.uploader.CheckUploadQuota(ctx, fileCommands.CheckUploadQuota{
+		MaxDownloads:       command.MaxDownloads,
+		TTL:                command.TTL,
+		RequestingUserInfo: command.RequestingUserInfo,
+	})
+
+	if err != nil {
+		log.Info("upload quota check failed", sl.Error(err), slog.Int64("user_id", command.UserID))
+		return nil, fmt.Errorf("%s: access denied: %w", fn, err)
+	}
+
+	idBytes := make([]byte, fetchIDBytes)
+	if _, err := rand.Read(idBytes); err != nil {
+		log.Error("failed to generate fetch id", sl.Error(err))
+		return nil, fmt.Errorf("%s: failed to generate fetch id: %w", fn, err)
+	}
+
+	fetch := &entities.Fetch{
+		ID:        hex.EncodeToString(idBytes),
+		URL:       target.Redacted(),
+		Filename:  command.Filename,
+		Status:    entities.FetchPending,
+		Size:      -1,
+		UserID:    command.UserID,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.add(fetch); err != nil {
+		log.Info("too many remote uploads", slog.Int64("user_id", command.UserID))
+		return nil, err
+	}
+
+	// the fetch is changed by run under lock from now on
+	result := &results.CreateFetch{
+		ID:     fetch.ID,
+		Status: fetch.Status,
+	}
+
+	log.Info("remote upload started", slog.String("fetch_id", fetch.ID), slog.String("url", fetch.URL))
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(result.ID, command)
+	}()
+
+	return result, nil
+}
+
+// add keeps the fetch unless the user runs too many of them
+func (s *Service) add(fetch *entities.Fetch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(fetch.CreatedAt)
+
+	active := 0
+	for _, other := range s.fetches {
+		if other.UserID == fetch.UserID && !other.Finished() {
+			active++
+		}
+	}
+
+	if active >= s.cfg.MaxActiveFetches {
+		return domainErrors.ErrTooManyFetches
+	}
+
+	s.fetches[fetch.ID] = fetch
+	return nil
+}
@@ -0,0 +1,297 @@
+package fetches
+
+import (
+	"context"
+	"expire-share/internal/config"
+	"expire-share/internal/domain/dto/fetches/commands"
+	fileCommands "expire-share/internal/domain/dto/files/commands"
+	fileResults "expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/infrastructure/remote"
+	"expire-share/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestConfig() config.Config {
+	return config.Config{
+		Storage: config.Storage{
+			MaxFileSizeInBytes: 1024,
+		},
+		Uploads: config.Uploads{
+			RemoteUploads: config.RemoteUploads{
+				FetchTimeout:     time.Minute,
+				MaxRedirects:     3,
+				AllowedPrefixes:  []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+				MaxActiveFetches: 1,
+				FetchStatusTTL:   time.Hour,
+			},
+		},
+	}
+}
+
+func newSourceServer(release <-chan struct{}) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/build.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("artifact"))
+	})
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		// flushing sends the body chunked, so its size is unknown
+		for range 4 {
+			_, _ = w.Write([]byte(strings.Repeat("a", 100)))
+			w.(http.Flusher).Flush()
+		}
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		_, _ = w.Write([]byte("done"))
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+
+	return httptest.NewServer(mux)
+}
+
+func TestService_CreateFetch(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := newTestConfig()
+
+	release := make(chan struct{})
+	server := newSourceServer(release)
+	defer server.Close()
+	defer close(release)
+
+	user := fileCommands.RequestingUserInfo{
+		UserID: 1,
+		Roles:  []entities.UserRole{entities.RoleUser},
+	}
+
+	command := commands.CreateFetch{
+		URL:                server.URL + "/build.tar.gz",
+		MaxDownloads:       3,
+		Password:           "secret",
+		TTL:                time.Hour,
+		RequestingUserInfo: user,
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUploader := mocks.NewMockUploader(ctrl)
+		mockUploader.EXPECT().CheckUploadQuota(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, command fileCommands.CheckUploadQuota) error {
+				require.Equal(t, int16(3), command.MaxDownloads)
+				require.Equal(t, time.Hour, command.TTL)
+				return nil
+			})
+		mockUploader.EXPECT().UploadFile(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, command fileCommands.UploadFile) (*fileResults.UploadFile, error) {
+				content, err := io.ReadAll(command.File)
+				require.NoError(t, err)
+				require.Equal(t, "artifact", string(content))
+				require.Equal(t, int64(8), command.FileSize)
+				require.Equal(t, "build.tar.gz", command.Filename)
+				require.Equal(t, "secret", command.Password)
+				require.Equal(t, int64(1), command.UserID)
+				return &fileResults.UploadFile{Alias: "abc123"}, nil
+			})
+
+		service := New(mockUploader, remote.NewClient(cfg.RemoteUploads), log, cfg)
+		created, err := service.CreateFetch(context.Background(), command)
+		require.NoError(t, err)
+		require.Len(t, created.ID, fetchIDBytes*2)
+		require.Equal(t, entities.FetchPending, created.Status)
+
+		service.wg.Wait()
+
+		fetch, err := service.GetFetch(context.Background(), commands.GetFetch{ID: created.ID, RequestingUserInfo: user})
+		require.NoError(t, err)
+		require.Equal(t, entities.FetchCompleted, fetch.Status)
+		require.Equal(t, "abc123", fetch.Alias)
+		require.Equal(t, "build.tar.gz", fetch.Filename)
+		require.Equal(t, int64(8), fetch.Size)
+		require.Equal(t, int64(8), fetch.Received)
+		require.Empty(t, fetch.Error)
+		require.False(t, fetch.FinishedAt.IsZero())
+	})
+
+	t.Run("file of unknown size is cut off at quota", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUploader := mocks.NewMockUploader(ctrl)
+		mockUploader.EXPECT().CheckUploadQuota(gomock.Any(), gomock.Any()).Return(nil)
+		mockUploader.EXPECT().GetQuota(gomock.Any(), gomock.Any()).
+			Return(&fileResults.GetQuota{MaxFileSize: 1024, MaxBytes: 500, UsedBytes: 200}, nil)
+		mockUploader.EXPECT().UploadFile(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, command fileCommands.UploadFile) (*fileResults.UploadFile, error) {
+				require.Zero(t, command.FileSize)
+				_, err := io.ReadAll(command.File)
+				return nil, err
+			})
+
+		service := New(mockUploader, remote.NewClient(cfg.RemoteUploads), log, cfg)
+		created, err := service.CreateFetch(context.Background(), commands.CreateFetch{
+			URL:                server.URL + "/stream",
+			RequestingUserInfo: user,
+		})
+		require.NoError(t, err)
+
+		service.wg.Wait()
+
+		fetch, err := service.GetFetch(context.Background(), commands.GetFetch{ID: created.ID, RequestingUserInfo: user})
+		require.NoError(t, err)
+		require.Equal(t, entities.FetchFailed, fetch.Status)
+		require.Equal(t, domainErrors.ErrFileSizeTooBig.Error(), fetch.Error)
+		require.Equal(t, int64(-1), fetch.Size)
+	})
+
+	t.Run("fetch failure is reported", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			url     string
+			allowed bool
+			message string
+		}{
+			{name: "error status", url: server.URL + "/missing", allowed: true, message: domainErrors.ErrRemoteFetchFailed.Error()},
+			{name: "private address", url: server.URL + "/build.tar.gz", message: domainErrors.ErrRemoteURLNotAllowed.Error()},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				remoteCfg := cfg.RemoteUploads
+				if !test.allowed {
+					remoteCfg.AllowedPrefixes = nil
+				}
+
+				mockUploader := mocks.NewMockUploader(ctrl)
+				mockUploader.EXPECT().CheckUploadQuota(gomock.Any(), gomock.Any()).Return(nil)
+
+				service := New(mockUploader, remote.NewClient(remoteCfg), log, cfg)
+				created, err := service.CreateFetch(context.Background(), commands.CreateFetch{
+					URL:                test.url,
+					RequestingUserInfo: user,
+				})
+				require.NoError(t, err)
+
+				service.wg.Wait()
+
+				fetch, err := service.GetFetch(context.Background(), commands.GetFetch{ID: created.ID, RequestingUserInfo: user})
+				require.NoError(t, err)
+				require.Equal(t, entities.FetchFailed, fetch.Status)
+				require.Equal(t, test.message, fetch.Error)
+			})
+		}
+	})
+
+	t.Run("invalid url", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		service := New(mocks.NewMockUploader(ctrl), mocks.NewMockRemoteFetcher(ctrl), log, cfg)
+
+		for _, rawURL := range []string{"file:///etc/passwd", "ftp://example.com/file", "https://", "not a url"} {
+			_, err := service.CreateFetch(context.Background(), commands.CreateFetch{URL: rawURL, RequestingUserInfo: user})
+			require.ErrorIs(t, err, domainErrors.ErrRemoteURLNotAllowed, rawURL)
+		}
+	})
+
+	t.Run("quota exceeded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUploader := mocks.NewMockUploader(ctrl)
+		mockUploader.EXPECT().CheckUploadQuota(gomock.Any(), gomock.Any()).Return(domainErrors.ErrUploadLimitExceeded)
+
+		service := New(mockUploader, mocks.NewMockRemoteFetcher(ctrl), log, cfg)
+		_, err := service.CreateFetch(context.Background(), command)
+		require.ErrorIs(t, err, domainErrors.ErrUploadLimitExceeded)
+	})
+
+	t.Run("too many active fetches", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		slowRelease := make(chan struct{})
+		slowServer := newSourceServer(slowRelease)
+		defer slowServer.Close()
+
+		mockUploader := mocks.NewMockUploader(ctrl)
+		mockUploader.EXPECT().CheckUploadQuota(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		mockUploader.EXPECT().UploadFile(gomock.Any(), gomock.Any()).
+			Return(&fileResults.UploadFile{Alias: "abc123"}, nil)
+
+		service := New(mockUploader, remote.NewClient(cfg.RemoteUploads), log, cfg)
+
+		slow := commands.CreateFetch{URL: slowServer.URL + "/slow", RequestingUserInfo: user}
+		_, err := service.CreateFetch(context.Background(), slow)
+		require.NoError(t, err)
+
+		_, err = service.CreateFetch(context.Background(), slow)
+		require.ErrorIs(t, err, domainErrors.ErrTooManyFetches)
+
+		close(slowRelease)
+		service.wg.Wait()
+	})
+}
+
+func TestService_GetFetch(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := newTestConfig()
+
+	owner := fileCommands.RequestingUserInfo{UserID: 1, Roles: []entities.UserRole{entities.RoleUser}}
+
+	newService := func(ctrl *gomock.Controller, fetches ...*entities.Fetch) *Service {
+		service := New(mocks.NewMockUploader(ctrl), mocks.NewMockRemoteFetcher(ctrl), log, cfg)
+		for _, fetch := range fetches {
+			service.fetches[fetch.ID] = fetch
+		}
+
+		return service
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		_, err := newService(ctrl).GetFetch(context.Background(), commands.GetFetch{ID: "missing", RequestingUserInfo: owner})
+		require.ErrorIs(t, err, domainErrors.ErrFetchNotFound)
+	})
+
+	t.Run("not owner", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		service := newService(ctrl, &entities.Fetch{ID: "abc", UserID: 2, Status: entities.FetchRunning})
+		_, err := service.GetFetch(context.Background(), commands.GetFetch{ID: "abc", RequestingUserInfo: owner})
+		require.ErrorIs(t, err, domainErrors.ErrForbidden)
+	})
+
+	t.Run("finished fetch expires", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		service := newService(ctrl,
+			&entities.Fetch{ID: "old", UserID: 1, Status: entities.FetchCompleted, FinishedAt: time.Now().Add(-2 * time.Hour)},
+			&entities.Fetch{ID: "running", UserID: 1, Status: entities.FetchRunning, CreatedAt: time.Now().Add(-2 * time.Hour)})
+
+		_, err := service.GetFetch(context.Background(), commands.GetFetch{ID: "old", RequestingUserInfo: owner})
+		require.ErrorIs(t, err, domainErrors.ErrFetchNotFound)
+
+		fetch, err := service.GetFetch(context.Background(), commands.GetFetch{ID: "running", RequestingUserInfo: owner})
+		require.NoError(t, err)
+		require.Equal(t, entities.FetchRunning, fetch.Status)
+	})
+}
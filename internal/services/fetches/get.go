@@ -0,0 +1,34 @@
+package fetches
+
+import (
+	"context"
+	"expire-share/internal/domain/dto/fetches/commands"
+	"expire-share/internal/domain/dto/fetches/results"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"log/slog"
+	"time"
+)
+
+// GetFetch returns the status of a remote upload of the requesting user
+func (s *Service) GetFetch(_ context.Context, command commands.GetFetch) (*results.GetFetch, error) {
+	const fn = "services.fetches.Service.GetFetch"
+	log := s.log.With(slog.String("fn", fn))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(time.Now())
+
+	fetch, ok := s.fetches[command.ID]
+	if !ok {
+		log.Info("fetch not found", slog.String("fetch_id", command.ID))
+		return nil, domainErrors.ErrFetchNotFound
+	}
+
+	if fetch.UserID != command.UserID {
+		log.Info("access denied", slog.String("fetch_id", command.ID), slog.Int64("requesting_user_id", command.UserID))
+		return nil, domainErrors.ErrForbidden
+	}
+
+	return &results.GetFetch{Fetch: *fetch}, nil
+}
@@ -0,0 +1,205 @@
+package fetches
+
+import (
+	"context"
+	"errors"
+	"expire-share/internal/domain/dto/fetches/commands"
+	fileCommands "expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// defaultFilename is used when neither the user nor the remote server
+// named the file
+const defaultFilename = "download"
+
+// reportedErrors are shown in the status of a failed fetch, other errors
+// are only logged
+var reportedErrors = []error{
+	domainErrors.ErrRemoteURLNotAllowed,
+	domainErrors.ErrRemoteFetchFailed,
+	domainErrors.ErrFileSizeTooBig,
+	domainErrors.ErrStorageQuotaExceeded,
+	domainErrors.ErrUploadLimitExceeded,
+	domainErrors.ErrTTLTooLong,
+	domainErrors.ErrTooManyDownloads,
+	domainErrors.ErrAliasTaken,
+	domainErrors.ErrInvalidAlias,
+	domainErrors.ErrVanityAliasForbidden,
+}
+
+// run fetches the file and shares it. It is not bound to the request which
+// started it, the fetch timeout limits it instead
+func (s *Service) run(id string, command commands.CreateFetch) {
+	const fn = "services.fetches.Service.run"
+	log := s.log.With(slog.String("fn", fn), slog.String("fetch_id", id))
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.FetchTimeout)
+	defer cancel()
+
+	alias, err := s.fetch(ctx, id, command)
+
+	s.update(id, func(fetch *entities.Fetch) {
+		fetch.FinishedAt = time.Now()
+		if err != nil {
+			fetch.Status = entities.FetchFailed
+			fetch.Error = failureMessage(err)
+			return
+		}
+
+		fetch.Status = entities.FetchCompleted
+		fetch.Alias = alias
+	})
+
+	if err != nil {
+		if isCtxError(err) || reported(err) != nil {
+			log.Info("remote upload failed", sl.Error(err))
+			return
+		}
+
+		log.Error("remote upload failed", sl.Error(err))
+		return
+	}
+
+	log.Info("remote upload completed", slog.String("alias", alias))
+}
+
+func (s *Service) fetch(ctx context.Context, id string, command commands.CreateFetch) (string, error) {
+	remote, err := s.fetcher.Fetch(ctx, command.URL)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() { _ = remote.Body.Close() }()
+
+	filename := command.Filename
+	if filename == "" {
+		filename = remote.Filename
+	}
+
+	if filename == "" {
+		filename = defaultFilename
+	}
+
+	s.update(id, func(fetch *entities.Fetch) {
+		fetch.Status = entities.FetchRunning
+		fetch.Filename = filename
+		fetch.Size = remote.Size
+	})
+
+	// an announced size is checked against the quota by the upload before
+	// the file is stored, a file of unknown size is cut off at what the
+	// quota leaves
+	limit := remote.Size
+	if limit < 0 {
+		limit, err = s.sizeLimit(ctx, command.RequestingUserInfo)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	body := &progressReader{
+		reader: remote.Body,
+		limit:  limit,
+		progress: func(received int64) {
+			s.update(id, func(fetch *entities.Fetch) { fetch.Received = received })
+		},
+	}
+
+	uploaded, err := s.uploader.UploadFile(ctx, fileCommands.UploadFile{
+		File:               body,
+		FileSize:           max(remote.Size, 0),
+		Filename:           filename,
+		MaxDownloads:       command.MaxDownloads,
+		Password:           command.Password,
+		TTL:                command.TTL,
+		Alias:              command.Alias,
+		RequestingUserInfo: command.RequestingUserInfo,
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return uploaded.Alias, nil
+}
+
+// sizeLimit is the largest file the user may still store. Storage max file
+// size limits every fetch, so a remote server can't send an endless file
+func (s *Service) sizeLimit(ctx context.Context, userInfo fileCommands.RequestingUserInfo) (int64, error) {
+	quota, err := s.uploader.GetQuota(ctx, fileCommands.GetQuota{RequestingUserInfo: userInfo})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get quota: %w", err)
+	}
+
+	limit := s.cfg.MaxFileSizeInBytes
+	if quota.Unlimited {
+		return limit, nil
+	}
+
+	if quota.MaxFileSize > 0 {
+		limit = min(limit, quota.MaxFileSize)
+	}
+
+	if quota.MaxBytes > 0 {
+		limit = min(limit, max(quota.MaxBytes-quota.UsedBytes, 0))
+	}
+
+	return limit, nil
+}
+
+// progressReader reports how much was read and fails once more than limit
+// bytes were read
+type progressReader struct {
+	reader   io.Reader
+	limit    int64
+	read     int64
+	progress func(read int64)
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.reader.Read(p)
+	pr.read += int64(n)
+
+	if pr.read > pr.limit {
+		return n, domainErrors.ErrFileSizeTooBig
+	}
+
+	if n > 0 {
+		pr.progress(pr.read)
+	}
+
+	return n, err
+}
+
+func failureMessage(err error) string {
+	if isCtxError(err) {
+		return "remote upload timed out"
+	}
+
+	if reportedErr := reported(err); reportedErr != nil {
+		return reportedErr.Error()
+	}
+
+	return "internal error"
+}
+
+// reported returns the reported error err is, nil if it is none of them
+func reported(err error) error {
+	for _, reportedErr := range reportedErrors {
+		if errors.Is(err, reportedErr) {
+			return reportedErr
+		}
+	}
+
+	return nil
+}
+
+func isCtxError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
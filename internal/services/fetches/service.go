@@ -0,0 +1,66 @@
+package fetches
+
+import (
+	"context"
+	"expire-share/internal/config"
+	"expire-share/internal/domain/dto/fetches/results"
+	fileCommands "expire-share/internal/domain/dto/files/commands"
+	fileResults "expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/domain/entities"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type Uploader interface {
+	UploadFile(ctx context.Context, command fileCommands.UploadFile) (*fileResults.UploadFile, error)
+	CheckUploadQuota(ctx context.Context, command fileCommands.CheckUploadQuota) error
+	GetQuota(ctx context.Context, command fileCommands.GetQuota) (*fileResults.GetQuota, error)
+}
+
+// RemoteFetcher gets files from remote servers
+type RemoteFetcher interface {
+	Fetch(ctx context.Context, rawURL string) (*results.RemoteFile, error)
+}
+
+// Service runs remote uploads in the background. Their statuses are kept
+// in memory, so they are polled from the replica that accepted the upload
+// and are lost on restart
+type Service struct {
+	uploader Uploader
+	fetcher  RemoteFetcher
+	mu       sync.Mutex
+	fetches  map[string]*entities.Fetch
+	// wg tracks running fetches, so tests can wait for them
+	wg  sync.WaitGroup
+	cfg config.Config
+	log *slog.Logger
+}
+
+func New(uploader Uploader, fetcher RemoteFetcher, log *slog.Logger, cfg config.Config) *Service {
+	return &Service{uploader: uploader,
+		fetcher: fetcher,
+		fetches: make(map[string]*entities.Fetch),
+		log:     log,
+		cfg:     cfg}
+}
+
+// sweep forgets fetches which finished longer than status ttl ago, it
+// must be called with mu held
+func (s *Service) sweep(now time.Time) {
+	for id, fetch := range s.fetches {
+		if fetch.Finished() && now.Sub(fetch.FinishedAt) >= s.cfg.FetchStatusTTL {
+			delete(s.fetches, id)
+		}
+	}
+}
+
+// update changes the fetch under lock
+func (s *Service) update(id string, change func(fetch *entities.Fetch)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if fetch, ok := s.fetches[id]; ok {
+		change(fetch)
+	}
+}
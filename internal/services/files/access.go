@@ -1,38 +1,112 @@
 package files
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"expire-share/internal/domain/dto/files/commands"
 	"expire-share/internal/domain/entities"
 	domainErrors "expire-share/internal/domain/entities/errors"
-
-	"golang.org/x/crypto/bcrypt"
+	"time"
 )
 
-func (fs *Service) checkAccess(fileInfo entities.File, userID int64, roles []entities.UserRole) error {
-	if hasRole(roles, entities.RoleAdmin) {
+func (fs *Service) checkAccess(fileInfo entities.File, user commands.RequestingUserInfo) error {
+	if hasRole(user.Roles, entities.RoleAdmin) {
 		return nil
 	}
 
-	return fs.checkOwner(fileInfo, userID)
+	// all guests share the same user id, only the token tells their files apart
+	if hasRole(user.Roles, entities.RoleGuest) {
+		return checkManagementToken(fileInfo, user.ManagementToken)
+	}
+
+	return fs.checkOwner(fileInfo, user.UserID)
 }
 
-func (fs *Service) checkUploadQuote(uploadedFilesCount int, filesize int64, roles []entities.UserRole) error {
-	if hasRole(roles, entities.RoleAdmin) {
+// roleLimits are upload limits of a role. Zero storage, ttl and
+// downloads limits mean there is no limit
+type roleLimits struct {
+	maxFiles     int
+	maxBytes     int64
+	maxFileSize  int64
+	maxTtl       time.Duration
+	maxDownloads int16
+}
+
+func (fs *Service) limitsFor(roles []entities.UserRole) roleLimits {
+	permissions := fs.cfg.Permissions
+	limits := roleLimits{
+		maxFiles:     permissions.MaxUploadedFileForUser,
+		maxBytes:     permissions.MaxStorageForUserInBytes,
+		maxFileSize:  permissions.MaxFileSizeForUserInBytes,
+		maxTtl:       permissions.MaxTtlForUser,
+		maxDownloads: permissions.MaxDownloadsForUser,
+	}
+
+	if hasRole(roles, entities.RoleGuest) {
+		limits = roleLimits{
+			maxFiles:     permissions.MaxUploadedFileForGuest,
+			maxBytes:     permissions.MaxStorageForGuestInBytes,
+			maxFileSize:  permissions.MaxFileSizeForGuestInBytes,
+			maxTtl:       permissions.MaxTtlForGuest,
+			maxDownloads: permissions.MaxDownloadsForGuest,
+		}
+	}
+
+	if hasRole(roles, entities.RoleVip) {
+		limits = roleLimits{
+			maxFiles:     permissions.MaxUploadedFileForVip,
+			maxBytes:     permissions.MaxStorageForVipInBytes,
+			maxFileSize:  permissions.MaxFileSizeForVipInBytes,
+			maxTtl:       permissions.MaxTtlForVip,
+			maxDownloads: permissions.MaxDownloadsForVip,
+		}
+	}
+
+	if limits.maxFileSize == 0 || limits.maxFileSize > fs.cfg.MaxFileSizeInBytes {
+		limits.maxFileSize = fs.cfg.MaxFileSizeInBytes
+	}
+
+	return limits
+}
+
+func (fs *Service) checkUploadQuote(usage entities.StorageUsage, command commands.CheckUploadQuota) error {
+	if hasRole(command.Roles, entities.RoleAdmin) {
 		return nil
 	}
 
-	if filesize > fs.cfg.MaxFileSizeInBytes {
+	limits := fs.limitsFor(command.Roles)
+	if command.FileSize > limits.maxFileSize {
 		return domainErrors.ErrFileSizeTooBig
 	}
 
-	if hasRole(roles, entities.RoleVip) && uploadedFilesCount < fs.cfg.Permissions.MaxUploadedFileForVip {
-		return nil
+	if usage.FilesCount >= limits.maxFiles {
+		return domainErrors.ErrUploadLimitExceeded
+	}
+
+	if limits.maxBytes > 0 && usage.UsedBytes+command.FileSize > limits.maxBytes {
+		return domainErrors.ErrStorageQuotaExceeded
 	}
 
-	if uploadedFilesCount < fs.cfg.Permissions.MaxUploadedFileForUser {
+	return fs.checkFileLimits(command.TTL, command.MaxDownloads, command.Roles)
+}
+
+// checkFileLimits validates ttl and downloads against role limits
+func (fs *Service) checkFileLimits(ttl time.Duration, downloads int16, roles []entities.UserRole) error {
+	if hasRole(roles, entities.RoleAdmin) {
 		return nil
 	}
 
-	return domainErrors.ErrUploadLimitExceeded
+	limits := fs.limitsFor(roles)
+	if limits.maxTtl > 0 && ttl > limits.maxTtl {
+		return domainErrors.ErrTTLTooLong
+	}
+
+	if limits.maxDownloads > 0 && downloads > limits.maxDownloads {
+		return domainErrors.ErrTooManyDownloads
+	}
+
+	return nil
 }
 
 func (fs *Service) checkOwner(fileInfo entities.File, userID int64) error {
@@ -43,19 +117,24 @@ func (fs *Service) checkOwner(fileInfo entities.File, userID int64) error {
 	return nil
 }
 
-func (fs *Service) checkPassword(fileInfo entities.File, password string) error {
-	if fileInfo.PasswordHash != "" && password == "" {
-		return domainErrors.ErrFilePasswordRequired
+func checkManagementToken(fileInfo entities.File, token string) error {
+	if fileInfo.ManagementTokenHash == "" || token == "" {
+		return domainErrors.ErrForbidden
 	}
 
-	err := bcrypt.CompareHashAndPassword([]byte(fileInfo.PasswordHash), []byte(password))
-	if err != nil && fileInfo.PasswordHash != "" {
-		return domainErrors.ErrFilePasswordInvalid
+	if subtle.ConstantTimeCompare([]byte(hashManagementToken(token)), []byte(fileInfo.ManagementTokenHash)) != 1 {
+		return domainErrors.ErrForbidden
 	}
 
 	return nil
 }
 
+// hashManagementToken hashes a random token, so a fast hash is enough
+func hashManagementToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func hasRole(roles []entities.UserRole, role entities.UserRole) bool {
 	for _, r := range roles {
 		if r == role {
@@ -0,0 +1,84 @@
+package files
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// sniffLength is how much of the content http.DetectContentType looks at
+const sniffLength = 512
+
+// contentReader hashes and counts the content while it is streamed to
+// storage and keeps its head to detect the content type
+type contentReader struct {
+	r    io.Reader
+	hash hash.Hash
+	size int64
+	head []byte
+}
+
+func newContentReader(r io.Reader) *contentReader {
+	return &contentReader{r: r, hash: sha256.New(), head: make([]byte, 0, sniffLength)}
+}
+
+func (cr *contentReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	if n > 0 {
+		cr.hash.Write(p[:n])
+		cr.size += int64(n)
+
+		if missing := sniffLength - len(cr.head); missing > 0 {
+			cr.head = append(cr.head, p[:min(n, missing)]...)
+		}
+	}
+
+	return n, err
+}
+
+// SHA256 returns hex encoded checksum of the content read so far
+func (cr *contentReader) SHA256() string {
+	return hex.EncodeToString(cr.hash.Sum(nil))
+}
+
+// ContentType sniffs the content. Extension of filename is trusted only
+// when sniffing can't tell more than that the content is text or binary,
+// e.g. for css, json or office documents
+func (cr *contentReader) ContentType(filename string) string {
+	sniffed := http.DetectContentType(cr.head)
+	if sniffed != "application/octet-stream" && !strings.HasPrefix(sniffed, "text/plain") {
+		return sniffed
+	}
+
+	byExtension := mime.TypeByExtension(filepath.Ext(filename))
+	if byExtension == "" {
+		return sniffed
+	}
+
+	// text can't be claimed to be binary, e.g. a script named image.png
+	if strings.HasPrefix(sniffed, "text/plain") && !isTextType(byExtension) {
+		return sniffed
+	}
+
+	return byExtension
+}
+
+func isTextType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+
+	for _, suffix := range []string{"json", "xml", "javascript"} {
+		if strings.HasSuffix(mediaType, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,50 @@
+package files
+
+import (
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+)
+
+func Test_contentReader(t *testing.T) {
+	t.Run("checksum and size of read content", func(t *testing.T) {
+		content := newContentReader(strings.NewReader("hello world"))
+
+		_, err := io.Copy(io.Discard, content)
+		require.NoError(t, err)
+		require.Equal(t, int64(11), content.size)
+		require.Equal(t, "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", content.SHA256())
+	})
+
+	tests := []struct {
+		name        string
+		content     string
+		filename    string
+		contentType string
+	}{
+		{name: "sniffed type wins over extension", content: "%PDF-1.7 document", filename: "report.txt", contentType: "application/pdf"},
+		{name: "binary is refined by extension", content: "\x00\x01\x02binary", filename: "data.wasm", contentType: "application/wasm"},
+		{name: "text is refined by text extension", content: `{"key": "value"}`, filename: "data.json", contentType: "application/json"},
+		{name: "text is not claimed to be binary", content: "plain text", filename: "image.png", contentType: "text/plain; charset=utf-8"},
+		{name: "unknown extension", content: "\x00\x01\x02binary", filename: "data.unknown", contentType: "application/octet-stream"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content := newContentReader(strings.NewReader(test.content))
+
+			_, err := io.Copy(io.Discard, content)
+			require.NoError(t, err)
+			require.Equal(t, test.contentType, content.ContentType(test.filename))
+		})
+	}
+
+	t.Run("only head of content is kept", func(t *testing.T) {
+		content := newContentReader(strings.NewReader(strings.Repeat("a", 4*sniffLength)))
+
+		_, err := io.Copy(io.Discard, content)
+		require.NoError(t, err)
+		require.Len(t, content.head, sniffLength)
+	})
+}
@@ -26,7 +26,7 @@ func (fs *Service) DeleteFile(ctx context.Context, command commands.DeleteFile)
 		return fmt.Errorf("%s: %s: %w", fn, msg, err)
 	}
 
-	err = fs.checkAccess(*fileInfo, command.UserID, command.Roles)
+	err = fs.checkAccess(*fileInfo, command.RequestingUserInfo)
 	if err != nil {
 		log.Info("access denied", sl.Error(err), slog.Int64("requesting_user_id", command.UserID), slog.String("alias", command.Alias))
 		return fmt.Errorf("%s: access denied: %w", fn, err)
@@ -59,15 +59,19 @@ func (fs *Service) DeleteFile(ctx context.Context, command commands.DeleteFile)
 		return fmt.Errorf("%s: %s: %w", fn, msg, err)
 	}
 
-	if err := fs.fileStorage.Delete(ctx, command.Alias); err != nil {
-		const msg = "failed to delete file from storage"
-		if isCtxError(err) {
-			log.Info(msg, sl.Error(err), slog.String("alias", command.Alias))
-			return err
-		}
+	// deduplicated content may be shared, the file worker removes it once
+	// no file refers to it
+	if fileInfo.StorageKey == "" {
+		if err := fs.fileStorage.Delete(ctx, command.Alias); err != nil {
+			const msg = "failed to delete file from storage"
+			if isCtxError(err) {
+				log.Info(msg, sl.Error(err), slog.String("alias", command.Alias))
+				return err
+			}
 
-		log.Error(msg, sl.Error(err), slog.String("alias", command.Alias))
-		return fmt.Errorf("%s: %s: %w", fn, msg, err)
+			log.Error(msg, sl.Error(err), slog.String("alias", command.Alias))
+			return fmt.Errorf("%s: %s: %w", fn, msg, err)
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
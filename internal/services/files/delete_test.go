@@ -74,7 +74,31 @@ func TestService_DeleteFile(t *testing.T) {
 
 		mockTx.EXPECT().Commit().Return(nil)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		err := fileService.DeleteFile(context.Background(), command)
+		require.NoError(t, err)
+	})
+
+	t.Run("shared content is left to the file worker", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).
+			Return(&entities.File{
+				Alias:      command.Alias,
+				UserID:     command.UserID,
+				StorageKey: ".blobs/KEY",
+			}, nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+		mockFileRepo.EXPECT().DeleteFileTx(gomock.Any(), mockTx, command.Alias).Return(nil)
+		mockTx.EXPECT().Commit().Return(nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
 		err := fileService.DeleteFile(context.Background(), command)
 		require.NoError(t, err)
 	})
@@ -93,7 +117,7 @@ func TestService_DeleteFile(t *testing.T) {
 				UserID:       int64(2),
 			}, nil)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
 		err := fileService.DeleteFile(context.Background(), command)
 		require.ErrorIs(t, err, domainErrors.ErrForbidden)
 	})
@@ -108,7 +132,7 @@ func TestService_DeleteFile(t *testing.T) {
 		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), gomock.Any()).
 			Return(nil, domainErrors.ErrFileNotFound)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
 		err := fileService.DeleteFile(context.Background(), command)
 		require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
 	})
@@ -137,7 +161,7 @@ func TestService_DeleteFile(t *testing.T) {
 		mockFileStorage.EXPECT().Delete(gomock.Any(), gomock.Any()).
 			Return(errors.New("internal error"))
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
 		err := fileService.DeleteFile(context.Background(), command)
 		require.Error(t, err)
 	})
@@ -171,7 +195,7 @@ func TestService_DeleteFile(t *testing.T) {
 		mockFileStorage.EXPECT().Delete(gomock.Any(), gomock.Any()).
 			Return(context.Canceled)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
 		err := fileService.DeleteFile(ctx, command)
 		require.ErrorIs(t, err, context.Canceled)
 	})
@@ -2,15 +2,25 @@ package files
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"expire-share/internal/domain/dto/files/commands"
 	"expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/domain/entities"
 	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/domain/interfaces/storage"
 	"expire-share/internal/lib/log/sl"
 	"fmt"
 	"log/slog"
+	"time"
 )
 
+// DownloadFile reserves a download slot for the transfer and issues a
+// download session for it. The download is counted only when the caller
+// confirms the reservation after a complete transfer, a failed transfer
+// must release it. Requests carrying a valid session (resumed or ranged
+// ones) belong to the download of the session and don't reserve another
+// slot until it was counted. With RequireSession only such requests are served
 func (fs *Service) DownloadFile(ctx context.Context, command commands.DownloadFile) (*results.DownloadFile, error) {
 	const fn = "services.files.Service.DownloadFile"
 	log := fs.log.With(slog.String("fn", fn))
@@ -27,13 +37,25 @@ func (fs *Service) DownloadFile(ctx context.Context, command commands.DownloadFi
 		return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
 	}
 
-	err = fs.checkPassword(*fileInfo, command.Password)
+	now := time.Now()
+	id, expiresAt, hasSession := fs.sessions.Verify(command.Session, command.Alias, now)
+	if !hasSession && command.RequireSession {
+		log.Info("download was not started explicitly", slog.String("alias", command.Alias))
+		return nil, domainErrors.ErrDownloadNotStarted
+	}
+
+	err = fs.checkPassword(ctx, *fileInfo, command.Password, command.IP)
 	if err != nil {
 		log.Info("access denied", sl.Error(err), slog.String("alias", command.Alias))
 		return nil, fmt.Errorf("%s: access denied: %w", fn, err)
 	}
 
-	result, err := fs.fileStorage.Download(ctx, command.Alias)
+	storageCtx := ctx
+	if fileInfo.PasswordHash != "" {
+		storageCtx = storage.WithPassword(ctx, command.Password)
+	}
+
+	result, err := fs.openContent(storageCtx, *fileInfo, command.Member)
 	if err != nil {
 		const msg = "failed to download file from storage"
 		if errors.Is(err, domainErrors.ErrFileNotFound) || isCtxError(err) {
@@ -45,32 +67,76 @@ func (fs *Service) DownloadFile(ctx context.Context, command commands.DownloadFi
 		return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
 	}
 
-	tx, err := fs.fileRepo.BeginTx(ctx)
-	if err != nil {
-		if err := result.Close(); err != nil {
-			log.Error("failed to close file", sl.Error(err))
+	success := false
+	defer func() {
+		if !success {
+			if err := result.Close(); err != nil {
+				log.Error("failed to close file", sl.Error(err))
+			}
+		}
+	}()
+
+	if hasSession {
+		reservation, err := fs.fileRepo.GetReservation(ctx, id)
+		if err != nil && !errors.Is(err, domainErrors.ErrReservationNotFound) {
+			const msg = "failed to get download reservation"
+			if isCtxError(err) {
+				log.Info(msg, sl.Error(err), slog.String("alias", command.Alias))
+				return nil, err
+			}
+
+			log.Error(msg, sl.Error(err), slog.String("alias", command.Alias))
+			return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
 		}
 
+		// a released reservation or one of a deleted file with the same
+		// alias holds no slot, and a counted one would let anyone holding
+		// the session download the file again, such a download is counted anew
+		if err == nil && reservation.FileID == fileInfo.ID && !reservation.Confirmed {
+			result.Session = command.Session
+			result.SessionExpiresAt = expiresAt
+			result.Reservation = reservation.ID
+
+			success = true
+			return result, nil
+		}
+	}
+
+	tx, err := fs.fileRepo.BeginTx(ctx)
+	if err != nil {
 		log.Error("failed to begin tx", sl.Error(err))
 		return nil, fmt.Errorf("%s: failed to begin tx: %w", fn, err)
 	}
 
-	success := false
 	defer func() {
 		if !success {
-			if err := result.Close(); err != nil {
-				log.Error("failed to close file", sl.Error(err))
-			}
-
 			if err := tx.Rollback(); err != nil {
 				log.Error("failed to rollback tx", sl.Error(err))
 			}
 		}
 	}()
 
-	downloadsLeft, err := fs.fileRepo.DecrementDownloadsByAliasTx(ctx, tx, command.Alias)
+	_, err = fs.fileRepo.DecrementDownloadsByAliasTx(ctx, tx, command.Alias)
 	if err != nil {
 		const msg = "failed to decrement downloads left"
+		if errors.Is(err, domainErrors.ErrNoDownloadsLeft) || isCtxError(err) {
+			log.Info(msg, sl.Error(err), slog.String("alias", command.Alias))
+			return nil, err
+		}
+
+		log.Error(msg, sl.Error(err), slog.String("alias", command.Alias))
+		return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
+	}
+
+	reservation := entities.DownloadReservation{
+		ID:        rand.Text(),
+		FileID:    fileInfo.ID,
+		ExpiresAt: now.Add(fs.cfg.ReservationTimeout),
+	}
+
+	err = fs.fileRepo.AddReservationTx(ctx, tx, reservation)
+	if err != nil {
+		const msg = "failed to reserve download"
 		if isCtxError(err) {
 			log.Info(msg, sl.Error(err), slog.String("alias", command.Alias))
 			return nil, err
@@ -80,43 +146,127 @@ func (fs *Service) DownloadFile(ctx context.Context, command commands.DownloadFi
 		return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
 	}
 
-	if downloadsLeft > 0 {
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Error(err))
+		return nil, fmt.Errorf("%s: failed to commit tx: %w", fn, err)
+	}
+
+	result.Session, result.SessionExpiresAt = fs.sessions.Issue(command.Alias, reservation.ID, now)
+	result.Reservation = reservation.ID
+	result.Reserved = true
+
+	success = true
+	return result, nil
+}
+
+// ConfirmDownload counts the download once all of the content was sent.
+// Bytes of ranged transfers of the session add up until they reach its
+// size, so a single range doesn't count the download. The session of a
+// counted download can't be continued, the reservation is left for the
+// file worker to remove
+func (fs *Service) ConfirmDownload(ctx context.Context, command commands.FinishDownload) error {
+	const fn = "services.files.Service.ConfirmDownload"
+	log := fs.log.With(slog.String("fn", fn))
+
+	tx, err := fs.fileRepo.BeginTx(ctx)
+	if err != nil {
+		log.Error("failed to begin tx", sl.Error(err))
+		return fmt.Errorf("%s: failed to begin tx: %w", fn, err)
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			if err := tx.Rollback(); err != nil {
+				log.Error("failed to rollback tx", sl.Error(err))
+			}
+		}
+	}()
+
+	sent, err := fs.fileRepo.AddReservationSentTx(ctx, tx, command.Reservation, command.Sent)
+	if err != nil {
+		const msg = "failed to count sent bytes"
+		if errors.Is(err, domainErrors.ErrReservationNotFound) || isCtxError(err) {
+			log.Info(msg, sl.Error(err), slog.String("alias", command.Alias))
+			return err
+		}
+
+		log.Error(msg, sl.Error(err), slog.String("alias", command.Alias))
+		return fmt.Errorf("%s: %s: %w", fn, msg, err)
+	}
+
+	if sent < command.Size {
 		if err := tx.Commit(); err != nil {
 			log.Error("failed to commit tx", sl.Error(err))
-			return nil, fmt.Errorf("%s: failed to commit tx: %w", fn, err)
+			return fmt.Errorf("%s: failed to commit tx: %w", fn, err)
 		}
 
+		log.Info("download is not complete yet", slog.String("alias", command.Alias),
+			slog.Int64("sent", sent), slog.Int64("size", command.Size))
+
 		success = true
-		return result, nil
+		return nil
 	}
 
-	if err := fs.fileRepo.DeleteFileTx(ctx, tx, command.Alias); err != nil {
-		const msg = "failed to delete file info"
-		if isCtxError(err) {
+	err = fs.fileRepo.ConfirmReservationTx(ctx, tx, command.Reservation, time.Now())
+	if err != nil {
+		const msg = "failed to confirm download reservation"
+		if errors.Is(err, domainErrors.ErrReservationNotFound) || isCtxError(err) {
 			log.Info(msg, sl.Error(err), slog.String("alias", command.Alias))
-			return nil, err
+			return err
 		}
 
 		log.Error(msg, sl.Error(err), slog.String("alias", command.Alias))
-		return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
+		return fmt.Errorf("%s: %s: %w", fn, msg, err)
 	}
 
-	if err := fs.fileStorage.Delete(ctx, command.Alias); err != nil {
-		const msg = "failed to delete file"
-		if isCtxError(err) {
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Error(err))
+		return fmt.Errorf("%s: failed to commit tx: %w", fn, err)
+	}
+
+	success = true
+	return nil
+}
+
+// ReleaseDownload gives the downloads slot of a failed transfer back, so
+// an interrupted download is not counted
+func (fs *Service) ReleaseDownload(ctx context.Context, command commands.FinishDownload) error {
+	const fn = "services.files.Service.ReleaseDownload"
+	log := fs.log.With(slog.String("fn", fn))
+
+	tx, err := fs.fileRepo.BeginTx(ctx)
+	if err != nil {
+		log.Error("failed to begin tx", sl.Error(err))
+		return fmt.Errorf("%s: failed to begin tx: %w", fn, err)
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			if err := tx.Rollback(); err != nil {
+				log.Error("failed to rollback tx", sl.Error(err))
+			}
+		}
+	}()
+
+	err = fs.fileRepo.ReleaseReservationTx(ctx, tx, command.Reservation)
+	if err != nil {
+		const msg = "failed to release download reservation"
+		if errors.Is(err, domainErrors.ErrReservationNotFound) || isCtxError(err) {
 			log.Info(msg, sl.Error(err), slog.String("alias", command.Alias))
-			return nil, err
+			return err
 		}
 
 		log.Error(msg, sl.Error(err), slog.String("alias", command.Alias))
-		return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
+		return fmt.Errorf("%s: %s: %w", fn, msg, err)
 	}
 
 	if err := tx.Commit(); err != nil {
 		log.Error("failed to commit tx", sl.Error(err))
-		return nil, fmt.Errorf("%s: failed to commit delete: %w", fn, err)
+		return fmt.Errorf("%s: failed to commit tx: %w", fn, err)
 	}
 
 	success = true
-	return result, nil
+	return nil
 }
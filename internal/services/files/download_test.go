@@ -1,13 +1,19 @@
 package files
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"errors"
 	"expire-share/internal/config"
 	"expire-share/internal/domain/dto/files/commands"
 	"expire-share/internal/domain/dto/files/results"
 	"expire-share/internal/domain/entities"
 	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/domain/interfaces/storage"
 	"expire-share/internal/domain/interfaces/tx"
+	"expire-share/internal/infrastructure/repotest"
+	"expire-share/internal/infrastructure/sqlite"
 	"expire-share/internal/mocks"
 	"expire-share/internal/testutil"
 	"github.com/golang/mock/gomock"
@@ -15,7 +21,9 @@ import (
 	"io"
 	"log/slog"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestService_DownloadFile(t *testing.T) {
@@ -32,6 +40,12 @@ func TestService_DownloadFile(t *testing.T) {
 				MaxUploadedFileForVip:  10,
 			},
 		},
+
+		Downloads: config.Downloads{
+			SessionTTL:         time.Hour,
+			SessionSecret:      "secret",
+			ReservationTimeout: 10 * time.Minute,
+		},
 	}
 
 	command := commands.DownloadFile{
@@ -39,13 +53,15 @@ func TestService_DownloadFile(t *testing.T) {
 		Password: "",
 	}
 
-	fileContent := "file content"
-	file := io.NopCloser(strings.NewReader(fileContent))
-	validStorageResult := &results.DownloadFile{
-		File:  file,
-		Close: file.Close,
+	newStorageResult := func() *results.DownloadFile {
+		return &results.DownloadFile{
+			File:  strings.NewReader("file content"),
+			Close: func() error { return nil },
+		}
 	}
 
+	validStorageResult := newStorageResult()
+
 	t.Run("success downloads left > 0", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -57,7 +73,10 @@ func TestService_DownloadFile(t *testing.T) {
 		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).
 			Return(&entities.File{
 				Alias:        command.Alias,
+				Filename:     "report.pdf",
 				PasswordHash: "",
+				ContentType:  "application/pdf",
+				SHA256:       "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
 			}, nil)
 
 		mockFileStorage.EXPECT().Download(gomock.Any(), command.Alias).
@@ -68,15 +87,22 @@ func TestService_DownloadFile(t *testing.T) {
 		mockFileRepo.EXPECT().DecrementDownloadsByAliasTx(gomock.Any(), mockTx, command.Alias).
 			Return(int16(2), nil)
 
+		mockFileRepo.EXPECT().AddReservationTx(gomock.Any(), mockTx, gomock.Any()).Return(nil)
+
 		mockTx.EXPECT().Commit().Return(nil)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
 		result, err := fileService.DownloadFile(context.Background(), command)
 		require.NoError(t, err)
 		require.NotNil(t, result)
+		require.NotEmpty(t, result.Reservation)
+		require.True(t, result.Reserved)
+		require.Equal(t, "report.pdf", result.Filename)
+		require.Equal(t, "application/pdf", result.ContentType)
+		require.Equal(t, "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", result.SHA256)
 	})
 
-	t.Run("success last download deletes file", func(t *testing.T) {
+	t.Run("last download is reserved until transfer ends", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
@@ -85,28 +111,259 @@ func TestService_DownloadFile(t *testing.T) {
 		mockFileStorage := mocks.NewMockFile(ctrl)
 
 		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).
-			Return(&entities.File{Alias: command.Alias}, nil)
+			Return(&entities.File{ID: 7, Alias: command.Alias}, nil)
 
 		mockFileStorage.EXPECT().Download(gomock.Any(), command.Alias).
-			Return(validStorageResult, nil)
+			Return(newStorageResult(), nil)
 
 		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
 
 		mockFileRepo.EXPECT().DecrementDownloadsByAliasTx(gomock.Any(), mockTx, command.Alias).
 			Return(int16(0), nil)
 
-		mockFileRepo.EXPECT().DeleteFileTx(gomock.Any(), mockTx, command.Alias).
-			Return(nil)
+		var reserved entities.DownloadReservation
+		mockFileRepo.EXPECT().AddReservationTx(gomock.Any(), mockTx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ tx.Tx, reservation entities.DownloadReservation) error {
+				reserved = reservation
+				return nil
+			})
+
+		mockTx.EXPECT().Commit().Return(nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.DownloadFile(context.Background(), command)
+		require.NoError(t, err)
+		require.NotEmpty(t, result.Session)
+		require.Equal(t, reserved.ID, result.Reservation)
+		require.Equal(t, int64(7), reserved.FileID)
+		require.False(t, reserved.Confirmed)
+		require.WithinDuration(t, time.Now().Add(cfg.ReservationTimeout), reserved.ExpiresAt, 2*time.Second)
+	})
+
+	t.Run("session of counted download is counted anew", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).
+			Return(&entities.File{Alias: command.Alias}, nil).Times(2)
+
+		mockFileStorage.EXPECT().Download(gomock.Any(), command.Alias).
+			DoAndReturn(func(context.Context, string) (*results.DownloadFile, error) {
+				return newStorageResult(), nil
+			}).Times(2)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil).Times(2)
+
+		mockFileRepo.EXPECT().DecrementDownloadsByAliasTx(gomock.Any(), mockTx, command.Alias).
+			Return(int16(3), nil).Times(2)
+
+		mockFileRepo.EXPECT().AddReservationTx(gomock.Any(), mockTx, gomock.Any()).Return(nil).Times(2)
+
+		mockTx.EXPECT().Commit().Return(nil).Times(2)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		first, err := fileService.DownloadFile(context.Background(), command)
+		require.NoError(t, err)
+
+		mockFileRepo.EXPECT().GetReservation(gomock.Any(), first.Reservation).
+			Return(&entities.DownloadReservation{ID: first.Reservation, Confirmed: true}, nil)
+
+		// the holder of a counted session can't download the file for free
+		second, err := fileService.DownloadFile(context.Background(), commands.DownloadFile{
+			Alias:   command.Alias,
+			Session: first.Session,
+		})
+		require.NoError(t, err)
+		require.True(t, second.Reserved)
+		require.NotEqual(t, first.Reservation, second.Reservation)
+	})
 
-		mockFileStorage.EXPECT().Delete(gomock.Any(), command.Alias).
-			Return(nil)
+	t.Run("session of transfer in progress shares its reservation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).
+			Return(&entities.File{Alias: command.Alias}, nil)
+
+		mockFileStorage.EXPECT().Download(gomock.Any(), command.Alias).
+			Return(newStorageResult(), nil)
+
+		mockFileRepo.EXPECT().GetReservation(gomock.Any(), "pending").
+			Return(&entities.DownloadReservation{ID: "pending"}, nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		session, _ := fileService.sessions.Issue(command.Alias, "pending", time.Now())
+
+		result, err := fileService.DownloadFile(context.Background(), commands.DownloadFile{
+			Alias:   command.Alias,
+			Session: session,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "pending", result.Reservation)
+		require.False(t, result.Reserved)
+	})
+
+	t.Run("session of released reservation is counted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).
+			Return(&entities.File{Alias: command.Alias}, nil)
+
+		mockFileStorage.EXPECT().Download(gomock.Any(), command.Alias).
+			Return(newStorageResult(), nil)
+
+		mockFileRepo.EXPECT().GetReservation(gomock.Any(), "released").
+			Return(nil, domainErrors.ErrReservationNotFound)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+
+		mockFileRepo.EXPECT().DecrementDownloadsByAliasTx(gomock.Any(), mockTx, command.Alias).
+			Return(int16(1), nil)
+
+		mockFileRepo.EXPECT().AddReservationTx(gomock.Any(), mockTx, gomock.Any()).Return(nil)
 
 		mockTx.EXPECT().Commit().Return(nil)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		session, _ := fileService.sessions.Issue(command.Alias, "released", time.Now())
+
+		result, err := fileService.DownloadFile(context.Background(), commands.DownloadFile{
+			Alias:   command.Alias,
+			Session: session,
+		})
+		require.NoError(t, err)
+		require.True(t, result.Reserved)
+		require.NotEqual(t, session, result.Session)
+	})
+
+	t.Run("reservation error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		closed := false
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).
+			Return(&entities.File{Alias: command.Alias}, nil)
+
+		mockFileStorage.EXPECT().Download(gomock.Any(), command.Alias).
+			Return(&results.DownloadFile{
+				File:  strings.NewReader("file content"),
+				Close: func() error { closed = true; return nil },
+			}, nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+
+		mockFileRepo.EXPECT().DecrementDownloadsByAliasTx(gomock.Any(), mockTx, command.Alias).
+			Return(int16(1), nil)
+
+		mockFileRepo.EXPECT().AddReservationTx(gomock.Any(), mockTx, gomock.Any()).
+			Return(errors.New("db error"))
+
+		mockTx.EXPECT().Rollback().Return(nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
 		result, err := fileService.DownloadFile(context.Background(), command)
+		require.Nil(t, result)
+		require.Error(t, err)
+		require.True(t, closed)
+	})
+
+	t.Run("session of another file is counted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).
+			Return(&entities.File{Alias: command.Alias}, nil)
+
+		mockFileStorage.EXPECT().Download(gomock.Any(), command.Alias).
+			Return(newStorageResult(), nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+
+		mockFileRepo.EXPECT().DecrementDownloadsByAliasTx(gomock.Any(), mockTx, command.Alias).
+			Return(int16(1), nil)
+
+		mockFileRepo.EXPECT().AddReservationTx(gomock.Any(), mockTx, gomock.Any()).Return(nil)
+
+		mockTx.EXPECT().Commit().Return(nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		otherSession, _ := fileService.sessions.Issue("other-alias", "reservation", time.Now())
+
+		result, err := fileService.DownloadFile(context.Background(), commands.DownloadFile{
+			Alias:   command.Alias,
+			Session: otherSession,
+		})
 		require.NoError(t, err)
-		require.NotNil(t, result)
+		require.NotEqual(t, otherSession, result.Session)
+	})
+
+	t.Run("download without valid session is not started when session is required", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).
+			Return(&entities.File{Alias: command.Alias}, nil).Times(2)
+
+		fileService := New(mockFileRepo, mocks.NewMockFile(ctrl), nil, log, cfg)
+		otherSession, _ := fileService.sessions.Issue("other-alias", "reservation", time.Now())
+
+		for _, session := range []string{"", otherSession} {
+			result, err := fileService.DownloadFile(context.Background(), commands.DownloadFile{
+				Alias:          command.Alias,
+				Session:        session,
+				RequireSession: true,
+			})
+			require.Nil(t, result)
+			require.ErrorIs(t, err, domainErrors.ErrDownloadNotStarted)
+		}
+	})
+
+	t.Run("no downloads left", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).
+			Return(&entities.File{Alias: command.Alias}, nil)
+
+		mockFileStorage.EXPECT().Download(gomock.Any(), command.Alias).
+			Return(newStorageResult(), nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+
+		mockFileRepo.EXPECT().DecrementDownloadsByAliasTx(gomock.Any(), mockTx, command.Alias).
+			Return(int16(0), domainErrors.ErrNoDownloadsLeft)
+
+		mockTx.EXPECT().Rollback().Return(nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.DownloadFile(context.Background(), command)
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrNoDownloadsLeft)
 	})
 
 	t.Run("success with password", func(t *testing.T) {
@@ -123,17 +380,23 @@ func TestService_DownloadFile(t *testing.T) {
 				PasswordHash: testutil.HashPassword(t, "correct-password"),
 			}, nil)
 
+		// storage may derive the file key from the verified password
 		mockFileStorage.EXPECT().Download(gomock.Any(), command.Alias).
-			Return(validStorageResult, nil)
+			DoAndReturn(func(ctx context.Context, _ string) (*results.DownloadFile, error) {
+				require.Equal(t, "correct-password", storage.PasswordFromContext(ctx))
+				return newStorageResult(), nil
+			})
 
 		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
 
 		mockFileRepo.EXPECT().DecrementDownloadsByAliasTx(gomock.Any(), mockTx, command.Alias).
 			Return(int16(1), nil)
 
+		mockFileRepo.EXPECT().AddReservationTx(gomock.Any(), mockTx, gomock.Any()).Return(nil)
+
 		mockTx.EXPECT().Commit().Return(nil)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
 		result, err := fileService.DownloadFile(context.Background(), commands.DownloadFile{
 			Alias:    command.Alias,
 			Password: "correct-password",
@@ -142,6 +405,129 @@ func TestService_DownloadFile(t *testing.T) {
 		require.NotNil(t, result)
 	})
 
+	t.Run("multi-file share", func(t *testing.T) {
+		share := &entities.File{ID: 3, Alias: command.Alias, Filename: bundleFilename, Members: 2, LoadedAt: time.Now()}
+		members := []entities.FileMember{
+			{Name: "docs/notes.txt", Size: 5, ContentType: "text/plain; charset=utf-8", SHA256: "hash", StorageKey: command.Alias + "/0"},
+			{Name: "notes (2).txt", Size: 6, StorageKey: command.Alias + "/1"},
+		}
+
+		contents := map[string]string{
+			command.Alias + "/0": "first",
+			command.Alias + "/1": "second",
+		}
+
+		download := func(_ context.Context, key string) (*results.DownloadFile, error) {
+			return &results.DownloadFile{
+				File:  strings.NewReader(contents[key]),
+				Close: func() error { return nil },
+			}, nil
+		}
+
+		expectDownload := func(mockFileRepo *mocks.MockFileRepo) {
+			mockTx := mocks.NewMockTx(gomock.NewController(t))
+			mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+			mockFileRepo.EXPECT().DecrementDownloadsByAliasTx(gomock.Any(), mockTx, command.Alias).Return(int16(1), nil)
+			mockFileRepo.EXPECT().AddReservationTx(gomock.Any(), mockTx, gomock.Any()).Return(nil)
+			mockTx.EXPECT().Commit().Return(nil)
+		}
+
+		t.Run("member is downloaded by path", func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFileRepo := mocks.NewMockFileRepo(ctrl)
+			mockFileStorage := mocks.NewMockFile(ctrl)
+
+			mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).Return(share, nil)
+			mockFileRepo.EXPECT().GetFileMembers(gomock.Any(), share.ID).Return(members, nil)
+			mockFileStorage.EXPECT().Download(gomock.Any(), command.Alias+"/0").DoAndReturn(download)
+			expectDownload(mockFileRepo)
+
+			memberCommand := command
+			memberCommand.Member = "docs/notes.txt"
+
+			fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+			result, err := fileService.DownloadFile(context.Background(), memberCommand)
+			require.NoError(t, err)
+			require.Nil(t, result.Bundle)
+			require.Equal(t, "notes.txt", result.Filename)
+			require.Equal(t, "text/plain; charset=utf-8", result.ContentType)
+			require.Equal(t, "hash", result.SHA256)
+			require.Equal(t, int64(11), result.ShareSize)
+		})
+
+		t.Run("whole share is bundled", func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFileRepo := mocks.NewMockFileRepo(ctrl)
+			mockFileStorage := mocks.NewMockFile(ctrl)
+
+			mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).Return(share, nil)
+			mockFileRepo.EXPECT().GetFileMembers(gomock.Any(), share.ID).Return(members, nil)
+			mockFileStorage.EXPECT().Download(gomock.Any(), gomock.Any()).DoAndReturn(download).Times(2)
+			expectDownload(mockFileRepo)
+
+			fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+			result, err := fileService.DownloadFile(context.Background(), command)
+			require.NoError(t, err)
+			require.NotNil(t, result.Bundle)
+			require.Equal(t, bundleFilename, result.Filename)
+			require.Equal(t, bundleContentType, result.ContentType)
+
+			var buf bytes.Buffer
+			require.NoError(t, result.Bundle(&buf))
+
+			archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			require.NoError(t, err)
+			require.Len(t, archive.File, 2)
+
+			for i, entry := range archive.File {
+				require.Equal(t, members[i].Name, entry.Name)
+
+				content, err := entry.Open()
+				require.NoError(t, err)
+				data, err := io.ReadAll(content)
+				require.NoError(t, err)
+				require.Equal(t, contents[members[i].StorageKey], string(data))
+			}
+		})
+
+		t.Run("unknown member is not found", func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFileRepo := mocks.NewMockFileRepo(ctrl)
+
+			mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).Return(share, nil)
+			mockFileRepo.EXPECT().GetFileMembers(gomock.Any(), share.ID).Return(members, nil)
+
+			memberCommand := command
+			memberCommand.Member = "missing.txt"
+
+			fileService := New(mockFileRepo, mocks.NewMockFile(ctrl), nil, log, cfg)
+			_, err := fileService.DownloadFile(context.Background(), memberCommand)
+			require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
+		})
+
+		t.Run("single file has no members", func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFileRepo := mocks.NewMockFileRepo(ctrl)
+			mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).
+				Return(&entities.File{Alias: command.Alias}, nil)
+
+			memberCommand := command
+			memberCommand.Member = "notes.txt"
+
+			fileService := New(mockFileRepo, mocks.NewMockFile(ctrl), nil, log, cfg)
+			_, err := fileService.DownloadFile(context.Background(), memberCommand)
+			require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
+		})
+	})
+
 	t.Run("invalid password", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -155,7 +541,7 @@ func TestService_DownloadFile(t *testing.T) {
 				PasswordHash: testutil.HashPassword(t, "correct-password"),
 			}, nil)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
 		result, err := fileService.DownloadFile(context.Background(), commands.DownloadFile{
 			Alias:    command.Alias,
 			Password: "wrong-password",
@@ -174,7 +560,7 @@ func TestService_DownloadFile(t *testing.T) {
 		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).
 			Return(nil, domainErrors.ErrFileNotFound)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
 		result, err := fileService.DownloadFile(context.Background(), command)
 		require.Nil(t, result)
 		require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
@@ -193,7 +579,7 @@ func TestService_DownloadFile(t *testing.T) {
 		mockFileStorage.EXPECT().Download(gomock.Any(), command.Alias).
 			Return(nil, domainErrors.ErrFileNotFound)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
 		result, err := fileService.DownloadFile(context.Background(), command)
 		require.Nil(t, result)
 		require.Error(t, err)
@@ -220,7 +606,7 @@ func TestService_DownloadFile(t *testing.T) {
 
 		mockTx.EXPECT().Rollback().Return(nil)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
 		result, err := fileService.DownloadFile(context.Background(), command)
 		require.Nil(t, result)
 		require.Error(t, err)
@@ -252,9 +638,260 @@ func TestService_DownloadFile(t *testing.T) {
 
 		mockTx.EXPECT().Rollback().Return(nil)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
 		result, err := fileService.DownloadFile(ctx, command)
 		require.Nil(t, result)
 		require.ErrorIs(t, err, context.Canceled)
 	})
 }
+
+func TestService_DownloadFile_Concurrent(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	cfg := config.Config{
+		Downloads: config.Downloads{
+			SessionTTL:         time.Hour,
+			SessionSecret:      "secret",
+			ReservationTimeout: time.Hour,
+		},
+	}
+
+	const (
+		maxDownloads = 3
+		attempts     = 20
+	)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fileRepo := sqlite.NewFileRepo(repotest.OpenSQLite(t), log)
+	mockFileStorage := mocks.NewMockFile(ctrl)
+
+	mockFileStorage.EXPECT().Download(gomock.Any(), "popular").
+		DoAndReturn(func(_ context.Context, _ string) (*results.DownloadFile, error) {
+			return &results.DownloadFile{
+				File:  strings.NewReader("file content"),
+				Close: func() error { return nil },
+			}, nil
+		}).AnyTimes()
+
+	addTx, err := fileRepo.BeginTx(context.Background())
+	require.NoError(t, err)
+
+	_, err = fileRepo.AddFileTx(context.Background(), addTx, commands.AddFile{
+		Filename:     "popular.txt",
+		Alias:        "popular",
+		MaxDownloads: maxDownloads,
+		TTL:          time.Hour,
+		UserID:       1,
+	})
+	require.NoError(t, err)
+	require.NoError(t, addTx.Commit())
+
+	fileService := New(fileRepo, mockFileStorage, nil, log, cfg)
+
+	errs := make(chan error, attempts)
+	var wg sync.WaitGroup
+	for range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result, err := fileService.DownloadFile(context.Background(), commands.DownloadFile{Alias: "popular"})
+			if err == nil {
+				_ = result.Close()
+			}
+
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+
+		require.ErrorIs(t, err, domainErrors.ErrNoDownloadsLeft)
+	}
+
+	require.Equal(t, maxDownloads, succeeded)
+}
+
+func TestService_FinishDownload(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	cfg := config.Config{
+		Downloads: config.Downloads{
+			SessionTTL:    time.Hour,
+			SessionSecret: "secret",
+		},
+	}
+
+	command := commands.FinishDownload{Alias: "file-alias", Reservation: "reservation", Sent: 1024, Size: 1024}
+
+	t.Run("confirm ends reservation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+		mockFileRepo.EXPECT().AddReservationSentTx(gomock.Any(), mockTx, command.Reservation, int64(1024)).Return(int64(1024), nil)
+		mockFileRepo.EXPECT().ConfirmReservationTx(gomock.Any(), mockTx, command.Reservation, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ tx.Tx, _ string, expiresAt time.Time) error {
+				require.WithinDuration(t, time.Now(), expiresAt, 2*time.Second)
+				return nil
+			})
+
+		mockTx.EXPECT().Commit().Return(nil)
+
+		fileService := New(mockFileRepo, mocks.NewMockFile(ctrl), nil, log, cfg)
+		require.NoError(t, fileService.ConfirmDownload(context.Background(), command))
+	})
+
+	t.Run("partial transfer is not confirmed until all of content was sent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil).Times(2)
+		mockFileRepo.EXPECT().AddReservationSentTx(gomock.Any(), mockTx, command.Reservation, int64(1)).Return(int64(1), nil)
+		mockFileRepo.EXPECT().AddReservationSentTx(gomock.Any(), mockTx, command.Reservation, int64(1023)).Return(int64(1024), nil)
+		mockFileRepo.EXPECT().ConfirmReservationTx(gomock.Any(), mockTx, command.Reservation, gomock.Any()).Return(nil)
+		mockTx.EXPECT().Commit().Return(nil).Times(2)
+
+		fileService := New(mockFileRepo, mocks.NewMockFile(ctrl), nil, log, cfg)
+
+		firstByte := command
+		firstByte.Sent = 1
+		require.NoError(t, fileService.ConfirmDownload(context.Background(), firstByte))
+
+		rest := command
+		rest.Sent = 1023
+		require.NoError(t, fileService.ConfirmDownload(context.Background(), rest))
+	})
+
+	t.Run("confirm released reservation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+		mockFileRepo.EXPECT().AddReservationSentTx(gomock.Any(), mockTx, command.Reservation, gomock.Any()).
+			Return(int64(0), domainErrors.ErrReservationNotFound)
+
+		mockTx.EXPECT().Rollback().Return(nil)
+
+		fileService := New(mockFileRepo, mocks.NewMockFile(ctrl), nil, log, cfg)
+		err := fileService.ConfirmDownload(context.Background(), command)
+		require.ErrorIs(t, err, domainErrors.ErrReservationNotFound)
+	})
+
+	t.Run("release", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+		mockFileRepo.EXPECT().ReleaseReservationTx(gomock.Any(), mockTx, command.Reservation).Return(nil)
+		mockTx.EXPECT().Commit().Return(nil)
+
+		fileService := New(mockFileRepo, mocks.NewMockFile(ctrl), nil, log, cfg)
+		require.NoError(t, fileService.ReleaseDownload(context.Background(), command))
+	})
+
+	t.Run("release error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+		mockFileRepo.EXPECT().ReleaseReservationTx(gomock.Any(), mockTx, command.Reservation).
+			Return(errors.New("db error"))
+
+		mockTx.EXPECT().Rollback().Return(nil)
+
+		fileService := New(mockFileRepo, mocks.NewMockFile(ctrl), nil, log, cfg)
+		require.Error(t, fileService.ReleaseDownload(context.Background(), command))
+	})
+}
+
+func TestService_DownloadFile_Interrupted(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	cfg := config.Config{
+		Downloads: config.Downloads{
+			SessionTTL:         time.Hour,
+			SessionSecret:      "secret",
+			ReservationTimeout: time.Hour,
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fileRepo := sqlite.NewFileRepo(repotest.OpenSQLite(t), log)
+	mockFileStorage := mocks.NewMockFile(ctrl)
+
+	mockFileStorage.EXPECT().Download(gomock.Any(), "once").
+		DoAndReturn(func(_ context.Context, _ string) (*results.DownloadFile, error) {
+			return &results.DownloadFile{
+				File:  strings.NewReader("file content"),
+				Close: func() error { return nil },
+			}, nil
+		}).AnyTimes()
+
+	addTx, err := fileRepo.BeginTx(context.Background())
+	require.NoError(t, err)
+
+	_, err = fileRepo.AddFileTx(context.Background(), addTx, commands.AddFile{
+		Filename:     "once.txt",
+		Alias:        "once",
+		MaxDownloads: 1,
+		TTL:          time.Hour,
+		UserID:       1,
+	})
+	require.NoError(t, err)
+	require.NoError(t, addTx.Commit())
+
+	fileService := New(fileRepo, mockFileStorage, nil, log, cfg)
+	ctx := context.Background()
+
+	interrupted, err := fileService.DownloadFile(ctx, commands.DownloadFile{Alias: "once"})
+	require.NoError(t, err)
+	require.True(t, interrupted.Reserved)
+
+	_, err = fileService.DownloadFile(ctx, commands.DownloadFile{Alias: "once"})
+	require.ErrorIs(t, err, domainErrors.ErrNoDownloadsLeft)
+
+	require.NoError(t, fileService.ReleaseDownload(ctx, commands.FinishDownload{Alias: "once", Reservation: interrupted.Reservation}))
+
+	// the session of a released download does not hold a slot anymore
+	resumed, err := fileService.DownloadFile(ctx, commands.DownloadFile{Alias: "once", Session: interrupted.Session})
+	require.NoError(t, err)
+	require.True(t, resumed.Reserved)
+	require.NotEqual(t, interrupted.Reservation, resumed.Reservation)
+
+	require.NoError(t, fileService.ConfirmDownload(ctx, commands.FinishDownload{Alias: "once", Reservation: resumed.Reservation}))
+
+	// the session of a counted download gives no more downloads
+	_, err = fileService.DownloadFile(ctx, commands.DownloadFile{Alias: "once", Session: resumed.Session})
+	require.ErrorIs(t, err, domainErrors.ErrNoDownloadsLeft)
+
+	_, err = fileService.DownloadFile(ctx, commands.DownloadFile{Alias: "once"})
+	require.ErrorIs(t, err, domainErrors.ErrNoDownloadsLeft)
+}
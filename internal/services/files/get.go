@@ -5,6 +5,7 @@ import (
 	"errors"
 	"expire-share/internal/domain/dto/files/commands"
 	"expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/domain/entities"
 	domainErrors "expire-share/internal/domain/entities/errors"
 	"expire-share/internal/lib/log/sl"
 	"fmt"
@@ -28,14 +29,87 @@ func (fs *Service) GetFileByAlias(ctx context.Context, command commands.GetFile)
 		return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
 	}
 
-	err = fs.checkAccess(*fileInfo, command.UserID, command.Roles)
+	err = fs.checkAccess(*fileInfo, command.RequestingUserInfo)
 	if err != nil {
 		log.Info("access denied", sl.Error(err), slog.String("alias", command.Alias))
 		return nil, fmt.Errorf("%s: access denied: %w", fn, err)
 	}
 
+	var members []entities.FileMember
+	if fileInfo.Members > 0 {
+		members, err = fs.fileRepo.GetFileMembers(ctx, fileInfo.ID)
+		if err != nil {
+			const msg = "failed to get file members"
+			if isCtxError(err) {
+				log.Info(msg, sl.Error(err), slog.String("alias", command.Alias))
+				return nil, err
+			}
+
+			log.Error(msg, sl.Error(err), slog.String("alias", command.Alias))
+			return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
+		}
+	}
+
+	// the count goes below zero when downloads running during an update
+	// take more slots than the owner left
 	return &results.GetFile{
-		DownloadsLeft: fileInfo.DownloadsLeft,
+		DownloadsLeft: max(fileInfo.DownloadsLeft, 0),
 		ExpiresIn:     time.Until(fileInfo.ExpiresAt),
+		Size:          fileInfo.Size,
+		ContentType:   fileInfo.ContentType,
+		SHA256:        fileInfo.SHA256,
+		Members:       shareMembers(members),
 	}, nil
 }
+
+// GetShare returns public info about a file for its landing page. It
+// neither checks the password nor counts a download. Names are left out
+// for a file with a password
+func (fs *Service) GetShare(ctx context.Context, command commands.GetShare) (*results.GetShare, error) {
+	const fn = "services.file.Service.GetShare"
+	log := fs.log.With(slog.String("fn", fn))
+
+	fileInfo, err := fs.fileRepo.GetFileByAlias(ctx, command.Alias)
+	if err != nil {
+		const msg = "failed to get file by alias"
+		if errors.Is(err, domainErrors.ErrFileNotFound) || isCtxError(err) {
+			log.Info(msg, sl.Error(err), slog.String("alias", command.Alias))
+			return nil, err
+		}
+
+		log.Error(msg, sl.Error(err), slog.String("alias", command.Alias))
+		return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
+	}
+
+	share := &results.GetShare{
+		Size:              fileInfo.Size,
+		DownloadsLeft:     max(fileInfo.DownloadsLeft, 0),
+		PasswordProtected: fileInfo.PasswordHash != "",
+		ExpiresAt:         fileInfo.ExpiresAt,
+		MultiFile:         fileInfo.Members > 0,
+	}
+
+	if share.PasswordProtected {
+		return share, nil
+	}
+
+	share.Filename = fileInfo.Filename
+
+	if share.MultiFile {
+		members, err := fs.fileRepo.GetFileMembers(ctx, fileInfo.ID)
+		if err != nil {
+			const msg = "failed to get file members"
+			if isCtxError(err) {
+				log.Info(msg, sl.Error(err), slog.String("alias", command.Alias))
+				return nil, err
+			}
+
+			log.Error(msg, sl.Error(err), slog.String("alias", command.Alias))
+			return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
+		}
+
+		share.Members = shareMembers(members)
+	}
+
+	return share, nil
+}
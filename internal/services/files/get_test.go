@@ -49,7 +49,7 @@ func TestService_GetFileByAlias(t *testing.T) {
 				}, nil
 			})
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
 		result, err := fileService.GetFileByAlias(context.Background(), command)
 		require.NoError(t, err)
 		require.NotNil(t, result)
@@ -57,6 +57,29 @@ func TestService_GetFileByAlias(t *testing.T) {
 		require.Positive(t, result.ExpiresIn)
 	})
 
+	t.Run("multi-file share lists its files", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).
+			Return(&entities.File{ID: 3, Alias: command.Alias, UserID: command.UserID, Members: 2}, nil)
+
+		mockFileRepo.EXPECT().GetFileMembers(gomock.Any(), int64(3)).
+			Return([]entities.FileMember{
+				{Name: "a.txt", Size: 1, StorageKey: command.Alias + "/0"},
+				{Name: "b.txt", Size: 2, StorageKey: command.Alias + "/1"},
+			}, nil)
+
+		fileService := New(mockFileRepo, mocks.NewMockFile(ctrl), nil, log, cfg)
+		result, err := fileService.GetFileByAlias(context.Background(), command)
+		require.NoError(t, err)
+		require.Len(t, result.Members, 2)
+		require.Equal(t, "b.txt", result.Members[1].Name)
+		require.Equal(t, int64(2), result.Members[1].Size)
+	})
+
 	t.Run("success admin bypasses access check", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -72,7 +95,7 @@ func TestService_GetFileByAlias(t *testing.T) {
 				ExpiresAt:    time.Now().Add(time.Hour),
 			}, nil)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
 		result, err := fileService.GetFileByAlias(context.Background(), commands.GetFile{
 			Alias: command.Alias,
 			RequestingUserInfo: commands.RequestingUserInfo{
@@ -94,7 +117,7 @@ func TestService_GetFileByAlias(t *testing.T) {
 		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).
 			Return(nil, domainErrors.ErrFileNotFound)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
 		result, err := fileService.GetFileByAlias(context.Background(), command)
 		require.Nil(t, result)
 		require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
@@ -113,12 +136,68 @@ func TestService_GetFileByAlias(t *testing.T) {
 				UserID: int64(99),
 			}, nil)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
 		result, err := fileService.GetFileByAlias(context.Background(), command)
 		require.Nil(t, result)
 		require.ErrorIs(t, err, domainErrors.ErrForbidden)
 	})
 
+	t.Run("guest access with management token", func(t *testing.T) {
+		guestFile := &entities.File{
+			Alias:               command.Alias,
+			UserID:              entities.GuestUserID,
+			ManagementTokenHash: hashManagementToken("guest-token"),
+			DownloadsLeft:       1,
+			ExpiresAt:           time.Now().Add(time.Hour),
+		}
+
+		tests := []struct {
+			name    string
+			file    *entities.File
+			token   string
+			wantErr error
+		}{
+			{name: "valid token", file: guestFile, token: "guest-token"},
+			{name: "invalid token", file: guestFile, token: "other-token", wantErr: domainErrors.ErrForbidden},
+			{name: "missing token", file: guestFile, wantErr: domainErrors.ErrForbidden},
+			{
+				name:    "file without management token",
+				file:    &entities.File{Alias: command.Alias, UserID: entities.GuestUserID, ExpiresAt: time.Now().Add(time.Hour)},
+				token:   "guest-token",
+				wantErr: domainErrors.ErrForbidden,
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				mockFileRepo := mocks.NewMockFileRepo(ctrl)
+				mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).Return(test.file, nil)
+
+				fileService := New(mockFileRepo, mocks.NewMockFile(ctrl), nil, log, cfg)
+				result, err := fileService.GetFileByAlias(context.Background(), commands.GetFile{
+					Alias: command.Alias,
+					RequestingUserInfo: commands.RequestingUserInfo{
+						UserID:          entities.GuestUserID,
+						Roles:           []entities.UserRole{entities.RoleGuest},
+						ManagementToken: test.token,
+					},
+				})
+
+				if test.wantErr != nil {
+					require.Nil(t, result)
+					require.ErrorIs(t, err, test.wantErr)
+					return
+				}
+
+				require.NoError(t, err)
+				require.Equal(t, int16(1), result.DownloadsLeft)
+			})
+		}
+	})
+
 	t.Run("context canceled", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -132,7 +211,7 @@ func TestService_GetFileByAlias(t *testing.T) {
 		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).
 			Return(nil, context.Canceled)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
 		result, err := fileService.GetFileByAlias(ctx, command)
 		require.Nil(t, result)
 		require.ErrorIs(t, err, context.Canceled)
@@ -148,9 +227,89 @@ func TestService_GetFileByAlias(t *testing.T) {
 		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), command.Alias).
 			Return(nil, errors.New("internal error"))
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
 		result, err := fileService.GetFileByAlias(context.Background(), command)
 		require.Nil(t, result)
 		require.Error(t, err)
 	})
 }
+
+func TestService_GetShare(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name             string
+		passwordHash     string
+		expectedFilename string
+	}{
+		{name: "public file", expectedFilename: "report.pdf"},
+		{name: "password-protected file hides filename", passwordHash: "hash"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFileRepo := mocks.NewMockFileRepo(ctrl)
+			mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), "abc123").
+				Return(&entities.File{
+					Alias:         "abc123",
+					Filename:      "report.pdf",
+					Size:          2048,
+					DownloadsLeft: 1,
+					PasswordHash:  test.passwordHash,
+					ExpiresAt:     expiresAt,
+				}, nil)
+
+			fileService := New(mockFileRepo, mocks.NewMockFile(ctrl), nil, log, config.Config{})
+			result, err := fileService.GetShare(context.Background(), commands.GetShare{Alias: "abc123"})
+			require.NoError(t, err)
+			require.Equal(t, test.expectedFilename, result.Filename)
+			require.Equal(t, int64(2048), result.Size)
+			require.Equal(t, int16(1), result.DownloadsLeft)
+			require.Equal(t, test.passwordHash != "", result.PasswordProtected)
+			require.Equal(t, expiresAt, result.ExpiresAt)
+		})
+	}
+
+	t.Run("multi-file share lists its files unless it has a password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), "abc123").
+			Return(&entities.File{ID: 3, Alias: "abc123", Filename: "files.zip", Members: 1}, nil)
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), "def456").
+			Return(&entities.File{ID: 4, Alias: "def456", Filename: "files.zip", Members: 1, PasswordHash: "hash"}, nil)
+
+		mockFileRepo.EXPECT().GetFileMembers(gomock.Any(), int64(3)).
+			Return([]entities.FileMember{{Name: "report/index.html", Size: 1}}, nil)
+
+		fileService := New(mockFileRepo, mocks.NewMockFile(ctrl), nil, log, config.Config{})
+		result, err := fileService.GetShare(context.Background(), commands.GetShare{Alias: "abc123"})
+		require.NoError(t, err)
+		require.True(t, result.MultiFile)
+		require.Len(t, result.Members, 1)
+		require.Equal(t, "report/index.html", result.Members[0].Name)
+
+		result, err = fileService.GetShare(context.Background(), commands.GetShare{Alias: "def456"})
+		require.NoError(t, err)
+		require.True(t, result.MultiFile)
+		require.Empty(t, result.Members)
+	})
+
+	t.Run("file not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), "missing").
+			Return(nil, domainErrors.ErrFileNotFound)
+
+		fileService := New(mockFileRepo, mocks.NewMockFile(ctrl), nil, log, config.Config{})
+		_, err := fileService.GetShare(context.Background(), commands.GetShare{Alias: "missing"})
+		require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
+	})
+}
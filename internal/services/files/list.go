@@ -0,0 +1,126 @@
+package files
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// cursor points at the last file of a page. It keeps the sort it was
+// issued for, so a cursor can't be reused with another ordering
+type cursor struct {
+	SortBy entities.FileSort `json:"s"`
+	Value  int64             `json:"v"`
+	ID     int64             `json:"id"`
+}
+
+func (fs *Service) ListFiles(ctx context.Context, command commands.ListFiles) (*results.ListFiles, error) {
+	const fn = "services.files.Service.ListFiles"
+	log := fs.log.With(slog.String("fn", fn))
+
+	if command.SortBy == "" {
+		command.SortBy = entities.FileSortCreated
+	}
+
+	if command.Limit <= 0 {
+		command.Limit = defaultListLimit
+	}
+
+	if command.Limit > maxListLimit {
+		command.Limit = maxListLimit
+	}
+
+	after, err := decodeCursor(command.Cursor, command.SortBy)
+	if err != nil {
+		log.Info("failed to decode cursor", sl.Error(err), slog.Int64("user_id", command.UserID))
+		return nil, domainErrors.ErrInvalidCursor
+	}
+
+	// one extra file is requested to find out whether there is a next page
+	files, err := fs.fileRepo.ListFilesByUserID(ctx, commands.ListUserFiles{
+		UserID: command.UserID,
+		SortBy: command.SortBy,
+		Desc:   command.Desc,
+		Status: command.Status,
+		After:  after,
+		Limit:  command.Limit + 1,
+	})
+
+	if err != nil {
+		const msg = "failed to list files by user id"
+		if isCtxError(err) {
+			log.Info(msg, sl.Error(err), slog.Int64("user_id", command.UserID))
+			return nil, err
+		}
+
+		log.Error(msg, sl.Error(err), slog.Int64("user_id", command.UserID))
+		return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
+	}
+
+	result := &results.ListFiles{Files: make([]results.FileSummary, 0, len(files))}
+	if len(files) > command.Limit {
+		files = files[:command.Limit]
+		result.NextCursor = encodeCursor(files[len(files)-1], command.SortBy)
+	}
+
+	now := time.Now()
+	for _, file := range files {
+		result.Files = append(result.Files, results.FileSummary{
+			Alias:             file.Alias,
+			Filename:          file.Filename,
+			Size:              file.Size,
+			DownloadsLeft:     max(file.DownloadsLeft, 0),
+			PasswordProtected: file.PasswordHash != "",
+			Status:            file.Status(now),
+			LoadedAt:          file.LoadedAt,
+			ExpiresAt:         file.ExpiresAt,
+		})
+	}
+
+	return result, nil
+}
+
+func encodeCursor(file entities.File, sortBy entities.FileSort) string {
+	value := file.LoadedAt
+	if sortBy == entities.FileSortExpires {
+		value = file.ExpiresAt
+	}
+
+	data, _ := json.Marshal(cursor{SortBy: sortBy, Value: value.UnixNano(), ID: file.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string, sortBy entities.FileSort) (*commands.FileCursor, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+
+	if c.SortBy != sortBy {
+		return nil, fmt.Errorf("cursor issued for sort by %s", c.SortBy)
+	}
+
+	return &commands.FileCursor{Value: time.Unix(0, c.Value), ID: c.ID}, nil
+}
@@ -0,0 +1,227 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"expire-share/internal/config"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestService_ListFiles(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	cfg := config.Config{}
+	now := time.Now()
+
+	userInfo := commands.RequestingUserInfo{
+		UserID: int64(1),
+		Roles:  []entities.UserRole{entities.RoleUser},
+	}
+
+	newFiles := func(count int) []entities.File {
+		files := make([]entities.File, 0, count)
+		for i := range count {
+			files = append(files, entities.File{
+				ID:            int64(count - i),
+				Alias:         "alias",
+				Filename:      "file.txt",
+				Size:          128,
+				DownloadsLeft: 1,
+				LoadedAt:      now.Add(-time.Duration(i) * time.Minute),
+				ExpiresAt:     now.Add(time.Hour),
+				UserID:        userInfo.UserID,
+			})
+		}
+
+		return files
+	}
+
+	t.Run("success single page", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		files := newFiles(2)
+		files[1].PasswordHash = "hash"
+		files[1].DownloadsLeft = 0
+
+		mockFileRepo.EXPECT().ListFilesByUserID(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, query commands.ListUserFiles) ([]entities.File, error) {
+				require.Equal(t, userInfo.UserID, query.UserID)
+				require.Equal(t, entities.FileSort(entities.FileSortCreated), query.SortBy)
+				require.Equal(t, defaultListLimit+1, query.Limit)
+				require.Nil(t, query.After)
+				return files, nil
+			})
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.ListFiles(context.Background(), commands.ListFiles{
+			Desc:               true,
+			RequestingUserInfo: userInfo,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, result.Files, 2)
+		require.Empty(t, result.NextCursor)
+		require.Equal(t, int64(128), result.Files[0].Size)
+		require.False(t, result.Files[0].PasswordProtected)
+		require.True(t, result.Files[1].PasswordProtected)
+		require.Equal(t, entities.FileStatus(entities.FileStatusActive), result.Files[0].Status)
+		require.Equal(t, entities.FileStatus(entities.FileStatusExhausted), result.Files[1].Status)
+	})
+
+	t.Run("limit above maximum is clamped", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().ListFilesByUserID(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, query commands.ListUserFiles) ([]entities.File, error) {
+				require.Equal(t, maxListLimit+1, query.Limit)
+				return newFiles(maxListLimit + 1), nil
+			})
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.ListFiles(context.Background(), commands.ListFiles{
+			Limit:              500,
+			RequestingUserInfo: userInfo,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, result.Files, maxListLimit)
+		require.NotEmpty(t, result.NextCursor)
+	})
+
+	t.Run("next page cursor", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		files := newFiles(3)
+
+		mockFileRepo.EXPECT().ListFilesByUserID(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, query commands.ListUserFiles) ([]entities.File, error) {
+				require.Equal(t, 3, query.Limit)
+				return files, nil
+			})
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		firstPage, err := fileService.ListFiles(context.Background(), commands.ListFiles{
+			Limit:              2,
+			SortBy:             entities.FileSortExpires,
+			RequestingUserInfo: userInfo,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, firstPage.Files, 2)
+		require.NotEmpty(t, firstPage.NextCursor)
+
+		mockFileRepo.EXPECT().ListFilesByUserID(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, query commands.ListUserFiles) ([]entities.File, error) {
+				require.NotNil(t, query.After)
+				require.Equal(t, files[1].ID, query.After.ID)
+				require.True(t, files[1].ExpiresAt.Equal(query.After.Value))
+				return files[2:], nil
+			})
+
+		secondPage, err := fileService.ListFiles(context.Background(), commands.ListFiles{
+			Cursor:             firstPage.NextCursor,
+			Limit:              2,
+			SortBy:             entities.FileSortExpires,
+			RequestingUserInfo: userInfo,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, secondPage.Files, 1)
+		require.Empty(t, secondPage.NextCursor)
+	})
+
+	t.Run("cursor issued for another sort", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.ListFiles(context.Background(), commands.ListFiles{
+			Cursor:             encodeCursor(newFiles(1)[0], entities.FileSortCreated),
+			SortBy:             entities.FileSortExpires,
+			RequestingUserInfo: userInfo,
+		})
+
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrInvalidCursor)
+	})
+
+	t.Run("malformed cursor", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.ListFiles(context.Background(), commands.ListFiles{
+			Cursor:             "not a cursor",
+			RequestingUserInfo: userInfo,
+		})
+
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrInvalidCursor)
+	})
+
+	t.Run("empty list", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().ListFilesByUserID(gomock.Any(), gomock.Any()).
+			Return(nil, nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.ListFiles(context.Background(), commands.ListFiles{
+			RequestingUserInfo: userInfo,
+		})
+
+		require.NoError(t, err)
+		require.NotNil(t, result.Files)
+		require.Empty(t, result.Files)
+	})
+
+	t.Run("repo error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().ListFilesByUserID(gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("db error"))
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.ListFiles(context.Background(), commands.ListFiles{
+			RequestingUserInfo: userInfo,
+		})
+
+		require.Nil(t, result)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,257 @@
+package files
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"context"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// bundleFilename is the name a multi-file share is listed and
+	// downloaded under
+	bundleFilename    = "files.zip"
+	bundleContentType = "application/zip"
+)
+
+// maxMemberPathLength limits paths of files in a share, ZIP archives and
+// filesystems refuse longer ones anyway
+const maxMemberPathLength = 1024
+
+// uploadMembers stores files of a multi-file share under the alias, one
+// key per file, so the whole share is removed with the alias. Paths are
+// those returned by memberPaths
+func (fs *Service) uploadMembers(ctx context.Context, alias string, uploads []commands.UploadMember, paths []string) ([]entities.FileMember, error) {
+	members := make([]entities.FileMember, 0, len(uploads))
+
+	for i, upload := range uploads {
+		name := paths[i]
+		key := alias + "/" + strconv.Itoa(i)
+
+		content := newContentReader(upload.File)
+		if err := fs.fileStorage.Upload(ctx, content, key, name); err != nil {
+			return nil, err
+		}
+
+		members = append(members, entities.FileMember{
+			Name:        name,
+			Size:        content.size,
+			ContentType: content.ContentType(path.Base(name)),
+			SHA256:      content.SHA256(),
+			StorageKey:  key,
+		})
+	}
+
+	return members, nil
+}
+
+// memberPaths turns filenames of a multi-file share into relative paths
+// of its tree. A repeated path gets a number, e.g. "docs/notes (2).txt",
+// and a file can't be a directory of another one
+func memberPaths(uploads []commands.UploadMember) ([]string, error) {
+	// isDir of every path taken by a file or a directory
+	taken := make(map[string]bool, len(uploads))
+	paths := make([]string, 0, len(uploads))
+
+	for _, upload := range uploads {
+		name, err := cleanMemberPath(upload.Filename)
+		if err != nil {
+			return nil, err
+		}
+
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			if isDir, ok := taken[dir]; ok && !isDir {
+				return nil, domainErrors.ErrInvalidFilePath
+			}
+		}
+
+		ext := path.Ext(name)
+		stem := strings.TrimSuffix(name, ext)
+
+		candidate := name
+		for i := 2; ; i++ {
+			if _, ok := taken[candidate]; !ok {
+				break
+			}
+
+			candidate = fmt.Sprintf("%s (%d)%s", stem, i, ext)
+		}
+
+		taken[candidate] = false
+		for dir := path.Dir(candidate); dir != "."; dir = path.Dir(dir) {
+			taken[dir] = true
+		}
+
+		paths = append(paths, candidate)
+	}
+
+	return paths, nil
+}
+
+// cleanMemberPath makes a relative slash-separated path of a filename sent
+// by the client. Backslashes separate directories too, absolute paths are
+// made relative and paths leaving the share are refused
+func cleanMemberPath(filename string) (string, error) {
+	name := strings.ReplaceAll(filename, `\`, "/")
+	if len(name) > maxMemberPathLength || strings.ContainsFunc(name, unicode.IsControl) {
+		return "", domainErrors.ErrInvalidFilePath
+	}
+
+	// drive of a Windows path, e.g. "C:/"
+	if len(name) >= 2 && name[1] == ':' && unicode.IsLetter(rune(name[0])) {
+		name = name[2:]
+	}
+
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." {
+			return "", domainErrors.ErrInvalidFilePath
+		}
+	}
+
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		name = "file"
+	}
+
+	return name, nil
+}
+
+// openContent opens what is downloaded from the share: the file itself,
+// one file of a multi-file share or the whole share as a ZIP bundle
+func (fs *Service) openContent(ctx context.Context, fileInfo entities.File, member string) (*results.DownloadFile, error) {
+	if fileInfo.Members == 0 {
+		if member != "" {
+			return nil, domainErrors.ErrFileNotFound
+		}
+
+		result, err := fs.fileStorage.Download(ctx, fileInfo.ContentKey())
+		if err != nil {
+			return nil, err
+		}
+
+		result.Filename = fileInfo.Filename
+		result.ContentType = fileInfo.ContentType
+		result.SHA256 = fileInfo.SHA256
+		return result, nil
+	}
+
+	members, err := fs.fileRepo.GetFileMembers(ctx, fileInfo.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if member == "" {
+		return &results.DownloadFile{
+			Bundle: func(w io.Writer) error {
+				return fs.writeBundle(ctx, w, members, fileInfo.LoadedAt)
+			},
+			Close:       func() error { return nil },
+			Filename:    fileInfo.Filename,
+			ContentType: bundleContentType,
+		}, nil
+	}
+
+	for _, m := range members {
+		if m.Name != member {
+			continue
+		}
+
+		result, err := fs.fileStorage.Download(ctx, m.StorageKey)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Filename = path.Base(m.Name)
+		result.ContentType = m.ContentType
+		result.SHA256 = m.SHA256
+		for _, m := range members {
+			result.ShareSize += m.Size
+		}
+
+		return result, nil
+	}
+
+	return nil, domainErrors.ErrFileNotFound
+}
+
+// writeBundle streams files of the share to w as a ZIP archive. Files are
+// read one at a time and nothing is buffered besides the compressor state
+func (fs *Service) writeBundle(ctx context.Context, w io.Writer, members []entities.FileMember, modified time.Time) error {
+	zw := zip.NewWriter(w)
+
+	// files are often compressed already, the fastest level costs little
+	// time on them and still shrinks text
+	zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(out, flate.BestSpeed)
+	})
+
+	for _, member := range members {
+		if err := fs.writeBundleMember(ctx, zw, member, modified); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func (fs *Service) writeBundleMember(ctx context.Context, zw *zip.Writer, member entities.FileMember, modified time.Time) error {
+	const fn = "services.files.Service.writeBundleMember"
+	log := fs.log.With(slog.String("fn", fn))
+
+	file, err := fs.fileStorage.Download(ctx, member.StorageKey)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", member.Name, err)
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Error("failed to close file", sl.Error(err))
+		}
+	}()
+
+	entry, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     member.Name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", member.Name, err)
+	}
+
+	if _, err := io.Copy(entry, file.File); err != nil {
+		return fmt.Errorf("failed to write %s: %w", member.Name, err)
+	}
+
+	return nil
+}
+
+func shareMembers(members []entities.FileMember) []results.ShareMember {
+	if len(members) == 0 {
+		return nil
+	}
+
+	result := make([]results.ShareMember, 0, len(members))
+	for _, member := range members {
+		result = append(result, results.ShareMember{
+			Name:        member.Name,
+			Size:        member.Size,
+			ContentType: member.ContentType,
+			SHA256:      member.SHA256,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,44 @@
+package files
+
+import (
+	"expire-share/internal/domain/dto/files/commands"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func Test_memberPaths(t *testing.T) {
+	tests := []struct {
+		name      string
+		filenames []string
+		paths     []string
+		err       error
+	}{
+		{name: "tree is kept", filenames: []string{"report/index.html", "report/css/style.css"}, paths: []string{"report/index.html", "report/css/style.css"}},
+		{name: "repeated path gets a number", filenames: []string{"a/notes.txt", "a/notes.txt", "b/notes.txt"}, paths: []string{"a/notes.txt", "a/notes (2).txt", "b/notes.txt"}},
+		{name: "backslashes separate directories", filenames: []string{`report\logs\run.log`}, paths: []string{"report/logs/run.log"}},
+		{name: "absolute path is made relative", filenames: []string{"/etc/passwd", `C:\Users\me\notes.txt`}, paths: []string{"etc/passwd", "Users/me/notes.txt"}},
+		{name: "redundant segments are dropped", filenames: []string{"./a//b/./c.txt"}, paths: []string{"a/b/c.txt"}},
+		{name: "empty name", filenames: []string{"", "/"}, paths: []string{"file", "file (2)"}},
+		{name: "file named like directory gets a number", filenames: []string{"a/b.txt", "a"}, paths: []string{"a/b.txt", "a (2)"}},
+		{name: "file can't be a directory", filenames: []string{"a", "a/b.txt"}, err: domainErrors.ErrInvalidFilePath},
+		{name: "parent directory is refused", filenames: []string{"../secret"}, err: domainErrors.ErrInvalidFilePath},
+		{name: "parent directory inside path is refused", filenames: []string{`a\..\..\secret`}, err: domainErrors.ErrInvalidFilePath},
+		{name: "control character is refused", filenames: []string{"a\x00b"}, err: domainErrors.ErrInvalidFilePath},
+		{name: "too long path is refused", filenames: []string{strings.Repeat("a/", maxMemberPathLength)}, err: domainErrors.ErrInvalidFilePath},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			uploads := make([]commands.UploadMember, 0, len(test.filenames))
+			for _, filename := range test.filenames {
+				uploads = append(uploads, commands.UploadMember{Filename: filename})
+			}
+
+			paths, err := memberPaths(uploads)
+			require.ErrorIs(t, err, test.err)
+			require.Equal(t, test.paths, paths)
+		})
+	}
+}
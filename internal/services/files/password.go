@@ -0,0 +1,128 @@
+package files
+
+import (
+	"context"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// checkPassword lets the caller in when the file has no password or the
+// right one is given. Wrong passwords are counted per file and per client
+// and lock them for a while once there are too many of them
+func (fs *Service) checkPassword(ctx context.Context, fileInfo entities.File, password, ip string) error {
+	const fn = "services.files.Service.checkPassword"
+	log := fs.log.With(slog.String("fn", fn), slog.String("alias", fileInfo.Alias))
+
+	if fileInfo.PasswordHash == "" {
+		return nil
+	}
+
+	if password == "" {
+		return domainErrors.ErrFilePasswordRequired
+	}
+
+	now := time.Now()
+	fileKey := "file:" + strconv.FormatInt(fileInfo.ID, 10)
+	clientKey := "ip:" + ip
+
+	// locked file refuses even the right password, otherwise the lock
+	// would only slow guessing down
+	if fs.fileLocks.LockedFor(fileKey, now) > 0 {
+		return domainErrors.ErrFileLocked
+	}
+
+	if ip != "" && fs.clientLocks.LockedFor(clientKey, now) > 0 {
+		return domainErrors.ErrTooManyPasswordAttempts
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(fileInfo.PasswordHash), []byte(password)) == nil {
+		fs.fileLocks.Reset(fileKey)
+		return nil
+	}
+
+	if ip != "" && fs.clientLocks.Fail(clientKey, now) {
+		log.Warn("client is locked after too many wrong passwords", slog.String("ip", ip))
+	}
+
+	if fs.fileLocks.Fail(fileKey, now) {
+		log.Warn("file is locked after too many wrong passwords", slog.String("ip", ip))
+		fs.notifyLocked(ctx, fileInfo, now.Add(fs.cfg.LockoutDuration))
+	}
+
+	if fs.cfg.DestroyAfterFailures > 0 {
+		if err := fs.addFailedPassword(ctx, fileInfo); err != nil {
+			log.Error("failed to count wrong password", sl.Error(err))
+		}
+	}
+
+	return domainErrors.ErrFilePasswordInvalid
+}
+
+func (fs *Service) notifyLocked(ctx context.Context, fileInfo entities.File, lockedUntil time.Time) {
+	// guests have no account to notify
+	if fs.notifier == nil || fileInfo.UserID == entities.GuestUserID {
+		return
+	}
+
+	if err := fs.notifier.FileLocked(ctx, fileInfo, lockedUntil); err != nil {
+		fs.log.Error("failed to notify owner about locked file", sl.Error(err),
+			slog.String("alias", fileInfo.Alias), slog.Int64("user_id", fileInfo.UserID))
+	}
+}
+
+// addFailedPassword counts a wrong password of the file and deletes the
+// file once it has had DestroyAfterFailures of them
+func (fs *Service) addFailedPassword(ctx context.Context, fileInfo entities.File) error {
+	const fn = "services.files.Service.addFailedPassword"
+	log := fs.log.With(slog.String("fn", fn), slog.String("alias", fileInfo.Alias))
+
+	tx, err := fs.fileRepo.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin tx: %w", fn, err)
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			if err := tx.Rollback(); err != nil {
+				log.Error("failed to rollback tx", sl.Error(err))
+			}
+		}
+	}()
+
+	attempts, err := fs.fileRepo.AddFailedPasswordAttemptTx(ctx, tx, fileInfo.ID)
+	if err != nil {
+		return fmt.Errorf("%s: failed to add failed password attempt: %w", fn, err)
+	}
+
+	destroy := attempts >= fs.cfg.DestroyAfterFailures
+	if destroy {
+		if err := fs.fileRepo.DeleteFileTx(ctx, tx, fileInfo.Alias); err != nil {
+			return fmt.Errorf("%s: failed to delete file info: %w", fn, err)
+		}
+
+		if fileInfo.StorageKey == "" {
+			if err := fs.fileStorage.Delete(ctx, fileInfo.Alias); err != nil {
+				return fmt.Errorf("%s: failed to delete file from storage: %w", fn, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit tx: %w", fn, err)
+	}
+
+	if destroy {
+		log.Warn("file is deleted after too many wrong passwords", slog.Int("attempts", attempts))
+	}
+
+	success = true
+	return nil
+}
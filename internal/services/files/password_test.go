@@ -0,0 +1,167 @@
+package files
+
+import (
+	"context"
+	"expire-share/internal/config"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/mocks"
+	"expire-share/internal/testutil"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestService_DownloadFile_PasswordLockout(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	cfg := config.Config{
+		Service: config.Service{
+			PasswordLockout: config.PasswordLockout{
+				LockoutMaxFailures:      3,
+				LockoutMaxFailuresPerIP: 5,
+				LockoutWindow:           time.Minute,
+				LockoutDuration:         time.Minute,
+			},
+		},
+	}
+
+	passwordHash := testutil.HashPassword(t, "correct-password")
+
+	newFile := func(id int64, alias string) *entities.File {
+		return &entities.File{ID: id, Alias: alias, UserID: 42, PasswordHash: passwordHash}
+	}
+
+	download := func(fileService *Service, alias, password, ip string) error {
+		_, err := fileService.DownloadFile(context.Background(), commands.DownloadFile{
+			Alias:    alias,
+			Password: password,
+			IP:       ip,
+		})
+
+		return err
+	}
+
+	t.Run("file is locked after too many wrong passwords", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockNotifier := mocks.NewMockLockNotifier(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), "locked").
+			Return(newFile(1, "locked"), nil).Times(4)
+
+		mockNotifier.EXPECT().FileLocked(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, file entities.File, lockedUntil time.Time) error {
+				require.Equal(t, "locked", file.Alias)
+				require.WithinDuration(t, time.Now().Add(time.Minute), lockedUntil, 2*time.Second)
+				return nil
+			})
+
+		fileService := New(mockFileRepo, mocks.NewMockFile(ctrl), mockNotifier, log, cfg)
+
+		ips := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}
+		for _, ip := range ips {
+			require.ErrorIs(t, download(fileService, "locked", "wrong-password", ip), domainErrors.ErrFilePasswordInvalid)
+		}
+
+		// the right password is refused too until the lock is over
+		require.ErrorIs(t, download(fileService, "locked", "correct-password", "10.0.0.4"), domainErrors.ErrFileLocked)
+	})
+
+	t.Run("client is limited across files", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, alias string) (*entities.File, error) {
+				return newFile(int64(len(alias)), alias), nil
+			}).AnyTimes()
+
+		fileService := New(mockFileRepo, mocks.NewMockFile(ctrl), nil, log, cfg)
+
+		aliases := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+		for _, alias := range aliases {
+			require.ErrorIs(t, download(fileService, alias, "wrong-password", "10.0.0.1"), domainErrors.ErrFilePasswordInvalid)
+		}
+
+		require.ErrorIs(t, download(fileService, "ffffff", "wrong-password", "10.0.0.1"), domainErrors.ErrTooManyPasswordAttempts)
+		require.ErrorIs(t, download(fileService, "ffffff", "wrong-password", "10.0.0.2"), domainErrors.ErrFilePasswordInvalid)
+	})
+
+	t.Run("right password resets failures of file", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), "guarded").
+			Return(newFile(1, "guarded"), nil).AnyTimes()
+
+		mockFileStorage.EXPECT().Download(gomock.Any(), "guarded").
+			Return(&results.DownloadFile{
+				File:  strings.NewReader("file content"),
+				Close: func() error { return nil },
+			}, nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+		mockFileRepo.EXPECT().DecrementDownloadsByAliasTx(gomock.Any(), mockTx, "guarded").Return(int16(1), nil)
+		mockFileRepo.EXPECT().AddReservationTx(gomock.Any(), mockTx, gomock.Any()).Return(nil)
+		mockTx.EXPECT().Commit().Return(nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+
+		for range 2 {
+			require.ErrorIs(t, download(fileService, "guarded", "wrong-password", "10.0.0.1"), domainErrors.ErrFilePasswordInvalid)
+		}
+
+		require.NoError(t, download(fileService, "guarded", "correct-password", "10.0.0.1"))
+
+		for range 2 {
+			require.ErrorIs(t, download(fileService, "guarded", "wrong-password", "10.0.0.1"), domainErrors.ErrFilePasswordInvalid)
+		}
+	})
+
+	t.Run("file is deleted after too many wrong passwords", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		destroyCfg := cfg
+		destroyCfg.DestroyAfterFailures = 2
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), "doomed").
+			Return(newFile(9, "doomed"), nil).Times(2)
+
+		gomock.InOrder(
+			mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil),
+			mockFileRepo.EXPECT().AddFailedPasswordAttemptTx(gomock.Any(), mockTx, int64(9)).Return(1, nil),
+			mockTx.EXPECT().Commit().Return(nil),
+
+			mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil),
+			mockFileRepo.EXPECT().AddFailedPasswordAttemptTx(gomock.Any(), mockTx, int64(9)).Return(2, nil),
+			mockFileRepo.EXPECT().DeleteFileTx(gomock.Any(), mockTx, "doomed").Return(nil),
+			mockFileStorage.EXPECT().Delete(gomock.Any(), "doomed").Return(nil),
+			mockTx.EXPECT().Commit().Return(nil),
+		)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, destroyCfg)
+
+		for range 2 {
+			require.ErrorIs(t, download(fileService, "doomed", "wrong-password", "10.0.0.1"), domainErrors.ErrFilePasswordInvalid)
+		}
+	})
+}
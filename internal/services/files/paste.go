@@ -0,0 +1,57 @@
+package files
+
+import (
+	"context"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/lib/language"
+	"log/slog"
+	"strings"
+)
+
+// pasteFilename is the name pastes are stored under, the extension comes
+// from their language
+const pasteFilename = "paste"
+
+// Paste stores text as a file named after its language. It has the same
+// limits, TTL, downloads and password as an uploaded file
+func (fs *Service) Paste(ctx context.Context, command commands.Paste) (*results.UploadFile, error) {
+	const fn = "services.file.Service.Paste"
+	log := fs.log.With(slog.String("fn", fn))
+
+	lang := strings.ToLower(command.Language)
+	if lang == "" {
+		lang = language.Text
+	}
+
+	ext, ok := language.Extension(lang)
+	if !ok {
+		log.Info("unknown language", slog.String("language", command.Language))
+		return nil, domainErrors.ErrUnknownLanguage
+	}
+
+	size := int64(len(command.Content))
+	if fs.cfg.MaxPasteSizeInBytes > 0 && size > fs.cfg.MaxPasteSizeInBytes {
+		log.Info("paste is too large", slog.Int64("size", size))
+		return nil, domainErrors.ErrFileSizeTooBig
+	}
+
+	result, err := fs.UploadFile(ctx, commands.UploadFile{
+		File:               strings.NewReader(command.Content),
+		FileSize:           size,
+		Filename:           pasteFilename + ext,
+		MaxDownloads:       command.MaxDownloads,
+		Password:           command.Password,
+		TTL:                command.TTL,
+		Alias:              command.Alias,
+		RequestingUserInfo: command.RequestingUserInfo,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	result.Language = lang
+	return result, nil
+}
@@ -0,0 +1,121 @@
+package files
+
+import (
+	"context"
+	"expire-share/internal/config"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/domain/interfaces/tx"
+	"expire-share/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestService_Paste(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	cfg := config.Config{
+		Storage: config.Storage{
+			MaxFileSizeInBytes: 1024,
+		},
+
+		Service: config.Service{
+			AliasLength:   6,
+			AliasAttempts: 1,
+			Permissions: config.Permissions{
+				MaxUploadedFileForUser:    1,
+				MaxStorageForUserInBytes:  1024,
+				MaxFileSizeForUserInBytes: 512,
+			},
+		},
+
+		Uploads: config.Uploads{
+			PendingTimeout: time.Hour,
+		},
+
+		Pastes: config.Pastes{
+			MaxPasteSizeInBytes: 16,
+		},
+	}
+
+	command := commands.Paste{
+		Content:      "panic: oops",
+		Language:     "Go",
+		MaxDownloads: 5,
+		TTL:          time.Hour,
+		RequestingUserInfo: commands.RequestingUserInfo{
+			UserID: int64(1),
+			Roles:  []entities.UserRole{entities.RoleUser},
+		},
+	}
+
+	t.Run("text is stored as file named after its language", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), command.UserID).
+			Return(entities.StorageUsage{}, nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil).Times(2)
+
+		mockFileRepo.EXPECT().AddFileTx(gomock.Any(), mockTx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ tx.Tx, cmd commands.AddFile) (*entities.File, error) {
+				require.Equal(t, "paste.go", cmd.Filename)
+				require.Equal(t, int64(11), cmd.Size)
+				require.Equal(t, command.MaxDownloads, cmd.MaxDownloads)
+				return &entities.File{Alias: cmd.Alias}, nil
+			})
+
+		mockFileStorage.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any(), "paste.go").
+			DoAndReturn(func(_ context.Context, file io.Reader, _ string, _ string) error {
+				content, err := io.ReadAll(file)
+				require.NoError(t, err)
+				require.Equal(t, command.Content, string(content))
+				return nil
+			})
+
+		mockFileRepo.EXPECT().PromoteFileTx(gomock.Any(), mockTx, gomock.Any()).Return(nil)
+		mockTx.EXPECT().Commit().Return(nil).Times(2)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.Paste(context.Background(), command)
+		require.NoError(t, err)
+		require.NotEmpty(t, result.Alias)
+		require.Equal(t, "go", result.Language)
+		require.Equal(t, int64(11), result.Size)
+	})
+
+	tests := []struct {
+		name     string
+		content  string
+		language string
+		wantErr  error
+	}{
+		{name: "unknown language", content: "text", language: "klingon", wantErr: domainErrors.ErrUnknownLanguage},
+		{name: "paste is too large", content: "much more than sixteen bytes", wantErr: domainErrors.ErrFileSizeTooBig},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			pasteCommand := command
+			pasteCommand.Content = test.content
+			pasteCommand.Language = test.language
+
+			fileService := New(mocks.NewMockFileRepo(ctrl), mocks.NewMockFile(ctrl), nil, log, cfg)
+			_, err := fileService.Paste(context.Background(), pasteCommand)
+			require.ErrorIs(t, err, test.wantErr)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package files
+
+import (
+	"context"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/domain/entities"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"log/slog"
+)
+
+// CheckUploadQuota checks that requesting user is allowed to upload a file
+// of given size, ttl and downloads. It is called before accepting uploads
+// that are published later, the quota is checked again on publishing
+func (fs *Service) CheckUploadQuota(ctx context.Context, command commands.CheckUploadQuota) error {
+	const fn = "services.files.Service.CheckUploadQuota"
+	log := fs.log.With(slog.String("fn", fn))
+
+	if hasRole(command.Roles, entities.RoleAdmin) {
+		return nil
+	}
+
+	usage, err := fs.usageOf(ctx, command.RequestingUserInfo)
+	if err != nil {
+		const msg = "failed to get storage usage"
+		if isCtxError(err) {
+			log.Info(msg, sl.Error(err), slog.Int64("user_id", command.UserID))
+			return err
+		}
+
+		log.Error(msg, sl.Error(err), slog.Int64("user_id", command.UserID))
+		return fmt.Errorf("%s: %s: %w", fn, msg, err)
+	}
+
+	if err := fs.checkUploadQuote(usage, command); err != nil {
+		log.Info("access denied", sl.Error(err), slog.Int64("user_id", command.UserID))
+		return fmt.Errorf("%s: access denied: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (fs *Service) GetQuota(ctx context.Context, command commands.GetQuota) (*results.GetQuota, error) {
+	const fn = "services.files.Service.GetQuota"
+	log := fs.log.With(slog.String("fn", fn))
+
+	usage, err := fs.fileRepo.GetUsageByUserID(ctx, command.UserID)
+	if err != nil {
+		const msg = "failed to get storage usage by user id"
+		if isCtxError(err) {
+			log.Info(msg, sl.Error(err), slog.Int64("user_id", command.UserID))
+			return nil, err
+		}
+
+		log.Error(msg, sl.Error(err), slog.Int64("user_id", command.UserID))
+		return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
+	}
+
+	if hasRole(command.Roles, entities.RoleAdmin) {
+		return &results.GetQuota{
+			Unlimited:  true,
+			FilesCount: usage.FilesCount,
+			UsedBytes:  usage.UsedBytes,
+		}, nil
+	}
+
+	limits := fs.limitsFor(command.Roles)
+	return &results.GetQuota{
+		FilesCount:   usage.FilesCount,
+		MaxFiles:     limits.maxFiles,
+		UsedBytes:    usage.UsedBytes,
+		MaxBytes:     limits.maxBytes,
+		MaxFileSize:  limits.maxFileSize,
+		MaxTTL:       limits.maxTtl,
+		MaxDownloads: limits.maxDownloads,
+	}, nil
+}
+
+// usageOf counts files of the user. Guests are counted per IP
+func (fs *Service) usageOf(ctx context.Context, user commands.RequestingUserInfo) (entities.StorageUsage, error) {
+	if hasRole(user.Roles, entities.RoleGuest) {
+		return fs.fileRepo.GetUsageByGuestIP(ctx, user.IP)
+	}
+
+	return fs.fileRepo.GetUsageByUserID(ctx, user.UserID)
+}
@@ -0,0 +1,124 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"expire-share/internal/config"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/entities"
+	"expire-share/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestService_GetQuota(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	cfg := config.Config{
+		Storage: config.Storage{
+			MaxFileSizeInBytes: 500,
+		},
+
+		Service: config.Service{
+			Permissions: config.Permissions{
+				MaxUploadedFileForUser:    1,
+				MaxUploadedFileForVip:     10,
+				MaxStorageForUserInBytes:  1000,
+				MaxStorageForVipInBytes:   10000,
+				MaxFileSizeForUserInBytes: 100,
+				MaxTtlForUser:             24 * time.Hour,
+				MaxTtlForVip:              168 * time.Hour,
+				MaxDownloadsForUser:       100,
+				MaxDownloadsForVip:        1000,
+			},
+		},
+	}
+
+	usage := entities.StorageUsage{FilesCount: 1, UsedBytes: 42}
+
+	newCommand := func(role entities.UserRole) commands.GetQuota {
+		return commands.GetQuota{
+			RequestingUserInfo: commands.RequestingUserInfo{
+				UserID: int64(1),
+				Roles:  []entities.UserRole{role},
+			},
+		}
+	}
+
+	t.Run("regular user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), int64(1)).Return(usage, nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.GetQuota(context.Background(), newCommand(entities.RoleUser))
+		require.NoError(t, err)
+		require.False(t, result.Unlimited)
+		require.Equal(t, 1, result.FilesCount)
+		require.Equal(t, 1, result.MaxFiles)
+		require.Equal(t, int64(42), result.UsedBytes)
+		require.Equal(t, int64(1000), result.MaxBytes)
+		require.Equal(t, int64(100), result.MaxFileSize)
+		require.Equal(t, 24*time.Hour, result.MaxTTL)
+		require.Equal(t, int16(100), result.MaxDownloads)
+	})
+
+	t.Run("vip falls back to global file size", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), int64(1)).Return(usage, nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.GetQuota(context.Background(), newCommand(entities.RoleVip))
+		require.NoError(t, err)
+		require.Equal(t, 10, result.MaxFiles)
+		require.Equal(t, int64(10000), result.MaxBytes)
+		require.Equal(t, int64(500), result.MaxFileSize)
+		require.Equal(t, int16(1000), result.MaxDownloads)
+	})
+
+	t.Run("admin is unlimited", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), int64(1)).Return(usage, nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.GetQuota(context.Background(), newCommand(entities.RoleAdmin))
+		require.NoError(t, err)
+		require.True(t, result.Unlimited)
+		require.Equal(t, int64(42), result.UsedBytes)
+		require.Zero(t, result.MaxBytes)
+	})
+
+	t.Run("internal error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), int64(1)).
+			Return(entities.StorageUsage{}, errors.New("internal error"))
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.GetQuota(context.Background(), newCommand(entities.RoleUser))
+		require.Nil(t, result)
+		require.Error(t, err)
+	})
+}
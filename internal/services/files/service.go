@@ -1,22 +1,61 @@
 package files
 
 import (
+	"context"
 	"expire-share/internal/config"
+	"expire-share/internal/domain/entities"
 	"expire-share/internal/domain/interfaces/repositories"
 	"expire-share/internal/domain/interfaces/storage"
+	"expire-share/internal/lib/alias"
+	"expire-share/internal/lib/lockout"
+	"expire-share/internal/lib/session"
 	"log/slog"
+	"time"
 )
 
+// AliasGenerator makes new aliases. Extra is how much longer than usual the
+// alias must be, it grows with every collision
+type AliasGenerator interface {
+	Gen(extra int16) string
+}
+
+// LockNotifier lets the owner know that their file was locked after too
+// many wrong passwords
+type LockNotifier interface {
+	FileLocked(ctx context.Context, file entities.File, lockedUntil time.Time) error
+}
+
 type Service struct {
 	fileRepo    repositories.FileRepo
 	fileStorage storage.File
+	notifier    LockNotifier
+	sessions    *session.Signer
+	aliases     AliasGenerator
+	fileLocks   *lockout.Counter
+	clientLocks *lockout.Counter
 	cfg         config.Config
 	log         *slog.Logger
 }
 
-func New(fileRepo repositories.FileRepo, fileStorage storage.File, log *slog.Logger, cfg config.Config) *Service {
+// New creates the files service, notifier may be nil when owners are not
+// notified about locked files
+func New(fileRepo repositories.FileRepo, fileStorage storage.File, notifier LockNotifier, log *slog.Logger, cfg config.Config) *Service {
+	lockCfg := cfg.PasswordLockout
 	return &Service{fileRepo: fileRepo,
 		fileStorage: fileStorage,
+		notifier:    notifier,
+		sessions:    session.NewSigner(cfg.SessionSecret, cfg.SessionTTL),
+		aliases:     newAliasGenerator(cfg.Service),
+		fileLocks:   lockout.NewCounter(lockCfg.LockoutMaxFailures, lockCfg.LockoutWindow, lockCfg.LockoutDuration),
+		clientLocks: lockout.NewCounter(lockCfg.LockoutMaxFailuresPerIP, lockCfg.LockoutWindow, lockCfg.LockoutDuration),
 		log:         log,
 		cfg:         cfg}
 }
+
+func newAliasGenerator(cfg config.Service) AliasGenerator {
+	if cfg.AliasMode == config.AliasModeWords {
+		return alias.Words{Count: cfg.AliasWordCount, Separator: cfg.AliasSeparator}
+	}
+
+	return alias.Random{Alphabet: cfg.AliasAlphabet, Length: cfg.AliasLength}
+}
@@ -0,0 +1,125 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (fs *Service) UpdateFile(ctx context.Context, command commands.UpdateFile) (*results.GetFile, error) {
+	const fn = "services.files.Service.UpdateFile"
+	log := fs.log.With(slog.String("fn", fn))
+
+	fileInfo, err := fs.fileRepo.GetFileByAlias(ctx, command.Alias)
+	if err != nil {
+		const msg = "failed to get file by alias"
+		if errors.Is(err, domainErrors.ErrFileNotFound) || isCtxError(err) {
+			log.Info(msg, sl.Error(err), slog.String("alias", command.Alias))
+			return nil, err
+		}
+
+		log.Error(msg, sl.Error(err), slog.String("alias", command.Alias))
+		return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
+	}
+
+	err = fs.checkAccess(*fileInfo, command.RequestingUserInfo)
+	if err != nil {
+		log.Info("access denied", sl.Error(err), slog.Int64("requesting_user_id", command.UserID), slog.String("alias", command.Alias))
+		return nil, fmt.Errorf("%s: access denied: %w", fn, err)
+	}
+
+	update := commands.UpdateFileInfo{Alias: command.Alias}
+	ttl := time.Until(fileInfo.ExpiresAt)
+
+	// limits are checked only for changed values, so a file uploaded
+	// before limits were lowered can still be edited
+	var changedTtl time.Duration
+	var changedDownloads int16
+
+	if command.TTL != nil {
+		expiresAt := time.Now().Add(*command.TTL)
+		update.ExpiresAt = &expiresAt
+		ttl, changedTtl = *command.TTL, *command.TTL
+	}
+
+	if command.DownloadsLeft != nil {
+		update.DownloadsLeft = command.DownloadsLeft
+		changedDownloads = *command.DownloadsLeft
+	}
+
+	err = fs.checkFileLimits(changedTtl, changedDownloads, command.Roles)
+	if err != nil {
+		log.Info("access denied", sl.Error(err), slog.String("alias", command.Alias))
+		return nil, fmt.Errorf("%s: access denied: %w", fn, err)
+	}
+
+	// content of such a file can be decrypted only with its password
+	if fileInfo.PasswordKey && (command.RemovePassword || command.Password != nil) {
+		log.Info("password of file is bound to its key", slog.String("alias", command.Alias))
+		return nil, domainErrors.ErrFilePasswordBound
+	}
+
+	if command.RemovePassword {
+		empty := ""
+		update.PasswordHash = &empty
+	}
+
+	if command.Password != nil {
+		hashedBytes, err := bcrypt.GenerateFromPassword([]byte(*command.Password), bcrypt.DefaultCost)
+		if err != nil {
+			log.Error("failed to hash password", sl.Error(err))
+			return nil, fmt.Errorf("%s: failed to hash password: %w", fn, err)
+		}
+
+		hash := string(hashedBytes)
+		update.PasswordHash = &hash
+	}
+
+	tx, err := fs.fileRepo.BeginTx(ctx)
+	if err != nil {
+		log.Error("failed to begin tx", sl.Error(err))
+		return nil, fmt.Errorf("%s: failed to begin tx: %w", fn, err)
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			if err := tx.Rollback(); err != nil {
+				log.Error("failed to rollback tx", sl.Error(err))
+			}
+		}
+	}()
+
+	// running downloads hold slots of a new limit, so the stored value is
+	// reported rather than the requested one
+	downloadsLeft, err := fs.fileRepo.UpdateFileTx(ctx, tx, update)
+	if err != nil {
+		const msg = "failed to update file info"
+		if errors.Is(err, domainErrors.ErrFileNotFound) || isCtxError(err) {
+			log.Info(msg, sl.Error(err), slog.String("alias", command.Alias))
+			return nil, err
+		}
+
+		log.Error(msg, sl.Error(err), slog.String("alias", command.Alias))
+		return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Error(err))
+		return nil, fmt.Errorf("%s: failed to commit tx: %w", fn, err)
+	}
+
+	success = true
+	return &results.GetFile{
+		DownloadsLeft: max(downloadsLeft, 0),
+		ExpiresIn:     ttl,
+	}, nil
+}
@@ -0,0 +1,389 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"expire-share/internal/config"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/domain/interfaces/tx"
+	"expire-share/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestService_UpdateFile(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	cfg := config.Config{
+		Service: config.Service{
+			Permissions: config.Permissions{
+				MaxTtlForUser:       24 * time.Hour,
+				MaxTtlForVip:        7 * 24 * time.Hour,
+				MaxDownloadsForUser: 10,
+				MaxDownloadsForVip:  100,
+			},
+		},
+	}
+
+	userInfo := commands.RequestingUserInfo{
+		UserID: int64(1),
+		Roles:  []entities.UserRole{entities.RoleUser},
+	}
+
+	existingFile := func() *entities.File {
+		return &entities.File{
+			Alias:         "file-alias",
+			UserID:        userInfo.UserID,
+			DownloadsLeft: 3,
+			PasswordHash:  "old-hash",
+			ExpiresAt:     time.Now().Add(time.Hour),
+		}
+	}
+
+	ttl := func(d time.Duration) *time.Duration { return &d }
+	downloads := func(n int16) *int16 { return &n }
+	password := func(p string) *string { return &p }
+
+	t.Run("success extend ttl and downloads", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), "file-alias").
+			Return(existingFile(), nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+
+		mockFileRepo.EXPECT().UpdateFileTx(gomock.Any(), mockTx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ tx.Tx, cmd commands.UpdateFileInfo) (int16, error) {
+				require.Equal(t, "file-alias", cmd.Alias)
+				require.NotNil(t, cmd.ExpiresAt)
+				require.WithinDuration(t, time.Now().Add(12*time.Hour), *cmd.ExpiresAt, time.Second)
+				require.Equal(t, int16(7), *cmd.DownloadsLeft)
+				require.Nil(t, cmd.PasswordHash)
+				return 7, nil
+			})
+
+		mockTx.EXPECT().Commit().Return(nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UpdateFile(context.Background(), commands.UpdateFile{
+			Alias:              "file-alias",
+			TTL:                ttl(12 * time.Hour),
+			DownloadsLeft:      downloads(7),
+			RequestingUserInfo: userInfo,
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, int16(7), result.DownloadsLeft)
+		require.Equal(t, 12*time.Hour, result.ExpiresIn)
+	})
+
+	t.Run("downloads left reported as stored while downloads are running", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), "file-alias").
+			Return(existingFile(), nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+
+		// one download is reserved, so the repo stores one less than requested
+		mockFileRepo.EXPECT().UpdateFileTx(gomock.Any(), mockTx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ tx.Tx, cmd commands.UpdateFileInfo) (int16, error) {
+				require.Equal(t, int16(5), *cmd.DownloadsLeft)
+				return 4, nil
+			})
+
+		mockTx.EXPECT().Commit().Return(nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UpdateFile(context.Background(), commands.UpdateFile{
+			Alias:              "file-alias",
+			DownloadsLeft:      downloads(5),
+			RequestingUserInfo: userInfo,
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, int16(4), result.DownloadsLeft)
+	})
+
+	t.Run("success set password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), "file-alias").
+			Return(existingFile(), nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+
+		mockFileRepo.EXPECT().UpdateFileTx(gomock.Any(), mockTx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ tx.Tx, cmd commands.UpdateFileInfo) (int16, error) {
+				require.Nil(t, cmd.ExpiresAt)
+				require.Nil(t, cmd.DownloadsLeft)
+				require.NotNil(t, cmd.PasswordHash)
+				require.NoError(t, bcrypt.CompareHashAndPassword([]byte(*cmd.PasswordHash), []byte("new-password")))
+				return existingFile().DownloadsLeft, nil
+			})
+
+		mockTx.EXPECT().Commit().Return(nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UpdateFile(context.Background(), commands.UpdateFile{
+			Alias:              "file-alias",
+			Password:           password("new-password"),
+			RequestingUserInfo: userInfo,
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, int16(3), result.DownloadsLeft)
+	})
+
+	t.Run("success remove password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), "file-alias").
+			Return(existingFile(), nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+
+		mockFileRepo.EXPECT().UpdateFileTx(gomock.Any(), mockTx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ tx.Tx, cmd commands.UpdateFileInfo) (int16, error) {
+				require.NotNil(t, cmd.PasswordHash)
+				require.Empty(t, *cmd.PasswordHash)
+				return existingFile().DownloadsLeft, nil
+			})
+
+		mockTx.EXPECT().Commit().Return(nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		_, err := fileService.UpdateFile(context.Background(), commands.UpdateFile{
+			Alias:              "file-alias",
+			RemovePassword:     true,
+			RequestingUserInfo: userInfo,
+		})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("ttl exceeds user limit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), "file-alias").
+			Return(existingFile(), nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UpdateFile(context.Background(), commands.UpdateFile{
+			Alias:              "file-alias",
+			TTL:                ttl(48 * time.Hour),
+			RequestingUserInfo: userInfo,
+		})
+
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrTTLTooLong)
+	})
+
+	t.Run("downloads exceed user limit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), "file-alias").
+			Return(existingFile(), nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UpdateFile(context.Background(), commands.UpdateFile{
+			Alias:              "file-alias",
+			DownloadsLeft:      downloads(11),
+			RequestingUserInfo: userInfo,
+		})
+
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrTooManyDownloads)
+	})
+
+	t.Run("vip has higher limits", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), "file-alias").
+			Return(existingFile(), nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+		mockFileRepo.EXPECT().UpdateFileTx(gomock.Any(), mockTx, gomock.Any()).Return(int16(1), nil)
+		mockTx.EXPECT().Commit().Return(nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		_, err := fileService.UpdateFile(context.Background(), commands.UpdateFile{
+			Alias:         "file-alias",
+			TTL:           ttl(48 * time.Hour),
+			DownloadsLeft: downloads(50),
+			RequestingUserInfo: commands.RequestingUserInfo{
+				UserID: userInfo.UserID,
+				Roles:  []entities.UserRole{entities.RoleUser, entities.RoleVip},
+			},
+		})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("admin edits foreign file without limits", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), "file-alias").
+			Return(existingFile(), nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+		mockFileRepo.EXPECT().UpdateFileTx(gomock.Any(), mockTx, gomock.Any()).Return(int16(1), nil)
+		mockTx.EXPECT().Commit().Return(nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		_, err := fileService.UpdateFile(context.Background(), commands.UpdateFile{
+			Alias:         "file-alias",
+			TTL:           ttl(365 * 24 * time.Hour),
+			DownloadsLeft: downloads(10000),
+			RequestingUserInfo: commands.RequestingUserInfo{
+				UserID: int64(2),
+				Roles:  []entities.UserRole{entities.RoleAdmin},
+			},
+		})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("forbidden for not owner", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), "file-alias").
+			Return(existingFile(), nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UpdateFile(context.Background(), commands.UpdateFile{
+			Alias:         "file-alias",
+			DownloadsLeft: downloads(1),
+			RequestingUserInfo: commands.RequestingUserInfo{
+				UserID: int64(2),
+				Roles:  []entities.UserRole{entities.RoleUser},
+			},
+		})
+
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrForbidden)
+	})
+
+	t.Run("password bound to file key can't be changed", func(t *testing.T) {
+		updates := []commands.UpdateFile{
+			{Alias: "file-alias", Password: password("new-password"), RequestingUserInfo: userInfo},
+			{Alias: "file-alias", RemovePassword: true, RequestingUserInfo: userInfo},
+		}
+
+		for _, command := range updates {
+			ctrl := gomock.NewController(t)
+
+			mockFileRepo := mocks.NewMockFileRepo(ctrl)
+			mockFileStorage := mocks.NewMockFile(ctrl)
+
+			file := existingFile()
+			file.PasswordKey = true
+
+			mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), "file-alias").
+				Return(file, nil)
+
+			fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+			result, err := fileService.UpdateFile(context.Background(), command)
+
+			require.Nil(t, result)
+			require.ErrorIs(t, err, domainErrors.ErrFilePasswordBound)
+			ctrl.Finish()
+		}
+	})
+
+	t.Run("file not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), "file-alias").
+			Return(nil, domainErrors.ErrFileNotFound)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UpdateFile(context.Background(), commands.UpdateFile{
+			Alias:              "file-alias",
+			RequestingUserInfo: userInfo,
+		})
+
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
+	})
+
+	t.Run("repo update error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetFileByAlias(gomock.Any(), "file-alias").
+			Return(existingFile(), nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+		mockFileRepo.EXPECT().UpdateFileTx(gomock.Any(), mockTx, gomock.Any()).
+			Return(int16(0), errors.New("db error"))
+		mockTx.EXPECT().Rollback().Return(nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UpdateFile(context.Background(), commands.UpdateFile{
+			Alias:              "file-alias",
+			DownloadsLeft:      downloads(1),
+			RequestingUserInfo: userInfo,
+		})
+
+		require.Nil(t, result)
+		require.Error(t, err)
+	})
+}
@@ -2,8 +2,13 @@ package files
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
 	"expire-share/internal/domain/dto/files/commands"
-	"expire-share/internal/lib/alias"
+	"expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/domain/interfaces/storage"
 	"expire-share/internal/lib/log/sl"
 	"fmt"
 	"log/slog"
@@ -11,43 +16,159 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-func (fs *Service) UploadFile(ctx context.Context, command commands.UploadFile) (string, error) {
+// blobKeyPrefix keeps storage keys of deduplicated contents apart from
+// aliases, which can't start with a dot
+const blobKeyPrefix = ".blobs/"
+
+// UploadFile stores the file, or several files of a multi-file share, under
+// a new alias. Guests get a management token to inspect and delete the
+// file later
+func (fs *Service) UploadFile(ctx context.Context, command commands.UploadFile) (*results.UploadFile, error) {
 	const fn = "services.file.Service.UploadFile"
 	log := fs.log.With(slog.String("fn", fn))
 
-	filesCount, err := fs.fileRepo.CountByUserID(ctx, command.UserID)
-	if err != nil {
-		const msg = "failed to count files by user id"
-		if isCtxError(err) {
-			log.Info(msg, sl.Error(err), slog.Int64("user_id", command.UserID))
-			return "", err
-		}
+	if len(command.Members) > max(fs.cfg.MaxShareFiles, 1) {
+		log.Info("too many files in share", slog.Int("files", len(command.Members)))
+		return nil, domainErrors.ErrTooManyFiles
+	}
 
-		log.Info(msg, sl.Error(err), slog.Int64("user_id", command.UserID))
-		return "", fmt.Errorf("%s: %s: %w", fn, msg, err)
+	// a key bound to the password needs the password itself, which is not
+	// known when only its hash was kept
+	if fs.cfg.PasswordKeys && command.PasswordHash != "" && len(command.Password) == 0 {
+		log.Info("password key can't be derived from password hash")
+		return nil, domainErrors.ErrPasswordKeyResumable
 	}
 
-	err = fs.checkUploadQuote(filesCount, command.FileSize, command.Roles)
+	paths, err := memberPaths(command.Members)
 	if err != nil {
-		log.Info("access denied", sl.Error(err), slog.Int64("user_id", command.UserID))
-		return "", fmt.Errorf("%s: failed to upload quote: %w", fn, err)
+		log.Info("invalid file path", sl.Error(err))
+		return nil, err
 	}
 
-	genAlias := alias.Gen(fs.cfg.AliasLength)
+	if command.Alias != "" {
+		if err := fs.checkVanityAlias(command.Alias, command.Roles); err != nil {
+			log.Info("vanity alias was refused", sl.Error(err), slog.String("alias", command.Alias))
+			return nil, err
+		}
+	}
 
-	var hashedBytes []byte
+	err = fs.CheckUploadQuota(ctx, commands.CheckUploadQuota{
+		FileSize:           command.FileSize,
+		MaxDownloads:       command.MaxDownloads,
+		TTL:                command.TTL,
+		RequestingUserInfo: command.RequestingUserInfo,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to upload quote: %w", fn, err)
+	}
+
+	hashedBytes := []byte(command.PasswordHash)
 	if len(command.Password) > 0 {
 		hashedBytes, err = bcrypt.GenerateFromPassword([]byte(command.Password), bcrypt.DefaultCost)
 		if err != nil {
 			log.Error("failed to hash password", sl.Error(err))
-			return "", fmt.Errorf("%s: failed to hash password: %w", fn, err)
+			return nil, fmt.Errorf("%s: failed to hash password: %w", fn, err)
+		}
+	}
+
+	addFile := commands.AddFile{
+		Filename:     command.Filename,
+		Alias:        command.Alias,
+		Size:         command.FileSize,
+		MaxDownloads: command.MaxDownloads,
+		TTL:          fs.cfg.PendingTimeout,
+		PasswordHash: string(hashedBytes),
+		UserID:       command.UserID,
+		Pending:      true,
+		PasswordKey:  fs.cfg.PasswordKeys && len(command.Password) > 0,
+	}
+
+	if len(command.Members) > 0 {
+		addFile.Filename = bundleFilename
+	}
+
+	// password-bound keys can't be shared, so such files are never
+	// deduplicated. Files of a multi-file share are stored under its alias
+	deduplicate := fs.cfg.Deduplicate && len(command.Members) == 0 && !addFile.PasswordKey
+	if deduplicate {
+		addFile.StorageKey = blobKeyPrefix + rand.Text()
+	}
+
+	var managementToken string
+	if hasRole(command.Roles, entities.RoleGuest) {
+		managementToken = rand.Text()
+		addFile.GuestIP = command.IP
+		addFile.ManagementTokenHash = hashManagementToken(managementToken)
+	}
+
+	genAlias, err := fs.addPendingFile(ctx, addFile)
+
+	if err != nil {
+		const msg = "failed to add file info"
+		if command.Alias != "" && errors.Is(err, domainErrors.ErrAliasTaken) || isCtxError(err) {
+			log.Info(msg, sl.Error(err), slog.Int64("user_id", command.UserID))
+			return nil, err
+		}
+
+		log.Error(msg, sl.Error(err), slog.Int64("user_id", command.UserID))
+		return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
+	}
+
+	contentKey := genAlias
+	if addFile.StorageKey != "" {
+		contentKey = addFile.StorageKey
+	}
+
+	promoted := false
+	defer func() {
+		if !promoted {
+			fs.discardPendingFile(context.WithoutCancel(ctx), genAlias, contentKey)
 		}
+	}()
+
+	// no transaction is held while the file is streamed, the pending row
+	// keeps the alias and counts against the quota meanwhile
+	storageCtx := ctx
+	if len(command.Password) > 0 {
+		storageCtx = storage.WithPassword(ctx, command.Password)
+	}
+
+	promote := commands.PromoteFile{
+		Alias:      genAlias,
+		TTL:        command.TTL,
+		StorageKey: addFile.StorageKey,
+	}
+
+	if len(command.Members) > 0 {
+		promote.Members, err = fs.uploadMembers(storageCtx, genAlias, command.Members, paths)
+		promote.ContentType = bundleContentType
+		for _, member := range promote.Members {
+			promote.Size += member.Size
+		}
+	} else {
+		content := newContentReader(command.File)
+		err = fs.fileStorage.Upload(storageCtx, content, contentKey, command.Filename)
+		promote.Size = content.size
+		promote.ContentType = content.ContentType(command.Filename)
+		promote.SHA256 = content.SHA256()
+	}
+
+	if err != nil {
+		const msg = "failed to upload file"
+		if isCtxError(err) {
+			log.Info(msg, sl.Error(err))
+			return nil, err
+		}
+
+		log.Error(msg, sl.Error(err))
+		return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
 	}
 
 	tx, err := fs.fileRepo.BeginTx(ctx)
 	if err != nil {
 		log.Error("failed to begin tx", sl.Error(err))
-		return "", fmt.Errorf("%s: failed to upload file: %w", fn, err)
+		return nil, fmt.Errorf("%s: failed to begin tx: %w", fn, err)
 	}
 
 	success := false
@@ -59,42 +180,157 @@ func (fs *Service) UploadFile(ctx context.Context, command commands.UploadFile)
 		}
 	}()
 
-	_, err = fs.fileRepo.AddFileTx(ctx, tx, commands.AddFile{
-		Filename:     command.Filename,
-		Alias:        genAlias,
-		MaxDownloads: command.MaxDownloads,
-		TTL:          command.TTL,
-		PasswordHash: string(hashedBytes),
-		UserID:       command.UserID,
-	})
+	if deduplicate {
+		blob := entities.Blob{SHA256: promote.SHA256, StorageKey: contentKey, Size: promote.Size}
+		promote.StorageKey, err = fs.fileRepo.AcquireBlobTx(ctx, tx, blob)
+		if err != nil {
+			const msg = "failed to acquire blob"
+			if isCtxError(err) {
+				log.Info(msg, sl.Error(err), slog.String("alias", genAlias))
+				return nil, err
+			}
 
-	if err != nil {
-		const msg = "failed to add file info"
-		if isCtxError(err) {
-			log.Info(msg, sl.Error(err), slog.Int64("user_id", command.UserID))
-			return "", err
+			log.Error(msg, sl.Error(err), slog.String("alias", genAlias))
+			return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
 		}
+	}
 
-		log.Error(msg, sl.Error(err), slog.Int64("user_id", command.UserID))
-		return "", fmt.Errorf("%s: %s: %w", fn, msg, err)
+	if err := fs.fileRepo.PromoteFileTx(ctx, tx, promote); err != nil {
+		const msg = "failed to promote file"
+		if errors.Is(err, domainErrors.ErrFileNotFound) || isCtxError(err) {
+			log.Info(msg, sl.Error(err), slog.String("alias", genAlias))
+			return nil, err
+		}
+
+		log.Error(msg, sl.Error(err), slog.String("alias", genAlias))
+		return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
 	}
 
-	if err := fs.fileStorage.Upload(ctx, command.File, genAlias, command.Filename); err != nil {
-		const msg = "failed to upload file"
-		if isCtxError(err) {
-			log.Info(msg, sl.Error(err))
-			return "", err
+	// the same content is stored already, so the uploaded copy is not needed.
+	// It goes before commit, the pending row still refers to it on failure
+	if deduplicate && promote.StorageKey != contentKey {
+		if err := fs.fileStorage.Delete(ctx, contentKey); err != nil {
+			const msg = "failed to delete duplicate from storage"
+			if isCtxError(err) {
+				log.Info(msg, sl.Error(err), slog.String("alias", genAlias))
+				return nil, err
+			}
+
+			log.Error(msg, sl.Error(err), slog.String("alias", genAlias))
+			return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
 		}
+	}
 
-		log.Error(msg, sl.Error(err))
-		return "", fmt.Errorf("%s: %s: %w", fn, msg, err)
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Error(err))
+		return nil, fmt.Errorf("%s: failed to commit tx: %w", fn, err)
+	}
+
+	success = true
+	promoted = true
+	return &results.UploadFile{
+		Alias:           genAlias,
+		ManagementToken: managementToken,
+		Size:            promote.Size,
+		ContentType:     promote.ContentType,
+		SHA256:          promote.SHA256,
+		Members:         shareMembers(promote.Members),
+	}, nil
+}
+
+// addPendingFile records the file before its content is uploaded, so
+// storage never holds a file without a row the file worker can find it by.
+// Without an alias in command a new one is generated, a taken generated
+// alias is retried with a longer one
+func (fs *Service) addPendingFile(ctx context.Context, command commands.AddFile) (string, error) {
+	const fn = "services.files.Service.addPendingFile"
+	log := fs.log.With(slog.String("fn", fn))
+
+	if command.Alias != "" {
+		return command.Alias, fs.insertPendingFile(ctx, command)
+	}
+
+	attempts := max(fs.cfg.AliasAttempts, 1)
+
+	var err error
+	for attempt := range attempts {
+		command.Alias = fs.aliases.Gen(int16(attempt))
+
+		err = fs.insertPendingFile(ctx, command)
+		if !errors.Is(err, domainErrors.ErrAliasTaken) {
+			return command.Alias, err
+		}
+
+		log.Info("alias is taken, retrying with longer alias",
+			slog.String("alias", command.Alias), slog.Int("attempt", attempt+1))
+	}
+
+	return "", fmt.Errorf("%s: no free alias after %d attempts: %w", fn, attempts, err)
+}
+
+func (fs *Service) insertPendingFile(ctx context.Context, command commands.AddFile) error {
+	const fn = "services.files.Service.insertPendingFile"
+	log := fs.log.With(slog.String("fn", fn))
+
+	tx, err := fs.fileRepo.BeginTx(ctx)
+	if err != nil {
+		log.Error("failed to begin tx", sl.Error(err))
+		return fmt.Errorf("%s: failed to begin tx: %w", fn, err)
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			if err := tx.Rollback(); err != nil {
+				log.Error("failed to rollback tx", sl.Error(err))
+			}
+		}
+	}()
+
+	if _, err := fs.fileRepo.AddFileTx(ctx, tx, command); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
 		log.Error("failed to commit tx", sl.Error(err))
-		return "", fmt.Errorf("%s: failed to upload file to storage: %w", fn, err)
+		return fmt.Errorf("%s: failed to commit tx: %w", fn, err)
 	}
 
 	success = true
-	return genAlias, nil
+	return nil
+}
+
+// discardPendingFile removes content and row of a file which upload failed.
+// Whatever is left is removed by the file worker once the pending file expires
+func (fs *Service) discardPendingFile(ctx context.Context, alias, contentKey string) {
+	const fn = "services.files.Service.discardPendingFile"
+	log := fs.log.With(slog.String("fn", fn), slog.String("alias", alias))
+
+	// the row goes last, content without a row would never be removed
+	if err := fs.fileStorage.Delete(ctx, contentKey); err != nil {
+		log.Warn("failed to delete file from storage", sl.Error(err))
+		return
+	}
+
+	tx, err := fs.fileRepo.BeginTx(ctx)
+	if err != nil {
+		log.Warn("failed to begin tx", sl.Error(err))
+		return
+	}
+
+	if err := fs.fileRepo.DeletePendingFileTx(ctx, tx, alias); err != nil {
+		if err := tx.Rollback(); err != nil {
+			log.Warn("failed to rollback tx", sl.Error(err))
+		}
+
+		if !errors.Is(err, domainErrors.ErrFileNotFound) {
+			log.Warn("failed to delete pending file", sl.Error(err))
+		}
+
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Warn("failed to commit tx", sl.Error(err))
+	}
 }
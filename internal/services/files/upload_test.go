@@ -2,11 +2,14 @@ package files
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"expire-share/internal/config"
 	"expire-share/internal/domain/dto/files/commands"
 	"expire-share/internal/domain/entities"
 	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/domain/interfaces/storage"
 	"expire-share/internal/domain/interfaces/tx"
 	"expire-share/internal/mocks"
 	"github.com/golang/mock/gomock"
@@ -27,11 +30,30 @@ func TestService_UploadFile(t *testing.T) {
 		},
 
 		Service: config.Service{
-			AliasLength: 6,
+			AliasLength:   6,
+			AliasAttempts: 2,
 			Permissions: config.Permissions{
-				MaxUploadedFileForUser: 1,
-				MaxUploadedFileForVip:  10,
+				MaxUploadedFileForUser:     1,
+				MaxUploadedFileForVip:      10,
+				MaxStorageForUserInBytes:   1024,
+				MaxFileSizeForUserInBytes:  512,
+				VanityAliasForVip:          true,
+				MaxUploadedFileForGuest:    1,
+				MaxStorageForGuestInBytes:  100,
+				MaxFileSizeForGuestInBytes: 50,
+				MaxTtlForGuest:             time.Hour,
+				MaxDownloadsForGuest:       1,
 			},
+			VanityAliases: config.VanityAliases{
+				VanityMinLength: 4,
+				VanityMaxLength: 50,
+				VanityBlocklist: []string{"darn"},
+			},
+		},
+
+		Uploads: config.Uploads{
+			PendingTimeout: time.Hour,
+			MaxShareFiles:  10,
 		},
 	}
 
@@ -56,10 +78,10 @@ func TestService_UploadFile(t *testing.T) {
 		mockFileRepo := mocks.NewMockFileRepo(ctrl)
 		mockFileStorage := mocks.NewMockFile(ctrl)
 
-		mockFileRepo.EXPECT().CountByUserID(gomock.Any(), command.UserID).
-			Return(0, nil)
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), command.UserID).
+			Return(entities.StorageUsage{}, nil)
 
-		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil).Times(2)
 
 		mockFileRepo.EXPECT().AddFileTx(gomock.Any(), mockTx, gomock.Any()).
 			DoAndReturn(func(_ context.Context, _ tx.Tx, cmd commands.AddFile) (*entities.File, error) {
@@ -67,18 +89,312 @@ func TestService_UploadFile(t *testing.T) {
 				require.Equal(t, command.MaxDownloads, cmd.MaxDownloads)
 				require.Empty(t, cmd.PasswordHash)
 				require.NotEmpty(t, cmd.Alias)
+				require.True(t, cmd.Pending)
+				require.Equal(t, cfg.PendingTimeout, cmd.TTL)
 				return &entities.File{Alias: cmd.Alias}, nil
 			})
 
 		mockFileStorage.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any(), command.Filename).
 			Return(nil)
 
-		mockTx.EXPECT().Commit().Return(nil)
+		mockFileRepo.EXPECT().PromoteFileTx(gomock.Any(), mockTx, gomock.Any()).Return(nil)
+
+		mockTx.EXPECT().Commit().Return(nil).Times(2)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UploadFile(context.Background(), command)
+		require.NoError(t, err)
+		require.NotEmpty(t, result.Alias)
+	})
+
+	t.Run("content is measured and hashed while uploaded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		pngCommand := command
+		pngCommand.File = io.NopCloser(strings.NewReader("\x89PNG\r\n\x1a\nimage"))
+		pngCommand.Filename = "picture.bin"
+
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), command.UserID).
+			Return(entities.StorageUsage{}, nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil).Times(2)
+		mockFileRepo.EXPECT().AddFileTx(gomock.Any(), mockTx, gomock.Any()).Return(int64(1), nil)
+
+		mockFileStorage.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any(), pngCommand.Filename).
+			DoAndReturn(func(_ context.Context, file io.Reader, _ string, _ string) error {
+				_, err := io.Copy(io.Discard, file)
+				return err
+			})
+
+		var promoted commands.PromoteFile
+		mockFileRepo.EXPECT().PromoteFileTx(gomock.Any(), mockTx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ tx.Tx, cmd commands.PromoteFile) error {
+				promoted = cmd
+				return nil
+			})
+
+		mockTx.EXPECT().Commit().Return(nil).Times(2)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UploadFile(context.Background(), pngCommand)
+		require.NoError(t, err)
+
+		sum := sha256.Sum256([]byte("\x89PNG\r\n\x1a\nimage"))
+		require.Equal(t, result.Alias, promoted.Alias)
+		require.Equal(t, command.TTL, promoted.TTL)
+		require.Equal(t, int64(13), promoted.Size)
+		require.Equal(t, "image/png", promoted.ContentType)
+		require.Equal(t, hex.EncodeToString(sum[:]), promoted.SHA256)
+		require.Equal(t, promoted.Size, result.Size)
+		require.Equal(t, promoted.ContentType, result.ContentType)
+		require.Equal(t, promoted.SHA256, result.SHA256)
+	})
+
+	t.Run("deduplicated content", func(t *testing.T) {
+		dedupCfg := cfg
+		dedupCfg.Deduplicate = true
+
+		sum := sha256.Sum256([]byte("file content"))
+		tests := []struct {
+			name      string
+			storedKey string
+			duplicate bool
+		}{
+			{name: "new content is kept as a blob"},
+			{name: "duplicate is dropped for the stored blob", storedKey: ".blobs/STORED", duplicate: true},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				mockTx := mocks.NewMockTx(ctrl)
+				mockFileRepo := mocks.NewMockFileRepo(ctrl)
+				mockFileStorage := mocks.NewMockFile(ctrl)
+
+				dedupCommand := command
+				dedupCommand.File = io.NopCloser(strings.NewReader("file content"))
+
+				mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), command.UserID).
+					Return(entities.StorageUsage{}, nil)
+
+				mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil).Times(2)
+
+				var added commands.AddFile
+				mockFileRepo.EXPECT().AddFileTx(gomock.Any(), mockTx, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ tx.Tx, cmd commands.AddFile) (int64, error) {
+						added = cmd
+						return 1, nil
+					})
+
+				mockFileStorage.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any(), command.Filename).
+					DoAndReturn(func(_ context.Context, file io.Reader, key string, _ string) error {
+						require.Equal(t, added.StorageKey, key)
+						_, err := io.Copy(io.Discard, file)
+						return err
+					})
+
+				mockFileRepo.EXPECT().AcquireBlobTx(gomock.Any(), mockTx, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ tx.Tx, blob entities.Blob) (string, error) {
+						require.Equal(t, hex.EncodeToString(sum[:]), blob.SHA256)
+						require.Equal(t, added.StorageKey, blob.StorageKey)
+						require.Equal(t, int64(12), blob.Size)
+						if test.storedKey != "" {
+							return test.storedKey, nil
+						}
+
+						return blob.StorageKey, nil
+					})
+
+				var promoted commands.PromoteFile
+				mockFileRepo.EXPECT().PromoteFileTx(gomock.Any(), mockTx, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ tx.Tx, cmd commands.PromoteFile) error {
+						promoted = cmd
+						return nil
+					})
+
+				if test.duplicate {
+					mockFileStorage.EXPECT().Delete(gomock.Any(), gomock.Any()).
+						DoAndReturn(func(_ context.Context, key string) error {
+							require.Equal(t, added.StorageKey, key)
+							return nil
+						})
+				}
+
+				mockTx.EXPECT().Commit().Return(nil).Times(2)
+
+				fileService := New(mockFileRepo, mockFileStorage, nil, log, dedupCfg)
+				result, err := fileService.UploadFile(context.Background(), dedupCommand)
+				require.NoError(t, err)
+				require.True(t, strings.HasPrefix(added.StorageKey, blobKeyPrefix))
+				require.NotEqual(t, result.Alias, added.StorageKey)
+
+				wantKey := added.StorageKey
+				if test.storedKey != "" {
+					wantKey = test.storedKey
+				}
+
+				require.Equal(t, wantKey, promoted.StorageKey)
+			})
+		}
+	})
+
+	t.Run("password-bound file is not deduplicated", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		boundCfg := cfg
+		boundCfg.Deduplicate = true
+		boundCfg.PasswordKeys = true
+
+		passwordCommand := command
+		passwordCommand.Password = "secret"
+
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), command.UserID).
+			Return(entities.StorageUsage{}, nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil).Times(2)
+
+		var added commands.AddFile
+		mockFileRepo.EXPECT().AddFileTx(gomock.Any(), mockTx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ tx.Tx, cmd commands.AddFile) (int64, error) {
+				added = cmd
+				return 1, nil
+			})
+
+		mockFileStorage.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any(), command.Filename).
+			DoAndReturn(func(_ context.Context, _ io.Reader, key string, _ string) error {
+				require.Equal(t, added.Alias, key)
+				return nil
+			})
+
+		mockFileRepo.EXPECT().PromoteFileTx(gomock.Any(), mockTx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ tx.Tx, cmd commands.PromoteFile) error {
+				require.Empty(t, cmd.StorageKey)
+				return nil
+			})
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
-		alias, err := fileService.UploadFile(context.Background(), command)
+		mockTx.EXPECT().Commit().Return(nil).Times(2)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, boundCfg)
+		_, err := fileService.UploadFile(context.Background(), passwordCommand)
 		require.NoError(t, err)
-		require.NotEmpty(t, alias)
+		require.Empty(t, added.StorageKey)
+		require.True(t, added.PasswordKey)
+	})
+
+	t.Run("password key can't be derived from password hash", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		boundCfg := cfg
+		boundCfg.PasswordKeys = true
+
+		hashCommand := command
+		hashCommand.PasswordHash = "$2a$10$hash"
+
+		fileService := New(mocks.NewMockFileRepo(ctrl), mocks.NewMockFile(ctrl), nil, log, boundCfg)
+		result, err := fileService.UploadFile(context.Background(), hashCommand)
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrPasswordKeyResumable)
+	})
+
+	t.Run("multi-file share", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		shareCommand := command
+		shareCommand.File = nil
+		shareCommand.Filename = ""
+		shareCommand.FileSize = 10
+		shareCommand.Members = []commands.UploadMember{
+			{File: strings.NewReader("first"), Filename: "notes.txt", Size: 5},
+			{File: strings.NewReader("again"), Filename: "notes.txt", Size: 5},
+		}
+
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), command.UserID).
+			Return(entities.StorageUsage{}, nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil).Times(2)
+
+		var alias string
+		mockFileRepo.EXPECT().AddFileTx(gomock.Any(), mockTx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ tx.Tx, cmd commands.AddFile) (*entities.File, error) {
+				require.Equal(t, bundleFilename, cmd.Filename)
+				require.Equal(t, shareCommand.FileSize, cmd.Size)
+				alias = cmd.Alias
+				return &entities.File{Alias: cmd.Alias}, nil
+			})
+
+		var keys []string
+		mockFileStorage.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any(), "notes.txt").
+			DoAndReturn(func(_ context.Context, file io.Reader, key string, _ string) error {
+				keys = append(keys, key)
+				_, err := io.Copy(io.Discard, file)
+				return err
+			})
+
+		mockFileStorage.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any(), "notes (2).txt").
+			DoAndReturn(func(_ context.Context, file io.Reader, key string, _ string) error {
+				keys = append(keys, key)
+				_, err := io.Copy(io.Discard, file)
+				return err
+			})
+
+		mockFileRepo.EXPECT().PromoteFileTx(gomock.Any(), mockTx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ tx.Tx, cmd commands.PromoteFile) error {
+				require.Equal(t, int64(10), cmd.Size)
+				require.Equal(t, bundleContentType, cmd.ContentType)
+				require.Empty(t, cmd.StorageKey)
+				require.Len(t, cmd.Members, 2)
+				require.Equal(t, "notes.txt", cmd.Members[0].Name)
+				require.Equal(t, "notes (2).txt", cmd.Members[1].Name)
+				require.Equal(t, alias+"/0", cmd.Members[0].StorageKey)
+				require.Equal(t, alias+"/1", cmd.Members[1].StorageKey)
+				require.Equal(t, "text/plain; charset=utf-8", cmd.Members[0].ContentType)
+				return nil
+			})
+
+		mockTx.EXPECT().Commit().Return(nil).Times(2)
+
+		deduplicatingCfg := cfg
+		deduplicatingCfg.Deduplicate = true
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, deduplicatingCfg)
+		result, err := fileService.UploadFile(context.Background(), shareCommand)
+		require.NoError(t, err)
+		require.Equal(t, []string{alias + "/0", alias + "/1"}, keys)
+		require.Len(t, result.Members, 2)
+		require.Equal(t, int64(5), result.Members[1].Size)
+	})
+
+	t.Run("too many files in share", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		shareCommand := command
+		shareCommand.Members = make([]commands.UploadMember, 3)
+
+		limitedCfg := cfg
+		limitedCfg.MaxShareFiles = 2
+
+		fileService := New(mocks.NewMockFileRepo(ctrl), mocks.NewMockFile(ctrl), nil, log, limitedCfg)
+		_, err := fileService.UploadFile(context.Background(), shareCommand)
+		require.ErrorIs(t, err, domainErrors.ErrTooManyFiles)
 	})
 
 	t.Run("success with password", func(t *testing.T) {
@@ -89,10 +405,10 @@ func TestService_UploadFile(t *testing.T) {
 		mockFileRepo := mocks.NewMockFileRepo(ctrl)
 		mockFileStorage := mocks.NewMockFile(ctrl)
 
-		mockFileRepo.EXPECT().CountByUserID(gomock.Any(), command.UserID).
-			Return(0, nil)
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), command.UserID).
+			Return(entities.StorageUsage{}, nil)
 
-		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil).Times(2)
 
 		mockFileRepo.EXPECT().AddFileTx(gomock.Any(), mockTx, gomock.Any()).
 			DoAndReturn(func(_ context.Context, _ tx.Tx, cmd commands.AddFile) (*entities.File, error) {
@@ -102,12 +418,17 @@ func TestService_UploadFile(t *testing.T) {
 			})
 
 		mockFileStorage.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-			Return(nil)
+			DoAndReturn(func(ctx context.Context, _ io.Reader, _, _ string) error {
+				require.Equal(t, "file-password", storage.PasswordFromContext(ctx))
+				return nil
+			})
 
-		mockTx.EXPECT().Commit().Return(nil)
+		mockFileRepo.EXPECT().PromoteFileTx(gomock.Any(), mockTx, gomock.Any()).Return(nil)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
-		alias, err := fileService.UploadFile(context.Background(), commands.UploadFile{
+		mockTx.EXPECT().Commit().Return(nil).Times(2)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UploadFile(context.Background(), commands.UploadFile{
 			File:         io.NopCloser(strings.NewReader("content")),
 			Filename:     "secret.txt",
 			Password:     "file-password",
@@ -119,7 +440,7 @@ func TestService_UploadFile(t *testing.T) {
 			},
 		})
 		require.NoError(t, err)
-		require.NotEmpty(t, alias)
+		require.NotEmpty(t, result.Alias)
 	})
 
 	t.Run("success vip user exceeds regular limit", func(t *testing.T) {
@@ -130,20 +451,22 @@ func TestService_UploadFile(t *testing.T) {
 		mockFileRepo := mocks.NewMockFileRepo(ctrl)
 		mockFileStorage := mocks.NewMockFile(ctrl)
 
-		mockFileRepo.EXPECT().CountByUserID(gomock.Any(), gomock.Any()).
-			Return(5, nil)
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), gomock.Any()).
+			Return(entities.StorageUsage{FilesCount: 5}, nil)
 
-		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil).Times(2)
 		mockFileRepo.EXPECT().AddFileTx(gomock.Any(), mockTx, gomock.Any()).
 			Return(int64(1), nil)
 
 		mockFileStorage.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Return(nil)
 
-		mockTx.EXPECT().Commit().Return(nil)
+		mockFileRepo.EXPECT().PromoteFileTx(gomock.Any(), mockTx, gomock.Any()).Return(nil)
+
+		mockTx.EXPECT().Commit().Return(nil).Times(2)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
-		alias, err := fileService.UploadFile(context.Background(), commands.UploadFile{
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UploadFile(context.Background(), commands.UploadFile{
 			File:         io.NopCloser(strings.NewReader("content")),
 			Filename:     "file.txt",
 			MaxDownloads: 1,
@@ -155,7 +478,7 @@ func TestService_UploadFile(t *testing.T) {
 		})
 
 		require.NoError(t, err)
-		require.NotEmpty(t, alias)
+		require.NotEmpty(t, result.Alias)
 	})
 
 	t.Run("upload limit exceeded for regular user", func(t *testing.T) {
@@ -165,16 +488,51 @@ func TestService_UploadFile(t *testing.T) {
 		mockFileRepo := mocks.NewMockFileRepo(ctrl)
 		mockFileStorage := mocks.NewMockFile(ctrl)
 
-		mockFileRepo.EXPECT().CountByUserID(gomock.Any(), command.UserID).
-			Return(1, nil)
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), command.UserID).
+			Return(entities.StorageUsage{FilesCount: 1}, nil)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
-		alias, err := fileService.UploadFile(context.Background(), command)
-		require.Empty(t, alias)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UploadFile(context.Background(), command)
+		require.Nil(t, result)
 		require.ErrorIs(t, err, domainErrors.ErrUploadLimitExceeded)
 	})
 
-	t.Run("storage upload error rollback", func(t *testing.T) {
+	t.Run("storage quota exceeded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), command.UserID).
+			Return(entities.StorageUsage{UsedBytes: 1020}, nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UploadFile(context.Background(), command)
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrStorageQuotaExceeded)
+	})
+
+	t.Run("file size exceeds role limit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), command.UserID).
+			Return(entities.StorageUsage{}, nil)
+
+		tooBig := command
+		tooBig.FileSize = 513
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UploadFile(context.Background(), tooBig)
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrFileSizeTooBig)
+	})
+
+	t.Run("taken alias is retried with longer alias", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
@@ -182,25 +540,364 @@ func TestService_UploadFile(t *testing.T) {
 		mockFileRepo := mocks.NewMockFileRepo(ctrl)
 		mockFileStorage := mocks.NewMockFile(ctrl)
 
-		mockFileRepo.EXPECT().CountByUserID(gomock.Any(), command.UserID).
-			Return(0, nil)
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), command.UserID).
+			Return(entities.StorageUsage{}, nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil).Times(3)
+
+		var aliases []string
+		mockFileRepo.EXPECT().AddFileTx(gomock.Any(), mockTx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ tx.Tx, cmd commands.AddFile) (int64, error) {
+				aliases = append(aliases, cmd.Alias)
+				if len(aliases) == 1 {
+					return 0, domainErrors.ErrAliasTaken
+				}
+
+				return 1, nil
+			}).Times(2)
+
+		mockTx.EXPECT().Rollback().Return(nil)
+
+		mockFileStorage.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any(), command.Filename).
+			Return(nil)
+
+		mockFileRepo.EXPECT().PromoteFileTx(gomock.Any(), mockTx, gomock.Any()).Return(nil)
+
+		mockTx.EXPECT().Commit().Return(nil).Times(2)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UploadFile(context.Background(), command)
+		require.NoError(t, err)
+		require.Len(t, aliases, 2)
+		require.Len(t, aliases[0], 6)
+		require.Len(t, aliases[1], 7)
+		require.Equal(t, aliases[1], result.Alias)
+	})
+
+	t.Run("success with word alias", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), command.UserID).
+			Return(entities.StorageUsage{}, nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil).Times(2)
+		mockFileRepo.EXPECT().AddFileTx(gomock.Any(), mockTx, gomock.Any()).Return(int64(1), nil)
+
+		mockFileStorage.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any(), command.Filename).
+			Return(nil)
+
+		mockFileRepo.EXPECT().PromoteFileTx(gomock.Any(), mockTx, gomock.Any()).Return(nil)
+
+		mockTx.EXPECT().Commit().Return(nil).Times(2)
+
+		wordsCfg := cfg
+		wordsCfg.AliasMode = config.AliasModeWords
+		wordsCfg.AliasWordCount = 3
+		wordsCfg.AliasSeparator = "-"
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, wordsCfg)
+		result, err := fileService.UploadFile(context.Background(), command)
+		require.NoError(t, err)
+		require.Regexp(t, `^[a-z]+-[a-z]+-[a-z]+-[0-9]{2}$`, result.Alias)
+	})
+
+	t.Run("no free alias after all attempts", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), command.UserID).
+			Return(entities.StorageUsage{}, nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil).Times(2)
+		mockFileRepo.EXPECT().AddFileTx(gomock.Any(), mockTx, gomock.Any()).
+			Return(int64(0), domainErrors.ErrAliasTaken).Times(2)
+		mockTx.EXPECT().Rollback().Return(nil).Times(2)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UploadFile(context.Background(), command)
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrAliasTaken)
+	})
+
+	vanityCommand := command
+	vanityCommand.Alias = "build-2026-10"
+	vanityCommand.Roles = []entities.UserRole{entities.RoleVip}
+
+	t.Run("success with vanity alias", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), command.UserID).
+			Return(entities.StorageUsage{}, nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil).Times(2)
+
+		mockFileRepo.EXPECT().AddFileTx(gomock.Any(), mockTx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ tx.Tx, cmd commands.AddFile) (int64, error) {
+				require.Equal(t, vanityCommand.Alias, cmd.Alias)
+				return 1, nil
+			})
+
+		mockFileStorage.EXPECT().Upload(gomock.Any(), gomock.Any(), vanityCommand.Alias, command.Filename).
+			Return(nil)
+
+		mockFileRepo.EXPECT().PromoteFileTx(gomock.Any(), mockTx, gomock.Any()).Return(nil)
+
+		mockTx.EXPECT().Commit().Return(nil).Times(2)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UploadFile(context.Background(), vanityCommand)
+		require.NoError(t, err)
+		require.Equal(t, vanityCommand.Alias, result.Alias)
+	})
+
+	t.Run("taken vanity alias is not retried", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), command.UserID).
+			Return(entities.StorageUsage{}, nil)
 
 		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
+		mockFileRepo.EXPECT().AddFileTx(gomock.Any(), mockTx, gomock.Any()).
+			Return(int64(0), domainErrors.ErrAliasTaken)
+		mockTx.EXPECT().Rollback().Return(nil)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UploadFile(context.Background(), vanityCommand)
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrAliasTaken)
+	})
+
+	t.Run("vanity alias is refused", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			alias string
+			roles []entities.UserRole
+			err   error
+		}{
+			{"role without vanity aliases", "build-2026-10", []entities.UserRole{entities.RoleUser}, domainErrors.ErrVanityAliasForbidden},
+			{"too short", "abc", []entities.UserRole{entities.RoleVip}, domainErrors.ErrInvalidAlias},
+			{"too long", strings.Repeat("a", 51), []entities.UserRole{entities.RoleVip}, domainErrors.ErrInvalidAlias},
+			{"path separator", "build/2026", []entities.UserRole{entities.RoleVip}, domainErrors.ErrInvalidAlias},
+			{"leading dash", "-build", []entities.UserRole{entities.RoleVip}, domainErrors.ErrInvalidAlias},
+			{"reserved word", "Swagger", []entities.UserRole{entities.RoleVip}, domainErrors.ErrInvalidAlias},
+			{"blocked word", "oh-DARN-it", []entities.UserRole{entities.RoleAdmin}, domainErrors.ErrInvalidAlias},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				fileService := New(mocks.NewMockFileRepo(ctrl), mocks.NewMockFile(ctrl), nil, log, cfg)
+
+				refused := command
+				refused.Alias = test.alias
+				refused.Roles = test.roles
+
+				result, err := fileService.UploadFile(context.Background(), refused)
+				require.Nil(t, result)
+				require.ErrorIs(t, err, test.err)
+			})
+		}
+	})
 
+	guestCommand := commands.UploadFile{
+		File:         io.NopCloser(strings.NewReader("file content")),
+		FileSize:     12,
+		Filename:     "guest.txt",
+		MaxDownloads: 1,
+		TTL:          time.Hour,
+		RequestingUserInfo: commands.RequestingUserInfo{
+			UserID: entities.GuestUserID,
+			Roles:  []entities.UserRole{entities.RoleGuest},
+			IP:     "10.0.0.1",
+		},
+	}
+
+	t.Run("guest upload returns management token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetUsageByGuestIP(gomock.Any(), "10.0.0.1").
+			Return(entities.StorageUsage{}, nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil).Times(2)
+
+		var added commands.AddFile
 		mockFileRepo.EXPECT().AddFileTx(gomock.Any(), mockTx, gomock.Any()).
-			Return(int64(1), nil)
+			DoAndReturn(func(_ context.Context, _ tx.Tx, cmd commands.AddFile) (int64, error) {
+				added = cmd
+				return 1, nil
+			})
+
+		mockFileStorage.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any(), guestCommand.Filename).
+			Return(nil)
+
+		mockFileRepo.EXPECT().PromoteFileTx(gomock.Any(), mockTx, gomock.Any()).Return(nil)
+
+		mockTx.EXPECT().Commit().Return(nil).Times(2)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UploadFile(context.Background(), guestCommand)
+		require.NoError(t, err)
+		require.NotEmpty(t, result.ManagementToken)
+		require.Equal(t, int64(entities.GuestUserID), added.UserID)
+		require.Equal(t, "10.0.0.1", added.GuestIP)
+		require.Equal(t, hashManagementToken(result.ManagementToken), added.ManagementTokenHash)
+	})
+
+	t.Run("guest limits are applied per ip", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			usage   entities.StorageUsage
+			change  func(command *commands.UploadFile)
+			wantErr error
+		}{
+			{
+				name:    "file count",
+				usage:   entities.StorageUsage{FilesCount: 1},
+				wantErr: domainErrors.ErrUploadLimitExceeded,
+			},
+			{
+				name:    "file size",
+				change:  func(command *commands.UploadFile) { command.FileSize = 51 },
+				wantErr: domainErrors.ErrFileSizeTooBig,
+			},
+			{
+				name:    "storage",
+				usage:   entities.StorageUsage{UsedBytes: 90},
+				wantErr: domainErrors.ErrStorageQuotaExceeded,
+			},
+			{
+				name:    "ttl",
+				change:  func(command *commands.UploadFile) { command.TTL = 2 * time.Hour },
+				wantErr: domainErrors.ErrTTLTooLong,
+			},
+			{
+				name:    "vanity alias",
+				change:  func(command *commands.UploadFile) { command.Alias = "guest-alias" },
+				wantErr: domainErrors.ErrVanityAliasForbidden,
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				refused := guestCommand
+				if test.change != nil {
+					test.change(&refused)
+				}
+
+				mockFileRepo := mocks.NewMockFileRepo(ctrl)
+				mockFileRepo.EXPECT().GetUsageByGuestIP(gomock.Any(), "10.0.0.1").
+					Return(test.usage, nil).AnyTimes()
+
+				fileService := New(mockFileRepo, mocks.NewMockFile(ctrl), nil, log, cfg)
+				result, err := fileService.UploadFile(context.Background(), refused)
+				require.Nil(t, result)
+				require.ErrorIs(t, err, test.wantErr)
+			})
+		}
+	})
+
+	t.Run("storage upload error discards pending file", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), command.UserID).
+			Return(entities.StorageUsage{}, nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil).Times(2)
+
+		var pendingAlias string
+		mockFileRepo.EXPECT().AddFileTx(gomock.Any(), mockTx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ tx.Tx, cmd commands.AddFile) (int64, error) {
+				pendingAlias = cmd.Alias
+				return 1, nil
+			})
 
 		mockFileStorage.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Return(errors.New("internal error"))
 
-		mockTx.EXPECT().Rollback().Return(nil)
+		gomock.InOrder(
+			mockFileStorage.EXPECT().Delete(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, alias string) error {
+					require.Equal(t, pendingAlias, alias)
+					return nil
+				}),
+			mockFileRepo.EXPECT().DeletePendingFileTx(gomock.Any(), mockTx, gomock.Any()).Return(nil),
+		)
+
+		mockTx.EXPECT().Commit().Return(nil).Times(2)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
-		alias, err := fileService.UploadFile(context.Background(), command)
-		require.Empty(t, alias)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UploadFile(context.Background(), command)
+		require.Nil(t, result)
 		require.Error(t, err)
 	})
 
+	t.Run("file abandoned during upload is not promoted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTx := mocks.NewMockTx(ctrl)
+		mockFileRepo := mocks.NewMockFileRepo(ctrl)
+		mockFileStorage := mocks.NewMockFile(ctrl)
+
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), command.UserID).
+			Return(entities.StorageUsage{}, nil)
+
+		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil).Times(3)
+		mockFileRepo.EXPECT().AddFileTx(gomock.Any(), mockTx, gomock.Any()).Return(int64(1), nil)
+		mockTx.EXPECT().Commit().Return(nil)
+
+		mockFileStorage.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil)
+
+		mockFileRepo.EXPECT().PromoteFileTx(gomock.Any(), mockTx, gomock.Any()).
+			Return(domainErrors.ErrFileNotFound)
+
+		mockFileStorage.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+		mockFileRepo.EXPECT().DeletePendingFileTx(gomock.Any(), mockTx, gomock.Any()).
+			Return(domainErrors.ErrFileNotFound)
+
+		mockTx.EXPECT().Rollback().Return(nil).Times(2)
+
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UploadFile(context.Background(), command)
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrFileNotFound)
+	})
+
 	t.Run("context canceled on add file", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -211,8 +908,8 @@ func TestService_UploadFile(t *testing.T) {
 
 		ctx, cancel := context.WithCancel(context.Background())
 
-		mockFileRepo.EXPECT().CountByUserID(gomock.Any(), command.UserID).
-			Return(0, nil)
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), command.UserID).
+			Return(entities.StorageUsage{}, nil)
 
 		mockFileRepo.EXPECT().BeginTx(gomock.Any()).Return(mockTx, nil)
 
@@ -224,25 +921,25 @@ func TestService_UploadFile(t *testing.T) {
 
 		mockTx.EXPECT().Rollback().Return(nil)
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
-		alias, err := fileService.UploadFile(ctx, command)
-		require.Empty(t, alias)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UploadFile(ctx, command)
+		require.Nil(t, result)
 		require.ErrorIs(t, err, context.Canceled)
 	})
 
-	t.Run("internal error on count", func(t *testing.T) {
+	t.Run("internal error on usage", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		mockFileRepo := mocks.NewMockFileRepo(ctrl)
 		mockFileStorage := mocks.NewMockFile(ctrl)
 
-		mockFileRepo.EXPECT().CountByUserID(gomock.Any(), command.UserID).
-			Return(0, errors.New("internal error"))
+		mockFileRepo.EXPECT().GetUsageByUserID(gomock.Any(), command.UserID).
+			Return(entities.StorageUsage{}, errors.New("internal error"))
 
-		fileService := New(mockFileRepo, mockFileStorage, log, cfg)
-		alias, err := fileService.UploadFile(context.Background(), command)
-		require.Empty(t, alias)
+		fileService := New(mockFileRepo, mockFileStorage, nil, log, cfg)
+		result, err := fileService.UploadFile(context.Background(), command)
+		require.Nil(t, result)
 		require.Error(t, err)
 	})
 }
@@ -0,0 +1,73 @@
+package files
+
+import (
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"strings"
+)
+
+// reservedAliases look like service routes and can't be chosen as aliases
+var reservedAliases = []string{
+	"api", "admin", "download", "downloads", "file", "files",
+	"health", "quota", "static", "swagger", "upload", "uploads",
+}
+
+// checkVanityAlias validates an alias chosen by the user. Admins may
+// choose aliases regardless of permissions
+func (fs *Service) checkVanityAlias(alias string, roles []entities.UserRole) error {
+	if !fs.vanityAliasAllowed(roles) {
+		return domainErrors.ErrVanityAliasForbidden
+	}
+
+	rules := fs.cfg.VanityAliases
+	if len(alias) < int(rules.VanityMinLength) || len(alias) > int(rules.VanityMaxLength) {
+		return domainErrors.ErrInvalidAlias
+	}
+
+	for i := 0; i < len(alias); i++ {
+		if !isAliasChar(alias[i], i == 0) {
+			return domainErrors.ErrInvalidAlias
+		}
+	}
+
+	lower := strings.ToLower(alias)
+	for _, reserved := range reservedAliases {
+		if lower == reserved {
+			return domainErrors.ErrInvalidAlias
+		}
+	}
+
+	for _, word := range rules.VanityBlocklist {
+		if strings.Contains(lower, word) {
+			return domainErrors.ErrInvalidAlias
+		}
+	}
+
+	return nil
+}
+
+func (fs *Service) vanityAliasAllowed(roles []entities.UserRole) bool {
+	switch {
+	case hasRole(roles, entities.RoleAdmin):
+		return true
+	case hasRole(roles, entities.RoleGuest):
+		return false
+	case hasRole(roles, entities.RoleVip):
+		return fs.cfg.VanityAliasForVip
+	default:
+		return fs.cfg.VanityAliasForUser
+	}
+}
+
+// isAliasChar reports whether c may be used in an alias, which must start
+// with a letter or a digit
+func isAliasChar(c byte, first bool) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	case c == '-' || c == '_':
+		return !first
+	default:
+		return false
+	}
+}
@@ -0,0 +1,40 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"log/slog"
+)
+
+// getOwnUpload returns staged upload if it belongs to requesting user.
+// Unlike shared files, admins have no access to someone else's
+// unfinished uploads since there is nothing to moderate yet
+func (us *Service) getOwnUpload(ctx context.Context, log *slog.Logger, id string, userInfo commands.RequestingUserInfo) (*entities.Upload, error) {
+	upload, err := us.staging.Get(ctx, id)
+	if err != nil {
+		const msg = "failed to get upload"
+		if errors.Is(err, domainErrors.ErrUploadNotFound) || isCtxError(err) {
+			log.Info(msg, sl.Error(err), slog.String("upload_id", id))
+			return nil, err
+		}
+
+		log.Error(msg, sl.Error(err), slog.String("upload_id", id))
+		return nil, fmt.Errorf("%s: %w", msg, err)
+	}
+
+	if upload.UserID != userInfo.UserID {
+		log.Info("access denied", slog.String("upload_id", id), slog.Int64("requesting_user_id", userInfo.UserID))
+		return nil, domainErrors.ErrForbidden
+	}
+
+	return upload, nil
+}
+
+func isCtxError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,90 @@
+package uploads
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	fileCommands "expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/uploads/commands"
+	"expire-share/internal/domain/dto/uploads/results"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const uploadIDBytes = 16
+
+func (us *Service) CreateUpload(ctx context.Context, command commands.CreateUpload) (*results.CreateUpload, error) {
+	const fn = "services.uploads.Service.CreateUpload"
+	log := us.log.With(slog.String("fn", fn))
+
+	// the password is not kept until the upload completes, so the file key
+	// can't be derived from it
+	if us.cfg.PasswordKeys && len(command.Password) > 0 {
+		log.Info("password-protected resumable upload was refused", slog.Int64("user_id", command.UserID))
+		return nil, domainErrors.ErrPasswordKeyResumable
+	}
+
+	// quotas are checked again on completion, this only rejects uploads
+	// that could never succeed before any byte is transferred
+	err := us.fileService.CheckUploadQuota(ctx, fileCommands.CheckUploadQuota{
+		FileSize:           command.Length,
+		MaxDownloads:       command.MaxDownloads,
+		TTL:                command.TTL,
+		RequestingUserInfo: command.RequestingUserInfo,
+	})
+
+	if err != nil {
+		log.Info("upload quota check failed", sl.Error(err), slog.Int64("user_id", command.UserID))
+		return nil, fmt.Errorf("%s: access denied: %w", fn, err)
+	}
+
+	var hashedBytes []byte
+	if len(command.Password) > 0 {
+		hashedBytes, err = bcrypt.GenerateFromPassword([]byte(command.Password), bcrypt.DefaultCost)
+		if err != nil {
+			log.Error("failed to hash password", sl.Error(err))
+			return nil, fmt.Errorf("%s: failed to hash password: %w", fn, err)
+		}
+	}
+
+	idBytes := make([]byte, uploadIDBytes)
+	if _, err := rand.Read(idBytes); err != nil {
+		log.Error("failed to generate upload id", sl.Error(err))
+		return nil, fmt.Errorf("%s: failed to generate upload id: %w", fn, err)
+	}
+
+	currentTime := time.Now()
+	upload := entities.Upload{
+		ID:           hex.EncodeToString(idBytes),
+		Filename:     command.Filename,
+		Length:       command.Length,
+		MaxDownloads: command.MaxDownloads,
+		TTL:          command.TTL,
+		PasswordHash: string(hashedBytes),
+		UserID:       command.UserID,
+		CreatedAt:    currentTime,
+		ExpiresAt:    currentTime.Add(us.cfg.UploadExpiration),
+	}
+
+	if err := us.staging.Create(ctx, upload); err != nil {
+		const msg = "failed to create upload"
+		if isCtxError(err) {
+			log.Info(msg, sl.Error(err), slog.Int64("user_id", command.UserID))
+			return nil, err
+		}
+
+		log.Error(msg, sl.Error(err), slog.Int64("user_id", command.UserID))
+		return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
+	}
+
+	return &results.CreateUpload{
+		ID:        upload.ID,
+		ExpiresAt: upload.ExpiresAt,
+	}, nil
+}
@@ -0,0 +1,149 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"expire-share/internal/config"
+	fileCommands "expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/uploads/commands"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func newTestConfig() config.Config {
+	return config.Config{
+		Storage: config.Storage{
+			MaxFileSizeInBytes: 10 * 1024 * 1024,
+		},
+		Uploads: config.Uploads{
+			UploadExpiration: 24 * time.Hour,
+		},
+	}
+}
+
+func TestService_CreateUpload(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := newTestConfig()
+
+	command := commands.CreateUpload{
+		Length:       1024,
+		Filename:     "video.mp4",
+		MaxDownloads: 3,
+		TTL:          time.Hour,
+		RequestingUserInfo: fileCommands.RequestingUserInfo{
+			UserID: 1,
+			Roles:  []entities.UserRole{entities.RoleUser},
+		},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStaging := mocks.NewMockStaging(ctrl)
+		mockFileService := mocks.NewMockFileService(ctrl)
+
+		mockFileService.EXPECT().CheckUploadQuota(gomock.Any(), gomock.Any()).Return(nil)
+
+		mockStaging.EXPECT().Create(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, upload entities.Upload) error {
+				require.NotEmpty(t, upload.ID)
+				require.Equal(t, command.Length, upload.Length)
+				require.Equal(t, command.Filename, upload.Filename)
+				require.Equal(t, command.UserID, upload.UserID)
+				require.Empty(t, upload.PasswordHash)
+				require.WithinDuration(t, time.Now().Add(cfg.UploadExpiration), upload.ExpiresAt, time.Minute)
+				return nil
+			})
+
+		service := New(mockStaging, mockFileService, log, cfg)
+		result, err := service.CreateUpload(context.Background(), command)
+		require.NoError(t, err)
+		require.Len(t, result.ID, uploadIDBytes*2)
+	})
+
+	t.Run("password is hashed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStaging := mocks.NewMockStaging(ctrl)
+		mockFileService := mocks.NewMockFileService(ctrl)
+
+		mockFileService.EXPECT().CheckUploadQuota(gomock.Any(), gomock.Any()).Return(nil)
+
+		mockStaging.EXPECT().Create(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, upload entities.Upload) error {
+				require.Contains(t, upload.PasswordHash, "$2a$")
+				return nil
+			})
+
+		withPassword := command
+		withPassword.Password = "secret"
+
+		service := New(mockStaging, mockFileService, log, cfg)
+		_, err := service.CreateUpload(context.Background(), withPassword)
+		require.NoError(t, err)
+	})
+
+	t.Run("password is refused when keys are bound to passwords", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		boundCfg := cfg
+		boundCfg.PasswordKeys = true
+
+		withPassword := command
+		withPassword.Password = "secret"
+
+		service := New(mocks.NewMockStaging(ctrl), mocks.NewMockFileService(ctrl), log, boundCfg)
+		result, err := service.CreateUpload(context.Background(), withPassword)
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrPasswordKeyResumable)
+	})
+
+	t.Run("quota is checked before staging", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStaging := mocks.NewMockStaging(ctrl)
+		mockFileService := mocks.NewMockFileService(ctrl)
+
+		mockFileService.EXPECT().CheckUploadQuota(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, quota fileCommands.CheckUploadQuota) error {
+				require.Equal(t, command.Length, quota.FileSize)
+				require.Equal(t, command.TTL, quota.TTL)
+				require.Equal(t, command.MaxDownloads, quota.MaxDownloads)
+				require.Equal(t, command.RequestingUserInfo, quota.RequestingUserInfo)
+				return domainErrors.ErrFileSizeTooBig
+			})
+
+		service := New(mockStaging, mockFileService, log, cfg)
+		result, err := service.CreateUpload(context.Background(), command)
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrFileSizeTooBig)
+	})
+
+	t.Run("staging error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStaging := mocks.NewMockStaging(ctrl)
+		mockFileService := mocks.NewMockFileService(ctrl)
+
+		mockFileService.EXPECT().CheckUploadQuota(gomock.Any(), gomock.Any()).Return(nil)
+
+		mockStaging.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("internal error"))
+
+		service := New(mockStaging, mockFileService, log, cfg)
+		result, err := service.CreateUpload(context.Background(), command)
+		require.Nil(t, result)
+		require.Error(t, err)
+	})
+}
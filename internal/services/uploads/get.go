@@ -0,0 +1,25 @@
+package uploads
+
+import (
+	"context"
+	"expire-share/internal/domain/dto/uploads/commands"
+	"expire-share/internal/domain/dto/uploads/results"
+	"fmt"
+	"log/slog"
+)
+
+func (us *Service) GetUpload(ctx context.Context, command commands.GetUpload) (*results.GetUpload, error) {
+	const fn = "services.uploads.Service.GetUpload"
+	log := us.log.With(slog.String("fn", fn))
+
+	upload, err := us.getOwnUpload(ctx, log, command.ID, command.RequestingUserInfo)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &results.GetUpload{
+		Offset:    upload.Offset,
+		Length:    upload.Length,
+		ExpiresAt: upload.ExpiresAt,
+	}, nil
+}
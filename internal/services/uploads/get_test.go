@@ -0,0 +1,60 @@
+package uploads
+
+import (
+	"context"
+	fileCommands "expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/uploads/commands"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestService_GetUpload(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := newTestConfig()
+
+	upload := &entities.Upload{ID: "upload-id", UserID: 1, Length: 10, Offset: 4}
+	command := commands.GetUpload{
+		ID: upload.ID,
+		RequestingUserInfo: fileCommands.RequestingUserInfo{
+			UserID: 1,
+			Roles:  []entities.UserRole{entities.RoleUser},
+		},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStaging := mocks.NewMockStaging(ctrl)
+		mockFileService := mocks.NewMockFileService(ctrl)
+
+		mockStaging.EXPECT().Get(gomock.Any(), upload.ID).Return(upload, nil)
+
+		service := New(mockStaging, mockFileService, log, cfg)
+		result, err := service.GetUpload(context.Background(), command)
+		require.NoError(t, err)
+		require.Equal(t, int64(4), result.Offset)
+		require.Equal(t, int64(10), result.Length)
+	})
+
+	t.Run("not upload owner", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStaging := mocks.NewMockStaging(ctrl)
+		mockFileService := mocks.NewMockFileService(ctrl)
+
+		mockStaging.EXPECT().Get(gomock.Any(), upload.ID).Return(&entities.Upload{ID: upload.ID, UserID: 2}, nil)
+
+		service := New(mockStaging, mockFileService, log, cfg)
+		result, err := service.GetUpload(context.Background(), command)
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrForbidden)
+	})
+}
@@ -0,0 +1,29 @@
+package uploads
+
+import (
+	"context"
+	"expire-share/internal/config"
+	"expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/domain/interfaces/storage"
+	"log/slog"
+)
+
+type FileService interface {
+	UploadFile(ctx context.Context, command commands.UploadFile) (*results.UploadFile, error)
+	CheckUploadQuota(ctx context.Context, command commands.CheckUploadQuota) error
+}
+
+type Service struct {
+	staging     storage.Staging
+	fileService FileService
+	cfg         config.Config
+	log         *slog.Logger
+}
+
+func New(staging storage.Staging, fileService FileService, log *slog.Logger, cfg config.Config) *Service {
+	return &Service{staging: staging,
+		fileService: fileService,
+		log:         log,
+		cfg:         cfg}
+}
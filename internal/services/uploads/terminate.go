@@ -0,0 +1,33 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"expire-share/internal/domain/dto/uploads/commands"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"log/slog"
+)
+
+func (us *Service) TerminateUpload(ctx context.Context, command commands.TerminateUpload) error {
+	const fn = "services.uploads.Service.TerminateUpload"
+	log := us.log.With(slog.String("fn", fn))
+
+	if _, err := us.getOwnUpload(ctx, log, command.ID, command.RequestingUserInfo); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := us.staging.Delete(ctx, command.ID); err != nil {
+		const msg = "failed to delete upload"
+		if errors.Is(err, domainErrors.ErrUploadNotFound) || isCtxError(err) {
+			log.Info(msg, sl.Error(err), slog.String("upload_id", command.ID))
+			return err
+		}
+
+		log.Error(msg, sl.Error(err), slog.String("upload_id", command.ID))
+		return fmt.Errorf("%s: %s: %w", fn, msg, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package uploads
+
+import (
+	"context"
+	fileCommands "expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/uploads/commands"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestService_TerminateUpload(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := newTestConfig()
+
+	upload := &entities.Upload{ID: "upload-id", UserID: 1, Length: 10}
+	command := commands.TerminateUpload{
+		ID: upload.ID,
+		RequestingUserInfo: fileCommands.RequestingUserInfo{
+			UserID: 1,
+			Roles:  []entities.UserRole{entities.RoleUser},
+		},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStaging := mocks.NewMockStaging(ctrl)
+		mockFileService := mocks.NewMockFileService(ctrl)
+
+		mockStaging.EXPECT().Get(gomock.Any(), upload.ID).Return(upload, nil)
+		mockStaging.EXPECT().Delete(gomock.Any(), upload.ID).Return(nil)
+
+		service := New(mockStaging, mockFileService, log, cfg)
+		require.NoError(t, service.TerminateUpload(context.Background(), command))
+	})
+
+	t.Run("not upload owner", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStaging := mocks.NewMockStaging(ctrl)
+		mockFileService := mocks.NewMockFileService(ctrl)
+
+		mockStaging.EXPECT().Get(gomock.Any(), upload.ID).Return(&entities.Upload{ID: upload.ID, UserID: 2}, nil)
+
+		service := New(mockStaging, mockFileService, log, cfg)
+		err := service.TerminateUpload(context.Background(), command)
+		require.ErrorIs(t, err, domainErrors.ErrForbidden)
+	})
+
+	t.Run("upload not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStaging := mocks.NewMockStaging(ctrl)
+		mockFileService := mocks.NewMockFileService(ctrl)
+
+		mockStaging.EXPECT().Get(gomock.Any(), upload.ID).Return(nil, domainErrors.ErrUploadNotFound)
+
+		service := New(mockStaging, mockFileService, log, cfg)
+		err := service.TerminateUpload(context.Background(), command)
+		require.ErrorIs(t, err, domainErrors.ErrUploadNotFound)
+	})
+}
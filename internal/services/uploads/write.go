@@ -0,0 +1,121 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	fileCommands "expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/uploads/commands"
+	"expire-share/internal/domain/dto/uploads/results"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/lib/log/sl"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+func (us *Service) WriteChunk(ctx context.Context, command commands.WriteChunk) (*results.WriteChunk, error) {
+	const fn = "services.uploads.Service.WriteChunk"
+	log := us.log.With(slog.String("fn", fn))
+
+	upload, err := us.getOwnUpload(ctx, log, command.ID, command.RequestingUserInfo)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	offset, err := us.staging.Append(ctx, command.ID, command.Offset, command.Chunk)
+	if err != nil {
+		const msg = "failed to append chunk"
+		if errors.Is(err, domainErrors.ErrUploadOffsetMismatch) ||
+			errors.Is(err, domainErrors.ErrUploadLengthExceeded) ||
+			errors.Is(err, domainErrors.ErrUploadNotFound) ||
+			isCtxError(err) {
+			log.Info(msg, sl.Error(err), slog.String("upload_id", command.ID))
+			return nil, err
+		}
+
+		log.Error(msg, sl.Error(err), slog.String("upload_id", command.ID))
+		return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
+	}
+
+	result := &results.WriteChunk{
+		Offset:    offset,
+		ExpiresAt: upload.ExpiresAt,
+	}
+
+	if offset < upload.Length {
+		return result, nil
+	}
+
+	// retried or concurrent requests at the final offset all get here,
+	// only the one holding the claim publishes the file
+	if err := us.staging.Claim(ctx, command.ID); err != nil {
+		const msg = "failed to claim upload completion"
+		if errors.Is(err, domainErrors.ErrUploadCompleting) ||
+			errors.Is(err, domainErrors.ErrUploadNotFound) ||
+			isCtxError(err) {
+			log.Info(msg, sl.Error(err), slog.String("upload_id", command.ID))
+			return nil, err
+		}
+
+		log.Error(msg, sl.Error(err), slog.String("upload_id", command.ID))
+		return nil, fmt.Errorf("%s: %s: %w", fn, msg, err)
+	}
+
+	// a failed completion keeps the staged data, so the client can retry
+	// it with an empty PATCH at the final offset
+	alias, err := us.complete(ctx, log, upload, command.RequestingUserInfo)
+	if err != nil {
+		if err := us.staging.Unclaim(context.WithoutCancel(ctx), command.ID); err != nil {
+			log.Error("failed to release upload completion", sl.Error(err), slog.String("upload_id", command.ID))
+		}
+
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	result.Alias = alias
+	return result, nil
+}
+
+func (us *Service) complete(ctx context.Context, log *slog.Logger, upload *entities.Upload, userInfo fileCommands.RequestingUserInfo) (string, error) {
+	file, err := us.staging.Open(ctx, upload.ID)
+	if err != nil {
+		const msg = "failed to open staged upload"
+		if errors.Is(err, domainErrors.ErrUploadNotFound) || isCtxError(err) {
+			log.Info(msg, sl.Error(err), slog.String("upload_id", upload.ID))
+			return "", err
+		}
+
+		log.Error(msg, sl.Error(err), slog.String("upload_id", upload.ID))
+		return "", fmt.Errorf("%s: %w", msg, err)
+	}
+
+	defer func(file io.ReadCloser) {
+		if err := file.Close(); err != nil {
+			log.Error("failed to close staged upload", sl.Error(err))
+		}
+	}(file)
+
+	uploaded, err := us.fileService.UploadFile(ctx, fileCommands.UploadFile{
+		File:               file,
+		FileSize:           upload.Length,
+		Filename:           upload.Filename,
+		MaxDownloads:       upload.MaxDownloads,
+		PasswordHash:       upload.PasswordHash,
+		TTL:                upload.TTL,
+		RequestingUserInfo: userInfo,
+	})
+
+	if err != nil {
+		log.Info("failed to complete upload", sl.Error(err), slog.String("upload_id", upload.ID))
+		return "", err
+	}
+
+	if err := us.staging.Delete(ctx, upload.ID); err != nil {
+		log.Warn("failed to delete completed upload, it will be reaped by worker",
+			sl.Error(err), slog.String("upload_id", upload.ID))
+	}
+
+	log.Info("upload completed", slog.String("upload_id", upload.ID), slog.String("alias", uploaded.Alias))
+	return uploaded.Alias, nil
+}
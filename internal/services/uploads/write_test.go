@@ -0,0 +1,245 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	fileCommands "expire-share/internal/domain/dto/files/commands"
+	"expire-share/internal/domain/dto/files/results"
+	"expire-share/internal/domain/dto/uploads/commands"
+	"expire-share/internal/domain/entities"
+	domainErrors "expire-share/internal/domain/entities/errors"
+	"expire-share/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestService_WriteChunk(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := newTestConfig()
+
+	userInfo := fileCommands.RequestingUserInfo{
+		UserID: 1,
+		Roles:  []entities.UserRole{entities.RoleUser},
+	}
+
+	upload := &entities.Upload{
+		ID:           "upload-id",
+		Filename:     "report.csv",
+		Length:       10,
+		Offset:       5,
+		MaxDownloads: 2,
+		TTL:          time.Hour,
+		PasswordHash: "hash",
+		UserID:       1,
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+
+	t.Run("success partial chunk", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStaging := mocks.NewMockStaging(ctrl)
+		mockFileService := mocks.NewMockFileService(ctrl)
+
+		mockStaging.EXPECT().Get(gomock.Any(), upload.ID).Return(upload, nil)
+		mockStaging.EXPECT().Append(gomock.Any(), upload.ID, int64(5), gomock.Any()).Return(int64(8), nil)
+
+		service := New(mockStaging, mockFileService, log, cfg)
+		result, err := service.WriteChunk(context.Background(), commands.WriteChunk{
+			ID:                 upload.ID,
+			Offset:             5,
+			Chunk:              strings.NewReader("abc"),
+			RequestingUserInfo: userInfo,
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, int64(8), result.Offset)
+		require.Empty(t, result.Alias)
+	})
+
+	t.Run("success last chunk completes upload", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStaging := mocks.NewMockStaging(ctrl)
+		mockFileService := mocks.NewMockFileService(ctrl)
+
+		mockStaging.EXPECT().Get(gomock.Any(), upload.ID).Return(upload, nil)
+		mockStaging.EXPECT().Append(gomock.Any(), upload.ID, int64(5), gomock.Any()).Return(int64(10), nil)
+		mockStaging.EXPECT().Claim(gomock.Any(), upload.ID).Return(nil)
+		mockStaging.EXPECT().Open(gomock.Any(), upload.ID).
+			Return(io.NopCloser(strings.NewReader("0123456789")), nil)
+
+		mockFileService.EXPECT().UploadFile(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, cmd fileCommands.UploadFile) (*results.UploadFile, error) {
+				require.Equal(t, upload.Filename, cmd.Filename)
+				require.Equal(t, upload.Length, cmd.FileSize)
+				require.Equal(t, upload.MaxDownloads, cmd.MaxDownloads)
+				require.Equal(t, upload.TTL, cmd.TTL)
+				require.Equal(t, upload.PasswordHash, cmd.PasswordHash)
+				require.Equal(t, userInfo, cmd.RequestingUserInfo)
+				return &results.UploadFile{Alias: "abc123"}, nil
+			})
+
+		mockStaging.EXPECT().Delete(gomock.Any(), upload.ID).Return(nil)
+
+		service := New(mockStaging, mockFileService, log, cfg)
+		result, err := service.WriteChunk(context.Background(), commands.WriteChunk{
+			ID:                 upload.ID,
+			Offset:             5,
+			Chunk:              strings.NewReader("56789"),
+			RequestingUserInfo: userInfo,
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, int64(10), result.Offset)
+		require.Equal(t, "abc123", result.Alias)
+	})
+
+	t.Run("failed completion keeps staged data", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStaging := mocks.NewMockStaging(ctrl)
+		mockFileService := mocks.NewMockFileService(ctrl)
+
+		mockStaging.EXPECT().Get(gomock.Any(), upload.ID).Return(upload, nil)
+		mockStaging.EXPECT().Append(gomock.Any(), upload.ID, int64(5), gomock.Any()).Return(int64(10), nil)
+		mockStaging.EXPECT().Claim(gomock.Any(), upload.ID).Return(nil)
+		mockStaging.EXPECT().Open(gomock.Any(), upload.ID).
+			Return(io.NopCloser(strings.NewReader("0123456789")), nil)
+
+		mockFileService.EXPECT().UploadFile(gomock.Any(), gomock.Any()).
+			Return(nil, domainErrors.ErrUploadLimitExceeded)
+		mockStaging.EXPECT().Unclaim(gomock.Any(), upload.ID).Return(nil)
+
+		service := New(mockStaging, mockFileService, log, cfg)
+		result, err := service.WriteChunk(context.Background(), commands.WriteChunk{
+			ID:                 upload.ID,
+			Offset:             5,
+			Chunk:              strings.NewReader("56789"),
+			RequestingUserInfo: userInfo,
+		})
+
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrUploadLimitExceeded)
+	})
+
+	t.Run("upload completed by another request is not published twice", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStaging := mocks.NewMockStaging(ctrl)
+		mockFileService := mocks.NewMockFileService(ctrl)
+
+		mockStaging.EXPECT().Get(gomock.Any(), upload.ID).Return(upload, nil)
+		mockStaging.EXPECT().Append(gomock.Any(), upload.ID, int64(10), gomock.Any()).Return(int64(10), nil)
+		mockStaging.EXPECT().Claim(gomock.Any(), upload.ID).Return(domainErrors.ErrUploadCompleting)
+
+		service := New(mockStaging, mockFileService, log, cfg)
+		result, err := service.WriteChunk(context.Background(), commands.WriteChunk{
+			ID:                 upload.ID,
+			Offset:             10,
+			Chunk:              strings.NewReader(""),
+			RequestingUserInfo: userInfo,
+		})
+
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrUploadCompleting)
+	})
+
+	t.Run("offset mismatch", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStaging := mocks.NewMockStaging(ctrl)
+		mockFileService := mocks.NewMockFileService(ctrl)
+
+		mockStaging.EXPECT().Get(gomock.Any(), upload.ID).Return(upload, nil)
+		mockStaging.EXPECT().Append(gomock.Any(), upload.ID, int64(2), gomock.Any()).
+			Return(int64(5), domainErrors.ErrUploadOffsetMismatch)
+
+		service := New(mockStaging, mockFileService, log, cfg)
+		result, err := service.WriteChunk(context.Background(), commands.WriteChunk{
+			ID:                 upload.ID,
+			Offset:             2,
+			Chunk:              strings.NewReader("abc"),
+			RequestingUserInfo: userInfo,
+		})
+
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrUploadOffsetMismatch)
+	})
+
+	t.Run("not upload owner", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStaging := mocks.NewMockStaging(ctrl)
+		mockFileService := mocks.NewMockFileService(ctrl)
+
+		mockStaging.EXPECT().Get(gomock.Any(), upload.ID).Return(upload, nil)
+
+		service := New(mockStaging, mockFileService, log, cfg)
+		result, err := service.WriteChunk(context.Background(), commands.WriteChunk{
+			ID:     upload.ID,
+			Offset: 5,
+			Chunk:  strings.NewReader("abc"),
+			RequestingUserInfo: fileCommands.RequestingUserInfo{
+				UserID: 2,
+				Roles:  []entities.UserRole{entities.RoleAdmin},
+			},
+		})
+
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrForbidden)
+	})
+
+	t.Run("upload not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStaging := mocks.NewMockStaging(ctrl)
+		mockFileService := mocks.NewMockFileService(ctrl)
+
+		mockStaging.EXPECT().Get(gomock.Any(), "missing").Return(nil, domainErrors.ErrUploadNotFound)
+
+		service := New(mockStaging, mockFileService, log, cfg)
+		result, err := service.WriteChunk(context.Background(), commands.WriteChunk{
+			ID:                 "missing",
+			Chunk:              strings.NewReader("abc"),
+			RequestingUserInfo: userInfo,
+		})
+
+		require.Nil(t, result)
+		require.ErrorIs(t, err, domainErrors.ErrUploadNotFound)
+	})
+
+	t.Run("internal error on append", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStaging := mocks.NewMockStaging(ctrl)
+		mockFileService := mocks.NewMockFileService(ctrl)
+
+		mockStaging.EXPECT().Get(gomock.Any(), upload.ID).Return(upload, nil)
+		mockStaging.EXPECT().Append(gomock.Any(), upload.ID, int64(5), gomock.Any()).
+			Return(int64(5), errors.New("internal error"))
+
+		service := New(mockStaging, mockFileService, log, cfg)
+		result, err := service.WriteChunk(context.Background(), commands.WriteChunk{
+			ID:                 upload.ID,
+			Offset:             5,
+			Chunk:              strings.NewReader("abc"),
+			RequestingUserInfo: userInfo,
+		})
+
+		require.Nil(t, result)
+		require.Error(t, err)
+	})
+}
@@ -12,10 +12,11 @@ import (
 )
 
 type FileWorker struct {
-	Delay time.Duration
-	repo  repositories.FileRepo
-	files storage.File
-	log   *slog.Logger
+	Delay   time.Duration
+	repo    repositories.FileRepo
+	files   storage.File
+	uploads storage.Staging
+	log     *slog.Logger
 }
 
 const batchLimit = 100
@@ -34,6 +35,10 @@ func (fw *FileWorker) Start(ctx context.Context) {
 			return
 
 		case <-ticker.C:
+			fw.deleteExpiredUploads(ctx)
+			fw.releaseExpiredReservations(ctx)
+			fw.collectBlobs(ctx)
+
 			tx, err := fw.repo.BeginTx(ctx)
 			if err != nil {
 				log.Warn("failed to begin tx. trying again in 5s", sl.Error(err))
@@ -47,7 +52,7 @@ func (fw *FileWorker) Start(ctx context.Context) {
 				}
 			}
 
-			aliases, err := fw.repo.DeleteExpiredFilesTx(ctx, tx, batchLimit)
+			keys, err := fw.repo.DeleteExpiredFilesTx(ctx, tx, batchLimit)
 			if err != nil {
 				log.Warn("failed to delete expired files from repo", sl.Error(err))
 				rollback()
@@ -55,15 +60,15 @@ func (fw *FileWorker) Start(ctx context.Context) {
 			}
 
 			success := true
-			for _, alias := range aliases {
-				if err := fw.files.Delete(ctx, alias); err != nil {
+			for _, key := range keys {
+				if err := fw.files.Delete(ctx, key); err != nil {
 					if errors.Is(err, context.Canceled) {
-						log.Info("file worker stopping", slog.String("alias", alias))
+						log.Info("file worker stopping", slog.String("key", key))
 						rollback()
 						return
 					}
 
-					log.Warn("failed to delete file from storage", sl.Error(err), slog.String("alias", alias))
+					log.Warn("failed to delete file from storage", sl.Error(err), slog.String("key", key))
 					success = false
 					break
 				}
@@ -79,8 +84,8 @@ func (fw *FileWorker) Start(ctx context.Context) {
 				continue
 			}
 
-			if len(aliases) > 0 {
-				log.Info("deleted expired files", slog.Int("count", len(aliases)))
+			if len(keys) > 0 {
+				log.Info("deleted expired files", slog.Int("count", len(keys)))
 				continue
 			}
 
@@ -89,11 +94,112 @@ func (fw *FileWorker) Start(ctx context.Context) {
 	}
 }
 
-func NewFileWorker(repo repositories.FileRepo, files storage.File, log *slog.Logger, cfg config.Config) *FileWorker {
+func (fw *FileWorker) deleteExpiredUploads(ctx context.Context) {
+	const fn = "services.worker.FileWorker.deleteExpiredUploads"
+	log := fw.log.With(slog.String("fn", fn))
+
+	ids, err := fw.uploads.DeleteExpired(ctx, time.Now())
+	if len(ids) > 0 {
+		log.Info("deleted expired uploads", slog.Int("count", len(ids)))
+	}
+
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Warn("failed to delete expired uploads", sl.Error(err))
+	}
+}
+
+// releaseExpiredReservations gives back download slots of transfers that
+// were never finished and ends expired download sessions, so exhausted
+// files no longer held by any of them can be deleted
+func (fw *FileWorker) releaseExpiredReservations(ctx context.Context) {
+	const fn = "services.worker.FileWorker.releaseExpiredReservations"
+	log := fw.log.With(slog.String("fn", fn))
+
+	tx, err := fw.repo.BeginTx(ctx)
+	if err != nil {
+		log.Warn("failed to begin tx", sl.Error(err))
+		return
+	}
+
+	count, err := fw.repo.ReleaseExpiredReservationsTx(ctx, tx, batchLimit)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			log.Warn("failed to rollback tx", sl.Error(err))
+		}
+
+		if !errors.Is(err, context.Canceled) {
+			log.Warn("failed to release expired reservations", sl.Error(err))
+		}
+
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Warn("failed to commit tx", sl.Error(err))
+		return
+	}
+
+	if count > 0 {
+		log.Info("released expired reservations", slog.Int("count", count))
+	}
+}
+
+// collectBlobs removes deduplicated contents no file refers to anymore.
+// Their rows stay locked until the contents are deleted, so an upload of
+// the same content waits and then stores its own copy instead
+func (fw *FileWorker) collectBlobs(ctx context.Context) {
+	const fn = "services.worker.FileWorker.collectBlobs"
+	log := fw.log.With(slog.String("fn", fn))
+
+	tx, err := fw.repo.BeginTx(ctx)
+	if err != nil {
+		log.Warn("failed to begin tx", sl.Error(err))
+		return
+	}
+
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Warn("failed to rollback tx", sl.Error(err))
+		}
+	}
+
+	keys, err := fw.repo.DeleteUnreferencedBlobsTx(ctx, tx, batchLimit)
+	if err != nil {
+		rollback()
+		if !errors.Is(err, context.Canceled) {
+			log.Warn("failed to delete unreferenced blobs from repo", sl.Error(err))
+		}
+
+		return
+	}
+
+	for _, key := range keys {
+		if err := fw.files.Delete(ctx, key); err != nil {
+			rollback()
+			if !errors.Is(err, context.Canceled) {
+				log.Warn("failed to delete blob from storage", sl.Error(err), slog.String("key", key))
+			}
+
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Warn("failed to commit tx", sl.Error(err))
+		return
+	}
+
+	if len(keys) > 0 {
+		log.Info("deleted unreferenced blobs", slog.Int("count", len(keys)))
+	}
+}
+
+func NewFileWorker(repo repositories.FileRepo, files storage.File, uploads storage.Staging, log *slog.Logger, cfg config.Config) *FileWorker {
 	return &FileWorker{
-		Delay: cfg.FileWorkerDelay,
-		repo:  repo,
-		files: files,
-		log:   log,
+		Delay:   cfg.FileWorkerDelay,
+		repo:    repo,
+		files:   files,
+		uploads: uploads,
+		log:     log,
 	}
 }
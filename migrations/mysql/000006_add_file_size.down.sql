@@ -0,0 +1,3 @@
+-- Delete size column and user files index
+DROP INDEX idx_files_user_id ON files;
+ALTER TABLE files DROP COLUMN size;
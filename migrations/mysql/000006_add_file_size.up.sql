@@ -0,0 +1,3 @@
+-- Add size column and index for listing user files
+ALTER TABLE files ADD COLUMN size BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX idx_files_user_id ON files (user_id, id);
@@ -0,0 +1,2 @@
+-- Delete table for download reservations
+DROP TABLE IF EXISTS download_reservations;
@@ -0,0 +1,9 @@
+-- Create table for download slots held while a file is being transferred
+CREATE TABLE IF NOT EXISTS download_reservations (
+    id VARCHAR(64) PRIMARY KEY,
+    file_id BIGINT NOT NULL,
+    confirmed BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMP NOT NULL,
+    INDEX idx_download_reservations_file_id (file_id),
+    INDEX idx_download_reservations_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
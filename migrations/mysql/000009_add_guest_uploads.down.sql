@@ -0,0 +1,4 @@
+-- Delete guest uploads columns
+DROP INDEX idx_files_guest_ip ON files;
+ALTER TABLE files DROP COLUMN management_token_hash;
+ALTER TABLE files DROP COLUMN guest_ip;
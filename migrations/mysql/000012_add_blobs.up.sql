@@ -0,0 +1,12 @@
+-- Store deduplicated contents once per SHA-256, files refer to them by storage key
+ALTER TABLE files ADD COLUMN storage_key VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS blobs (
+    sha256 VARCHAR(64) PRIMARY KEY,
+    storage_key VARCHAR(64) NOT NULL,
+    size BIGINT NOT NULL,
+    ref_count INT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE INDEX idx_blobs_storage_key (storage_key),
+    INDEX idx_blobs_ref_count (ref_count)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,13 @@
+-- Store files of multi-file shares, the share itself is a row of files
+ALTER TABLE files ADD COLUMN members INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS file_members (
+    file_id BIGINT NOT NULL,
+    position INT NOT NULL,
+    name TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    content_type VARCHAR(255) NOT NULL DEFAULT '',
+    sha256 VARCHAR(64) NOT NULL DEFAULT '',
+    storage_key VARCHAR(128) NOT NULL,
+    PRIMARY KEY (file_id, position)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
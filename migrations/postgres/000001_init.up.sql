@@ -0,0 +1,15 @@
+-- Create table for file
+CREATE TABLE IF NOT EXISTS files (
+    id BIGSERIAL PRIMARY KEY,
+    file_name TEXT NOT NULL,
+    alias VARCHAR(50) NOT NULL UNIQUE,
+    size BIGINT NOT NULL DEFAULT 0,
+    downloads_left SMALLINT,
+    loaded_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    password_hash VARCHAR(255) NULL,
+    user_id BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_files_user_id ON files (user_id, id);
+CREATE INDEX IF NOT EXISTS idx_files_expires_at ON files (expires_at);
@@ -0,0 +1,10 @@
+-- Create table for download slots held while a file is being transferred
+CREATE TABLE IF NOT EXISTS download_reservations (
+    id VARCHAR(64) PRIMARY KEY,
+    file_id BIGINT NOT NULL,
+    confirmed BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_download_reservations_file_id ON download_reservations (file_id);
+CREATE INDEX IF NOT EXISTS idx_download_reservations_expires_at ON download_reservations (expires_at);
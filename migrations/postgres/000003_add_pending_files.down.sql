@@ -0,0 +1,2 @@
+-- Delete pending flag of files
+ALTER TABLE files DROP COLUMN pending;
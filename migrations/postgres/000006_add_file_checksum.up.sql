@@ -0,0 +1,3 @@
+-- Store sniffed content type and SHA-256 of file content
+ALTER TABLE files ADD COLUMN content_type VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE files ADD COLUMN sha256 VARCHAR(64) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- Delete blobs table and storage key column
+DROP TABLE IF EXISTS blobs;
+ALTER TABLE files DROP COLUMN storage_key;
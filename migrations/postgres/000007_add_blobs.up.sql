@@ -0,0 +1,13 @@
+-- Store deduplicated contents once per SHA-256, files refer to them by storage key
+ALTER TABLE files ADD COLUMN storage_key VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS blobs (
+    sha256 VARCHAR(64) PRIMARY KEY,
+    storage_key VARCHAR(64) NOT NULL,
+    size BIGINT NOT NULL,
+    ref_count INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_blobs_storage_key ON blobs (storage_key);
+CREATE INDEX IF NOT EXISTS idx_blobs_ref_count ON blobs (ref_count);
@@ -0,0 +1,2 @@
+-- Record files whose content key is derived from their password
+ALTER TABLE files ADD COLUMN password_key BOOLEAN NOT NULL DEFAULT FALSE;
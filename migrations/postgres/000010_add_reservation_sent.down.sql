@@ -0,0 +1,2 @@
+-- Delete sent bytes of downloads
+ALTER TABLE download_reservations DROP COLUMN sent;
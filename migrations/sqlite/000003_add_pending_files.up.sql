@@ -0,0 +1,2 @@
+-- Add pending flag for files that are still being uploaded
+ALTER TABLE files ADD COLUMN pending BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+-- Delete guest uploads columns
+DROP INDEX IF EXISTS idx_files_guest_ip;
+ALTER TABLE files DROP COLUMN management_token_hash;
+ALTER TABLE files DROP COLUMN guest_ip;
@@ -0,0 +1,4 @@
+-- Add guest uploads: files of guests have user_id 0 and are managed with a token
+ALTER TABLE files ADD COLUMN guest_ip VARCHAR(45) NOT NULL DEFAULT '';
+ALTER TABLE files ADD COLUMN management_token_hash VARCHAR(64) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_files_guest_ip ON files (guest_ip);
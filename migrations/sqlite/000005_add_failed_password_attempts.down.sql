@@ -0,0 +1,2 @@
+-- Delete failed password attempts counter
+ALTER TABLE files DROP COLUMN failed_password_attempts;
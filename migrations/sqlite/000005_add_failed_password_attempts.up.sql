@@ -0,0 +1,2 @@
+-- Count wrong passwords of a file, it is deleted after too many of them
+ALTER TABLE files ADD COLUMN failed_password_attempts INT NOT NULL DEFAULT 0;
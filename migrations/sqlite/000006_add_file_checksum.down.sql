@@ -0,0 +1,3 @@
+-- Delete content type and checksum columns
+ALTER TABLE files DROP COLUMN sha256;
+ALTER TABLE files DROP COLUMN content_type;
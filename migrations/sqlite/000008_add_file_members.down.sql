@@ -0,0 +1,3 @@
+-- Delete file members table and members column
+DROP TABLE IF EXISTS file_members;
+ALTER TABLE files DROP COLUMN members;
@@ -0,0 +1,2 @@
+-- Delete password key flag
+ALTER TABLE files DROP COLUMN password_key;
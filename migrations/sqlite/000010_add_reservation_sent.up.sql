@@ -0,0 +1,2 @@
+-- Count bytes sent within a download, ranged transfers add up to the whole file
+ALTER TABLE download_reservations ADD COLUMN sent BIGINT NOT NULL DEFAULT 0;